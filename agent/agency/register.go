@@ -22,6 +22,7 @@ var (
 
 type readyTracker struct {
 	ready bool
+	done  chan struct{}
 	l     sync.RWMutex
 }
 
@@ -32,12 +33,31 @@ func (r *readyTracker) IsReady() bool {
 	return ready
 }
 
+// Done returns the channel which is closed when the registered agents are
+// loaded.
+func (r *readyTracker) Done() <-chan struct{} {
+	r.l.Lock()
+	defer r.l.Unlock()
+	return r.doneCh()
+}
+
 func (r *readyTracker) RegisteringComplete() {
 	r.l.Lock()
 	defer r.l.Unlock()
+	if !r.ready {
+		close(r.doneCh())
+	}
 	r.ready = true
 }
 
+// doneCh returns the done channel, the lock must be held.
+func (r *readyTracker) doneCh() chan struct{} {
+	if r.done == nil {
+		r.done = make(chan struct{})
+	}
+	return r.done
+}
+
 func init() {
 	err := Register.Load("")
 	if err != nil {
@@ -31,3 +31,24 @@ func Test_timeToBackup(t *testing.T) {
 		})
 	}
 }
+
+func TestReadyTracker(t *testing.T) {
+	var r readyTracker
+	done := r.Done()
+	select {
+	case <-done:
+		t.Fatal("ready before registering complete")
+	default:
+	}
+	if r.IsReady() {
+		t.Fatal("ready before registering complete")
+	}
+
+	r.RegisteringComplete()
+	r.RegisteringComplete() // the agency can be reset many times
+	<-done
+	<-r.Done()
+	if !r.IsReady() {
+		t.Fatal("not ready after registering complete")
+	}
+}
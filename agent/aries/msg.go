@@ -48,6 +48,7 @@ func createMsg(d didcomm.MsgInit) Msg {
 		Thread: th,
 		ID:     d.ID,
 		Ready:  d.Ready,
+		Info:   d.Info,
 		Msg:    d.Msg,
 	}
 	return m
@@ -89,6 +90,10 @@ func (m *msgImpl) Ready() bool {
 	return m.Msg.Ready
 }
 
+func (m *msgImpl) Info() string {
+	return m.Msg.Info
+}
+
 func (m *msgImpl) FieldObj() interface{} {
 	return m.Msg
 }
@@ -101,6 +106,7 @@ type Msg struct {
 
 	ID    string                 `json:"id,omitempty"`    // Used for transferring additional ID like the Cred Def ID
 	Ready bool                   `json:"ready,omitempty"` // In queries tells if something is ready when true
+	Info  string                 `json:"info,omitempty"`  // Free form data of the user action
 	Msg   map[string]interface{} `json:"msg,omitempty"`   // Forwarded message
 }
 
@@ -78,9 +78,13 @@ func (pl *PayloadImpl) MsgHdr() didcomm.MessageHdr {
 	return pl.MessageHdr
 }
 
+// ThreadID returns the ID of the protocol the payload belongs to. In the
+// connection protocols the parent thread is the invitation, whose ID is the
+// protocol ID. In the other protocols the parent thread is another protocol,
+// which has started this one.
 func (pl *PayloadImpl) ThreadID() string {
 	if th := pl.Thread(); th != nil {
-		if th.PID != "" {
+		if th.PID != "" && parentIsInvitation(pl.Type()) {
 			return th.PID
 		}
 		if th.ID != "" {
@@ -135,6 +139,15 @@ func (pl *PayloadImpl) Namespace() string {
 	return didcomm.FieldAtInd(pl.Type(), 0)
 }
 
+func parentIsInvitation(typeStr string) bool {
+	switch ProtocolForType(typeStr) {
+	case pltype.ProtocolConnection, pltype.AriesProtocolConnection,
+		pltype.AriesProtocolDIDExchange:
+		return true
+	}
+	return false
+}
+
 func ProtocolForType(typeStr string) string {
 	return didcomm.FieldAtInd(typeStr, 1)
 }
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/std/decorator"
 )
 
 func TestPayload_ReadWriteJSON(t *testing.T) {
@@ -24,3 +26,29 @@ func TestPayload_ReadWriteJSON(t *testing.T) {
 		t.Errorf("%v to JSON from %v", pl, pl2)
 	}
 }
+
+func TestPayload_ThreadID(t *testing.T) {
+	tests := []struct {
+		name   string
+		msgTyp string
+		want   string
+	}{
+		{"child protocol", pltype.DIDOrgBasicMessageSend, "thid"},
+		{"did exchange", pltype.DIDOrgAriesDIDExchange + "/1.0/request", "pthid"},
+		{"connection", pltype.AriesConnection + "/1.0/request", "pthid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := PayloadCreator.New(didcomm.PayloadInit{
+				ID:   "id",
+				Type: tt.msgTyp,
+				MsgInit: didcomm.MsgInit{
+					Thread: &decorator.Thread{ID: "thid", PID: "pthid"},
+				},
+			})
+			if got := pl.ThreadID(); got != tt.want {
+				t.Errorf("ThreadID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
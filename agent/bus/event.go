@@ -35,8 +35,10 @@ type AgentNotify struct {
 	Timestamp        int64
 	UserActionType   string
 	Role             pb.Protocol_Role
+	Label            string // their label for the new connection notifications
 	*IssuePropose
 	*ProofVerify
+	*CredentialReceived
 }
 
 const sysRebootType = "SystemReboot"
@@ -54,6 +56,14 @@ type IssuePropose struct {
 	ValuesJSON string
 }
 
+// CredentialReceived is the holder's new credential which is stored to the
+// wallet.
+type CredentialReceived struct {
+	CredDefID  string
+	SchemaID   string
+	Attributes []didcomm.CredentialAttribute
+}
+
 type ProofVerify struct {
 	Attrs []didcomm.ProofValue
 }
@@ -110,8 +120,13 @@ var (
 	WantAllPSMCleanup    mapIndex = psmCleanup
 )
 
+// agentListenBufSize is the buffer size of the agent listener, which lets the
+// slow client fall behind for a while without blocking the notifications of
+// the other clients, see broadcast.
+const agentListenBufSize = 16
+
 func (m mapIndex) AgentAddListener(key AgentKeyType) AgentStateChan {
-	c := make(AgentStateChan, 1)
+	c := make(AgentStateChan, agentListenBufSize)
 
 	agentMaps[m].Lock()
 	_, alreadyExists := agentMaps[m].agentStationMap[key]
@@ -149,7 +164,11 @@ func (m mapIndex) checkBuffered() {
 		e = e.Next()
 
 		// broadcast sends notifications only those agent's ctrls that listen
-		if m.lockedBroadcast(notif) {
+		sent, skipped := m.lockedBroadcast(notif)
+		for _, s := range skipped {
+			l.PushBack(s)
+		}
+		if sent {
 			// now it's safe to remove during the for loop
 			glog.V(14).Infoln("removing: ", notif.ClientID)
 			l.Remove(old)
@@ -175,14 +194,19 @@ func (m mapIndex) AgentRmListener(key AgentKeyType) {
 
 // AgentBroadcast broadcasts the notification. If no Agent Ctrls are currently
 // connected notifications are buffered and agency send them immediately any of
-// the controllers connect.
+// the controllers connect. The notifications which a controller couldn't
+// receive are buffered for it as well.
 //
 // TODO: add persistence that agency can be restarted.
 func (m mapIndex) AgentBroadcast(state AgentNotify) {
 	agentMaps[m].Lock()
 	defer agentMaps[m].Unlock()
 
-	if !m.broadcast(&state) { //
+	found, skipped := m.broadcast(&state)
+	for _, s := range skipped {
+		m.pushBufferedNotify(s)
+	}
+	if !found {
 		glog.V(3).Infoln(state.ClientID, "there are no one to listen us!")
 		if m == WantAllAgentActions {
 			m.pushBufferedNotify(&state)
@@ -208,33 +232,48 @@ func (m mapIndex) pushBufferedNotify(state *AgentNotify) {
 // It frees buffer level locks first and acquires map level lock before
 // forecast. In the end it does it in reserve order. Go's normal defer is not
 // used to make easier to read what functions does.
-func (m mapIndex) lockedBroadcast(state *AgentNotify) (sent bool) {
+func (m mapIndex) lockedBroadcast(state *AgentNotify) (sent bool, skipped []*AgentNotify) {
 	agentMaps[m].buffer.Unlock() // Free buffer lock which was on in caller
 	agentMaps[m].Lock()          // Lock map level lock for broadcast function
 
-	sent = m.broadcast(state)
+	sent, skipped = m.broadcast(state)
 
 	agentMaps[m].Unlock()      // first free the map level lock
 	agentMaps[m].buffer.Lock() // 2nd put our lock for buffer on
 
-	return sent
+	return sent, skipped
 }
 
-// broadcast broadcasts notification to listeners. Note! It doesn't lock
-// the maps.
-func (m mapIndex) broadcast(state *AgentNotify) (found bool) {
+// broadcast broadcasts notification to all listeners of the agent, e.g. its
+// web and mobile clients. The notification which has the client ID is sent
+// only to that client. The listener which buffer is full is skipped that the
+// slow client doesn't block the others, and the notification is returned in
+// skipped with the client's ID for the caller to buffer it. It's found only
+// if some of the listeners got the notification. If none did, the caller
+// buffers it like there would be no listeners. Note! It doesn't lock the maps.
+func (m mapIndex) broadcast(state *AgentNotify) (found bool, skipped []*AgentNotify) {
 	broadcastKey := state.AgentKeyType
 	for listenKey, ch := range agentMaps[m].agentStationMap {
-		hit := broadcastKey.AgentDID == listenKey.AgentDID ||
-			listenKey.AgentDID == AllAgents
+		hit := (broadcastKey.AgentDID == listenKey.AgentDID ||
+			listenKey.AgentDID == AllAgents) &&
+			(broadcastKey.ClientID == "" ||
+				broadcastKey.ClientID == listenKey.ClientID)
 		if hit {
-			found = hit
 			glog.V(3).Infoln(broadcastKey.AgentDID,
 				"agent broadcast notify: ", listenKey.ClientID)
 			sendState := *state
 			sendState.ClientID = listenKey.ClientID
-			ch <- sendState
+			select {
+			case ch <- sendState:
+				found = true
+			default:
+				glog.Warningln(listenKey.ClientID,
+					"agent listener full, buffering notify", state.ID)
+				if broadcastKey.ClientID == "" {
+					skipped = append(skipped, &sendState)
+				}
+			}
 		}
 	}
-	return found
+	return found, skipped
 }
@@ -0,0 +1,106 @@
+package bus
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestAgentBroadcast_Clients(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const agentDID = "fanOutAgentDID"
+	webKey := AgentKeyType{AgentDID: agentDID, ClientID: "web"}
+	mobileKey := AgentKeyType{AgentDID: agentDID, ClientID: "mobile"}
+	web := WantAllAgencyActions.AgentAddListener(webKey)
+	defer WantAllAgencyActions.AgentRmListener(webKey)
+	mobile := WantAllAgencyActions.AgentAddListener(mobileKey)
+	defer WantAllAgencyActions.AgentRmListener(mobileKey)
+
+	var (
+		wg       sync.WaitGroup
+		received [2]AgentNotify
+	)
+	for i, c := range []AgentStateChan{web, mobile} {
+		wg.Add(1)
+		go func(i int, c AgentStateChan) {
+			defer wg.Done()
+			received[i] = <-c
+		}(i, c)
+	}
+	WantAllAgencyActions.AgentBroadcast(AgentNotify{
+		AgentKeyType: AgentKeyType{AgentDID: agentDID},
+		ID:           "notifyID",
+	})
+	wg.Wait()
+
+	assert.Equal(received[0].ID, "notifyID")
+	assert.Equal(received[0].ClientID, webKey.ClientID)
+	assert.Equal(received[1].ID, "notifyID")
+	assert.Equal(received[1].ClientID, mobileKey.ClientID)
+}
+
+func TestAgentBroadcast_SlowClient(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const agentDID = "slowClientAgentDID"
+	slowKey := AgentKeyType{AgentDID: agentDID, ClientID: "slow"}
+	fastKey := AgentKeyType{AgentDID: agentDID, ClientID: "fast"}
+	_ = WantAllAgencyActions.AgentAddListener(slowKey) // never read
+	defer WantAllAgencyActions.AgentRmListener(slowKey)
+	fast := WantAllAgencyActions.AgentAddListener(fastKey)
+	defer WantAllAgencyActions.AgentRmListener(fastKey)
+
+	// the slow client's buffer fills up, but it doesn't block the fast one
+	const count = 2 * agentListenBufSize
+	for i := 0; i < count; i++ {
+		WantAllAgencyActions.AgentBroadcast(AgentNotify{
+			AgentKeyType: AgentKeyType{AgentDID: agentDID},
+		})
+		notify := <-fast
+		assert.Equal(notify.ClientID, fastKey.ClientID)
+	}
+}
+
+func TestAgentBroadcast_BufferSkipped(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const agentDID = "bufferSkippedAgentDID"
+	slowKey := AgentKeyType{AgentDID: agentDID, ClientID: "slow"}
+	fastKey := AgentKeyType{AgentDID: agentDID, ClientID: "fast"}
+	_ = WantAllAgentActions.AgentAddListener(slowKey) // never read
+	fast := WantAllAgentActions.AgentAddListener(fastKey)
+	defer WantAllAgentActions.AgentRmListener(fastKey)
+
+	// the fast client gets all, the slow one misses the ones after its
+	// buffer is full
+	const count = agentListenBufSize + 2
+	for i := 0; i < count; i++ {
+		WantAllAgentActions.AgentBroadcast(AgentNotify{
+			AgentKeyType: AgentKeyType{AgentDID: agentDID},
+			ID:           fmt.Sprint(i),
+		})
+		notify := <-fast
+		assert.Equal(notify.ID, fmt.Sprint(i))
+	}
+
+	// the missed ones are buffered for the slow client only
+	WantAllAgentActions.AgentRmListener(slowKey)
+	slow := WantAllAgentActions.AgentAddListener(slowKey)
+	defer WantAllAgentActions.AgentRmListener(slowKey)
+	for i := agentListenBufSize; i < count; i++ {
+		notify := <-slow
+		assert.Equal(notify.ID, fmt.Sprint(i))
+		assert.Equal(notify.ClientID, slowKey.ClientID)
+	}
+	select {
+	case notify := <-fast:
+		t.Errorf("buffered notify (%s) sent to other client", notify.ID)
+	default:
+	}
+}
@@ -2,15 +2,18 @@ package cloud
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/endp"
 	"github.com/findy-network/findy-agent/agent/sec"
 	"github.com/findy-network/findy-agent/agent/ssi"
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
 	"github.com/findy-network/findy-agent/agent/utils"
 	"github.com/findy-network/findy-agent/core"
 	"github.com/findy-network/findy-agent/enclave"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
 	"github.com/findy-network/findy-wrapper-go/wallet"
 	"github.com/golang/glog"
 	"github.com/lainio/err2"
@@ -71,6 +74,22 @@ func (ap *agentPtr) testAndSet(set func() *Agent) *Agent {
 	return ap.agent
 }
 
+// testAndSetErr is like testAndSet, but the agent isn't set if the set
+// function fails, which allows the next call to try again.
+func (ap *agentPtr) testAndSetErr(set func() (*Agent, error)) (_ *Agent, err error) {
+	ap.Lock()
+	defer ap.Unlock()
+
+	if ap.agent == nil {
+		a, err := set()
+		if err != nil {
+			return nil, err
+		}
+		ap.agent = a
+	}
+	return ap.agent, nil
+}
+
 func (ap *agentPtr) get() *Agent {
 	ap.RLock()
 	defer ap.RUnlock()
@@ -84,15 +103,36 @@ func (a *Agent) AutoPermission() bool {
 	return autoPermissionOn
 }
 
+// Pause stops the agent from starting new protocols, e.g. for the maintenance.
+// The protocols already running are completed normally.
+func (a *Agent) Pause() {
+	comm.SetPaused(a.WDID(), true)
+	glog.V(1).Infoln("agent paused:", a.WDID())
+}
+
+// Resume lets the paused agent start new protocols again.
+func (a *Agent) Resume() {
+	comm.SetPaused(a.WDID(), false)
+	glog.V(1).Infoln("agent resumed:", a.WDID())
+}
+
+// Paused tells if the agent cannot start new protocols, because it or the
+// whole agency is paused.
+func (a *Agent) Paused() bool {
+	return comm.IsPaused(a.WDID())
+}
+
 type SeedAgent struct {
 	RootDID  string
 	CADID    string
 	CAVerKey string
+	PoolName string // empty means the agency's default pool
 	*ssi.Wallet
 }
 
 func (s *SeedAgent) Prepare() (h comm.Handler, err error) {
 	agent := &Agent{myDID: ssi.NewDid(s.CADID, s.CAVerKey)}
+	agent.SetPoolName(s.PoolName)
 	agent.OpenWallet(*s.Wallet)
 
 	rd := agent.LoadDID(s.RootDID)
@@ -103,6 +143,7 @@ func (s *SeedAgent) Prepare() (h comm.Handler, err error) {
 
 func (s *SeedAgent) Migrate() (h comm.Handler, err error) {
 	agent := &Agent{}
+	agent.SetPoolName(s.PoolName)
 	agent.OpenWallet(*s.Wallet)
 
 	rd := agent.LoadDID(s.RootDID)
@@ -201,25 +242,28 @@ func (a *Agent) PwPipe(connID string) (cp sec.Pipe, err error) {
 	return cp, nil
 }
 
+// walletKeyByDID is the enclave's wallet key getter, which the tests can
+// replace to inject enclave failures.
+var walletKeyByDID = enclave.WalletKeyByDID
+
 // workerAgent creates worker agent for our EA if it isn't already done. Worker
 // is a pseudo EA which presents EA in the cloud and so it is always ONLINE. By
 // this other agents can connect to us even when all of our EAs are offline.
-// This is under construction.
-func (a *Agent) workerAgent(waDID, suffix string) (wa *Agent) {
+// A failure, e.g. an enclave error, is returned, and the creation is tried
+// again in the next call.
+func (a *Agent) workerAgent(waDID, suffix string) (wa *Agent, err error) {
 	ca := a // to help us to read the code, receiver is CA
-	return ca.worker.testAndSet(func() *Agent {
+	return ca.worker.testAndSetErr(func() (_ *Agent, err error) {
+		defer err2.Handle(&err, "worker agent (%s)", waDID)
+
 		glog.V(2).Infof("starting worker agent (%s) creation process", waDID)
 		assert.That(waDID == ca.WDID(), "Agent URL doesn't match with Transport")
 
+		// getting wallet credentials
+		key := try.To1(walletKeyByDID(ca.myDID.Did()))
+
 		cfg := ca.WalletH.Config().(*ssi.Wallet)
 		aWallet := cfg.WorkerWalletBy(suffix)
-
-		// getting wallet credentials
-		key, err := enclave.WalletKeyByDID(ca.myDID.Did())
-		if err != nil {
-			glog.Error("cannot get wallet key:", err)
-			panic(err)
-		}
 		aWallet.Credentials.Key = key
 		aWallet.Create()
 
@@ -233,6 +277,8 @@ func (a *Agent) workerAgent(waDID, suffix string) (wa *Agent) {
 			pws:   make(PipeMap),
 			myDID: ca.myDID,
 		}
+		// worker resolves ledger data same way as its CA
+		wca.SetPoolName(ca.PoolName())
 
 		wca.OpenWallet(*aWallet)
 		// cleanup, secure enclave stuff, minimize time in memory
@@ -242,7 +288,7 @@ func (a *Agent) workerAgent(waDID, suffix string) (wa *Agent) {
 
 		wca.loadPWMap()
 
-		return wca
+		return wca, nil
 	})
 }
 
@@ -254,6 +300,48 @@ func (a *Agent) MasterSecret() (string, error) {
 	return enclave.WalletMasterSecretByDID(a.myDID.Did())
 }
 
+// PrevMasterSecrets returns the master secrets which were in use before the
+// RotateMasterSecret calls, from the newest to the oldest. Credentials issued
+// before a rotation are bound to one of them.
+func (a *Agent) PrevMasterSecrets() ([]string, error) {
+	secs, err := enclave.WalletPrevMasterSecretsByDID(a.myDID.Did())
+	if err == enclave.ErrNotExists {
+		return nil, nil
+	}
+	return secs, err
+}
+
+// proverCreateMasterSecret registers the master secret to the agent's wallet.
+// The tests can replace it to run without the wallet.
+var proverCreateMasterSecret = func(a *Agent, sec string) (err error) {
+	defer err2.Handle(&err)
+
+	r := <-anoncreds.ProverCreateMasterSecret(a.Wallet(), sec)
+	try.To(r.Err())
+	assert.Equal(sec, r.Str1())
+	return nil
+}
+
+// RotateMasterSecret creates a new master secret to the agent's wallet and
+// sets it to the enclave as the current one. The old master secrets stay in
+// the wallet and they can be read with PrevMasterSecrets.
+//
+// Note! Rotating the master secret doesn't re-bind existing credentials. All
+// new credential requests use the new secret, and the proofs are built with
+// the secret the used credentials were issued to.
+func (a *Agent) RotateMasterSecret() (sec string, err error) {
+	defer err2.Handle(&err, "rotate master secret")
+
+	sec, prevs := try.To2(enclave.RotateWalletMasterSecret(a.myDID.Did(),
+		func(sec string) error {
+			return proverCreateMasterSecret(a, sec)
+		}))
+	glog.V(1).Infof("master secret rotated for (%s), prev count: %d",
+		a.myDID.Did(), len(prevs))
+
+	return sec, nil
+}
+
 // WDID returns DID string of the WA and CALLED from CA.
 func (a *Agent) WDID() string {
 	assert.That(a.IsCA())
@@ -265,19 +353,23 @@ func (a *Agent) WDID() string {
 }
 
 // WEA returns CA's worker agent. It creates and inits it correctly if needed.
-// The TR is attached to worker EA here!
-func (a *Agent) WEA() (wa *Agent) {
+// The TR is attached to worker EA here! An error is returned if the worker
+// cannot be created, e.g. the enclave fails.
+func (a *Agent) WEA() (wa *Agent, err error) {
 	ca := a
-	if ca.worker.get() != nil {
-		return ca.worker.get()
+	if wa = ca.worker.get(); wa != nil {
+		return wa, nil
 	}
 	glog.V(4).Infoln("worker NOT ready, starting creation process")
 	waDID := ca.WDID()
 	return ca.workerAgent(waDID, "")
 }
 
+// WorkerEA returns the worker agent as comm.Receiver. Because the interface
+// cannot return errors, it panics with the error of WEA, which the err2
+// handlers of the callers catch.
 func (a *Agent) WorkerEA() comm.Receiver {
-	return a.WEA()
+	return try.To1(a.WEA())
 }
 
 func (a *Agent) ExportWallet(key string, exportPath string) string {
@@ -295,6 +387,53 @@ func (a *Agent) ExportWallet(key string, exportPath string) string {
 	return fileLocation
 }
 
+// ImportConnections restores the connections exported with ExportConnections
+// and adds them to the pairwise map. The connections are the worker agent's,
+// see ssi.DIDAgent.ImportConnections.
+func (a *Agent) ImportConnections(key, path string) (n int, err error) {
+	defer err2.Handle(&err)
+
+	assert.That(a.IsWorker(), "connections are imported to the worker agent")
+	conns := try.To1(a.DIDAgent.ImportConnections(key, path))
+	for _, conn := range conns {
+		if conn.TheirDID == "" {
+			continue
+		}
+		if _, err := a.refreshPipe(conn, a.loadPipe); err != nil {
+			glog.Warningln("imported connection without pipe:", err)
+		}
+	}
+	return len(conns), nil
+}
+
+// CycleWallet closes the worker agent's wallet and storage and reopens them,
+// e.g. to recover from a storage failure. The pairwise map is reloaded from
+// the reopened storage. The caller must make sure that the worker isn't
+// processing protocols during the cycle, see Pause.
+func (a *Agent) CycleWallet() (err error) {
+	defer err2.Handle(&err)
+
+	wa := try.To1(a.WEA())
+	try.To(wa.DIDAgent.CycleWallet())
+
+	// the pipes are refreshed one by one, which means that the senders find
+	// a pipe for the connection during the cycle as well
+	conns := try.To1(wa.ConnectionStorage().ListConnections())
+	for _, conn := range conns {
+		if conn.TheirDID == "" {
+			continue // pre-allocated, see AddToPWMap
+		}
+		if _, err := wa.refreshPipe(conn, wa.loadPipe); err != nil {
+			glog.Warningln("wallet cycle:", err)
+		}
+	}
+	return nil
+}
+
+// pwLoadWorkers is the maximum amount of goroutines loading the connections
+// of the wallet in loadPWMap.
+const pwLoadWorkers = 8
+
 func (a *Agent) loadPWMap() {
 	defer err2.Catch(err2.Err(func(err error) {
 		glog.Error("cannot load PW map:", err)
@@ -304,30 +443,80 @@ func (a *Agent) loadPWMap() {
 
 	connections := try.To1(a.ConnectionStorage().ListConnections())
 
+	pws := loadPipes(connections, pwLoadWorkers, a.loadPipe)
+
 	a.pwLock.Lock()
 	defer a.pwLock.Unlock()
 
-	for _, conn := range connections {
-		if conn.TheirDID == "" {
-			glog.V(15).Infof("connection (%s) TheirDID is empty", conn.TheirDID)
-			continue
+	for connID, p := range pws {
+		a.pws[connID] = p
+	}
+}
+
+func (a *Agent) loadPipe(conn storage.Connection) (p sec.Pipe, ok bool) {
+	defer err2.Catch(err2.Err(func(err error) {
+		glog.Warningf("cannot load connection (%s): %v", conn.ID, err)
+	}))
+
+	outDID := a.LoadTheirDID(conn)
+	outDID.StartEndp(a.ManagedStorage(), conn.ID)
+	return sec.NewPipe(a.LoadDID(conn.MyDID), outDID), true
+}
+
+// loadPipes builds the pipes of the connections with the load function which
+// is run by the maximum of workers goroutines at the same time. Loading DIDs
+// is mostly waiting for the wallet, which is why they are loaded in parallel.
+func loadPipes(
+	connections []storage.Connection,
+	workers int,
+	load func(storage.Connection) (sec.Pipe, bool),
+) PipeMap {
+	type result struct {
+		connID string
+		pipe   sec.Pipe
+		ok     bool
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	conns := make(chan storage.Connection)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for conn := range conns {
+				p, ok := load(conn)
+				results <- result{connID: conn.ID, pipe: p, ok: ok}
+			}
+		}()
+	}
+	go func() {
+		for _, conn := range connections {
+			if conn.TheirDID == "" {
+				glog.V(15).Infof("connection (%s) TheirDID is empty", conn.ID)
+				continue
+			}
+			conns <- conn
 		}
-		outDID := a.LoadTheirDID(conn)
-		outDID.StartEndp(a.ManagedStorage(), conn.ID)
-		p := sec.Pipe{
-			In:  a.LoadDID(conn.MyDID),
-			Out: outDID,
+		close(conns)
+		wg.Wait()
+		close(results)
+	}()
+
+	pws := make(PipeMap, len(connections))
+	for r := range results {
+		if r.ok {
+			pws[r.connID] = r.pipe
 		}
-
-		a.pws[conn.ID] = p
 	}
+	return pws
 }
 
 func (a *Agent) AddToPWMap(me, you core.DID, connID string) sec.Pipe {
-	pipe := sec.Pipe{
-		In:  me,
-		Out: you,
-	}
+	pipe := sec.NewPipe(me, you)
 
 	a.pwLock.Lock()
 	defer a.pwLock.Unlock()
@@ -344,9 +533,37 @@ func (a *Agent) AddPipeToPWMap(p sec.Pipe, connID string) {
 	a.pws[connID] = p
 }
 
+// RefreshPipe reloads the connection's pipe from the storage and replaces the
+// one in the PW map. The pipes already returned by SecPipe are snapshots, and
+// the senders using them aren't disturbed.
+func (a *Agent) RefreshPipe(connID string) (p sec.Pipe, err error) {
+	defer err2.Handle(&err, "refresh pipe")
+
+	conn := try.To1(a.ConnectionStorage().GetConnection(connID))
+	return a.refreshPipe(*conn, a.loadPipe)
+}
+
+func (a *Agent) refreshPipe(
+	conn storage.Connection,
+	load func(storage.Connection) (sec.Pipe, bool),
+) (
+	p sec.Pipe,
+	err error,
+) {
+	p, ok := load(conn)
+	if !ok {
+		return p, fmt.Errorf("cannot load connection (%s)", conn.ID)
+	}
+	a.AddPipeToPWMap(p, conn.ID)
+	return p.Snapshot(), nil
+}
+
+// SecPipe returns the snapshot of the connection's pipe, which can be used from
+// many goroutines. The pipe's DIDs aren't changed by the later updates of the
+// connection, see RefreshPipe.
 func (a *Agent) SecPipe(connID string) sec.Pipe {
 	a.pwLock.Lock()
 	defer a.pwLock.Unlock()
 
-	return a.pws[connID]
+	return a.pws[connID].Snapshot()
 }
@@ -1,11 +1,19 @@
 package cloud
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/findy-network/findy-agent/agent/endp"
+	"github.com/findy-network/findy-agent/agent/sec"
+	"github.com/findy-network/findy-agent/agent/service"
 	"github.com/findy-network/findy-agent/agent/ssi"
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
 	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/enclave"
 	"github.com/lainio/err2/assert"
 )
 
@@ -37,3 +45,212 @@ func TestCAEndp(t *testing.T) {
 		VerKey:    a.myDID.VerKey(),
 	}, *endpoint)
 }
+
+func TestWEA_EnclaveFailure(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	defer func(f func(string) (string, error)) { walletKeyByDID = f }(walletKeyByDID)
+	walletKeyByDID = func(string) (string, error) {
+		return "", errors.New("enclave failure")
+	}
+	ca := &Agent{myDID: ssi.NewDid("did", "verkey")}
+
+	wa, err := ca.WEA()
+	assert.Error(err)
+	assert.That(wa == nil)
+	assert.That(ca.worker.get() == nil, "failed worker must not be cached")
+
+	// the next call tries again
+	_, err = ca.WEA()
+	assert.Error(err)
+}
+
+func TestRotateMasterSecret(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const dbFilename = "rotate-enclave_test.bolt"
+	assert.NoError(enclave.InitSealedBox(dbFilename, "",
+		"15308490f1e4026284594dd08d31291bc8ef2aeac730d0daf6ff87bb92d4336c"))
+	defer func() {
+		enclave.Close()
+		enclave.WipeSealedBox()
+	}()
+
+	a := &Agent{myDID: ssi.NewDid("rotate_did", "verkey")}
+	first, err := enclave.NewWalletMasterSecret(a.myDID.Did())
+	assert.NoError(err)
+
+	defer func(f func(*Agent, string) error) { proverCreateMasterSecret = f }(proverCreateMasterSecret)
+
+	// the enclave isn't touched if the wallet fails
+	proverCreateMasterSecret = func(*Agent, string) error {
+		return errors.New("wallet failure")
+	}
+	_, err = a.RotateMasterSecret()
+	assert.Error(err)
+	current, err := a.MasterSecret()
+	assert.NoError(err)
+	assert.Equal(first, current)
+	prevs, err := a.PrevMasterSecrets()
+	assert.NoError(err)
+	assert.SLen(prevs, 0)
+
+	// the secret is in the wallet before it's set to the enclave
+	registered := make([]string, 0, 2)
+	proverCreateMasterSecret = func(_ *Agent, sec string) error {
+		current, err := a.MasterSecret()
+		assert.NoError(err)
+		assert.NotEqual(sec, current)
+		registered = append(registered, sec)
+		return nil
+	}
+	second, err := a.RotateMasterSecret()
+	assert.NoError(err)
+	third, err := a.RotateMasterSecret()
+	assert.NoError(err)
+	assert.DeepEqual([]string{second, third}, registered)
+
+	current, err = a.MasterSecret()
+	assert.NoError(err)
+	assert.Equal(third, current)
+
+	// the second rotation keeps the oldest secret
+	prevs, err = a.PrevMasterSecrets()
+	assert.NoError(err)
+	assert.DeepEqual([]string{second, first}, prevs)
+}
+
+func testConnections(n int) []storage.Connection {
+	conns := make([]storage.Connection, n)
+	for i := range conns {
+		conns[i] = storage.Connection{
+			ID:       fmt.Sprintf("conn%d", i),
+			MyDID:    fmt.Sprintf("my%d", i),
+			TheirDID: fmt.Sprintf("their%d", i),
+		}
+	}
+	// connections without their DID aren't loaded
+	conns[0].TheirDID = ""
+	return conns
+}
+
+func testLoad(latency time.Duration) func(storage.Connection) (sec.Pipe, bool) {
+	return func(conn storage.Connection) (sec.Pipe, bool) {
+		time.Sleep(latency)
+		if conn.ID == "conn1" {
+			return sec.Pipe{}, false
+		}
+		return sec.Pipe{
+			In:  ssi.NewDid(conn.MyDID, "verkey"),
+			Out: ssi.NewDid(conn.TheirDID, "verkey"),
+		}, true
+	}
+}
+
+func TestLoadPipes(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	conns := testConnections(100)
+	load := testLoad(0)
+
+	sequential := loadPipes(conns, 1, load)
+	assert.MLen(sequential, 98)
+
+	for _, workers := range []int{0, 2, 8, 200} {
+		pws := loadPipes(conns, workers, load)
+		assert.MLen(pws, len(sequential))
+		for connID, p := range sequential {
+			got, ok := pws[connID]
+			assert.That(ok, "connection (%s) missing", connID)
+			assert.Equal(got.In.Did(), p.In.Did())
+			assert.Equal(got.Out.Did(), p.Out.Did())
+		}
+	}
+}
+
+// TestPWMap_Concurrent is meant to be run with the race detector: the pipes
+// are used while the connection is refreshed and its DIDs are updated.
+func TestPWMap_Concurrent(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	a := &Agent{pws: make(PipeMap)}
+	conn := storage.Connection{ID: "conn", MyDID: "my", TheirDID: "their"}
+	var (
+		lk  sync.Mutex
+		out *ssi.DID
+	)
+	load := func(c storage.Connection) (sec.Pipe, bool) {
+		d := ssi.NewDid(c.TheirDID, "verkey")
+		d.SetAEndp(service.Addr{Endp: "http://localhost/0", Key: "verkey"})
+		lk.Lock()
+		out = d
+		lk.Unlock()
+		return sec.Pipe{In: ssi.NewDid(c.MyDID, "verkey"), Out: d}, true
+	}
+	p, err := a.refreshPipe(conn, load)
+	assert.NoError(err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p := a.SecPipe(conn.ID)
+				_, _ = p.Out.AEndp()
+				_ = p.Out.Did()
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		lk.Lock()
+		d := out
+		lk.Unlock()
+		d.SetAEndp(service.Addr{
+			Endp: fmt.Sprintf("http://localhost/%d", j),
+			Key:  "verkey",
+		})
+		_, err := a.refreshPipe(conn, load)
+		assert.NoError(err)
+	}
+	wg.Wait()
+
+	// the pipe taken before the updates isn't changed by them
+	ae, err := p.Out.AEndp()
+	assert.NoError(err)
+	assert.Equal(ae.Endp, "http://localhost/0")
+
+	// but the next pipe taken from the map has the update
+	out.SetAEndp(service.Addr{Endp: "http://localhost/new", Key: "verkey"})
+	ae, err = a.SecPipe(conn.ID).Out.AEndp()
+	assert.NoError(err)
+	assert.Equal(ae.Endp, "http://localhost/new")
+
+	// the failing refresh keeps the old pipe
+	_, err = a.refreshPipe(conn, func(storage.Connection) (sec.Pipe, bool) {
+		return sec.Pipe{}, false
+	})
+	assert.Error(err)
+	assert.Equal(a.SecPipe(conn.ID).Out.Did(), "their")
+}
+
+func benchmarkLoadPipes(b *testing.B, workers int) {
+	conns := testConnections(1000)
+	load := testLoad(50 * time.Microsecond)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		loadPipes(conns, workers, load)
+	}
+}
+
+func BenchmarkLoadPipes_Sequential(b *testing.B) {
+	benchmarkLoadPipes(b, 1)
+}
+
+func BenchmarkLoadPipes_Parallel(b *testing.B) {
+	benchmarkLoadPipes(b, pwLoadWorkers)
+}
@@ -125,6 +125,23 @@ It doesn't resend PL in case of failure. The recovering in done at PSM level.
 func SendPL(sendPipe sec.Pipe, task Task, opl didcomm.Payload) (err error) {
 	defer err2.Handle(&err, "send payload")
 
+	eps, cryptSendPL := try.To2(packPL(sendPipe, task, opl))
+	return sendRoute(eps, cryptSendPL)
+}
+
+// packPL encrypts the payload for the receiver of the task and returns it
+// with the receiver's endpoints.
+func packPL(
+	sendPipe sec.Pipe,
+	task Task,
+	opl didcomm.Payload,
+) (
+	eps []endpoint,
+	data []byte,
+	err error,
+) {
+	defer err2.Handle(&err, "pack payload")
+
 	cnxAddr := endp.NewAddrFromPublic(task.ReceiverEndp())
 
 	if glog.V(3) {
@@ -134,9 +151,12 @@ func SendPL(sendPipe sec.Pipe, task Task, opl didcomm.Payload) (err error) {
 		glog.Info("=====")
 	}
 
-	cryptSendPL, _ := try.To2(sendPipe.Pack(opl.JSON()))
+	data, _ = try.To2(sendPipe.Pack(opl.JSON()))
+	return endpoints(task.ReceiverEndp(), sendPipe.Out), data, nil
+}
 
-	_, err = SendAndWaitReq(cnxAddr.Address(), bytes.NewReader(cryptSendPL),
+func send(address string, data []byte) (err error) {
+	_, err = SendAndWaitReq(address, bytes.NewReader(data),
 		utils.Settings.Timeout())
-	return err
+	return WrapProtocolError(ErrTransportFailed, err)
 }
@@ -0,0 +1,96 @@
+package comm
+
+import (
+	"sync"
+	"time"
+)
+
+// Health is the reachability of the connection derived from its recent
+// message exchanges.
+type Health string
+
+const (
+	// HealthHealthy means that a message has been exchanged with the
+	// connection lately.
+	HealthHealthy Health = "healthy"
+
+	// HealthStale means that there is no recent activity with the connection
+	// to tell if it's reachable, e.g. after the agency restart.
+	HealthStale Health = "stale"
+
+	// HealthDead means that the latest sends to the connection have failed
+	// repeatedly.
+	HealthDead Health = "dead"
+)
+
+const (
+	// connHealthyFor is the time after the last successful exchange during
+	// which the connection is healthy.
+	connHealthyFor = time.Hour
+
+	// connDeadFailures is the amount of the consecutive send failures after
+	// which the connection is dead.
+	connDeadFailures = 3
+)
+
+// connHealth holds the activity records by the connection IDs.
+var connHealth = struct {
+	sync.Mutex
+	conns map[string]*connActivity
+}{
+	conns: make(map[string]*connActivity),
+}
+
+type connActivity struct {
+	lastSuccess time.Time
+	lastFailure time.Time
+	failures    int // consecutive send failures after the last success
+}
+
+// ReportReceived records the message received from the connection, which
+// makes it healthy.
+func ReportReceived(connID string) {
+	reportConnection(connID, nil)
+}
+
+// reportConnection records the result of the message exchange with the
+// connection. The success resets the failures.
+func reportConnection(connID string, err error) {
+	if connID == "" {
+		return
+	}
+	connHealth.Lock()
+	defer connHealth.Unlock()
+
+	a, ok := connHealth.conns[connID]
+	if !ok {
+		a = &connActivity{}
+		connHealth.conns[connID] = a
+	}
+	if err != nil {
+		a.lastFailure = now()
+		a.failures++
+		return
+	}
+	a.lastSuccess = now()
+	a.failures = 0
+}
+
+// ConnectionHealth returns the health of the connection. The connection
+// without the recorded activity is stale.
+func ConnectionHealth(connID string) Health {
+	connHealth.Lock()
+	defer connHealth.Unlock()
+
+	a, ok := connHealth.conns[connID]
+	switch {
+	case !ok:
+		return HealthStale
+	case a.failures >= connDeadFailures:
+		return HealthDead
+	case a.failures == 0 && now().Sub(a.lastSuccess) <= connHealthyFor:
+		return HealthHealthy
+	default:
+		return HealthStale
+	}
+}
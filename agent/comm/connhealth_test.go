@@ -0,0 +1,44 @@
+package comm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestConnectionHealth(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	orgNow := now
+	defer func() {
+		now = orgNow
+		connHealth.conns = make(map[string]*connActivity)
+	}()
+	t0 := time.Now()
+	now = func() time.Time { return t0 }
+
+	assert.Equal(HealthStale, ConnectionHealth("unknown"))
+
+	ReportReceived("conn1")
+	assert.Equal(HealthHealthy, ConnectionHealth("conn1"))
+
+	// no activity for a while
+	now = func() time.Time { return t0.Add(connHealthyFor + time.Second) }
+	assert.Equal(HealthStale, ConnectionHealth("conn1"))
+
+	sendErr := errors.New("connection refused")
+	reportConnection("conn2", nil)
+	for i := 1; i < connDeadFailures; i++ {
+		reportConnection("conn2", sendErr)
+		assert.Equal(HealthStale, ConnectionHealth("conn2"))
+	}
+	reportConnection("conn2", sendErr)
+	assert.Equal(HealthDead, ConnectionHealth("conn2"))
+
+	// the connection is back
+	reportConnection("conn2", nil)
+	assert.Equal(HealthHealthy, ConnectionHealth("conn2"))
+}
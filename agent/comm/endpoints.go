@@ -0,0 +1,176 @@
+package comm
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/findy-network/findy-agent/agent/endp"
+	"github.com/findy-network/findy-agent/agent/service"
+	"github.com/findy-network/findy-agent/core"
+	"github.com/golang/glog"
+)
+
+const (
+	// healthWindow is the amount of the recent sends which are used to track
+	// the endpoint's health.
+	healthWindow = 10
+
+	// unhealthyFailures is the amount of failures in the health window after
+	// which the endpoint is unhealthy.
+	unhealthyFailures = 3
+
+	// healthRetryAfter is the time after the last failure when the unhealthy
+	// endpoint is tried again like the healthy ones.
+	healthRetryAfter = time.Minute
+)
+
+var (
+	// now and randFloat64 are proxy functions for the tests.
+	now         = time.Now
+	randFloat64 = rand.Float64
+
+	health = struct {
+		sync.Mutex
+		endps map[string]*endpointHealth
+	}{
+		endps: make(map[string]*endpointHealth),
+	}
+)
+
+// endpoint is the transport address of the message receiver.
+type endpoint struct {
+	address string
+	weight  int
+}
+
+type endpointHealth struct {
+	results     []bool // recent send results, true means success
+	lastFailure time.Time
+}
+
+// endpointer is implemented by the DIDs which can have several access points,
+// e.g. did:peer, see method.Peer.Endpoints.
+type endpointer interface {
+	Endpoints() ([]service.WeightedAddr, error)
+}
+
+// endpoints returns the transport endpoints of the receiver: the address and
+// its alternates if the receiver's DID has them.
+func endpoints(ae service.Addr, out core.DID) []endpoint {
+	addrs := []service.WeightedAddr{{Addr: ae}}
+	if d, ok := out.(endpointer); ok {
+		all, err := d.Endpoints()
+		if err == nil && len(all) > 0 && all[0].Endp == ae.Endp {
+			all[0].Addr = ae
+			addrs = all
+		}
+	}
+	eps := make([]endpoint, 0, len(addrs))
+	for _, a := range addrs {
+		w := a.Weight
+		if w <= 0 {
+			w = 1
+		}
+		eps = append(eps, endpoint{
+			address: endp.NewAddrFromPublic(a.Addr).Address(),
+			weight:  w,
+		})
+	}
+	return eps
+}
+
+// sendOrder returns the endpoints in the order to try them. The healthy
+// endpoints are first in a random order where the endpoints with more weight
+// are more likely first. The unhealthy ones are last, the least failed first.
+func sendOrder(eps []endpoint) []endpoint {
+	if len(eps) < 2 {
+		return eps
+	}
+	type ranked struct {
+		endpoint
+		failures int
+		key      float64
+	}
+	ranks := make([]ranked, len(eps))
+	for i, ep := range eps {
+		ranks[i] = ranked{
+			endpoint: ep,
+			failures: failures(ep.address),
+			// weighted random sampling by Efraimidis and Spirakis
+			key: math.Pow(randFloat64(), 1/float64(ep.weight)),
+		}
+	}
+	sort.SliceStable(ranks, func(i, j int) bool {
+		if ranks[i].failures != ranks[j].failures {
+			return ranks[i].failures < ranks[j].failures
+		}
+		return ranks[i].key > ranks[j].key
+	})
+	ordered := make([]endpoint, len(ranks))
+	for i, r := range ranks {
+		ordered[i] = r.endpoint
+	}
+	return ordered
+}
+
+// failures returns the failure count of the unhealthy endpoint, and zero for
+// the healthy one.
+func failures(address string) int {
+	health.Lock()
+	defer health.Unlock()
+
+	h, ok := health.endps[address]
+	if !ok || now().Sub(h.lastFailure) > healthRetryAfter {
+		return 0
+	}
+	n := 0
+	for _, success := range h.results {
+		if !success {
+			n++
+		}
+	}
+	if n < unhealthyFailures {
+		return 0
+	}
+	return n
+}
+
+// reportSend records the result of the send to the endpoint's health.
+func reportSend(address string, err error) {
+	health.Lock()
+	defer health.Unlock()
+
+	h, ok := health.endps[address]
+	if !ok {
+		h = &endpointHealth{}
+		health.endps[address] = h
+	}
+	if err != nil {
+		h.lastFailure = now()
+	}
+	h.results = append(h.results, err == nil)
+	if len(h.results) > healthWindow {
+		h.results = h.results[1:]
+	}
+}
+
+// sendRoute sends the data to the first endpoint which accepts it in the send
+// order. It returns the error of the last endpoint tried. The health is tracked
+// only for the receivers having alternate endpoints.
+func sendRoute(eps []endpoint, data []byte) (err error) {
+	if len(eps) == 1 {
+		return send(eps[0].address, data)
+	}
+	for _, ep := range sendOrder(eps) {
+		err = send(ep.address, data)
+		reportSend(ep.address, err)
+		if err == nil {
+			return nil
+		}
+		glog.Warningf("send to endpoint (%s) failed: %v", ep.address, err)
+	}
+	return err
+}
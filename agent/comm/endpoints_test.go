@@ -0,0 +1,93 @@
+package comm
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/findy-network/findy-agent/agent/endp"
+	"github.com/findy-network/findy-agent/agent/service"
+	"github.com/findy-network/findy-agent/core"
+	"github.com/lainio/err2/assert"
+)
+
+type relayDID struct {
+	core.DID
+	addrs []service.WeightedAddr
+}
+
+func (d relayDID) Endpoints() ([]service.WeightedAddr, error) {
+	return d.addrs, nil
+}
+
+func TestEndpoints(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	ae := service.Addr{Endp: "http://relay1/a2a/did/did/conn"}
+	out := relayDID{addrs: []service.WeightedAddr{
+		{Addr: service.Addr{Endp: "http://relay1/a2a/did/did/conn"}},
+		{Addr: service.Addr{Endp: "http://relay2/a2a/did/did/conn"}, Weight: 3},
+	}}
+	eps := endpoints(ae, out)
+	assert.SLen(eps, 2)
+	assert.Equal(1, eps[0].weight)
+	assert.Equal(3, eps[1].weight)
+	assert.Equal(endp.NewAddrFromPublic(ae).Address(), eps[0].address)
+	assert.That(eps[0].address != eps[1].address)
+
+	// the alternates are for the DID's first endpoint only
+	eps = endpoints(service.Addr{Endp: "http://elsewhere"}, out)
+	assert.SLen(eps, 1)
+
+	// the DIDs without alternates have only the address
+	eps = endpoints(ae, nil)
+	assert.SLen(eps, 1)
+}
+
+func TestSendRoute_Failover(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	orgSend, orgRand, orgNow := SendAndWaitReq, randFloat64, now
+	defer func() {
+		SendAndWaitReq, randFloat64, now = orgSend, orgRand, orgNow
+		health.endps = make(map[string]*endpointHealth)
+	}()
+	// with a fixed random value the heavier endpoint is always tried first
+	randFloat64 = func() float64 { return 0.5 }
+	clock := time.Now()
+	now = func() time.Time { return clock }
+
+	calls := make(map[string]int)
+	SendAndWaitReq = func(address string, _ io.Reader, _ time.Duration) ([]byte, error) {
+		calls[address]++
+		if address == "http://failing" {
+			return nil, errors.New("connection refused")
+		}
+		return []byte{}, nil
+	}
+	eps := []endpoint{
+		{address: "http://failing", weight: 2},
+		{address: "http://healthy", weight: 1},
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(sendRoute(eps, []byte("msg")))
+	}
+	// the failing endpoint is deprioritized after the repeated failures
+	assert.Equal(unhealthyFailures, calls["http://failing"])
+	assert.Equal(10, calls["http://healthy"])
+	assert.Equal("http://healthy", sendOrder(eps)[0].address)
+
+	// the unhealthy endpoint is tried again after a while
+	clock = clock.Add(healthRetryAfter + time.Second)
+	assert.Equal("http://failing", sendOrder(eps)[0].address)
+
+	// all endpoints failing is an error
+	SendAndWaitReq = func(string, io.Reader, time.Duration) ([]byte, error) {
+		return nil, errors.New("connection refused")
+	}
+	assert.Error(sendRoute(eps, []byte("msg")))
+}
@@ -0,0 +1,76 @@
+package comm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies protocol failures so that clients can branch on them.
+type ErrorCode string
+
+const (
+	// ErrUnsupportedMessage means that there is no handler for the message or
+	// no creator for the task.
+	ErrUnsupportedMessage ErrorCode = "unsupported-message"
+	// ErrValidationFailed means that the protocol's input data isn't valid.
+	ErrValidationFailed ErrorCode = "validation-failed"
+	// ErrSARejected means that the controller of the agent declined to
+	// continue the protocol.
+	ErrSARejected ErrorCode = "sa-rejected"
+	// ErrTransportFailed means that the message couldn't be delivered to the
+	// other end.
+	ErrTransportFailed ErrorCode = "transport-failed"
+	// ErrAgentPaused means that the agent doesn't start new protocols for
+	// now, see SetPaused.
+	ErrAgentPaused ErrorCode = "agent-paused"
+)
+
+// ProtocolError is the typed error of protocol processing. Err is the cause,
+// if any.
+type ProtocolError struct {
+	Code ErrorCode
+	Msg  string
+	Err  error
+}
+
+// NewProtocolError returns a protocol error with the formatted message.
+func NewProtocolError(code ErrorCode, format string, a ...any) *ProtocolError {
+	return &ProtocolError{Code: code, Msg: fmt.Sprintf(format, a...)}
+}
+
+// WrapProtocolError wraps the error to a protocol error with the code. Nil
+// and errors which already have a code are returned as is.
+func WrapProtocolError(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := ProtocolErrorCode(err); ok {
+		return err
+	}
+	return &ProtocolError{Code: code, Err: err}
+}
+
+// ProtocolErrorCode returns the code of the first ProtocolError in the
+// error's chain.
+func ProtocolErrorCode(err error) (code ErrorCode, ok bool) {
+	var pErr *ProtocolError
+	if errors.As(err, &pErr) {
+		return pErr.Code, true
+	}
+	return "", false
+}
+
+func (e *ProtocolError) Error() string {
+	switch {
+	case e.Err == nil:
+		return fmt.Sprintf("%s: %s", e.Code, e.Msg)
+	case e.Msg == "":
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	default:
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Msg, e.Err)
+	}
+}
+
+func (e *ProtocolError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,178 @@
+package comm
+
+import (
+	"fmt"
+	"sync"
+
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// The feature flags of the agents select the behavior of the single agent at
+// the decision points of the protocols, e.g. auto-accepting for test agents
+// and strict checks for production ones. A flag which isn't set for the agent
+// falls back to the default behavior of the decision point.
+const (
+	// FeatureAutoAcceptProofs accepts the proof requests and presentations
+	// without the user action. The default comes from AutoPermission.
+	FeatureAutoAcceptProofs = "auto_accept_proofs"
+
+	// FeatureAllowSelfAttested lets the prover self-attest the requested
+	// attributes without restrictions and the verifier accept them. It's on
+	// by default.
+	FeatureAllowSelfAttested = "allow_self_attested"
+
+	// FeatureRequireRevocationCheck makes the verifier accept only the proofs
+	// of which every credential has the revocation state. It's off by
+	// default.
+	FeatureRequireRevocationCheck = "require_revocation_check"
+
+	// FeatureRejectUnfulfillable makes the prover NACK the accepted proof
+	// request which its credentials cannot fulfill, and log the missing
+	// attributes and predicates. It's off by default.
+	FeatureRejectUnfulfillable = "reject_unfulfillable"
+)
+
+var knownFeatures = map[string]bool{
+	FeatureAutoAcceptProofs:       true,
+	FeatureAllowSelfAttested:      true,
+	FeatureRequireRevocationCheck: true,
+	FeatureRejectUnfulfillable:    true,
+}
+
+// features holds the feature flags keyed by the agent DIDs, which are the same
+// for the CA and its worker.
+var features = struct {
+	sync.RWMutex
+	agents map[string]map[string]bool
+}{
+	agents: make(map[string]map[string]bool),
+}
+
+// CheckFeature returns an error if the feature flag isn't known.
+func CheckFeature(name string) error {
+	if !knownFeatures[name] {
+		return fmt.Errorf("unknown feature flag: %q", name)
+	}
+	return nil
+}
+
+// SetFeatures replaces all of the feature flags of the agent, e.g. when they
+// are loaded with the agent. Empty flags clear them.
+func SetFeatures(agentDID string, flags map[string]bool) error {
+	for name := range flags {
+		if err := CheckFeature(name); err != nil {
+			return err
+		}
+	}
+	features.Lock()
+	defer features.Unlock()
+
+	if len(flags) == 0 {
+		delete(features.agents, agentDID)
+		return nil
+	}
+	features.agents[agentDID] = copyFlags(flags)
+	return nil
+}
+
+// SetFeature sets the feature flag of the agent, and returns all of its flags.
+func SetFeature(agentDID, name string, on bool) (map[string]bool, error) {
+	if err := CheckFeature(name); err != nil {
+		return nil, err
+	}
+	features.Lock()
+	defer features.Unlock()
+
+	flags := features.agents[agentDID]
+	if flags == nil {
+		flags = make(map[string]bool)
+		features.agents[agentDID] = flags
+	}
+	flags[name] = on
+	return copyFlags(flags), nil
+}
+
+// Features returns the feature flags set for the agent.
+func Features(agentDID string) map[string]bool {
+	features.RLock()
+	defer features.RUnlock()
+
+	return copyFlags(features.agents[agentDID])
+}
+
+// FeatureOr returns the feature flag of the agent, or def if it isn't set.
+func FeatureOr(agentDID, name string, def bool) bool {
+	features.RLock()
+	defer features.RUnlock()
+
+	if on, ok := features.agents[agentDID][name]; ok {
+		return on
+	}
+	return def
+}
+
+// AutoAccept tells if the receiver accepts the protocol step of the feature
+// flag without the user action. Without the flag AutoPermission decides.
+func AutoAccept(r Receiver, name string) bool {
+	features.RLock()
+	on, ok := features.agents[r.MyDID().Did()][name]
+	features.RUnlock()
+
+	if ok {
+		return on
+	}
+	return r.AutoPermission()
+}
+
+// ConnectionAutoAccept tells if the receiver accepts the protocol step on the
+// connection without the user action. The auto response of the connection
+// overrides the agent's feature flag and AutoPermission, which lets the
+// operator trust only some of the partners. The empty feature name skips the
+// flag.
+func ConnectionAutoAccept(r Receiver, connID, name string) bool {
+	if connID != "" {
+		conn, err := r.FindPWByID(connID)
+		if err != nil {
+			glog.Warningf("connection (%s) auto response: %v", connID, err)
+		} else if conn != nil {
+			switch conn.AutoResponse {
+			case storage.AutoResponseAccept:
+				return true
+			case storage.AutoResponseManual:
+				return false
+			}
+		}
+	}
+	if name == "" {
+		return r.AutoPermission()
+	}
+	return AutoAccept(r, name)
+}
+
+// SetAutoResponse sets the auto response of the connection, see
+// storage.AutoResponseAccept.
+func SetAutoResponse(rcvr Receiver, connID, response string) (err error) {
+	defer err2.Handle(&err, "set auto response")
+
+	switch response {
+	case storage.AutoResponseDefault, storage.AutoResponseAccept,
+		storage.AutoResponseManual:
+	default:
+		return fmt.Errorf("unknown auto response: %q", response)
+	}
+	store := agentStorage(rcvr).ConnectionStorage()
+	conn := try.To1(store.GetConnection(connID))
+	conn.AutoResponse = response
+	return store.SaveConnection(*conn)
+}
+
+func copyFlags(flags map[string]bool) map[string]bool {
+	c := make(map[string]bool, len(flags))
+	for name, on := range flags {
+		c[name] = on
+	}
+	return c
+}
@@ -0,0 +1,104 @@
+package comm
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/ssi"
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-agent/core"
+	"github.com/lainio/err2/assert"
+)
+
+type featureRcvr struct {
+	Receiver
+	did        string
+	permissive bool
+}
+
+func (r *featureRcvr) MyDID() core.DID {
+	return ssi.NewDid(r.did, "verkey")
+}
+
+func (r *featureRcvr) AutoPermission() bool {
+	return r.permissive
+}
+
+func TestFeatures(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	test := &featureRcvr{did: "testAgent", permissive: true}
+	prod := &featureRcvr{did: "prodAgent", permissive: true}
+	defer func() {
+		assert.NoError(SetFeatures(test.did, nil))
+		assert.NoError(SetFeatures(prod.did, nil))
+	}()
+
+	// without flags AutoPermission decides
+	assert.That(AutoAccept(test, FeatureAutoAcceptProofs))
+	assert.That(AutoAccept(prod, FeatureAutoAcceptProofs))
+
+	flags, err := SetFeature(prod.did, FeatureAutoAcceptProofs, false)
+	assert.NoError(err)
+	assert.DeepEqual(flags, map[string]bool{FeatureAutoAcceptProofs: false})
+	_, err = SetFeature(prod.did, FeatureRequireRevocationCheck, true)
+	assert.NoError(err)
+
+	assert.That(!AutoAccept(prod, FeatureAutoAcceptProofs))
+	assert.That(FeatureOr(prod.did, FeatureRequireRevocationCheck, false))
+	assert.That(FeatureOr(prod.did, FeatureAllowSelfAttested, true))
+
+	// the other agent isn't affected
+	assert.That(AutoAccept(test, FeatureAutoAcceptProofs))
+	assert.That(!FeatureOr(test.did, FeatureRequireRevocationCheck, false))
+	assert.MLen(Features(test.did), 0)
+	assert.MLen(Features(prod.did), 2)
+
+	// the returned flags are copies
+	Features(prod.did)[FeatureAutoAcceptProofs] = true
+	assert.That(!AutoAccept(prod, FeatureAutoAcceptProofs))
+
+	_, err = SetFeature(test.did, "no_such_feature", true)
+	assert.Error(err)
+	assert.Error(SetFeatures(test.did, map[string]bool{"no_such_feature": true}))
+	assert.MLen(Features(test.did), 0)
+
+	assert.NoError(SetFeatures(prod.did, nil))
+	assert.That(AutoAccept(prod, FeatureAutoAcceptProofs))
+}
+
+type connRcvr struct {
+	featureRcvr
+	conns map[string]*storage.Connection
+}
+
+func (r *connRcvr) FindPWByID(id string) (*storage.Connection, error) {
+	return r.conns[id], nil
+}
+
+func TestConnectionAutoAccept(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	r := &connRcvr{
+		featureRcvr: featureRcvr{did: "connAgent"},
+		conns: map[string]*storage.Connection{
+			"trusted": {ID: "trusted", AutoResponse: storage.AutoResponseAccept},
+			"manual":  {ID: "manual", AutoResponse: storage.AutoResponseManual},
+			"other":   {ID: "other"},
+		},
+	}
+
+	// the agent requires the user action by default
+	assert.That(ConnectionAutoAccept(r, "trusted", FeatureAutoAcceptProofs))
+	assert.That(ConnectionAutoAccept(r, "trusted", ""))
+	assert.That(!ConnectionAutoAccept(r, "other", FeatureAutoAcceptProofs))
+	assert.That(!ConnectionAutoAccept(r, "other", ""))
+
+	// the permissive agent still waits on the manual connection
+	r.permissive = true
+	assert.That(!ConnectionAutoAccept(r, "manual", FeatureAutoAcceptProofs))
+	assert.That(!ConnectionAutoAccept(r, "manual", ""))
+	assert.That(ConnectionAutoAccept(r, "other", FeatureAutoAcceptProofs))
+	assert.That(ConnectionAutoAccept(r, "other", ""))
+}
@@ -0,0 +1,138 @@
+package comm
+
+import (
+	"time"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/sec"
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// SetQueueOffline turns the outbound queue of the connection on or off. When
+// it's on, the messages which cannot be delivered to the connection's endpoint
+// are stored to the agent's storage, and they are resent by RetryQueued until
+// the peer is reachable again. Already queued messages stay in the queue.
+func SetQueueOffline(rcvr Receiver, connID string, on bool) (err error) {
+	defer err2.Handle(&err, "set queue offline")
+
+	store := agentStorage(rcvr).ConnectionStorage()
+	conn := try.To1(store.GetConnection(connID))
+	conn.QueueOffline = on
+	return store.SaveConnection(*conn)
+}
+
+// SendOrQueuePL sends the payload like SendPL. If the connection's outbound
+// queue is on, see SetQueueOffline, the payload is queued when the sending
+// fails, and it's not an error. The payload is queued without sending as well
+// if the queue already has messages for the connection, which keeps the
+// messages in order.
+func SendOrQueuePL(
+	rcvr Receiver,
+	connID string,
+	sendPipe sec.Pipe,
+	task Task,
+	opl didcomm.Payload,
+) (
+	err error,
+) {
+	defer err2.Handle(&err, "send or queue payload")
+
+	eps, data := try.To2(packPL(sendPipe, task, opl))
+	return sendOrQueue(agentStorage(rcvr), connID, eps, data)
+}
+
+// RetryQueued tries to deliver the queued messages of all of the active
+// agents. It's meant to be called periodically by the scheduler.
+func RetryQueued() {
+	ActiveRcvrs.Lk.Lock()
+	rcvrs := make([]Receiver, 0, len(ActiveRcvrs.Rcvrs))
+	for _, r := range ActiveRcvrs.Rcvrs {
+		rcvrs = append(rcvrs, r)
+	}
+	ActiveRcvrs.Lk.Unlock()
+
+	for _, r := range rcvrs {
+		n, err := redeliver(agentStorage(r))
+		if err != nil {
+			glog.Errorln("retry queued messages:", err)
+			continue
+		}
+		if n > 0 {
+			glog.V(1).Infof("%d queued messages delivered by %s", n, r.WDID())
+		}
+	}
+}
+
+// sendOrQueue sends the data to the endpoints or queues it when the connection
+// has the queue on. The queued data is resent to the first endpoint.
+func sendOrQueue(
+	store storage.AgentStorage,
+	connID string,
+	eps []endpoint,
+	data []byte,
+) (
+	err error,
+) {
+	defer err2.Handle(&err)
+
+	conn, err := store.ConnectionStorage().GetConnection(connID)
+	if err != nil || !conn.QueueOffline {
+		err := sendRoute(eps, data)
+		reportConnection(connID, err)
+		return err
+	}
+
+	queue := store.MessageQueueStorage()
+	if len(try.To1(queue.QueuedMessages(connID))) == 0 {
+		err := sendRoute(eps, data)
+		reportConnection(connID, err)
+		if err == nil {
+			return nil
+		}
+		glog.Warningf("queueing message to connection (%s): %v", connID, err)
+	}
+	return queue.QueueMessage(storage.QueuedMessage{
+		ID:           utils.UUID(),
+		ConnectionID: connID,
+		Address:      eps[0].address,
+		Data:         data,
+		Created:      time.Now().UnixNano(),
+	})
+}
+
+// redeliver sends the queued messages and removes the delivered ones from the
+// queue. Delivery to the connection stops at its first failure to keep the
+// messages in order. It returns the amount of the delivered messages.
+func redeliver(store storage.AgentStorage) (n int, err error) {
+	defer err2.Handle(&err, "redeliver")
+
+	queue := store.MessageQueueStorage()
+	failed := make(map[string]bool)
+	for _, msg := range try.To1(queue.QueuedMessages("")) {
+		if failed[msg.ConnectionID] {
+			continue
+		}
+		err := send(msg.Address, msg.Data)
+		reportConnection(msg.ConnectionID, err)
+		if err != nil {
+			glog.V(3).Infof("connection (%s) still offline: %v",
+				msg.ConnectionID, err)
+			failed[msg.ConnectionID] = true
+			msg.Tries++
+			try.To(queue.QueueMessage(msg))
+			continue
+		}
+		try.To(queue.DequeueMessage(msg.ID))
+		n++
+	}
+	return n, nil
+}
+
+func agentStorage(rcvr Receiver) storage.AgentStorage {
+	_, mgdStorage := rcvr.ManagedWallet()
+	return mgdStorage.Storage()
+}
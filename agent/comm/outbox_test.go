@@ -0,0 +1,74 @@
+package comm
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-agent/agent/storage/mgddb"
+	"github.com/lainio/err2/assert"
+)
+
+func TestSendOrQueue(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	store, err := mgddb.New(storage.AgentStorageConfig{
+		AgentKey: mgddb.GenerateKey(),
+		AgentID:  "outbox_test",
+		FilePath: t.TempDir(),
+	})
+	assert.NoError(err)
+	defer store.Close()
+
+	assert.NoError(store.SaveConnection(storage.Connection{
+		ID:           "conn1",
+		QueueOffline: true,
+	}))
+	assert.NoError(store.SaveConnection(storage.Connection{ID: "conn2"}))
+
+	peerUp := false
+	delivered := make([]string, 0, 2)
+	orgSend := SendAndWaitReq
+	defer func() { SendAndWaitReq = orgSend }()
+	SendAndWaitReq = func(_ string, msg io.Reader, _ time.Duration) ([]byte, error) {
+		if !peerUp {
+			return nil, errors.New("connection refused")
+		}
+		data, _ := io.ReadAll(msg)
+		delivered = append(delivered, string(data))
+		return []byte{}, nil
+	}
+	queue := store.MessageQueueStorage()
+	peer := []endpoint{{address: "http://peer", weight: 1}}
+
+	// peer is down: the message is queued, and it's not an error
+	assert.NoError(sendOrQueue(store, "conn1", peer, []byte("msg1")))
+	msgs, err := queue.QueuedMessages("conn1")
+	assert.NoError(err)
+	assert.SLen(msgs, 1)
+
+	n, err := redeliver(store)
+	assert.NoError(err)
+	assert.Equal(0, n)
+	msgs, _ = queue.QueuedMessages("conn1")
+	assert.Equal(1, msgs[0].Tries)
+
+	// the connection without the queue fails as before
+	assert.Error(sendOrQueue(store, "conn2", peer, []byte("msg")))
+
+	// peer is back, but the new message must wait its turn
+	peerUp = true
+	assert.NoError(sendOrQueue(store, "conn1", peer, []byte("msg2")))
+	assert.SLen(delivered, 0)
+
+	n, err = redeliver(store)
+	assert.NoError(err)
+	assert.Equal(2, n)
+	assert.DeepEqual([]string{"msg1", "msg2"}, delivered)
+	msgs, err = queue.QueuedMessages("")
+	assert.NoError(err)
+	assert.SLen(msgs, 0)
+}
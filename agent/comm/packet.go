@@ -3,10 +3,15 @@ package comm
 import (
 	"github.com/findy-network/findy-agent/agent/didcomm"
 	"github.com/findy-network/findy-agent/agent/endp"
+	"github.com/findy-network/findy-agent/std/decorator"
 )
 
 type Packet struct {
 	Payload  didcomm.Payload
 	Address  *endp.Addr
 	Receiver Receiver
+
+	// PleaseAck is the sender's ack request of the incoming message. It's nil
+	// if the sender didn't ask acks.
+	PleaseAck *decorator.PleaseAck
 }
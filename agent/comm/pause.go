@@ -0,0 +1,44 @@
+package comm
+
+import "sync"
+
+// paused holds the agents which don't start new protocols, e.g. during the
+// maintenance. The running protocols are processed normally. The agents are
+// keyed by their worker DIDs, and all means that the whole agency is paused.
+var paused = struct {
+	sync.RWMutex
+	all    bool
+	agents map[string]bool
+}{
+	agents: make(map[string]bool),
+}
+
+// SetPaused pauses or resumes the agent of the worker DID.
+func SetPaused(wDID string, pause bool) {
+	paused.Lock()
+	defer paused.Unlock()
+
+	if pause {
+		paused.agents[wDID] = true
+	} else {
+		delete(paused.agents, wDID)
+	}
+}
+
+// SetAllPaused pauses or resumes the whole agency. Resuming the agency doesn't
+// resume the agents paused with SetPaused.
+func SetAllPaused(pause bool) {
+	paused.Lock()
+	defer paused.Unlock()
+
+	paused.all = pause
+}
+
+// IsPaused tells if the agent of the worker DID is paused by itself or by the
+// agency.
+func IsPaused(wDID string) bool {
+	paused.RLock()
+	defer paused.RUnlock()
+
+	return paused.all || paused.agents[wDID]
+}
@@ -1,6 +1,8 @@
 package comm
 
 import (
+	"sort"
+
 	"github.com/findy-network/findy-agent/agent/didcomm"
 	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
 	"github.com/golang/glog"
@@ -30,11 +32,32 @@ func (p *processor) Process(packet Packet) (err error) {
 		glog.Errorf("No handler in processor for Type: %s\nPL:\n%s",
 			packet.Payload.Type(),
 			string(packet.Payload.JSON()))
-		panic("No handler in processor")
+		return NewProtocolError(ErrUnsupportedMessage,
+			"no protocol handler for %s", packet.Payload.Type())
 	}
 	return handler.Process(packet)
 }
 
+// Protocols returns the protocol families which have a registered handler in
+// sorted order.
+func (p *processor) Protocols() []string {
+	protocols := make([]string, 0, len(p.protHandlers))
+	for protocol := range p.protHandlers {
+		protocols = append(protocols, protocol)
+	}
+	sort.Strings(protocols)
+	return protocols
+}
+
+// Versions returns the versions of the protocol family we implement. Protocol
+// processors which don't declare their versions implement only the 1.0.
+func (p *processor) Versions(protocol string) []string {
+	if pp, ok := p.protHandlers[protocol].(ProtProc); ok && len(pp.Versions) > 0 {
+		return pp.Versions
+	}
+	return []string{"1.0"}
+}
+
 func (p *processor) Add(t string, proc ProtHandler) {
 	if p.protHandlers == nil {
 		p.protHandlers = make(map[string]ProtHandler)
@@ -62,6 +85,10 @@ type ProtProc struct {
 	Handlers map[string]HandlerFunc
 	Continuator
 	FillStatus
+
+	// Versions are the protocol versions the Handlers understand. Nil means
+	// that only the 1.0 is supported.
+	Versions []string
 }
 
 type Creator func(header *TaskHeader, protocol *pb.Protocol) (Task, error)
@@ -80,9 +107,9 @@ func (p ProtProc) Process(packet Packet) (err error) {
 	handler, ok := p.Handlers[packet.Payload.ProtocolMsg()]
 	if !ok {
 		glog.Info(string(packet.Payload.JSON()))
-		s := "!!!! No handler in processor !!!"
-		glog.Error(s)
-		panic(s)
+		glog.Error("!!!! No handler in processor !!!")
+		return NewProtocolError(ErrUnsupportedMessage,
+			"no message handler for %s", packet.Payload.Type())
 	}
 	return handler(packet)
 }
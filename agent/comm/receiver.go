@@ -33,6 +33,7 @@ type Receiver interface {
 	CAEndp(connID string) (endP *endp.Addr)
 	AddPipeToPWMap(p sec.Pipe, name string)
 	MasterSecret() (string, error)
+	PrevMasterSecrets() ([]string, error)
 	AutoPermission() bool
 	ID() string
 }
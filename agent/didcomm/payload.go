@@ -132,6 +132,13 @@ type CredentialAttribute struct {
 	Name     string `json:"name,omitempty"`
 	Value    string `json:"value,omitempty"`
 	MimeType string `json:"mime-type,omitempty"`
+	DataType string `json:"data-type,omitempty"`
+}
+
+// CanonicalAttrName returns the attribute name in the form anoncreds compares
+// them: lower case without white space.
+func CanonicalAttrName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
 }
 
 // ProofAttribute for proof request attributes
@@ -141,6 +148,10 @@ type ProofAttribute struct {
 	CredDefID string `json:"credDefId,omitempty"`
 	Predicate string `json:"predicate,omitempty"`
 	Value     string `json:"-"`
+
+	// Equals are the attribute values by name which the credential of the
+	// attribute must have, e.g. {"country": "FI"}. They aren't revealed.
+	Equals map[string]string `json:"equals,omitempty"`
 }
 
 // ProofPredicate for proof request predicates
@@ -167,6 +178,10 @@ type Msg interface {
 	SubLevelID() string
 
 	Ready() bool
+
+	// Info is the free form data of the user action, e.g. the countered
+	// proof request.
+	Info() string
 }
 
 // MsgInit is a helper struct for factors to construct new message instances.
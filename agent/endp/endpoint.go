@@ -149,6 +149,18 @@ func (e *Addr) Address() string {
 	return strings.TrimSuffix(basePath, "/")
 }
 
+// ServiceAddr returns a copy of the address where the service name is replaced
+// with the given one, e.g. a protocol family specific service name. The API
+// version of the address is kept.
+func (e *Addr) ServiceAddr(serviceName string) *Addr {
+	addr := *e
+	if e.v2Api {
+		serviceName += Version2EndpSuffix
+	}
+	addr.Service = serviceName
+	return &addr
+}
+
 func (e *Addr) IsEncrypted() bool {
 	return !IsInEndpoints(e.PlRcvr)
 }
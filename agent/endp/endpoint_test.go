@@ -207,6 +207,28 @@ func TestNewEndp(t *testing.T) {
 	}
 }
 
+func TestAddr_ServiceAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"v1", "http://host/a2a/endpoint/transport/connID", "http://host/a2a-proof/endpoint/transport/connID"},
+		{"v2", "http://host/a2a-2/endpoint/transport/connID", "http://host/a2a-proof-2/endpoint/transport/connID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ea := NewClientAddr(tt.url)
+			if got := ea.ServiceAddr("a2a-proof").Address(); got != tt.want {
+				t.Errorf("ServiceAddr() = %v, want %v", got, tt.want)
+			}
+			if ea.Address() != tt.url {
+				t.Errorf("original address changed: %v", ea.Address())
+			}
+		})
+	}
+}
+
 func TestNewEndpAddr(t *testing.T) {
 	type args struct {
 		s string
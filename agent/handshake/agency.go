@@ -8,17 +8,20 @@ package handshake
 
 import (
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/findy-network/findy-agent/agent/accessmgr"
 	"github.com/findy-network/findy-agent/agent/agency"
 	"github.com/findy-network/findy-agent/agent/cloud"
+	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/ssi"
 	"github.com/findy-network/findy-agent/agent/utils"
 	"github.com/findy-network/findy-agent/core"
 	"github.com/findy-network/findy-agent/enclave"
 	"github.com/findy-network/findy-agent/method"
+	"github.com/findy-network/findy-common-go/dto"
 	"github.com/findy-network/findy-wrapper-go"
 	"github.com/findy-network/findy-wrapper-go/anoncreds"
 	"github.com/golang/glog"
@@ -116,10 +119,9 @@ func AnchorAgent(agentName, seed string) (agent *cloud.Agent, caDid core.DID, er
 	try.To(enclave.SetKeysDID(key, caDid.Did()))
 
 	glog.V(2).Infof("Creating a master secret into agent wallet (%s)", caDid.Did())
-	masterSec, err := enclave.NewWalletMasterSecret(caDid.Did())
-	assert.NoError(err)
+	masterSec := try.To1(enclave.NewWalletMasterSecret(caDid.Did()))
 	r := <-anoncreds.ProverCreateMasterSecret(ca.Wallet(), masterSec)
-	assert.NoError(r.Err())
+	try.To(r.Err())
 	assert.Equal(masterSec, r.Str1())
 
 	return agent, caDid, nil
@@ -152,9 +154,16 @@ func LoadRegistered(filename string) (err error) {
 			email := values[0]
 			rootDid := values[1]
 			caVerKey := ""
-			if len(values) == 3 {
+			if len(values) >= 3 {
 				caVerKey = values[2]
 			}
+			poolName := ""
+			if len(values) >= 4 {
+				poolName = values[3]
+			}
+			if len(values) >= 5 && values[4] != "" {
+				loadFeatures(caDID, values[4])
+			}
 			name := strings.Replace(email, "@", "_", -1)
 
 			// don't let crash on panics
@@ -185,8 +194,9 @@ func LoadRegistered(filename string) (err error) {
 
 				alreadyRegistered[name] = true
 
-				agency.AddSeedHandler(caDID,
-					cloud.NewSeedAgent(rootDid, caDID, caVerKey, aw))
+				sa := cloud.NewSeedAgent(rootDid, caDID, caVerKey, aw)
+				sa.PoolName = poolName
+				agency.AddSeedHandler(caDID, sa)
 			} else {
 				glog.Fatal("Duplicate registered wallet!")
 			}
@@ -199,6 +209,61 @@ func LoadRegistered(filename string) (err error) {
 	return nil
 }
 
+// SetAgentPool selects the ledger pool the CA and its worker resolve their
+// DIDs and credential definitions against, and stores it to the agent's entry
+// in the register. Empty pool name returns the agent to the agency's default
+// pool.
+func SetAgentPool(ca *cloud.Agent, poolName string) (err error) {
+	defer err2.Handle(&err, "set agent pool")
+
+	caDID := ca.MyDID().Did()
+	values, ok := agency.Register.Get(caDID)
+	assert.That(ok && len(values) >= 2, "agent (%s) not registered", caDID)
+
+	for len(values) < 4 {
+		values = append(values, "")
+	}
+	values[3] = poolName
+	agency.Register.Add(caDID, values...)
+	agency.SaveRegistered()
+
+	ca.SetPoolName(poolName)
+	try.To1(ca.WEA()).SetPoolName(poolName)
+	return nil
+}
+
+// loadFeatures sets the feature flags of the agent from the register. The
+// agent is loaded even if the flags cannot be read.
+func loadFeatures(caDID, flagsJSON string) {
+	var flags map[string]bool
+	err := json.Unmarshal([]byte(flagsJSON), &flags)
+	if err == nil {
+		err = comm.SetFeatures(caDID, flags)
+	}
+	if err != nil {
+		glog.Warningf("agent (%s) feature flags: %v", caDID, err)
+	}
+}
+
+// SetAgentFeature sets the feature flag of the agent of the CA DID, see
+// comm.SetFeature, and stores the agent's flags to its entry in the register.
+// It returns all of the flags set for the agent.
+func SetAgentFeature(caDID, name string, on bool) (_ map[string]bool, err error) {
+	defer err2.Handle(&err, "set agent feature")
+
+	values, ok := agency.Register.Get(caDID)
+	assert.That(ok && len(values) >= 2, "agent (%s) not registered", caDID)
+
+	flags := try.To1(comm.SetFeature(caDID, name, on))
+	for len(values) < 5 {
+		values = append(values, "")
+	}
+	values[4] = dto.ToJSON(flags)
+	agency.Register.Add(caDID, values...)
+	agency.SaveRegistered()
+	return flags, nil
+}
+
 // SetStewardFromWallet sets steward DID for us from pre-created wallet and
 // named DID string.
 func SetStewardFromWallet(wallet *ssi.Wallet, DID string) (stwd *cloud.Agent) {
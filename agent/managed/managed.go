@@ -7,6 +7,7 @@ import storage "github.com/findy-network/findy-agent/agent/storage/api"
 // configurations with ssi.Wallet and open them with ssi.Wallets.Open().
 type Wallet interface {
 	Close()
+	Cycle() int
 	Handle() int
 	Config() WalletCfg
 	Storage() storage.AgentStorage
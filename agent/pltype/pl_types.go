@@ -85,6 +85,20 @@ const (
 	DIDOrgIssueCredentialACK               = DIDOrgIssueCredential + "/1.0/" + HandlerIssueCredentialACK
 	DIDOrgIssueCredentialNACK              = DIDOrgIssueCredential + "/1.0/" + HandlerIssueCredentialNACK
 	DIDOrgIssueCredentialCredentialPreview = DIDOrgIssueCredential + "/1.0/" + ObjectTypeCredentialPreview
+
+	// Issue Credential 2.0 exists only in the didcomm.org namespace. The
+	// attachments are described with the formats, see
+	// IssueCredentialFormatIndy*.
+	DIDOrgIssueCredentialV2Offer             = DIDOrgIssueCredential + "/2.0/" + HandlerIssueCredentialOffer
+	DIDOrgIssueCredentialV2Request           = DIDOrgIssueCredential + "/2.0/" + HandlerIssueCredentialRequest
+	DIDOrgIssueCredentialV2Issue             = DIDOrgIssueCredential + "/2.0/" + HandlerIssueCredentialIssue
+	DIDOrgIssueCredentialV2ACK               = DIDOrgIssueCredential + "/2.0/" + HandlerIssueCredentialACK
+	DIDOrgIssueCredentialV2NACK              = DIDOrgIssueCredential + "/2.0/" + HandlerIssueCredentialNACK
+	DIDOrgIssueCredentialV2CredentialPreview = DIDOrgIssueCredential + "/2.0/" + ObjectTypeCredentialPreview
+
+	IssueCredentialFormatIndyOffer   = "hlindy/cred-abstract@v2.0"
+	IssueCredentialFormatIndyRequest = "hlindy/cred-req@v2.0"
+	IssueCredentialFormatIndy        = "hlindy/cred@v2.0"
 )
 
 // DID exchange aka Connection related constants
@@ -126,6 +140,14 @@ const (
 	DIDOrgAriesDIDExchangeRequest  = DIDOrgAriesDIDExchange + "/1.0/" + HandlerRequest
 	DIDOrgAriesDIDExchangeResponse = DIDOrgAriesDIDExchange + "/1.0/" + HandlerResponse
 	DIDOrgAriesDIDExchangeComplete = DIDOrgAriesDIDExchange + "/1.0/" + HandlerComplete
+
+	// DID Exchange 1.1 exchanges peer DIDs, and the responder signs its DID
+	// with the invitation key.
+	DIDOrgAriesDIDExchange10         = DIDOrgAriesDIDExchange + "/1.0"
+	DIDOrgAriesDIDExchange11         = DIDOrgAriesDIDExchange + "/1.1"
+	DIDOrgAriesDIDExchangeRequest11  = DIDOrgAriesDIDExchange11 + "/" + HandlerRequest
+	DIDOrgAriesDIDExchangeResponse11 = DIDOrgAriesDIDExchange11 + "/" + HandlerResponse
+	DIDOrgAriesDIDExchangeComplete11 = DIDOrgAriesDIDExchange11 + "/" + HandlerComplete
 )
 
 // Present Proof protocol constants
@@ -155,6 +177,17 @@ const (
 	DIDOrgPresentProofACK          = DIDOrgPresentProof + "/1.0/" + HandlerPresentProofACK
 	DIDOrgPresentProofNACK         = DIDOrgPresentProof + "/1.0/" + HandlerPresentProofNACK
 	DIDOrgPresentationPreviewObj   = DIDOrgPresentProof + "/1.0/" + ObjectTypePresentationPreview
+
+	// Present Proof 2.0 exists only in the didcomm.org namespace. The message
+	// names are the same as in 1.0, but the attachments are described with
+	// the formats, see PresentProofFormatIndy*.
+	DIDOrgPresentProofV2Request      = DIDOrgPresentProof + "/2.0/" + HandlerPresentProofRequest
+	DIDOrgPresentProofV2Presentation = DIDOrgPresentProof + "/2.0/" + HandlerPresentProofPresentation
+	DIDOrgPresentProofV2ACK          = DIDOrgPresentProof + "/2.0/" + HandlerPresentProofACK
+	DIDOrgPresentProofV2NACK         = DIDOrgPresentProof + "/2.0/" + HandlerPresentProofNACK
+
+	PresentProofFormatIndyRequest = "hlindy/proof-req@v2.0"
+	PresentProofFormatIndyProof   = "hlindy/proof@v2.0"
 )
 
 // Basic Message protocol constants
@@ -182,6 +215,20 @@ const (
 	DIDOrgTrustPingResponse = DIDOrgTrustPing + "/1.0/" + HandlerPingResponse
 )
 
+// Discover Features protocol constants
+const (
+	ProtocolDiscoverFeatures = "discover-features"
+	HandlerQuery             = "query"
+	HandlerDisclose          = "disclose"
+	DiscoverFeatures         = Aries + "/" + ProtocolDiscoverFeatures
+	DiscoverFeaturesQuery    = DiscoverFeatures + "/1.0/" + HandlerQuery
+	DiscoverFeaturesDisclose = DiscoverFeatures + "/1.0/" + HandlerDisclose
+
+	DIDOrgDiscoverFeatures         = DIDOrgAries + "/" + ProtocolDiscoverFeatures
+	DIDOrgDiscoverFeaturesQuery    = DIDOrgDiscoverFeatures + "/1.0/" + HandlerQuery
+	DIDOrgDiscoverFeaturesDisclose = DIDOrgDiscoverFeatures + "/1.0/" + HandlerDisclose
+)
+
 // SA API msg types
 const (
 	SAPing                         = SA + "/ping/1.0/ping"
@@ -230,6 +277,8 @@ const (
 	CANotify           = CA + "/notify"
 	CANotifyStatus     = CANotify + "/1.0/status"
 	CANotifyUserAction = CANotify + "/1.0/user-action"
+	CANotifyConnection = CANotify + "/1.0/connection"
+	CANotifyCredential = CANotify + "/1.0/credential"
 
 	// Protocol launchers - protocol string must match Aries protocol
 	CACred        = CA + "/" + ProtocolIssueCredential
@@ -244,6 +293,9 @@ const (
 	// Protocol launcher - protocol string must match Aries protocol
 	CABasicMessage = CA + "/" + ProtocolBasicMessage + "/1.0/send"
 
+	// Protocol launcher - protocol string must match Aries protocol
+	CADiscoverFeatures = CA + "/" + ProtocolDiscoverFeatures + "/1.0/query"
+
 	CAProblemReport = CA + "/notification/1.0/problem_report"
 
 	CAPingOwnCA = CA + "/ping/1.0/own_ca"
@@ -284,4 +336,5 @@ var receiversRoleFromType = map[string]pb.Protocol_Role{
 	HandlerPresentProofPropose:    pb.Protocol_INITIATOR,
 	HandlerPing:                   pb.Protocol_ADDRESSEE,
 	HandlerMessage:                pb.Protocol_ADDRESSEE,
+	HandlerQuery:                  pb.Protocol_ADDRESSEE,
 }
@@ -1,12 +1,23 @@
 package pool
 
 import (
+	"sync"
+
 	"github.com/findy-network/findy-agent/agent/async"
 	indypool "github.com/findy-network/findy-wrapper-go/pool"
 )
 
 var pool async.Future
 
+// named pools are the ones agents have selected for themselves instead of
+// the agency's default pool, see HandleFor.
+var named = struct {
+	sync.Mutex
+	pools map[string]*async.Future
+}{
+	pools: make(map[string]*async.Future),
+}
+
 // Open opens ledger connection first time called. After that returns previous
 // handle without checking the pool name. If caller wants to reopen new pool it
 // must call Close() first.
@@ -22,12 +33,43 @@ func Open(name string) *async.Future {
 	return &pool
 }
 
+// OpenNamed opens ledger connection to the named pool if it isn't already
+// open. Every pool name has its own connection which is shared by all of the
+// agents using it. Empty name means the default pool, see Open.
+func OpenNamed(name string) *async.Future {
+	if name == "" {
+		return &pool
+	}
+
+	named.Lock()
+	defer named.Unlock()
+
+	if f, ok := named.pools[name]; ok && !f.IsEmpty() && f.Int() > 0 {
+		return f
+	}
+	f := async.NewFuture(indypool.OpenLedger(name))
+	named.pools[name] = f
+	return f
+}
+
+// Close closes the default pool and all of the named pools.
 func Close() {
 	oldPool := Handle()
 	if oldPool != 0 {
 		pool.SetChan(indypool.CloseLedger(oldPool))
 		pool.Int() // call to make this a blocking call
 	}
+
+	named.Lock()
+	defer named.Unlock()
+
+	for name, f := range named.pools {
+		if !f.IsEmpty() && f.Int() != 0 {
+			f.SetChan(indypool.CloseLedger(f.Int()))
+			f.Int()
+		}
+		delete(named.pools, name)
+	}
 }
 
 func Handle() (v int) {
@@ -36,3 +78,12 @@ func Handle() (v int) {
 	}
 	return pool.Int()
 }
+
+// HandleFor returns the ledger handle of the named pool, and opens the pool if
+// needed. Empty name returns the handle of the default pool.
+func HandleFor(name string) (v int) {
+	if name == "" {
+		return Handle()
+	}
+	return OpenNamed(name).Int()
+}
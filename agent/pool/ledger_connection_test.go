@@ -35,3 +35,34 @@ func TestLedgerConnection_Open(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleFor(t *testing.T) {
+	pool.V = indyDto.Result{Data: indyDto.Data{Handle: 1}}
+	pool.On = async.Consumed
+	named.pools["pool-a"] = &async.Future{
+		V: indyDto.Result{Data: indyDto.Data{Handle: 2}}, On: async.Consumed}
+	named.pools["pool-b"] = &async.Future{
+		V: indyDto.Result{Data: indyDto.Data{Handle: 3}}, On: async.Consumed}
+	defer func() {
+		delete(named.pools, "pool-a")
+		delete(named.pools, "pool-b")
+	}()
+
+	tests := []struct {
+		name     string
+		poolName string
+		want     int
+	}{
+		{"default", "", 1},
+		{"agent A", "pool-a", 2},
+		{"agent B", "pool-b", 3},
+		{"agent A again", "pool-a", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HandleFor(tt.poolName); got != tt.want {
+				t.Errorf("HandleFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package prot
+
+import (
+	"strings"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/sec"
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// Statuses of the acks we send for the please ack requests.
+const (
+	ackStatusOK   = "OK"
+	ackStatusFail = "FAIL"
+)
+
+// sendOrQueuePL is the sender of the acks, which tests can replace.
+var sendOrQueuePL = comm.SendOrQueuePL
+
+// sendPleasedAck sends the ack which the sender of the packet asked at the
+// point, see decorator.PleaseAck. The ack is threaded to the protocol.
+func sendPleasedAck(
+	packet comm.Packet,
+	task comm.Task,
+	on, status string,
+) (err error) {
+	defer err2.Handle(&err, "send please ack (%s)", on)
+
+	if !packet.PleaseAck.Wants(on) {
+		return nil
+	}
+	connID := packet.Address.ConnID
+	pipe := try.To1(packet.Receiver.PwPipe(connID))
+	task.SetReceiverEndp(try.To1(pipe.EA()))
+
+	ackType := pltype.NotificationAck
+	if strings.HasPrefix(packet.Payload.Type(), pltype.DIDOrgAries) {
+		ackType = pltype.DIDOrgNotificationAck
+	}
+	msg := aries.MsgCreator.Create(didcomm.MsgInit{
+		Type:   ackType,
+		Info:   status,
+		Thread: packet.Payload.Thread(),
+	})
+	opl := aries.PayloadCreator.NewMsg(utils.UUID(), ackType, msg)
+
+	utils.V(packet.Receiver.WDID(), 3).Infoln("sending please ack", on, "for",
+		packet.Payload.ID())
+	return sendOrQueuePL(packet.Receiver, connID, pipe, task, opl)
+}
+
+// withPleaseAck returns the payload asking the acks at the points. The payload
+// isn't changed if there are no points.
+func withPleaseAck(opl didcomm.Payload, on []string) (_ didcomm.Payload, err error) {
+	defer err2.Handle(&err, "please ack")
+
+	if len(on) == 0 {
+		return opl, nil
+	}
+	data := try.To1(decorator.AddPleaseAck(opl.JSON(), on...))
+	return &pleaseAckPayload{Payload: opl, data: data}, nil
+}
+
+// pleaseAckPayload is the outgoing payload with the please ack decorator which
+// the typed messages don't have.
+type pleaseAckPayload struct {
+	didcomm.Payload
+	data []byte
+}
+
+func (p *pleaseAckPayload) JSON() []byte {
+	return p.data
+}
+
+// sendWithPleaseAck sends the payload asking the acks at the points, and
+// counts the request to the PSM.
+func sendWithPleaseAck(
+	rcvr comm.Receiver,
+	key psm.StateKey,
+	connID string,
+	pipe sec.Pipe,
+	task comm.Task,
+	opl didcomm.Payload,
+	on []string,
+) (err error) {
+	defer err2.Handle(&err)
+
+	try.To(comm.SendOrQueuePL(rcvr, connID, pipe, task,
+		try.To1(withPleaseAck(opl, on))))
+	if len(on) == 0 {
+		return nil
+	}
+	return updateAcks(key, func(m *psm.PSM) { m.AcksRequested++ })
+}
+
+// AckReceived counts the ack received for the message asking it to the PSM of
+// the protocol.
+func AckReceived(key psm.StateKey) (err error) {
+	defer err2.Handle(&err, "ack received")
+
+	return updateAcks(key, func(m *psm.PSM) { m.AcksReceived++ })
+}
+
+func updateAcks(key psm.StateKey, update func(m *psm.PSM)) (err error) {
+	defer err2.Handle(&err)
+
+	m := try.To1(psm.GetPSM(key))
+	update(m)
+	return psm.AddPSM(m)
+}
@@ -0,0 +1,102 @@
+package prot
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/endp"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/sec"
+	"github.com/findy-network/findy-agent/agent/service"
+	"github.com/findy-network/findy-agent/core"
+	"github.com/findy-network/findy-agent/std/common"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/lainio/err2/assert"
+)
+
+type ackReceiver struct {
+	comm.Receiver
+}
+
+func (r *ackReceiver) WDID() string {
+	return "worker-did"
+}
+
+func (r *ackReceiver) PwPipe(string) (sec.Pipe, error) {
+	return sec.Pipe{Out: &ackDID{}}, nil
+}
+
+type ackDID struct {
+	core.DID
+}
+
+func (d *ackDID) AEndp() (service.Addr, error) {
+	return service.Addr{Endp: "http://localhost:8080/ack", Key: "verkey"}, nil
+}
+
+func TestSendPleasedAck(t *testing.T) {
+	defer assert.PushTester(t)()
+
+	var sent []didcomm.Payload
+	orig := sendOrQueuePL
+	sendOrQueuePL = func(
+		_ comm.Receiver,
+		connID string,
+		_ sec.Pipe,
+		task comm.Task,
+		opl didcomm.Payload,
+	) error {
+		assert.Equal(connID, "conn")
+		assert.Equal(task.ReceiverEndp().Endp, "http://localhost:8080/ack")
+		sent = append(sent, opl)
+		return nil
+	}
+	defer func() { sendOrQueuePL = orig }()
+
+	data := []byte(`{
+		"@id": "msg-id",
+		"@type": "https://didcomm.org/basicmessage/1.0/message",
+		"~thread": {"thid": "thread-id"},
+		"~please_ack": {"on": ["RECEIPT"]}
+	}`)
+	packet := comm.Packet{
+		Payload:   aries.PayloadCreator.NewFromData(data),
+		Address:   &endp.Addr{ConnID: "conn"},
+		Receiver:  &ackReceiver{},
+		PleaseAck: decorator.PleaseAckOf(data),
+	}
+	task := &comm.TaskBase{TaskHeader: comm.TaskHeader{TaskID: "thread-id"}}
+
+	// the outcome wasn't asked
+	assert.NoError(sendPleasedAck(packet, task, decorator.PleaseAckOutcome, ackStatusOK))
+	assert.SLen(sent, 0)
+
+	assert.NoError(sendPleasedAck(packet, task, decorator.PleaseAckReceipt, ackStatusOK))
+	assert.SLen(sent, 1)
+	assert.Equal(sent[0].Type(), pltype.DIDOrgNotificationAck)
+	assert.Equal(sent[0].ThreadID(), "thread-id")
+	ack := sent[0].MsgHdr().FieldObj().(*common.Ack)
+	assert.Equal(ack.Status, ackStatusOK)
+
+	// no ack without the decorator
+	packet.PleaseAck = nil
+	assert.NoError(sendPleasedAck(packet, task, decorator.PleaseAckReceipt, ackStatusOK))
+	assert.SLen(sent, 1)
+}
+
+func TestWithPleaseAck(t *testing.T) {
+	defer assert.PushTester(t)()
+
+	opl := aries.PayloadCreator.NewFromData(
+		[]byte(`{"@id":"1","@type":"https://didcomm.org/basicmessage/1.0/message"}`))
+	same, err := withPleaseAck(opl, nil)
+	assert.NoError(err)
+	assert.Equal(same, opl)
+
+	pl, err := withPleaseAck(opl, []string{decorator.PleaseAckReceipt})
+	assert.NoError(err)
+	assert.Equal(pl.ID(), "1")
+	assert.That(decorator.PleaseAckOf(pl.JSON()).Wants(decorator.PleaseAckReceipt))
+}
@@ -0,0 +1,66 @@
+package prot
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/utils"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// CommentVars are the variables of the default comment template, e.g.
+// "{{.Protocol}} from ACME to {{.ConnectionName}}".
+type CommentVars struct {
+	ConnectionID   string
+	ConnectionName string // their label of the connection
+	Protocol       string // protocol family like issue-credential
+}
+
+// TaskComment returns the comment for the protocol message the task starts.
+// If the client hasn't given one, the agency's default comment is used.
+func TaskComment(receiver comm.Receiver, t comm.Task, family, comment string) string {
+	if comment != "" || utils.Settings.ProtocolComment() == "" {
+		return comment
+	}
+	return Comment(utils.Settings.ProtocolComment(), comment, CommentVars{
+		ConnectionID:   t.ConnectionID(),
+		ConnectionName: theirLabel(receiver, t.ConnectionID()),
+		Protocol:       family,
+	})
+}
+
+// Comment returns the comment if it's given. If not, the comment is rendered
+// from the template. Rendering errors leave the comment empty.
+func Comment(tmpl, comment string, vars CommentVars) string {
+	if comment != "" || tmpl == "" {
+		return comment
+	}
+	defer err2.Catch(err2.Err(func(err error) {
+		glog.Warningln("default comment:", err)
+	}))
+
+	t := try.To1(template.New("comment").Parse(tmpl))
+	var b strings.Builder
+	try.To(t.Execute(&b, vars))
+	return b.String()
+}
+
+// theirLabel returns their label of the connection, or an empty string if
+// it's not available.
+func theirLabel(receiver comm.Receiver, connID string) (label string) {
+	defer err2.Catch(err2.Err(func(err error) {
+		glog.Warningf("connection (%s) label: %v", connID, err)
+	}), func(v any) {
+		glog.Warningf("connection (%s) label: %v", connID, v)
+	})
+
+	key := psm.StateKey{DID: receiver.WDID(), Nonce: connID}
+	ps := FillStatus(pltype.AriesProtocolConnection, key, &pb.ProtocolStatus{})
+	return ps.GetDIDExchange().GetTheirLabel()
+}
@@ -0,0 +1,34 @@
+package prot
+
+import (
+	"testing"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestComment(t *testing.T) {
+	vars := CommentVars{
+		ConnectionID:   "d84ef2d8-0d4c-4a7c-9c7e-8f1a5c8f4a35",
+		ConnectionName: "Alice",
+		Protocol:       "present-proof",
+	}
+	tests := []struct {
+		name    string
+		tmpl    string
+		comment string
+		want    string
+	}{
+		{"default", "{{.Protocol}} for {{.ConnectionName}}", "", "present-proof for Alice"},
+		{"supplied", "{{.Protocol}} for {{.ConnectionName}}", "given", "given"},
+		{"no default", "", "", ""},
+		{"bad template", "{{.Protocol", "", ""},
+		{"unknown var", "{{.Unknown}}", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer assert.PushTester(t)()
+
+			assert.Equal(Comment(tt.tmpl, tt.comment, vars), tt.want)
+		})
+	}
+}
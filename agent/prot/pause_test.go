@@ -0,0 +1,75 @@
+package prot
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/lainio/err2/assert"
+)
+
+type pauseReceiver struct {
+	comm.Receiver
+	wDID string
+}
+
+func (r *pauseReceiver) WDID() string {
+	return r.wDID
+}
+
+func TestFindAndStartTask_Paused(t *testing.T) {
+	defer assert.PushTester(t)()
+
+	const typeID = "test/pause/1.0"
+	started := make(chan string, 1)
+	continued := make(chan string, 1)
+	AddStarter(typeID, comm.ProtProc{
+		Starter: func(_ comm.Receiver, task comm.Task) {
+			started <- task.ID()
+		},
+	})
+	AddContinuator(typeID, comm.ProtProc{
+		Continuator: func(_ comm.Receiver, im didcomm.Msg) {
+			continued <- im.SubLevelID()
+		},
+	})
+	defer func() {
+		delete(starters, typeID)
+		delete(continuators, typeID)
+	}()
+
+	rcvr := &pauseReceiver{wDID: "pausedDID"}
+	task := &comm.TaskBase{TaskHeader: comm.TaskHeader{
+		TaskID: "task-id",
+		TypeID: typeID,
+	}}
+
+	comm.SetPaused(rcvr.wDID, true)
+	defer comm.SetPaused(rcvr.wDID, false)
+
+	err := FindAndStartTask(rcvr, task)
+	assert.Error(err)
+	code, ok := comm.ProtocolErrorCode(err)
+	assert.That(ok)
+	assert.Equal(code, comm.ErrAgentPaused)
+	assert.Equal(len(started), 0)
+
+	// the running protocols are continued
+	Resume(rcvr, typeID, "running-id", true, "")
+	assert.Equal(<-continued, "running-id")
+
+	// other agents aren't paused
+	assert.That(!comm.IsPaused("otherDID"))
+
+	comm.SetPaused(rcvr.wDID, false)
+	comm.SetAllPaused(true)
+	defer comm.SetAllPaused(false)
+	assert.That(comm.IsPaused(rcvr.wDID))
+	assert.That(comm.IsPaused("otherDID"))
+	err = FindAndStartTask(rcvr, task)
+	code, _ = comm.ProtocolErrorCode(err)
+	assert.Equal(code, comm.ErrAgentPaused)
+
+	comm.SetAllPaused(false)
+	assert.That(!comm.IsPaused(rcvr.wDID))
+}
@@ -25,6 +25,7 @@ type Transition struct {
 	SendOnNACK  string           // the type to send when we NACK
 	InOut                        // the handler func, NOTE! return false in all NACK cases
 	TaskHeader  *comm.TaskHeader // updated task data
+	PleaseAck   []string         // the ack points we ask for the sent PL if any
 }
 
 // InOut is a type for Transition to process PSM state transition.
@@ -41,6 +42,7 @@ type Initial struct {
 	Ca          comm.Receiver // the start CA
 	T           comm.Task     // the start TAsk
 	Setup                     // setup & save the msg data at the PSM start
+	PleaseAck   []string      // the ack points we ask for the sent PL if any
 }
 type Setup func(key psm.StateKey, msg didcomm.MessageHdr) (err error)
 
@@ -90,7 +92,8 @@ func StartPSM(ts Initial) (err error) {
 	opl := aries.PayloadCreator.NewMsg(ts.T.ID(), ts.SendNext, msg)
 
 	try.To(UpdatePSM(wDID, connID, ts.T, opl, psm.Sending))
-	try.To(comm.SendPL(pipe, ts.T, opl))
+	try.To(sendWithPleaseAck(wa, psm.StateKey{DID: wDID, Nonce: ts.T.ID()},
+		connID, pipe, ts.T, opl, ts.PleaseAck))
 
 	// sending went OK, update PSM for what we are doing next: waiting a
 	// message from other side or we are ready.
@@ -137,7 +140,7 @@ func ContinuePSM(shift Again) (err error) {
 	outDID := wa.LoadTheirDID(*pairwise)
 	_, storageH := wa.ManagedWallet()
 	outDID.StartEndp(storageH, pairwise.ID)
-	pipe := sec.Pipe{In: inDID, Out: outDID}
+	pipe := sec.NewPipe(inDID, outDID)
 
 	sendBack := shift.SendNext != pltype.Terminate
 	plType := shift.SendNext
@@ -168,7 +171,7 @@ func ContinuePSM(shift Again) (err error) {
 		presentTask.SetReceiverEndp(agentEndp)
 
 		try.To(UpdatePSM(meDID, connID, presentTask, opl, psm.Sending))
-		try.To(comm.SendPL(pipe, presentTask, opl))
+		try.To(comm.SendOrQueuePL(wa, connID, pipe, presentTask, opl))
 	}
 	if isLast {
 		wpl := aries.PayloadCreator.New(didcomm.PayloadInit{ID: presentTask.ID(), Type: plType})
@@ -213,7 +216,9 @@ func ExecPSM(ts Transition) (err error) {
 		return err
 	})
 
+	comm.ReportReceived(connID)
 	try.To(UpdatePSM(meDID, connID, task, ts.Payload, psm.Received))
+	try.To(sendPleasedAck(ts.Packet, task, decorator.PleaseAckReceipt, ackStatusOK))
 
 	var om didcomm.MessageHdr
 	var ep sec.Pipe
@@ -227,7 +232,7 @@ func ExecPSM(ts Transition) (err error) {
 		_, storageH := ts.Receiver.ManagedWallet()
 		outDID.StartEndp(storageH, pairwise.ID)
 
-		ep = sec.Pipe{In: inDID, Out: outDID}
+		ep = sec.NewPipe(inDID, outDID)
 		im := ts.Payload.MsgHdr()
 
 		opl := aries.PayloadCreator.NewMsg(task.ID(), ts.Payload.Type(), im)
@@ -257,10 +262,16 @@ func ExecPSM(ts Transition) (err error) {
 		task.SetReceiverEndp(agentEndp)
 
 		try.To(UpdatePSM(meDID, connID, task, opl, psm.Sending))
-		try.To(comm.SendPL(ep, task, opl))
+		try.To(sendWithPleaseAck(ts.Receiver, psm.StateKey{DID: meDID, Nonce: task.ID()},
+			connID, ep, task, opl, ts.PleaseAck))
 	}
 
 	if isLast {
+		status := ackStatusOK
+		if ackFlag == psm.NACK {
+			status = ackStatusFail
+		}
+		try.To(sendPleasedAck(ts.Packet, task, decorator.PleaseAckOutcome, status))
 		wpl := aries.PayloadCreator.New(didcomm.PayloadInit{ID: task.ID(), Type: plType})
 		try.To(UpdatePSM(meDID, connID, task, wpl, psm.Ready|ackFlag))
 	} else {
@@ -315,31 +326,42 @@ func CreateTask(header *comm.TaskHeader, protocol *pb.Protocol) (t comm.Task, er
 	protocolType := aries.ProtocolForType(header.TypeID)
 	taskCreator, ok := creators[protocolType]
 	if !ok {
-		s := "!!!! No task creator !!! %s, %s"
-		glog.Errorf(s, protocolType, header.TypeID)
-		panic(s)
+		glog.Errorf("!!!! No task creator !!! %s, %s", protocolType, header.TypeID)
+		return nil, comm.NewProtocolError(comm.ErrUnsupportedMessage,
+			"no task creator for %s", header.TypeID)
 	}
 
-	return taskCreator.Creator(header, protocol)
+	t, err = taskCreator.Creator(header, protocol)
+	return t, comm.WrapProtocolError(comm.ErrValidationFailed, err)
 }
 
 // FindAndStartTask start the protocol by using CA API Type in the packet.PL.
-func FindAndStartTask(receiver comm.Receiver, task comm.Task) {
-	defer err2.Catch(err2.Err(func(err error) {
+// New protocols aren't started while the agent is paused, but the running ones
+// are continued, see comm.SetPaused.
+func FindAndStartTask(receiver comm.Receiver, task comm.Task) (err error) {
+	defer err2.Handle(&err, func(err error) error {
 		glog.Errorf("Cannot start protocol: %s", err)
-	}))
+		return err
+	})
 
+	if wDID := receiver.WDID(); comm.IsPaused(wDID) {
+		return comm.NewProtocolError(comm.ErrAgentPaused,
+			"agent paused (%s), cannot start %s", wDID, task.Type())
+	}
 	proc, ok := starters[task.Type()]
 	if !ok {
-		s := "!!!! No protocol starter !!!"
-		glog.Error(s, task.Type())
-		panic(s)
+		glog.Error("!!!! No protocol starter !!!", task.Type())
+		return comm.NewProtocolError(comm.ErrUnsupportedMessage,
+			"no protocol starter for %s", task.Type())
 	}
 	updatePSM(receiver, task, psm.Sending)
 	go proc.Starter(receiver, task)
+	return nil
 }
 
-func Resume(rcvr comm.Receiver, typeID, protocolID string, ack bool) {
+// Resume continues the protocol which waits for the user action. The info is
+// passed to the protocol's continuator, see didcomm.Msg.Info.
+func Resume(rcvr comm.Receiver, typeID, protocolID string, ack bool, info string) {
 	proc, ok := continuators[typeID]
 	if !ok {
 		glog.Error("!!No prot continuator for:", typeID)
@@ -348,6 +370,7 @@ func Resume(rcvr comm.Receiver, typeID, protocolID string, ack bool) {
 
 	om := aries.MsgCreator.Create(didcomm.MsgInit{
 		Ready: ack,
+		Info:  info,
 		ID:    protocolID, // This Has the SubLevelID() Getter
 		Nonce: protocolID, // This makes the Thread decorator
 	}).(didcomm.Msg)
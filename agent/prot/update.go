@@ -3,7 +3,6 @@ package prot
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/findy-network/findy-agent/agent/bus"
 	"github.com/findy-network/findy-agent/agent/comm"
@@ -24,6 +23,10 @@ type notifyEdge struct {
 	timestamp int64  // the timestamp of the PSM
 	pwName    string // connection ID (note!! not a pairwise Label)
 	family    string // protocol family
+	label     string // their label, only for new connection notifications
+
+	// credential is set only for credential received notifications
+	credential *bus.CredentialReceived
 
 	// startedByUs if we are the one who sent the first message
 	startedByUs bool
@@ -41,14 +44,16 @@ func NotifyEdge(ne notifyEdge) {
 			}))
 
 			bus.WantAllAgentActions.AgentBroadcast(bus.AgentNotify{
-				AgentKeyType:     bus.AgentKeyType{AgentDID: ne.did},
-				ID:               utils.UUID(),
-				NotificationType: ne.plType,
-				ProtocolID:       ne.nonce,
-				ProtocolFamily:   ne.family,
-				ConnectionID:     ne.pwName,
-				Timestamp:        ne.timestamp,
-				Role:             ne.role,
+				AgentKeyType:       bus.AgentKeyType{AgentDID: ne.did},
+				ID:                 utils.UUID(),
+				NotificationType:   ne.plType,
+				ProtocolID:         ne.nonce,
+				ProtocolFamily:     ne.family,
+				ConnectionID:       ne.pwName,
+				Timestamp:          ne.timestamp,
+				Role:               ne.role,
+				Label:              ne.label,
+				CredentialReceived: ne.credential,
 			})
 		}()
 	} else {
@@ -76,7 +81,7 @@ func UpdatePSM(
 ) {
 	defer err2.Handle(&err, "create psm")
 
-	if glog.V(5) {
+	if utils.V(agentDID, 5) {
 		glog.Infof("-- %s->%s[%s:%s]",
 			strings.ToUpper(opl.ProtocolMsg()), stateType, agentDID, task.ID())
 	}
@@ -85,7 +90,7 @@ func UpdatePSM(
 	foundPSM := try.To1(psm.FindPSM(PSMKey))
 
 	var currentPSM *psm.PSM
-	timestamp := time.Now().UnixNano()
+	timestamp := psm.Timestamp()
 	currentState := psm.State{
 		Timestamp: timestamp,
 		T:         task,
@@ -115,7 +120,7 @@ func UpdatePSM(
 			role = pltype.ProtocolRoleForType(opl.ProtocolMsg())
 		}
 
-		glog.V(3).Infof("----- We (send by us: %v) are %s (%s) ----",
+		utils.V(agentDID, 3).Infof("----- We (send by us: %v) are %s (%s) ----",
 			startedByUs,
 			agentDID,
 			role,
@@ -130,6 +135,9 @@ func UpdatePSM(
 		}
 	}
 	try.To(psm.AddPSM(currentPSM))
+	if dumpMsg(opl, stateType) {
+		try.To(psm.AddRawMsg(PSMKey, timestamp, opl.JSON()))
+	}
 
 	plType := opl.Type()
 	if plType == pltype.Nothing {
@@ -155,6 +163,16 @@ func UpdatePSM(
 	return nil
 }
 
+// dumpMsg tells if the message is stored for debugging, i.e. the message dump
+// is enabled and the message is one which is sent or received.
+func dumpMsg(opl didcomm.Payload, stateType psm.SubState) bool {
+	if !utils.Settings.MessageDump() || opl.Type() == pltype.Nothing {
+		return false
+	}
+	sub := stateType.Pure()
+	return sub == psm.Sending || sub == psm.Received
+}
+
 // AddFlagUpdatePSM updates existing PSM by adding a sub-state with state flag:
 //
 //	lastSubState | subState  => adding a new sub state flag to last one
@@ -173,7 +191,7 @@ func AddAndSetFlagUpdatePSM(
 
 	clearedLastSubState := m.LastState().Sub &^ unsetSubState
 	var machine *psm.PSM
-	timestamp := time.Now().UnixNano()
+	timestamp := psm.Timestamp()
 	s := psm.State{Timestamp: timestamp, Sub: subState | clearedLastSubState}
 	if m != nil { // update existing one
 		m.States = append(m.States, s)
@@ -215,10 +233,10 @@ func notifyArchiving(machine *psm.PSM, info endingInfo) {
 		Role:             machine.Role,
 	}
 	if info.subState&psm.Archiving != 0 {
-		glog.V(1).Infoln("archiving:", key)
+		utils.V(key.DID, 1).Infoln("archiving:", key)
 		bus.WantAllAgencyActions.AgentBroadcast(notify)
 	} else if info.subState&psm.Archived != 0 {
-		glog.V(1).Infoln("**** ARCHIVED:", key)
+		utils.V(key.DID, 1).Infoln("**** ARCHIVED:", key)
 		bus.WantAllPSMCleanup.AgentBroadcast(notify)
 	}
 
@@ -266,6 +284,12 @@ func triggerEnd(info endingInfo) {
 				startedByUs: info.startedByUs,
 				role:        info.role,
 			})
+			if isConnectionFamily(info.protocolFamily) {
+				notifyConnection(info)
+			}
+			if isCredentialReceived(info) {
+				notifyCredential(info)
+			}
 		}
 	case psm.Waiting, psm.Failure:
 		plType := pltype.Nothing
@@ -294,3 +318,81 @@ func triggerEnd(info endingInfo) {
 	// To brave one who wants to know all
 	bus.WantAll.Broadcast(key, info.subState)
 }
+
+func isConnectionFamily(family string) bool {
+	return family == pltype.AriesProtocolConnection ||
+		family == pltype.AriesProtocolDIDExchange
+}
+
+// notifyConnection notifies CA's controllers about the new connection which is
+// ready to use. Their label is read from the connection protocol's status.
+func notifyConnection(info endingInfo) {
+	defer err2.Catch(err2.Err(func(err error) {
+		glog.Error("new connection notification:", err)
+	}))
+
+	key := psm.StateKey{
+		DID:   info.meDID,
+		Nonce: info.nonce,
+	}
+	ps := FillStatus(info.protocolFamily, key, &pb.ProtocolStatus{})
+
+	NotifyEdge(notifyEdge{
+		did:         info.meDID,
+		plType:      pltype.CANotifyConnection,
+		nonce:       info.nonce,
+		timestamp:   info.timestamp,
+		pwName:      info.pwName,
+		family:      info.protocolFamily,
+		label:       ps.GetDIDExchange().GetTheirLabel(),
+		startedByUs: info.startedByUs,
+		role:        info.role,
+	})
+}
+
+// isCredentialReceived tells if the issuing protocol ended at the holder,
+// which is the one sending the final ACK.
+func isCredentialReceived(info endingInfo) bool {
+	return info.protocolFamily == pltype.ProtocolIssueCredential &&
+		strings.HasSuffix(info.plType, "/"+pltype.HandlerIssueCredentialACK)
+}
+
+// notifyCredential notifies CA's controllers about the credential which the
+// holder has stored to the wallet. The credential's details are read from the
+// issuing protocol's status.
+func notifyCredential(info endingInfo) {
+	defer err2.Catch(err2.Err(func(err error) {
+		glog.Error("credential received notification:", err)
+	}))
+
+	key := psm.StateKey{
+		DID:   info.meDID,
+		Nonce: info.nonce,
+	}
+	ps := FillStatus(info.protocolFamily, key, &pb.ProtocolStatus{})
+	issue := ps.GetIssueCredential()
+	attrs := issue.GetAttributes().GetAttributes()
+	cred := &bus.CredentialReceived{
+		CredDefID:  issue.GetCredDefID(),
+		SchemaID:   issue.GetSchemaID(),
+		Attributes: make([]didcomm.CredentialAttribute, len(attrs)),
+	}
+	for i, attr := range attrs {
+		cred.Attributes[i] = didcomm.CredentialAttribute{
+			Name:  attr.Name,
+			Value: attr.Value,
+		}
+	}
+
+	NotifyEdge(notifyEdge{
+		did:         info.meDID,
+		plType:      pltype.CANotifyCredential,
+		nonce:       info.nonce,
+		timestamp:   info.timestamp,
+		pwName:      info.pwName,
+		family:      info.protocolFamily,
+		credential:  cred,
+		startedByUs: info.startedByUs,
+		role:        info.role,
+	})
+}
@@ -0,0 +1,34 @@
+package prot
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/lainio/err2/assert"
+)
+
+func TestIsCredentialReceived(t *testing.T) {
+	tests := []struct {
+		name   string
+		family string
+		plType string
+		want   bool
+	}{
+		{"holder ack", pltype.ProtocolIssueCredential, pltype.IssueCredentialACK, true},
+		{"holder ack did org", pltype.ProtocolIssueCredential, pltype.DIDOrgIssueCredentialACK, true},
+		{"holder ack v2", pltype.ProtocolIssueCredential, pltype.DIDOrgIssueCredentialV2ACK, true},
+		{"issuer", pltype.ProtocolIssueCredential, pltype.IssueCredentialOffer, false},
+		{"other protocol", pltype.ProtocolPresentProof, pltype.PresentProofACK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer assert.PushTester(t)()
+
+			got := isCredentialReceived(endingInfo{
+				protocolFamily: tt.family,
+				plType:         tt.plType,
+			})
+			assert.Equal(got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package psm
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock gives the time for the PSM timestamps. The default is the real time.
+// Tests can replace it with SetClock to control the ages of the states.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+var (
+	clockLk sync.RWMutex
+	clock   Clock = realClock{}
+)
+
+// SetClock sets the clock of the PSM timestamps and returns the previous one,
+// which can be used to restore it. Nil sets the real time clock.
+func SetClock(c Clock) (prev Clock) {
+	clockLk.Lock()
+	defer clockLk.Unlock()
+
+	if c == nil {
+		c = realClock{}
+	}
+	prev, clock = clock, c
+	return prev
+}
+
+// Now returns the current time of the PSM clock.
+func Now() time.Time {
+	clockLk.RLock()
+	defer clockLk.RUnlock()
+
+	return clock.Now()
+}
+
+// Timestamp returns the current time of the PSM clock as a state timestamp.
+func Timestamp() int64 {
+	return Now().UnixNano()
+}
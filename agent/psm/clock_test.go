@@ -0,0 +1,61 @@
+package psm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestClock_StateAge(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)}
+	defer SetClock(SetClock(fc))
+
+	m := testPSM(Timestamp())
+	m.Key.Nonce = "clock"
+	m.States[0].Sub = Ready | ACK | Archived
+	try.To(AddPSM(m))
+	assert.Equal(m.Age(), time.Duration(0))
+
+	fc.Add(90 * time.Minute)
+	assert.Equal(m.Age(), 90*time.Minute)
+
+	defer utils.Settings.SetPSMRetention(utils.Settings.PSMRetention())
+	utils.Settings.SetPSMRetention(2 * time.Hour)
+
+	SweepArchived()
+	found, err := FindPSM(m.Key)
+	assert.NoError(err)
+	assert.NotNil(found, "PSM purged too early")
+
+	fc.Add(time.Hour)
+	SweepArchived()
+	found, err = FindPSM(m.Key)
+	assert.NoError(err)
+	assert.Nil(found)
+}
+
+func TestSetClock_Nil(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	defer SetClock(SetClock(nil))
+	assert.That(time.Since(Now()) < time.Minute)
+}
@@ -2,6 +2,7 @@ package psm
 
 import (
 	"crypto/md5"
+	"errors"
 	"fmt"
 
 	"github.com/findy-network/findy-agent/agent/endp"
@@ -19,6 +20,9 @@ const (
 	BucketBasicMessage
 	BucketIssueCred
 	BucketPresentProof
+	BucketDiscoverFeatures
+	BucketTrustPing
+	BucketRawMsg
 )
 
 var (
@@ -29,6 +33,9 @@ var (
 		{BucketBasicMessage},
 		{BucketIssueCred},
 		{BucketPresentProof},
+		{BucketDiscoverFeatures},
+		{BucketTrustPing},
+		{BucketRawMsg},
 	}
 
 	theCipher *crypto.Cipher
@@ -134,6 +141,9 @@ func AddPSM(p *PSM) (err error) {
 	return addData(p.Key.Data(), p.Data(), BucketPSM)
 }
 
+// ErrNotFound is returned by GetPSM when the PSM doesn't exist.
+var ErrNotFound = errors.New("PSM not found")
+
 // GetPSM get existing PSM from DB. If the PSM doesn't exist it returns error.
 // See FindPSM for version which doesn't return error if the PSM doesn't exist.
 func GetPSM(k StateKey) (m *PSM, err error) {
@@ -143,7 +153,7 @@ func GetPSM(k StateKey) (m *PSM, err error) {
 	})
 	if !found {
 		assert.That(m == nil)
-		return nil, fmt.Errorf("PSM with key %s/%s not found", k.DID, k.Nonce)
+		return nil, fmt.Errorf("%w: key %s/%s", ErrNotFound, k.DID, k.Nonce)
 	}
 	return m, err
 }
@@ -184,10 +194,17 @@ func RmPSM(p *PSM) (err error) {
 		err = rm(p.Key, BucketIssueCred)
 	case pltype.ProtocolPresentProof:
 		err = rm(p.Key, BucketPresentProof)
+	case pltype.ProtocolDiscoverFeatures:
+		err = rm(p.Key, BucketDiscoverFeatures)
+	case pltype.ProtocolTrustPing:
+		err = rm(p.Key, BucketTrustPing)
 	}
 	if err != nil {
 		return err
 	}
+	if err = rm(p.Key, BucketRawMsg); err != nil {
+		return err
+	}
 	return rm(p.Key, BucketPSM)
 }
 
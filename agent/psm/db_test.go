@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/findy-network/findy-common-go/dto"
 	"github.com/lainio/err2"
@@ -119,6 +120,45 @@ func Test_addBaseRep(t *testing.T) {
 	}
 }
 
+func TestPurgeArchived(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	now := time.Now()
+	archived := func(age time.Duration) *PSM {
+		m := testPSM(now.Add(-age).UnixNano())
+		m.States[0].Sub = Ready | ACK | Archived
+		try.To(AddPSM(m))
+		return m
+	}
+	old := archived(48 * time.Hour)
+	recent := archived(time.Hour)
+	newest := archived(time.Minute)
+	running := testPSM(now.Add(-72 * time.Hour).UnixNano())
+	running.States[0].Sub = Waiting
+	try.To(AddPSM(running))
+
+	n, err := PurgeArchived(now, 24*time.Hour, 0)
+	assert.NoError(err)
+	assert.Equal(n, 1)
+	m, err := FindPSM(old.Key)
+	assert.NoError(err)
+	assert.Nil(m)
+	m, err = FindPSM(running.Key)
+	assert.NoError(err)
+	assert.NotNil(m)
+
+	n, err = PurgeArchived(now, 24*time.Hour, 1)
+	assert.NoError(err)
+	assert.Equal(n, 1)
+	m, err = FindPSM(recent.Key)
+	assert.NoError(err)
+	assert.Nil(m)
+	m, err = FindPSM(newest.Key)
+	assert.NoError(err)
+	assert.NotNil(m)
+}
+
 func Test_Close(t *testing.T) {
 	assert.PushTester(t)
 	defer assert.PopTester()
@@ -1,6 +1,8 @@
 package psm
 
 import (
+	"time"
+
 	"github.com/findy-network/findy-agent/agent/aries"
 	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/pltype"
@@ -192,6 +194,11 @@ type PSM struct {
 
 	// States has all ouf the state history of this PSM in timestamp order
 	States []State
+
+	// AcksRequested is the amount of our messages which asked the other end
+	// to ack them, and AcksReceived is the amount of the acks received.
+	AcksRequested int
+	AcksReceived  int
 }
 
 func NewPSM(d []byte) *PSM {
@@ -219,6 +226,24 @@ func (p *PSM) Timestamp() int64 {
 	return 0
 }
 
+// Age returns the time since the last state by the PSM clock. It's zero if
+// there are no states.
+func (p *PSM) Age() time.Duration {
+	ts := p.Timestamp()
+	if ts == 0 {
+		return 0
+	}
+	return Now().Sub(time.Unix(0, ts))
+}
+
+// AcksPending returns the amount of the requested acks not received yet.
+func (p *PSM) AcksPending() int {
+	if pending := p.AcksRequested - p.AcksReceived; pending > 0 {
+		return pending
+	}
+	return 0
+}
+
 // Next is for getting the upcoming protocol message type. For example, if we
 // are waiting a certain message from other end, we can check the message type
 // with this function.
@@ -244,6 +269,16 @@ func (p *PSM) PendingUserAction() bool {
 	return false
 }
 
+// InFlight returns true if the PSM is processing a message right now or it's
+// being archived. The PSMs waiting for the other end or a user action aren't
+// in flight.
+func (p *PSM) InFlight() bool {
+	if state := p.LastState(); state != nil {
+		return state.Sub&(Received|Decrypted|Sending|Archiving) != 0
+	}
+	return false
+}
+
 func (p *PSM) FirstState() *State {
 	sCount := len(p.States)
 	if sCount > 0 {
@@ -0,0 +1,119 @@
+package psm
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+// RawMsgs are the plain DIDComm messages of the PSM by the timestamps of its
+// states. They are stored only for debugging, because they include all of
+// the data of the protocol.
+type RawMsgs struct {
+	StateKey
+	Msgs map[int64][]byte
+}
+
+// redacted replaces the values of the sanitized message fields.
+const redacted = "<redacted>"
+
+// secretFields are the parts of the field names which are never stored.
+var secretFields = []string{"private", "secret", "seed", "signkey", "sign_key"}
+
+func init() {
+	Creator.Add(BucketRawMsg, NewRawMsgs)
+}
+
+func NewRawMsgs(d []byte) Rep {
+	p := &RawMsgs{}
+	dto.FromGOB(d, p)
+	return p
+}
+
+func (p *RawMsgs) Key() StateKey {
+	return p.StateKey
+}
+
+func (p *RawMsgs) Data() []byte {
+	return dto.ToGOB(p)
+}
+
+func (p *RawMsgs) Type() byte {
+	return BucketRawMsg
+}
+
+// AddRawMsg stores the message of the PSM state of the timestamp. The fields
+// which may include key material are sanitized before the message is stored.
+func AddRawMsg(k StateKey, timestamp int64, msg []byte) (err error) {
+	defer err2.Handle(&err, "add raw msg")
+
+	rep := try.To1(GetRawMsgs(k))
+	if rep == nil {
+		rep = &RawMsgs{StateKey: k, Msgs: make(map[int64][]byte)}
+	}
+	rep.Msgs[timestamp] = try.To1(SanitizeMsg(msg))
+	return AddRep(rep)
+}
+
+// GetRawMsgs returns the stored messages of the PSM. It returns nil if there
+// are none.
+func GetRawMsgs(k StateKey) (rep *RawMsgs, err error) {
+	defer err2.Handle(&err, "get raw msgs")
+
+	r := try.To1(GetRep(BucketRawMsg, k))
+	if r == nil {
+		return nil, nil
+	}
+	rep, ok := r.(*RawMsgs)
+	assert.That(ok, "raw msgs type mismatch")
+	return rep, nil
+}
+
+// SanitizeMsg returns the JSON message where the values of the fields, which
+// names refer to private keys, secrets, or seeds, are redacted.
+func SanitizeMsg(msg []byte) (_ []byte, err error) {
+	defer err2.Handle(&err, "sanitize msg")
+
+	var v any
+	try.To(json.Unmarshal(msg, &v))
+
+	// the message content isn't HTML, and it's dumped as it was received
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	try.To(enc.Encode(sanitize(v)))
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func sanitize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for name, value := range v {
+			if isSecretField(name) {
+				v[name] = redacted
+			} else {
+				v[name] = sanitize(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = sanitize(value)
+		}
+	}
+	return v
+}
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretFields {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package psm
+
+import (
+	"testing"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestSanitizeMsg(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	msg := `{"@type":"test","content":"hello",
+		"keys":[{"privateKey":"pk","id":"k1"}],
+		"nested":{"seed":"000000000000000000000000Steward1","master_secret":"ms"}}`
+	got, err := SanitizeMsg([]byte(msg))
+	assert.NoError(err)
+	assert.Equal(string(got), `{"@type":"test","content":"hello",`+
+		`"keys":[{"id":"k1","privateKey":"<redacted>"}],`+
+		`"nested":{"master_secret":"<redacted>","seed":"<redacted>"}}`)
+
+	_, err = SanitizeMsg([]byte(`{"seed":`))
+	assert.Error(err)
+}
+
+func TestAddRawMsg(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	m := testPSM(1234)
+	assert.NoError(AddPSM(m))
+
+	none, err := GetRawMsgs(m.Key)
+	assert.NoError(err)
+	assert.That(none == nil)
+
+	assert.NoError(AddRawMsg(m.Key, 1, []byte(`{"content":"first"}`)))
+	assert.NoError(AddRawMsg(m.Key, 2, []byte(`{"sign_key":"sk"}`)))
+	rep, err := GetRawMsgs(m.Key)
+	assert.NoError(err)
+	assert.MLen(rep.Msgs, 2)
+	assert.Equal(string(rep.Msgs[1]), `{"content":"first"}`)
+	assert.Equal(string(rep.Msgs[2]), `{"sign_key":"<redacted>"}`)
+
+	// the messages are removed with the PSM
+	assert.NoError(RmPSM(m))
+	none, err = GetRawMsgs(m.Key)
+	assert.NoError(err)
+	assert.That(none == nil)
+}
@@ -0,0 +1,82 @@
+package psm
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sort"
+	"time"
+
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// SweepArchived purges archived PSMs according to the retention policy in
+// utils.Settings. It's meant to be called periodically by the scheduler.
+func SweepArchived() {
+	defer err2.Catch(err2.Err(func(err error) {
+		glog.Errorln("sweep archived PSMs:", err)
+	}))
+
+	maxAge := utils.Settings.PSMRetention()
+	maxCount := utils.Settings.PSMRetentionCount()
+	if maxAge == 0 && maxCount == 0 {
+		return
+	}
+	count := try.To1(PurgeArchived(Now(), maxAge, maxCount))
+	glog.V(1).Infoln("archived PSMs purged:", count)
+}
+
+// PurgeArchived removes the archived PSMs which are older than maxAge, and
+// the oldest ones which exceed the maxCount newest archived PSMs. The age is
+// calculated from the PSM's last state. Zero value disables the limit. It
+// returns the amount of the removed PSMs.
+func PurgeArchived(now time.Time, maxAge time.Duration, maxCount int) (n int, err error) {
+	defer err2.Handle(&err, "purge archived")
+
+	archived := try.To1(archivedPSMs())
+
+	// newest first, then the count limit is easy to apply
+	sort.Slice(archived, func(i, j int) bool {
+		return archived[i].Timestamp() > archived[j].Timestamp()
+	})
+	oldest := now.Add(-maxAge).UnixNano()
+	for i, m := range archived {
+		tooOld := maxAge != 0 && m.Timestamp() < oldest
+		tooMany := maxCount != 0 && i >= maxCount
+		if !tooOld && !tooMany {
+			continue
+		}
+		try.To(RmPSM(m))
+		n++
+	}
+	return n, nil
+}
+
+func archivedPSMs() (archived []*PSM, err error) {
+	return FindPSMs(func(m *PSM) bool {
+		s := m.LastState()
+		return s != nil && s.Sub&(Archiving|Archived) != 0
+	})
+}
+
+// FindPSMs returns the PSMs of all of the agents which the match function
+// accepts. PSMs which cannot be decoded are skipped.
+func FindPSMs(match func(m *PSM) bool) (found []*PSM, err error) {
+	defer err2.Handle(&err, "find PSMs")
+
+	values := try.To1(mgdDB.GetAllValuesFromBucket(buckets[BucketPSM], decrypt))
+
+	for _, value := range values {
+		m := &PSM{}
+		if err := gob.NewDecoder(bytes.NewReader(value)).Decode(m); err != nil {
+			glog.Warningln("skipping undecodable PSM:", err)
+			continue
+		}
+		if match(m) {
+			found = append(found, m)
+		}
+	}
+	return found, nil
+}
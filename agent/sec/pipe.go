@@ -1,6 +1,8 @@
 package sec
 
 import (
+	"fmt"
+
 	"github.com/findy-network/findy-agent/agent/service"
 	"github.com/findy-network/findy-agent/agent/ssi"
 	"github.com/findy-network/findy-agent/agent/storage/api"
@@ -25,10 +27,36 @@ func Init(defMediaType string) {
 
 // Pipe is a secure way to transport data between DID connection. All agent to
 // agent communication uses it. For its internal structure we must define the
-// direction of the pipe.
+// direction of the pipe. Scheme is the pack scheme, e.g. SchemeAnoncrypt, and
+// the empty is SchemeAuthcrypt, see SchemeFor.
 type Pipe struct {
-	In  core.DID
-	Out core.DID
+	In     core.DID
+	Out    core.DID
+	Scheme string
+}
+
+// NewPipe creates a new secure pipe between the DIDs. The pack scheme is
+// negotiated by the other end's DID doc, see SchemeFor.
+func NewPipe(in, out core.DID) Pipe {
+	return Pipe{In: in, Out: out, Scheme: SchemeFor(out)}
+}
+
+// snapshotter is implemented by the DIDs which can be updated while they are
+// in the pipes, see ssi.DID.Snapshot.
+type snapshotter interface {
+	Snapshot() core.DID
+}
+
+// Snapshot returns a copy of the pipe with the snapshots of its DIDs. The
+// updates of the pipe's DIDs, e.g. their endpoints, don't change the copy.
+func (p Pipe) Snapshot() Pipe {
+	if s, ok := p.In.(snapshotter); ok {
+		p.In = s.Snapshot()
+	}
+	if s, ok := p.Out.(snapshotter); ok {
+		p.Out = s.Snapshot()
+	}
+	return p
 }
 
 // NewPipeByVerkey creates a new secure pipe by our DID and other end's public
@@ -55,17 +83,33 @@ func (p Pipe) Pack(src []byte) (dst []byte, vk string, err error) {
 	route := p.Out.Route()
 	toKeys = append(toKeys, route...)
 
-	// pack a non-empty envelope using packer selected by mediaType - should pass
+	// the packer is selected by mediaType and if the sender is given
+	scheme := p.PackScheme()
+	if scheme != SchemeAuthcrypt && scheme != SchemeAnoncrypt {
+		return nil, "", fmt.Errorf("unknown pack scheme: %s", scheme)
+	}
+	var fromKey []byte
+	if scheme == SchemeAuthcrypt {
+		fromKey = []byte(p.In.String())
+	}
 	dst = try.To1(p.packager().PackMessage(&transport.Envelope{
 		MediaTypeProfile: media,
 		Message:          src,
-		FromKey:          []byte(p.In.String()),
+		FromKey:          fromKey,
 		ToKeys:           toKeys,
 	}))
 
 	return
 }
 
+// PackScheme returns the pack scheme of the pipe.
+func (p Pipe) PackScheme() string {
+	if p.Scheme == "" {
+		return SchemeAuthcrypt
+	}
+	return p.Scheme
+}
+
 // Unpack unpacks the source bytes and returns our verification key as well.
 func (p Pipe) Unpack(src []byte) (dst []byte, vk string, err error) {
 	defer err2.Handle(&err, "sec pipe unpack")
@@ -192,6 +192,58 @@ func TestPackUnpackWithSeparatedWallets(t *testing.T) {
 
 }
 
+func TestPackSchemes(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	didIn2, _ := agent2.NewDID(method.TypeKey, "")
+	didIn, _ := agent.NewDID(method.TypeKey, "")
+	didOut := try.To1(agent.NewOutDID(didIn2.URI()))
+	didOut2 := try.To1(agent2.NewOutDID(didIn.URI()))
+
+	message := []byte("message")
+	for _, scheme := range []string{"", sec.SchemeAuthcrypt, sec.SchemeAnoncrypt} {
+		p := sec.Pipe{In: didIn, Out: didOut, Scheme: scheme}
+		p2 := sec.Pipe{In: didIn2, Out: didOut2}
+
+		packed, _, err := p.Pack(message)
+		assert.NoError(err, scheme)
+		received, _, err := p2.Unpack(packed)
+		assert.NoError(err, scheme)
+		assert.DeepEqual(message, received)
+	}
+
+	p := sec.Pipe{In: didIn, Out: didOut, Scheme: "unknown"}
+	_, _, err := p.Pack(message)
+	assert.Error(err)
+}
+
+func TestNegotiateScheme(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	tests := []struct {
+		accept []string
+		scheme string
+		ok     bool
+	}{
+		{nil, sec.SchemeAuthcrypt, true},
+		{[]string{"didcomm/aip1"}, sec.SchemeAuthcrypt, true},
+		{[]string{"didcomm/v2", "didcomm/aip2; env=rfc19"}, sec.SchemeAuthcrypt, true},
+		{[]string{"didcomm/v2", "didcomm/aip2;env=rfc587"}, "", false},
+	}
+	for _, tt := range tests {
+		scheme, err := sec.NegotiateScheme(tt.accept)
+		if !tt.ok {
+			assert.Error(err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(scheme, tt.scheme)
+	}
+	assert.Equal(sec.SchemeFor(nil), sec.SchemeAuthcrypt)
+}
+
 func TestIndyPipe(t *testing.T) {
 	assert.PushTester(t)
 	defer assert.PopTester()
@@ -235,6 +287,46 @@ func TestIndyPipe(t *testing.T) {
 	assert.DeepEqual(message, received)
 }
 
+func TestImportedDIDPipe(t *testing.T) {
+	defer assert.PushTester(t)()
+
+	const (
+		importDID    = "YMNQRjSbhv5Q7vXZ4XfXuM"
+		importVerKey = "J65ELAijH6BUeL3VZcck7TEsf1TDyRWENkHGHU7mesFn"
+		importSeed   = "000000000000000000000000Import01"
+	)
+	// the failing asserts of ImportDID must return errors instead of failing
+	// the test
+	old := assert.SetDefault(assert.Production)
+	_, err := agent.ImportDID(importDID, importVerKey, "000000000000000000000000Import02")
+	assert.SetDefault(old)
+	assert.Error(err, "verkey doesn't match the seed")
+
+	didIn, err := agent.ImportDID("did:sov:"+importDID, importVerKey, importSeed)
+	assert.NoError(err)
+	assert.Equal(didIn.Did(), importDID)
+	assert.Equal(didIn.VerKey(), importVerKey)
+
+	old = assert.SetDefault(assert.Production)
+	_, err = agent.ImportDID(importDID, importVerKey, importSeed)
+	assert.SetDefault(old)
+	assert.Error(err, "existing DID cannot be overwritten")
+
+	didIn2, _ := agent2.NewDID(method.TypeSov, "")
+	didOut, err := agent.NewOutDID("did:sov:", didIn2.VerKey())
+	assert.NoError(err)
+	didOut2, err := agent2.NewOutDID("did:sov:", importVerKey)
+	assert.NoError(err)
+
+	p := sec.Pipe{In: didIn, Out: didOut}
+	p2 := sec.Pipe{In: didIn2, Out: didOut2}
+
+	message := []byte("message")
+	packed, _ := try.To2(p.Pack(message))
+	received, _ := try.To2(p2.Unpack(packed))
+	assert.DeepEqual(message, received)
+}
+
 type protected struct {
 	Recipients []struct {
 		Header struct {
@@ -0,0 +1,72 @@
+package sec
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/findy-network/findy-agent/core"
+	"github.com/findy-network/findy-agent/method"
+	"github.com/golang/glog"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+)
+
+// Pack schemes of the pipe. Both use the RFC 0019 envelope and the Ed25519
+// keys of the DIDs. The anoncrypt doesn't tell the sender to the receiver.
+const (
+	SchemeAuthcrypt = "authcrypt"
+	SchemeAnoncrypt = "anoncrypt"
+)
+
+// ErrNoScheme is returned when the other end doesn't accept any of the media
+// type profiles we can pack with.
+var ErrNoScheme = errors.New("no common pack scheme")
+
+// rfc0019Profiles are the accept values of the DID doc services which mean
+// the RFC 0019 envelope. DIDComm v2 profiles, e.g. 'didcomm/v2', need
+// X25519 key agreement keys, which our DIDs don't have.
+var rfc0019Profiles = []string{
+	"didcomm/aip1",
+	"didcomm/aip2;env=rfc19",
+}
+
+// NegotiateScheme returns the pack scheme for the accept values of the other
+// end's DID doc services. No values means the default, SchemeAuthcrypt, like
+// the RFC 0019 profile.
+func NegotiateScheme(accept []string) (string, error) {
+	if len(accept) == 0 {
+		return SchemeAuthcrypt, nil
+	}
+	for _, a := range accept {
+		a = strings.ReplaceAll(strings.ToLower(a), " ", "")
+		for _, profile := range rfc0019Profiles {
+			if a == profile {
+				return SchemeAuthcrypt, nil
+			}
+		}
+	}
+	return "", ErrNoScheme
+}
+
+// SchemeFor returns the pack scheme for the other end of the pipe according
+// to the capabilities in its DID doc. Only did:peer docs have them, and other
+// DIDs and the failed negotiations get the default scheme.
+func SchemeFor(out core.DID) string {
+	if out == nil || !method.Accept(out, method.TypePeer) {
+		return SchemeAuthcrypt
+	}
+	doc, ok := out.DOC().(*did.Doc)
+	if !ok || doc == nil {
+		return SchemeAuthcrypt
+	}
+	var accept []string
+	for _, s := range doc.Service {
+		accept = append(accept, s.Accept...)
+	}
+	scheme, err := NegotiateScheme(accept)
+	if err != nil {
+		glog.Warningf("%s accepts %v: %v, using %s", out.URI(), accept, err,
+			SchemeAuthcrypt)
+		return SchemeAuthcrypt
+	}
+	return scheme
+}
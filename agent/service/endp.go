@@ -5,3 +5,11 @@ type Addr struct {
 	Endp string `json:"endpoint"`
 	Key  string `json:"verkey"`
 }
+
+// WeightedAddr is one of the Agent's redundant access points, e.g. relays.
+// Weight is its share of the traffic among the other access points of the
+// Agent. Zero means the default weight, which is one.
+type WeightedAddr struct {
+	Addr
+	Weight int `json:"weight,omitempty"`
+}
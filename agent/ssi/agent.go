@@ -1,12 +1,17 @@
 package ssi
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"path/filepath"
 	"sync"
 
 	"github.com/findy-network/findy-agent/agent/async"
+	"github.com/findy-network/findy-agent/agent/endp"
 	"github.com/findy-network/findy-agent/agent/managed"
 	"github.com/findy-network/findy-agent/agent/pool"
 	"github.com/findy-network/findy-agent/agent/service"
@@ -27,6 +32,7 @@ import (
 	"github.com/lainio/err2"
 	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
+	"github.com/mr-tron/base58"
 )
 
 type AgentType interface {
@@ -102,9 +108,11 @@ type DIDAgent struct {
 	// Agent type: CA, EA, Worker, etc.
 	Type Type
 
-	sync.Mutex // Currently saImplID makes the agent mutable
+	sync.Mutex // Currently saImplID and poolName make the agent mutable
 
 	saImplID string        // SA implementation ID, used mostly for tests
+	pwCount  int           // amount of the derived pairwise DIDs, see pairwiseSeed
+	poolName string        // ledger pool of the agent, empty is agency's default
 	EAEndp   *service.Addr // EA endpoint if set, used for SA API and notifications
 }
 
@@ -120,6 +128,20 @@ func (a *DIDAgent) SetSAImplID(id string) {
 	a.saImplID = id
 }
 
+// PoolName returns the name of the ledger pool the agent resolves its DIDs and
+// credential definitions against. Empty name means the agency's default pool.
+func (a *DIDAgent) PoolName() string {
+	a.Lock()
+	defer a.Unlock()
+	return a.poolName
+}
+
+func (a *DIDAgent) SetPoolName(name string) {
+	a.Lock()
+	defer a.Unlock()
+	a.poolName = name
+}
+
 func (a *DIDAgent) AddDIDCache(DID *DID) {
 	a.DidCache.Add(DID)
 }
@@ -179,6 +201,21 @@ func (a *DIDAgent) CloseWallet() {
 	}
 }
 
+// CycleWallet closes the agent's wallet and storage and reopens them. The DID
+// cache is emptied, which means that the DIDs are loaded from the reopened
+// wallet.
+func (a *DIDAgent) CycleWallet() (err error) {
+	defer err2.Handle(&err, "cycle wallet")
+
+	a.AssertWallet()
+	for _, w := range []managed.Wallet{a.WalletH, a.StorageH} {
+		h := w.Cycle()
+		assert.That(h != 0, "cannot reopen wallet (%s)", w.Config().ID())
+	}
+	a.DidCache.Reset()
+	return nil
+}
+
 func (a *DIDAgent) Wallet() (h int) {
 	return a.WalletH.Handle()
 }
@@ -201,8 +238,9 @@ func (a *DIDAgent) OpenPool(name string) {
 	pool.Open(name)
 }
 
+// Pool returns the ledger handle of the agent's pool, see PoolName.
 func (a *DIDAgent) Pool() (v int) {
-	return pool.Handle()
+	return pool.HandleFor(a.PoolName())
 }
 
 func (a *DIDAgent) VDR() *vdr.VDR {
@@ -282,6 +320,40 @@ func (a *DIDAgent) NewOutDID(didInfo ...string) (id core.DID, err error) {
 	}
 }
 
+// ImportDID stores an external indy DID with its keys to the agent's wallet,
+// which allows it to be used as our identity, e.g. when an identity is
+// migrated to the agency. The sign key is given as a 32 character seed,
+// because it's how the indy wallet accepts key material. The verkey must match
+// the seed. An existing DID isn't overwritten.
+func (a *DIDAgent) ImportDID(didStr, verkey, seed string) (_ core.DID, err error) {
+	defer err2.Handle(&err, "import DID")
+
+	a.AssertWallet()
+
+	didStr = indy.DID2KID(didStr)
+	assert.That(endp.IsDID(didStr), "invalid DID %s", didStr)
+	assert.Equal(len(seed), ed25519.SeedSize, "seed length must be %d",
+		ed25519.SeedSize)
+	pubKey := ed25519.NewKeyFromSeed([]byte(seed)).Public().(ed25519.PublicKey)
+	assert.Equal(base58.Encode(pubKey), verkey, "verkey doesn't match the seed")
+
+	_, err = a.DIDStorage().GetDID(didStr)
+	assert.That(err != nil, "DID %s already exists", didStr)
+
+	didRes := <-did.CreateAndStore(a.Wallet(), did.Did{Did: didStr, Seed: seed})
+	try.To(didRes.Err())
+	try.To(a.DIDStorage().SaveDID(storage.DID{
+		ID:         didStr,
+		DID:        didStr,
+		IndyVerKey: verkey,
+	}))
+	glog.V(1).Infoln("DID imported:", didStr)
+
+	d := NewAgentDid(a.WalletH, &async.Future{V: didRes, On: async.Consumed})
+	a.DidCache.Add(d)
+	return d, nil
+}
+
 // myCreateDID creates a new DID thru the Future which means that returned *DID
 // follows 'lazy fetch' principle. You should call this as early as possible for
 // the performance reasons. Most cases seed should be empty string.
@@ -293,7 +365,7 @@ func (a *DIDAgent) myCreateDID(seed string) (agentDid *DID) {
 	ap := seed
 	if u, err := url.Parse(seed); err == nil && u.Scheme != "" {
 		glog.V(10).Infoln("Seed is URL:", ap)
-		seed = ""
+		seed = a.pairwiseSeed()
 	} else {
 		glog.V(10).Infoln("Seed is EXT-seed, using external Steward!")
 		ap = ""
@@ -327,6 +399,26 @@ func (a *DIDAgent) myCreateDID(seed string) (agentDid *DID) {
 	return d
 }
 
+// pairwiseSeed returns the seed of the agent's next pairwise DID if the
+// deterministic DIDs are on, see utils.Settings.SetPairwiseSeed. The seed is
+// derived from the base seed, the wallet ID, and the amount of the agent's
+// pairwise DIDs. Empty seed means a random DID.
+func (a *DIDAgent) pairwiseSeed() string {
+	base := utils.Settings.PairwiseSeed()
+	if base == "" {
+		return ""
+	}
+
+	a.Lock()
+	a.pwCount++
+	n := a.pwCount
+	a.Unlock()
+
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d",
+		base, a.WalletH.Config().ID(), n)))
+	return hex.EncodeToString(h[:])[:32] // indy seeds are 32 characters
+}
+
 func (a *DIDAgent) RootDid() core.DID {
 	return a.Root
 }
@@ -353,6 +445,10 @@ func (a *DIDAgent) ConnectionStorage() storage.ConnectionStorage {
 	return a.Storage().ConnectionStorage()
 }
 
+func (a *DIDAgent) CredentialStorage() storage.CredentialStorage {
+	return a.Storage().CredentialStorage()
+}
+
 func (a *DIDAgent) DIDStorage() storage.DIDStorage {
 	return a.Storage().DIDStorage()
 }
@@ -0,0 +1,58 @@
+package ssi
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/method"
+	"github.com/lainio/err2/assert"
+)
+
+func TestDIDAgent_PairwiseSeed(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	utils.Settings.SetPairwiseSeed("pairwise-test-seed")
+	defer utils.Settings.SetPairwiseSeed("")
+
+	// the run is repeated with the recreated wallet of the same name
+	dids := pairwiseDIDs(walletName1 + "_pw_seed")
+	assert.SLen(dids, 2)
+	assert.NotEqual(dids[0], dids[1])
+	assert.DeepEqual(pairwiseDIDs(walletName1+"_pw_seed"), dids)
+
+	// other agents get their own DIDs
+	others := pairwiseDIDs(walletName1 + "_pw_seed_other")
+	assert.NotEqual(others[0], dids[0])
+	assert.NotEqual(others[1], dids[1])
+
+	// random DIDs by default
+	utils.Settings.SetPairwiseSeed("")
+	random := pairwiseDIDs(walletName1 + "_pw_seed")
+	assert.NotEqual(random[0], dids[0])
+	assert.NotEqual(random[1], dids[1])
+}
+
+// pairwiseDIDs creates two pairwise DIDs to the new wallet, which is removed
+// after that.
+func pairwiseDIDs(walletName string) []string {
+	cfg := NewRawWalletCfg(walletName, key)
+	cfg.Create()
+	a := new(DIDAgent)
+	a.OpenWallet(*cfg)
+	defer func() {
+		a.CloseWallet()
+		a.StorageH.Close()
+		home := utils.IndyBaseDir()
+		removeFiles(home, "/.indy_client/wallet/"+walletName)
+		removeFiles(home, "/storage/"+walletName+"*")
+	}()
+
+	dids := make([]string, 2)
+	for i := range dids {
+		d, err := a.NewDID(method.TypeSov, "http://localhost:8080/a2a/conn-1")
+		assert.NoError(err)
+		dids[i] = d.Did()
+	}
+	return dids
+}
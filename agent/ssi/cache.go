@@ -50,6 +50,15 @@ func (c *Cache) Get(s string, sure bool) *DID {
 	return c.cache[s]
 }
 
+// Reset empties the cache, e.g. when the DIDs must be read from the wallet
+// again.
+func (c *Cache) Reset() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.cache = nil
+}
+
 func (c *Cache) Clone() Cache {
 	c.Lock()
 	defer c.Unlock()
@@ -0,0 +1,112 @@
+package ssi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-agent/method"
+	"github.com/findy-network/findy-common-go/crypto"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+// connectionBackup is the content of the connection export file. DIDs are the
+// storage records of the both ends of the connections.
+type connectionBackup struct {
+	Connections []storage.Connection
+	DIDs        []storage.DID
+}
+
+// ExportConnections writes the agent's connections with their DID records to
+// the file encrypted with the key, which is 32 bytes in hex. The private keys
+// of our DIDs stay in the wallet, which means that the connections can be
+// used only with a wallet having them, e.g. restored from the wallet export.
+func (a *DIDAgent) ExportConnections(key, path string) (err error) {
+	defer err2.Handle(&err, "export connections")
+
+	a.AssertWallet()
+	cipher := try.To1(newBackupCipher(key))
+
+	backup := connectionBackup{
+		Connections: try.To1(a.ConnectionStorage().ListConnections()),
+	}
+	for _, conn := range backup.Connections {
+		for _, didStr := range []string{conn.MyDID, conn.TheirDID} {
+			if didStr == "" {
+				continue
+			}
+			d, err := a.DIDStorage().GetDID(didStr)
+			if err != nil {
+				glog.Warningf("connection (%s) DID (%s) not exported: %v",
+					conn.ID, didStr, err)
+				continue
+			}
+			backup.DIDs = append(backup.DIDs, *d)
+		}
+	}
+	data := try.To1(json.Marshal(backup))
+	try.To(os.WriteFile(path, cipher.TryEncrypt(data), 0600))
+	glog.V(1).Infoln("connections exported:", len(backup.Connections))
+	return nil
+}
+
+// ImportConnections restores the connections exported with ExportConnections
+// to the agent's storage. Existing connections aren't overwritten. It returns
+// the imported connections.
+func (a *DIDAgent) ImportConnections(key, path string) (
+	conns []storage.Connection,
+	err error,
+) {
+	defer err2.Handle(&err, "import connections")
+
+	a.AssertWallet()
+	cipher := try.To1(newBackupCipher(key))
+
+	data := try.To1(os.ReadFile(path))
+	assert.That(len(data) > 12, "connection backup (%s) too short", path)
+	var backup connectionBackup
+	try.To(json.Unmarshal(cipher.TryDecrypt(data), &backup))
+
+	theirDIDs := make(map[string]bool, len(backup.Connections))
+	for _, conn := range backup.Connections {
+		theirDIDs[conn.TheirDID] = true
+	}
+	for _, d := range backup.DIDs {
+		if _, err := a.DIDStorage().GetDID(d.ID); err == nil {
+			continue
+		}
+		// the wallet needs their indy verkeys for packing the messages
+		if theirDIDs[d.DID] && isIndyDID(d.DID) {
+			try.To(a.SaveTheirDID(d.DID, d.IndyVerKey))
+		}
+		try.To(a.DIDStorage().SaveDID(d))
+	}
+
+	for _, conn := range backup.Connections {
+		if _, err := a.ConnectionStorage().GetConnection(conn.ID); err == nil {
+			glog.V(3).Infof("connection (%s) exists, not imported", conn.ID)
+			continue
+		}
+		try.To(a.ConnectionStorage().SaveConnection(conn))
+		conns = append(conns, conn)
+	}
+	glog.V(1).Infoln("connections imported:", len(conns))
+	return conns, nil
+}
+
+func isIndyDID(didStr string) bool {
+	t := method.DIDType(didStr)
+	return t != method.TypeKey && t != method.TypePeer
+}
+
+func newBackupCipher(key string) (_ *crypto.Cipher, err error) {
+	defer err2.Handle(&err)
+
+	k := try.To1(hex.DecodeString(key))
+	assert.SLen(k, 32, "backup key must be 32 bytes")
+	return crypto.NewCipher(k), nil
+}
@@ -0,0 +1,73 @@
+package ssi
+
+import (
+	"path/filepath"
+	"testing"
+
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-agent/method"
+	"github.com/lainio/err2/assert"
+)
+
+const backupKey = "15308490f1e4026284594dd08d31291bc8ef2aeac730d0daf6ff87bb92d4336c"
+
+func TestExportImportConnections(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const (
+		theirDID    = "YMNQRjSbhv5Q7vXZ4XfXuM"
+		theirVerKey = "J65ELAijH6BUeL3VZcck7TEsf1TDyRWENkHGHU7mesFn"
+	)
+
+	exporter := new(DIDAgent)
+	cfg := NewRawWalletCfg(walletName1+"_conn_export", key)
+	cfg.Create()
+	exporter.OpenWallet(*cfg)
+
+	myDID, err := exporter.NewDID(method.TypeKey, "")
+	assert.NoError(err)
+	assert.NoError(exporter.SaveTheirDID(theirDID, theirVerKey))
+	conn := storage.Connection{
+		ID:            "conn-1",
+		MyDID:         myDID.URI(),
+		TheirDID:      theirDID,
+		TheirEndpoint: "http://localhost:8080/a2a/conn-1",
+		TheirRoute:    []string{},
+	}
+	assert.NoError(exporter.ConnectionStorage().SaveConnection(conn))
+
+	path := filepath.Join(t.TempDir(), "connections.backup")
+	assert.NoError(exporter.ExportConnections(backupKey, path))
+
+	importer := new(DIDAgent)
+	cfg = NewRawWalletCfg(walletName1+"_conn_import", key)
+	cfg.Create()
+	importer.OpenWallet(*cfg)
+
+	// wrong key cannot decrypt the backup
+	_, err = importer.ImportConnections(
+		"25308490f1e4026284594dd08d31291bc8ef2aeac730d0daf6ff87bb92d4336c", path)
+	assert.Error(err)
+
+	conns, err := importer.ImportConnections(backupKey, path)
+	assert.NoError(err)
+	assert.SLen(conns, 1)
+
+	want, err := exporter.ConnectionStorage().ListConnections()
+	assert.NoError(err)
+	got, err := importer.ConnectionStorage().ListConnections()
+	assert.NoError(err)
+	assert.DeepEqual(got, want)
+
+	d, err := importer.DIDStorage().GetDID(theirDID)
+	assert.NoError(err)
+	assert.Equal(d.IndyVerKey, theirVerKey)
+	_, err = importer.DIDStorage().GetDID(myDID.URI())
+	assert.NoError(err)
+
+	// the existing connections are kept as is
+	conns, err = importer.ImportConnections(backupKey, path)
+	assert.NoError(err)
+	assert.SLen(conns, 0)
+}
@@ -2,12 +2,15 @@ package ssi
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/findy-network/findy-agent/agent/async"
+	"github.com/findy-network/findy-agent/agent/endp"
 	"github.com/findy-network/findy-agent/agent/managed"
 	"github.com/findy-network/findy-agent/agent/service"
 	"github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-agent/agent/utils"
 	"github.com/findy-network/findy-agent/core"
 	"github.com/findy-network/findy-agent/indy"
 	sov "github.com/findy-network/findy-agent/std/sov/did"
@@ -305,11 +308,35 @@ func (d *DID) Endpoint() string {
 	return ""
 }
 
+// SetAEndp sets the endpoint address. The DIDs are shared by the pipes, which
+// is why the future is swapped under the lock like in StartEndp.
 func (d *DID) SetAEndp(ae service.Addr) {
-	d.endp = &async.Future{
+	f := &async.Future{
 		V:  indyDto.Result{Data: indyDto.Data{Str1: ae.Endp, Str2: ae.Key}},
 		On: async.Consumed,
 	}
+	d.Lock()
+	d.endp = f
+	d.Unlock()
+}
+
+// Snapshot returns a copy of the DID. Setting the futures of the DID, e.g.
+// its endpoint, doesn't change the copy, which is why the pipes give the
+// snapshots to their users.
+func (d *DID) Snapshot() core.DID {
+	d.Lock()
+	defer d.Unlock()
+
+	return &DID{
+		wallet: d.wallet,
+		data:   d.data,
+		stored: d.stored,
+		key:    d.key,
+		meta:   d.meta,
+		pw:     d.pw,
+		endp:   d.endp,
+		pwMeta: d.pwMeta,
+	}
 }
 
 var ErrNoData = fmt.Errorf("no data")
@@ -404,10 +431,36 @@ func NewDoc(did core.DID, ae service.Addr) *sov.Doc {
 		Context:   "https://w3id.org/did/v1",
 		ID:        didURI,
 		PublicKey: []sov.PublicKey{pubK},
-		Service:   []sov.Service{service},
+		Service:   append([]sov.Service{service}, familyServices(did, ae)...),
 		Authentication: []sov.VerificationMethod{{
 			Type:      "Ed25519SignatureAuthentication2018",
 			PublicKey: didURIRef,
 		}},
 	}}
 }
+
+// familyServices returns the services of the protocol families which have
+// their own endpoint paths. The default service stays the first one, because
+// most of the agents use only it.
+func familyServices(did core.DID, ae service.Addr) []sov.Service {
+	families := utils.Settings.ServiceFamilies()
+	if len(families) == 0 || ae.Endp == "" {
+		return nil
+	}
+	addr := endp.NewClientAddr(ae.Endp)
+	if strings.TrimSuffix(addr.Service, endp.Version2EndpSuffix) !=
+		utils.Settings.ServiceName() {
+		return nil // not our endpoint
+	}
+	services := make([]sov.Service, 0, len(families))
+	for _, family := range families {
+		services = append(services, sov.Service{
+			ID:              did.URI() + "#" + family,
+			Type:            "IndyAgent",
+			Priority:        1,
+			RecipientKeys:   []string{did.VerKey()},
+			ServiceEndpoint: addr.ServiceAddr(utils.Settings.ServiceNameFor(family)).Address(),
+		})
+	}
+	return services
+}
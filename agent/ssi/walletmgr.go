@@ -17,6 +17,8 @@ var maxOpened = 10
 
 type manager interface {
 	reopen(h *Handle) int
+	remove(h *Handle)
+	setConfig(h *Handle, cfg managed.WalletCfg)
 }
 
 // SetWalletMgrPoolSize sets pool size, i.e. how many wallets can kept open in
@@ -55,13 +57,44 @@ func (h *Handle) Config() managed.WalletCfg {
 // NOT important or desired to call this function during the agency process is
 // running.
 func (h *Handle) Close() {
-	defer err2.Catch(err2.Err(func(err error) {
-		glog.Warning("closing error:", err)
-	}))
+	h.l.Lock()
+	defer h.l.Unlock()
 
+	h.close()
+}
+
+// Cycle closes the wallet and reopens it right away, e.g. to recover from a
+// storage failure. It returns the new wallet handle, which is zero if the
+// wallet couldn't be reopened.
+func (h *Handle) Cycle() int {
 	h.l.Lock()
 	defer h.l.Unlock()
 
+	// the manager mustn't pick us to be closed when it makes room for us
+	h.mgr.remove(h)
+	if h.h != 0 {
+		h.close()
+	}
+	return h.mgr.reopen(h)
+}
+
+// rekey closes the wallet and sets the configuration with the new key. The
+// wallet is reopened with it on the next use.
+func (h *Handle) rekey(cfg managed.WalletCfg) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	if h.h != 0 {
+		h.close()
+	}
+	h.mgr.setConfig(h, cfg)
+}
+
+func (h *Handle) close() {
+	defer err2.Catch(err2.Err(func(err error) {
+		glog.Warning("closing error:", err)
+	}))
+
 	try.To(h.cfg.CloseWallet(h.h))
 	if glog.V(10) {
 		glog.Info("closing wallet: ", h.cfg.UniqueID())
@@ -131,9 +164,28 @@ var storages = &Mgr{
 	opened: make(WalletMap, maxOpened),
 }
 
-// Open opens a wallet configuration and returns a managed wallet.
+// Open opens a wallet configuration and returns a managed wallet. A wallet
+// which is already managed is returned from the cache, if its key is the same.
+// This saves reopening the wallet when it's used by many operations in the
+// same process. If the key is changed, the cached wallet is closed and it's
+// reopened with the new key on its next use.
 func (m *Mgr) Open(cfg managed.WalletCfg) managed.Wallet {
 	m.l.Lock()
+
+	if h, ok := m.opened[cfg.UniqueID()]; ok {
+		if h.cfg.Key() == cfg.Key() {
+			m.l.Unlock()
+			glog.V(10).Infoln("cached wallet:", cfg.UniqueID())
+			return h
+		}
+		// others may hold the handle, which is why it isn't replaced, and
+		// it's rekeyed after our lock is released to keep the lock order
+		delete(m.opened, cfg.UniqueID())
+		m.l.Unlock()
+		glog.V(3).Infoln("wallet key changed, reopening:", cfg.UniqueID())
+		h.rekey(cfg)
+		return h
+	}
 	defer m.l.Unlock()
 
 	if len(m.opened) < maxOpened {
@@ -181,6 +233,25 @@ func (m *Mgr) reopen(h *Handle) int {
 	return m.closeOldestAndReopen(h)
 }
 
+// setConfig sets the configuration of the handle, which is locked by the
+// caller. Our lock is taken as well, because the configurations of the managed
+// handles are read with it.
+func (m *Mgr) setConfig(h *Handle, cfg managed.WalletCfg) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	h.cfg = cfg
+}
+
+func (m *Mgr) remove(h *Handle) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.opened[h.cfg.UniqueID()] == h {
+		delete(m.opened, h.cfg.UniqueID())
+	}
+}
+
 func (m *Mgr) closeOldestAndReopen(h *Handle) int {
 	oldest := m.findOldest()
 	w := m.opened[oldest]
@@ -215,6 +286,13 @@ func (m *Mgr) findOldest() string {
 	return id
 }
 
+// CloseWallets closes all the managed wallets and agent storages. It should
+// be called when the process exits.
+func CloseWallets() {
+	wallets.Reset()
+	storages.Reset()
+}
+
 // Reset resets the managed wallet buffer which means that all the current
 // wallet configurations must be registered again with ssi.Wallets.Open. Note!
 // You should not need to use this!
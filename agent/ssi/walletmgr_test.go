@@ -123,3 +123,60 @@ func TestMgr_NewOpen(t *testing.T) {
 		})
 	}
 }
+
+func TestMgr_OpenCached(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	SetWalletMgrPoolSize(3)
+	defer wallets.Reset()
+
+	w := wallets.Open(NewRawWalletCfg(walletName1, key))
+	assert.That(w != nil)
+	handle := w.Handle()
+	assert.That(handle > 0)
+
+	// repeated opens hit the cache
+	for i := 0; i < 3; i++ {
+		w2 := wallets.Open(NewRawWalletCfg(walletName1, key))
+		assert.That(w2 == w, "wallet not from cache")
+		assert.Equal(w2.Handle(), handle)
+	}
+	assert.MLen(wallets.opened, 1)
+
+	// an explicit close is reopened on the next use
+	w.Close()
+	w2 := wallets.Open(NewRawWalletCfg(walletName1, key))
+	assert.That(w2 == w, "closed wallet not from cache")
+	assert.That(w2.Handle() > 0)
+
+	// the key change closes the shared wallet, which is reopened with the new
+	// key by its next use
+	const otherKey = "9p6SDnRWSnNhhJNasbZLHTd42uqHMpa3xJm6ZLJqhzN1"
+	w3 := wallets.Open(NewRawWalletCfg(walletName1, otherKey))
+	assert.That(w3 == w, "shared wallet replaced")
+	assert.Equal(w.(*Handle).h, 0)
+	assert.Equal(w.Config().Key(), otherKey)
+	assert.MLen(wallets.opened, 0)
+}
+
+func TestHandle_Cycle(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	SetWalletMgrPoolSize(1) // the cycled wallet fills the pool
+	defer wallets.Reset()
+
+	w := wallets.Open(NewRawWalletCfg(walletName1, key))
+	assert.That(w.Handle() > 0)
+
+	handle := w.Cycle()
+	assert.That(handle > 0)
+	assert.Equal(w.Handle(), handle)
+	assert.MLen(wallets.opened, 1)
+
+	// a closed wallet is opened as well
+	w.Close()
+	assert.That(w.Cycle() > 0)
+	assert.MLen(wallets.opened, 1)
+}
@@ -23,6 +23,7 @@ type AgentStorage interface {
 	DIDStorage() DIDStorage
 	ConnectionStorage() ConnectionStorage
 	CredentialStorage() CredentialStorage
+	MessageQueueStorage() MessageQueueStorage
 
 	OurPackager() Packager
 
@@ -43,16 +44,95 @@ type Connection struct {
 	TheirDID      string
 	TheirEndpoint string
 	TheirRoute    []string
+	QueueOffline  bool // queue messages which cannot be delivered
+
+	// AutoResponse tells how the proof and credential protocol steps of the
+	// connection are responded. Empty uses the agent's setting.
+	AutoResponse string
+
+	// InvitationExpires is the Unix time when our invitation of the
+	// connection expires, and the connection requests are rejected. Zero
+	// means that the invitation doesn't expire.
+	InvitationExpires int64
 }
 
+// The auto responses of the connection, see Connection.AutoResponse.
+const (
+	AutoResponseDefault = ""
+	AutoResponseAccept  = "accept" // accept without the user action
+	AutoResponseManual  = "manual" // always wait for the user action
+)
+
 type ConnectionStorage interface {
 	SaveConnection(conn Connection) error
 	GetConnection(id string) (*Connection, error)
 	ListConnections() ([]Connection, error)
 }
 
+// Credential is holder side metadata of the credential stored to the wallet.
+// ID is the credential ID given by the wallet. Tag is a free form category
+// used to group credentials, e.g. in the wallet UI. RevRegID is empty for
+// credentials which cannot be revoked, and CredRevID is the credential's index
+// in the revocation registry. MasterSecret is the ID of the master secret the
+// credential was requested with.
+type Credential struct {
+	ID           string
+	CredDefID    string
+	SchemaID     string
+	RevRegID     string
+	Tag          string
+	Attributes   []CredentialAttribute
+	CredRevID    string
+	MasterSecret string
+}
+
+// CredentialAttribute is the attribute of the stored credential. MimeType
+// tells how the value is read, e.g. the structured values are JSON, and
+// DataType if the value can be used in the proof predicates.
+type CredentialAttribute struct {
+	Name     string
+	Value    string
+	MimeType string
+	DataType string
+}
+
+// CredentialFilter selects credentials by the set fields. Zero value selects
+// all of them.
+type CredentialFilter struct {
+	SchemaID  string
+	CredDefID string
+	Tag       string
+}
+
+func (f CredentialFilter) Match(cred Credential) bool {
+	return (f.SchemaID == "" || f.SchemaID == cred.SchemaID) &&
+		(f.CredDefID == "" || f.CredDefID == cred.CredDefID) &&
+		(f.Tag == "" || f.Tag == cred.Tag)
+}
+
 type CredentialStorage interface {
-	// Similar than DIDStorage, relevant functionality for storing credential data
+	SaveCredential(cred Credential) error
+	GetCredential(id string) (*Credential, error)
+	ListCredentials(filter CredentialFilter) ([]Credential, error)
+}
+
+// QueuedMessage is a packed outbound message waiting for its delivery to the
+// connection's endpoint. Created is in nanoseconds and it orders the messages.
+type QueuedMessage struct {
+	ID           string
+	ConnectionID string
+	Address      string
+	Data         []byte
+	Created      int64
+	Tries        int
+}
+
+type MessageQueueStorage interface {
+	QueueMessage(msg QueuedMessage) error
+	// QueuedMessages returns the connection's messages in their queuing order.
+	// Empty connection ID returns the messages of all the connections.
+	QueuedMessages(connectionID string) ([]QueuedMessage, error)
+	DequeueMessage(id string) error
 }
 
 type Packager interface {
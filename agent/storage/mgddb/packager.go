@@ -8,6 +8,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/anoncrypt"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/authcrypt"
+	legacyanon "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/anoncrypt"
 	legacy "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/authcrypt"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
@@ -56,6 +57,9 @@ func NewPackager(
 	// legacy authcrypt
 	p.packers = append(p.packers, legacy.New(p))
 
+	// legacy anoncrypt, used when the pipe doesn't tell the sender
+	p.packers = append(p.packers, legacyanon.New(p))
+
 	// authcrypt
 	authPacker := try.To1(authcrypt.New(p, jose.A256CBCHS512))
 	p.packers = append(p.packers, authPacker)
@@ -3,6 +3,7 @@ package mgddb
 import (
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/findy-network/findy-agent/agent/storage/api"
 	"github.com/findy-network/findy-agent/agent/storage/wrapper"
@@ -21,6 +22,7 @@ const (
 	NameDID        = "did"
 	NameConnection = "connection"
 	NameCredential = "credential"
+	NameQueue      = "queue"
 
 	NameVDRPeer = "peer"
 )
@@ -31,6 +33,8 @@ var bucketIDs = []string{
 	NameConnection,
 	NameCredential,
 	NameVDRPeer,
+	// buckets are keyed by their position, new ones must be added last
+	NameQueue,
 }
 
 type Storage struct {
@@ -38,6 +42,8 @@ type Storage struct {
 	keyStorage *kmsStorage
 	didStore   wrapper.Store
 	connStore  wrapper.Store
+	credStore  wrapper.Store
+	queueStore wrapper.Store
 	packager   api.Packager
 }
 
@@ -57,6 +63,8 @@ func New(config api.AgentStorageConfig) (a *Storage, err error) {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
 	}
 
 	try.To(me.Init())
@@ -73,6 +81,14 @@ func New(config api.AgentStorageConfig) (a *Storage, err error) {
 	me.connStore, ok = connStore.(wrapper.Store)
 	assert.That(ok, "conn store should always be wrapper store")
 
+	credStore := try.To1(me.OpenStore(NameCredential))
+	me.credStore, ok = credStore.(wrapper.Store)
+	assert.That(ok, "cred store should always be wrapper store")
+
+	queueStore := try.To1(me.OpenStore(NameQueue))
+	me.queueStore, ok = queueStore.(wrapper.Store)
+	assert.That(ok, "queue store should always be wrapper store")
+
 	vdr := try.To1(vdr.New(me))
 
 	me.packager = try.To1(NewPackager(me, vdr.Registry()))
@@ -103,8 +119,11 @@ func (s *Storage) ConnectionStorage() api.ConnectionStorage {
 }
 
 func (s *Storage) CredentialStorage() api.CredentialStorage {
-	// TODO
-	return nil
+	return s
+}
+
+func (s *Storage) MessageQueueStorage() api.MessageQueueStorage {
+	return s
 }
 
 func (s *Storage) OurPackager() api.Packager {
@@ -148,8 +167,9 @@ func (s *Storage) ListConnections() (res []api.Connection, err error) {
 	defer err2.Handle(&err, "conn storage list conn")
 
 	res = make([]api.Connection, 0)
-	conn := &api.Connection{}
 	try.To1(s.connStore.GetAll(func(bytes []byte) []byte {
+		// GOB leaves out zero values, which is why every one needs its own
+		conn := &api.Connection{}
 		dto.FromGOB(bytes, conn)
 		res = append(res, *conn)
 		return bytes
@@ -158,6 +178,65 @@ func (s *Storage) ListConnections() (res []api.Connection, err error) {
 	return res, nil
 }
 
+// CredentialStorage
+func (s *Storage) SaveCredential(cred api.Credential) error {
+	return s.credStore.Put(cred.ID, dto.ToGOB(cred))
+}
+
+func (s *Storage) GetCredential(id string) (cred *api.Credential, err error) {
+	defer err2.Handle(&err, fmt.Sprintf("cred storage get cred %s", id))
+
+	assert.That(id != "", "credential ID is empty")
+
+	bytes := try.To1(s.credStore.Get(id))
+
+	cred = &api.Credential{}
+	dto.FromGOB(bytes, cred)
+	return
+}
+
+func (s *Storage) ListCredentials(filter api.CredentialFilter) (res []api.Credential, err error) {
+	defer err2.Handle(&err, "cred storage list cred")
+
+	res = make([]api.Credential, 0)
+	try.To1(s.credStore.GetAll(func(bytes []byte) []byte {
+		cred := api.Credential{}
+		dto.FromGOB(bytes, &cred)
+		if filter.Match(cred) {
+			res = append(res, cred)
+		}
+		return bytes
+	}))
+
+	return res, nil
+}
+
+// MessageQueueStorage
+func (s *Storage) QueueMessage(msg api.QueuedMessage) error {
+	return s.queueStore.Put(msg.ID, dto.ToGOB(msg))
+}
+
+func (s *Storage) QueuedMessages(connectionID string) (res []api.QueuedMessage, err error) {
+	defer err2.Handle(&err, "queue storage list messages")
+
+	res = make([]api.QueuedMessage, 0)
+	try.To1(s.queueStore.GetAll(func(bytes []byte) []byte {
+		msg := api.QueuedMessage{}
+		dto.FromGOB(bytes, &msg)
+		if connectionID == "" || connectionID == msg.ConnectionID {
+			res = append(res, msg)
+		}
+		return bytes
+	}))
+	sort.Slice(res, func(i, j int) bool { return res[i].Created < res[j].Created })
+
+	return res, nil
+}
+
+func (s *Storage) DequeueMessage(id string) error {
+	return s.queueStore.Delete(id)
+}
+
 // AFGO StorageProvider placeholder implementations
 // We needed direct wrapping because Go couldn't keep on with transitive
 // type support of aggregated types.
@@ -166,3 +166,50 @@ func TestConnectionStore(t *testing.T) {
 		})
 	}
 }
+
+func TestCredentialStore(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	for index := range kmsTestStorages {
+		testCase := kmsTestStorages[index]
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+			store := testCase.storage.CredentialStorage()
+			testCred := api.Credential{
+				ID:        "cred-123",
+				CredDefID: "cred-def-id",
+				SchemaID:  "schema-id",
+				Tag:       "education",
+				Attributes: []api.CredentialAttribute{
+					{Name: "degree", Value: "Maths"},
+				},
+			}
+			err := store.SaveCredential(testCred)
+			assert.NoError(err)
+
+			gotCred, err := store.GetCredential(testCred.ID)
+			assert.NoError(err)
+			assert.DeepEqual(testCred, *gotCred)
+
+			testCred2 := testCred
+			testCred2.ID = "cred-456"
+			testCred2.Tag = "membership"
+			err = store.SaveCredential(testCred2)
+			assert.NoError(err)
+
+			creds, err := store.ListCredentials(api.CredentialFilter{Tag: "education"})
+			assert.NoError(err)
+			assert.SLen(creds, 1)
+			assert.DeepEqual(testCred, creds[0])
+
+			creds, err = store.ListCredentials(api.CredentialFilter{SchemaID: "schema-id"})
+			assert.NoError(err)
+			assert.SLen(creds, 2)
+
+			creds, err = store.ListCredentials(api.CredentialFilter{CredDefID: "other"})
+			assert.NoError(err)
+			assert.SLen(creds, 0)
+		})
+	}
+}
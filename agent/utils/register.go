@@ -39,6 +39,14 @@ func (r *Reg) Exist(key keyDID) bool {
 	return ok
 }
 
+// Get returns a copy of the values registered with the key.
+func (r *Reg) Get(key keyDID) (value []string, ok bool) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	v, ok := r.r[key]
+	return append([]string(nil), v...), ok
+}
+
 func (r *Reg) Add(key keyDID, value ...string) {
 	glog.V(3).Infof("Handshake register add: %s -> %s\n", key, value)
 	r.l.Lock()
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/findy-network/findy-agent/method"
@@ -22,14 +23,33 @@ type Hub struct {
 
 	gRPCAdmin string
 
-	serviceName string        // name of the this service which is used in URLs, etc.
-	hostAddr    string        // Ip host name of the server's host seen from internet
-	versionInfo string        // Version number etc. in free format as a string
-	timeout     time.Duration // timeout setting for http requests and connections
-	exportPath  string        // wallet export path
+	psmRetention      time.Duration // how long archived PSMs are kept, 0 = forever
+	psmRetentionCount int           // how many archived PSMs are kept, 0 = all
+
+	credOfferTTL    time.Duration // how long sent cred offers are valid, 0 = forever
+	credOfferNotify bool          // tells if holders are notified of expired offers
+
+	proofMaxAttrs      int // max attributes of a proof request, 0 = no limit
+	proofMaxPredicates int // max predicates of a proof request, 0 = no limit
+
+	messageDump bool // tells if protocol messages are stored for debugging
+
+	ledgerFallback bool // tells if cached ledger data is used when ledger is down
+	queueOffline   bool // tells if new connections queue undelivered messages
+
+	protocolComment string // template of the comment when client doesn't give one
+
+	serviceName  string            // name of the this service which is used in URLs, etc.
+	servicePaths map[string]string // protocol family specific service names
+	hostAddr     string            // Ip host name of the server's host seen from internet
+	versionInfo  string            // Version number etc. in free format as a string
+	timeout      time.Duration     // timeout setting for http requests and connections
+	exportPath   string            // wallet export path
 
 	localTestMode bool // tells if are running unit tests, will be obsolete
 
+	pairwiseSeed string // base seed of derived pairwise DIDs, empty = random DIDs
+
 	didMethod method.Type // the DID method to use as a default
 }
 
@@ -80,6 +100,90 @@ func (h *Hub) SetRegisterBackupInterval(interval time.Duration) {
 	h.registerBackupInterval = interval
 }
 
+func (h *Hub) PSMRetention() time.Duration {
+	return h.psmRetention
+}
+
+func (h *Hub) SetPSMRetention(d time.Duration) {
+	h.psmRetention = d
+}
+
+func (h *Hub) PSMRetentionCount() int {
+	return h.psmRetentionCount
+}
+
+func (h *Hub) SetPSMRetentionCount(count int) {
+	h.psmRetentionCount = count
+}
+
+func (h *Hub) CredOfferTTL() time.Duration {
+	return h.credOfferTTL
+}
+
+func (h *Hub) SetCredOfferTTL(d time.Duration) {
+	h.credOfferTTL = d
+}
+
+func (h *Hub) CredOfferNotify() bool {
+	return h.credOfferNotify
+}
+
+func (h *Hub) SetCredOfferNotify(notify bool) {
+	h.credOfferNotify = notify
+}
+
+// ProofMaxAttrs returns the maximum amount of the requested attributes in a
+// proof request. Zero means no limit.
+func (h *Hub) ProofMaxAttrs() int {
+	return h.proofMaxAttrs
+}
+
+func (h *Hub) SetProofMaxAttrs(n int) {
+	h.proofMaxAttrs = n
+}
+
+// ProofMaxPredicates returns the maximum amount of the requested predicates in
+// a proof request. Zero means no limit.
+func (h *Hub) ProofMaxPredicates() int {
+	return h.proofMaxPredicates
+}
+
+func (h *Hub) SetProofMaxPredicates(n int) {
+	h.proofMaxPredicates = n
+}
+
+func (h *Hub) MessageDump() bool {
+	return h.messageDump
+}
+
+func (h *Hub) SetMessageDump(enabled bool) {
+	h.messageDump = enabled
+}
+
+func (h *Hub) QueueOffline() bool {
+	return h.queueOffline
+}
+
+func (h *Hub) SetQueueOffline(enabled bool) {
+	h.queueOffline = enabled
+}
+
+func (h *Hub) LedgerFallback() bool {
+	return h.ledgerFallback
+}
+
+func (h *Hub) SetLedgerFallback(enabled bool) {
+	h.ledgerFallback = enabled
+}
+
+func (h *Hub) ProtocolComment() string {
+	return h.protocolComment
+}
+
+func (h *Hub) SetProtocolComment(tmpl string) {
+	h.protocolComment = tmpl
+}
+
 func (h *Hub) RegisterName() string {
 	return h.registerName
 }
@@ -96,6 +200,20 @@ func (h *Hub) SetLocalTestMode(localTestMode bool) {
 	h.localTestMode = localTestMode
 }
 
+// PairwiseSeed returns the base seed of the deterministic pairwise DIDs. Empty
+// means that the pairwise DIDs are random.
+func (h *Hub) PairwiseSeed() string {
+	return h.pairwiseSeed
+}
+
+// SetPairwiseSeed turns on the deterministic pairwise DIDs, which are derived
+// from the seed. It's only for the tests and the development environments,
+// which need the same DIDs on every run. The agency command doesn't set it,
+// and production uses random DIDs.
+func (h *Hub) SetPairwiseSeed(seed string) {
+	h.pairwiseSeed = seed
+}
+
 // SetTimeout sets the default timeout for HTTP and WS requests.
 func (h *Hub) SetTimeout(to time.Duration) {
 	h.timeout = to
@@ -111,6 +229,44 @@ func (h *Hub) ServiceName() string {
 	return h.serviceName
 }
 
+// SetServicePaths sets protocol family specific service names, e.g.
+// "present-proof" -> "a2a-proof". Messages of the family can be received from
+// the path in addition to the default service name.
+func (h *Hub) SetServicePaths(paths map[string]string) {
+	h.servicePaths = paths
+}
+
+// ServiceFamilies returns the protocol families having their own service
+// name, sorted.
+func (h *Hub) ServiceFamilies() []string {
+	families := make([]string, 0, len(h.servicePaths))
+	for family := range h.servicePaths {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	return families
+}
+
+// ServiceNameFor returns the service name for the protocol family. If the
+// family doesn't have its own, the default service name is returned.
+func (h *Hub) ServiceNameFor(family string) string {
+	if name, ok := h.servicePaths[family]; ok {
+		return name
+	}
+	return h.serviceName
+}
+
+// ServiceFamily returns the protocol family of the service name, or an empty
+// string if the service name isn't family specific.
+func (h *Hub) ServiceFamily(serviceName string) string {
+	for family, name := range h.servicePaths {
+		if name == serviceName {
+			return family
+		}
+	}
+	return ""
+}
+
 // SetVersionInfo sets current version info of this agency. The info is shown in
 // the certain API calls like Ping.
 func (h *Hub) SetVersionInfo(info string) {
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// verbosity has the logging levels of the agents which are debugged without
+// raising the global glog verbosity. The agents are keyed by their DIDs.
+var verbosity = struct {
+	sync.RWMutex
+	levels map[string]glog.Level
+}{levels: make(map[string]glog.Level)}
+
+// SetVerbosity sets the logging level of the DID. Zero level removes the
+// override, and the global verbosity is used again.
+func SetVerbosity(DID string, level glog.Level) {
+	verbosity.Lock()
+	defer verbosity.Unlock()
+
+	if level <= 0 {
+		delete(verbosity.levels, DID)
+		return
+	}
+	verbosity.levels[DID] = level
+}
+
+// Verbosity returns the logging level override of the DID, zero if it has
+// none.
+func Verbosity(DID string) glog.Level {
+	verbosity.RLock()
+	defer verbosity.RUnlock()
+	return verbosity.levels[DID]
+}
+
+// V is glog.V for the logging of the DID's protocols. The level is enabled if
+// the global verbosity or the DID's override enables it.
+func V(DID string, level glog.Level) glog.Verbose {
+	if v := glog.V(level); v {
+		return v
+	}
+	return glog.Verbose(Verbosity(DID) >= level)
+}
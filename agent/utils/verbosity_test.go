@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestV(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const (
+		debugDID = "debugDID"
+		otherDID = "otherDID"
+	)
+	assert.That(!bool(V(debugDID, 5)))
+
+	SetVerbosity(debugDID, 5)
+	defer SetVerbosity(debugDID, 0)
+	assert.Equal(Verbosity(debugDID), 5)
+	assert.That(bool(V(debugDID, 3)))
+	assert.That(bool(V(debugDID, 5)))
+	assert.That(!bool(V(debugDID, 6)))
+
+	// other DIDs use the global verbosity
+	assert.Equal(Verbosity(otherDID), 0)
+	assert.That(!bool(V(otherDID, 3)))
+
+	SetVerbosity(debugDID, 0)
+	assert.That(!bool(V(debugDID, 3)))
+}
@@ -0,0 +1,70 @@
+package vc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/findy-network/findy-wrapper-go"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/findy-network/findy-wrapper-go/ledger"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+// CredDefAlreadyExistsError is the indy error code when the wallet has the
+// cred def of the schema and the tag already.
+const CredDefAlreadyExistsError = 213
+
+// CreateCredDef creates the cred def of the schema to the wallet and writes it
+// to the ledger. It returns the cred def ID. If the wallet has the cred def
+// already, its ID is returned when the ledger has it as well. That makes the
+// function idempotent for the issuer setup.
+func CreateCredDef(
+	pool, wallet int,
+	DID string,
+	s *Schema,
+	tag string,
+	supportRevocation bool,
+) (
+	id string,
+	err error,
+) {
+	defer err2.Handle(&err, "create cred def (%s) by DID (%s)", tag, DID)
+
+	assert.NotEmpty(tag, "cred def tag missing")
+	assert.NotNil(s.Stored, "schema not read from ledger")
+
+	cfg := try.To1(json.Marshal(anoncreds.CredDefCfg{
+		SupportRevocation: supportRevocation,
+	}))
+	r := <-anoncreds.IssuerCreateAndStoreCredentialDef(wallet, DID,
+		s.Stored.Str2(), tag, findy.NullString, string(cfg))
+	if r.Err() != nil {
+		if r.ErrCode() != CredDefAlreadyExistsError {
+			return "", r.Err()
+		}
+		id = try.To1(credDefID(DID, s, tag))
+		glog.V(1).Infoln("cred def exists:", id)
+		try.To1(CredDefFromLedger(pool, DID, id))
+		return id, nil
+	}
+
+	try.To(ledger.WriteCredDef(pool, wallet, DID, r.Str2()))
+	return r.Str1(), nil
+}
+
+// credDefID returns the cred def ID which indy gives to the schema's cred def
+// with the tag.
+func credDefID(DID string, s *Schema, tag string) (_ string, err error) {
+	defer err2.Handle(&err, "cred def ID")
+
+	var sch struct {
+		SeqNo uint64 `json:"seqNo"`
+	}
+	try.To(json.Unmarshal([]byte(s.Stored.Str2()), &sch))
+	assert.That(sch.SeqNo != 0, "schema (%s) seqNo missing", s.ValidID())
+
+	return fmt.Sprintf("%s:3:CL:%d:%s", DID, sch.SeqNo, tag), nil
+}
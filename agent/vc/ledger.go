@@ -0,0 +1,64 @@
+package vc
+
+import (
+	"sync"
+
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-wrapper-go/ledger"
+	"github.com/golang/glog"
+)
+
+// readCredDef and readSchema read the ledger. They are variables that the
+// tests can take the ledger down.
+var (
+	readCredDef = ledger.ReadCredDef
+	readSchema  = ledger.ReadSchema
+)
+
+// ledgerCache has the schemas and the cred defs read from the ledger by their
+// IDs. They never change on the ledger, which makes them safe to use when the
+// ledger cannot be reached, see utils.Settings.LedgerFallback.
+var ledgerCache = struct {
+	sync.RWMutex
+	items map[string]string
+}{items: make(map[string]string)}
+
+// cachedRead reads the ledger and caches the result. If the read fails in the
+// fallback mode, the cached data is returned as stale.
+func cachedRead(ID string, read func() (string, error)) (data string, stale bool, err error) {
+	data, err = read()
+	if err == nil {
+		ledgerCache.Lock()
+		ledgerCache.items[ID] = data
+		ledgerCache.Unlock()
+		return data, false, nil
+	}
+	if !utils.Settings.LedgerFallback() {
+		return "", false, err
+	}
+	ledgerCache.RLock()
+	data, found := ledgerCache.items[ID]
+	ledgerCache.RUnlock()
+	if !found {
+		return "", false, err
+	}
+	glog.Warningf("ledger read (%s): %v, using cached data", ID, err)
+	return data, true, nil
+}
+
+// ReadCredDef reads the cred def from the ledger of the pool. Stale tells that
+// the ledger couldn't be reached and the cred def is from the cache.
+func ReadCredDef(pool int, DID, credDefID string) (cd string, stale bool, err error) {
+	return cachedRead(credDefID, func() (string, error) {
+		_, cd, err := readCredDef(pool, DID, credDefID)
+		return cd, err
+	})
+}
+
+// ReadSchema reads the schema from the ledger like ReadCredDef.
+func ReadSchema(pool int, DID, schemaID string) (s string, stale bool, err error) {
+	return cachedRead(schemaID, func() (string, error) {
+		_, s, err := readSchema(pool, DID, schemaID)
+		return s, err
+	})
+}
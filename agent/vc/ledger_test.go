@@ -0,0 +1,173 @@
+package vc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-wrapper-go"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/findy-network/findy-wrapper-go/did"
+	"github.com/findy-network/findy-wrapper-go/dto"
+	"github.com/findy-network/findy-wrapper-go/helpers"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+var errLedgerDown = errors.New("ledger unreachable")
+
+// setLedger replaces the ledger reads with the data by IDs, or with the error
+// when the ledger is down, and returns the function to restore them.
+func setLedger(data map[string]string, down bool) (restore func()) {
+	origCredDef, origSchema := readCredDef, readSchema
+	read := func(_ int, _, ID string) (string, string, error) {
+		if down {
+			return "", "", errLedgerDown
+		}
+		return ID, data[ID], nil
+	}
+	readCredDef, readSchema = read, read
+	return func() { readCredDef, readSchema = origCredDef, origSchema }
+}
+
+func TestLedgerFallback_Proof(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	utils.Settings.SetLedgerFallback(true)
+	defer utils.Settings.SetLedgerFallback(false)
+
+	w, name := helpers.CreateAndOpenTestWallet(t)
+	defer helpers.CloseAndDeleteTestWallet(w, name, t)
+	r := <-did.CreateAndStore(w, did.Did{})
+	try.To(r.Err())
+	issuerDID := r.Str1()
+
+	// the schema and the cred def as they are on the ledger, the seqNo is
+	// given by the ledger
+	r = <-anoncreds.IssuerCreateSchema(issuerDID, "email", "1.0", `["email"]`)
+	try.To(r.Err())
+	schemaID := r.Str1()
+	var sch map[string]any
+	try.To(json.Unmarshal([]byte(r.Str2()), &sch))
+	sch["seqNo"] = 14
+	schemaJSON := dto.ToJSON(sch)
+	r = <-anoncreds.IssuerCreateAndStoreCredentialDef(w, issuerDID, schemaJSON,
+		"T1", findy.NullString, findy.NullString)
+	try.To(r.Err())
+	credDefID := r.Str1()
+	ledgerData := map[string]string{
+		schemaID:  schemaJSON,
+		credDefID: r.Str2(),
+	}
+
+	// the credential is issued while the ledger is up, which caches the
+	// schema and the cred def
+	restore := setLedger(ledgerData, false)
+	credDef, err := CredDefFromLedger(0, issuerDID, credDefID)
+	assert.NoError(err)
+	ledgerSch := &Schema{ID: schemaID}
+	assert.NoError(ledgerSch.FromLedger(0, issuerDID))
+	assert.That(!ledgerSch.Stale)
+
+	r = <-anoncreds.IssuerCreateCredentialOffer(w, credDefID)
+	try.To(r.Err())
+	offer := r.Str1()
+	r = <-anoncreds.ProverCreateMasterSecret(w, "fallback_master_secret")
+	try.To(r.Err())
+	masterSecret := r.Str1()
+	r = <-anoncreds.ProverCreateCredentialReq(w, issuerDID, offer, credDef,
+		masterSecret)
+	try.To(r.Err())
+	credReqMeta := r.Str2()
+	var values struct {
+		Email anoncreds.CredDefAttr `json:"email"`
+	}
+	values.Email.SetRaw("holder@example.com")
+	r = <-anoncreds.IssuerCreateCredential(w, offer, r.Str1(),
+		dto.ToJSON(values), findy.NullString, findy.NullHandle)
+	try.To(r.Err())
+	r = <-anoncreds.ProverStoreCredential(w, findy.NullString, credReqMeta,
+		r.Str1(), credDef, findy.NullString)
+	try.To(r.Err())
+	credID := r.Str1()
+	restore()
+
+	// the proof is built and verified from the cache while the ledger is down
+	restore = setLedger(nil, true)
+	defer restore()
+	proofReq := dto.ToJSON(anoncreds.ProofRequest{
+		Name:    "email",
+		Version: "0.1",
+		Nonce:   "12345678901234567890",
+		RequestedAttributes: map[string]anoncreds.AttrInfo{
+			"attr1_referent": {Name: "email"},
+		},
+		RequestedPredicates: map[string]anoncreds.PredicateInfo{},
+	})
+	reqCred := dto.ToJSON(anoncreds.RequestedCredentials{
+		SelfAttestedAttributes: map[string]string{},
+		RequestedAttributes: map[string]anoncreds.RequestedAttrObject{
+			"attr1_referent": {CredID: credID, Revealed: true},
+		},
+		RequestedPredicates: map[string]anoncreds.RequestedPredObject{},
+	})
+	schemasJSON, credDefsJSON := proofLedgerData(issuerDID, schemaID, credDefID)
+	r = <-anoncreds.ProverCreateProof(w, proofReq, reqCred, masterSecret,
+		schemasJSON, credDefsJSON, "{}")
+	try.To(r.Err())
+	proof := r.Str1()
+	r = <-anoncreds.VerifierVerifyProof(proofReq, proof, schemasJSON,
+		credDefsJSON, "{}", "{}")
+	try.To(r.Err())
+	assert.That(r.Yes())
+
+	// uncached data cannot be read
+	_, _, err = ReadCredDef(0, issuerDID, "Th7MpTaRZVRYnPiabds81Y:3:CL:14:T2")
+	assert.Error(err)
+
+	// without the fallback mode the read fails
+	utils.Settings.SetLedgerFallback(false)
+	_, err = CredDefFromLedger(0, issuerDID, credDefID)
+	assert.Error(err)
+}
+
+// proofLedgerData reads the schema and the cred def of the proof like the
+// present proof protocol does, and checks that they come from the cache.
+func proofLedgerData(DID, schemaID, credDefID string) (schemasJSON, credDefsJSON string) {
+	sch := &Schema{ID: schemaID}
+	assert.NoError(sch.FromLedger(0, DID))
+	assert.That(sch.Stale)
+	cd, stale, err := ReadCredDef(0, DID, credDefID)
+	assert.NoError(err)
+	assert.That(stale)
+
+	var schemaObject, credDefObject map[string]any
+	try.To(json.Unmarshal([]byte(sch.LazySchema()), &schemaObject))
+	try.To(json.Unmarshal([]byte(cd), &credDefObject))
+	return dto.ToJSON(map[string]any{schemaID: schemaObject}),
+		dto.ToJSON(map[string]any{credDefID: credDefObject})
+}
+
+func TestReadLedger_Pool(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	origCredDef := readCredDef
+	defer func() { readCredDef = origCredDef }()
+	readCredDef = func(pool int, _, ID string) (string, string, error) {
+		return ID, fmt.Sprintf(`{"pool":%d}`, pool), nil
+	}
+
+	// the agents of the different pools read the same ID from their own
+	// ledgers
+	const credDefID = "Th7MpTaRZVRYnPiabds81Y:3:CL:14:pool"
+	cd, err := CredDefFromLedger(1, "DID", credDefID)
+	assert.NoError(err)
+	assert.Equal(cd, `{"pool":1}`)
+	cd, err = CredDefFromLedger(2, "DID", credDefID)
+	assert.NoError(err)
+	assert.Equal(cd, `{"pool":2}`)
+}
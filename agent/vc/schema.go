@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 
 	"github.com/findy-network/findy-agent/agent/async"
-	"github.com/findy-network/findy-agent/agent/pool"
 	"github.com/findy-network/findy-wrapper-go/anoncreds"
 	indyDto "github.com/findy-network/findy-wrapper-go/dto"
 	"github.com/findy-network/findy-wrapper-go/ledger"
@@ -18,6 +17,7 @@ type Schema struct {
 	Version string        `json:"version,omitempty"` // version number in string
 	Attrs   []string      `json:"attrs,omitempty"`   // attribute string list
 	Stored  *async.Future `json:"-"`                 // info from ledger
+	Stale   bool          `json:"-"`                 // cached, ledger is down
 }
 
 func (s *Schema) Create(DID string) (err error) {
@@ -40,23 +40,25 @@ func (s *Schema) ValidID() string {
 	return s.ID
 }
 
-func (s *Schema) ToLedger(wallet int, DID string) error {
+func (s *Schema) ToLedger(pool, wallet int, DID string) error {
 	scJSON := s.Stored.Str2()
-	return ledger.WriteSchema(pool.Handle(), wallet, DID, scJSON)
+	return ledger.WriteSchema(pool, wallet, DID, scJSON)
 }
 
-func CredDefFromLedger(DID, credDefID string) (cd string, err error) {
+func CredDefFromLedger(pool int, DID, credDefID string) (cd string, err error) {
 	defer err2.Handle(&err, "process get cred def")
 
-	_, cd, err = ledger.ReadCredDef(pool.Handle(), DID, credDefID)
+	cd, _, err = ReadCredDef(pool, DID, credDefID)
 	return cd, err
 }
 
-func (s *Schema) FromLedger(DID string) (err error) {
+func (s *Schema) FromLedger(pool int, DID string) (err error) {
 	defer err2.Handle(&err, "schema from ledger")
 
-	sID, schema := try.To2(ledger.ReadSchema(pool.Handle(), DID, s.ValidID()))
+	sID := s.ValidID()
+	schema, stale := try.To2(ReadSchema(pool, DID, sID))
 	s.Stored = &async.Future{V: indyDto.Result{Data: indyDto.Data{Str1: sID, Str2: schema}}, On: async.Consumed}
+	s.Stale = stale
 
 	return nil
 }
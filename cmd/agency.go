@@ -55,6 +55,18 @@ var agencyStartEnvs = map[string]string{
 	"wallet-backup-time":       "WALLET_BACKUP_TIME",
 	"wallet-pool":              "WALLET_POOL",
 	"request-timeout":          "REQUEST_TIMEOUT",
+	"psm-retention":            "PSM_RETENTION",
+	"psm-retention-count":      "PSM_RETENTION_COUNT",
+	"cred-offer-ttl":           "CRED_OFFER_TTL",
+	"cred-offer-notify":        "CRED_OFFER_NOTIFY",
+	"proof-max-attrs":          "PROOF_MAX_ATTRS",
+	"proof-max-predicates":     "PROOF_MAX_PREDICATES",
+	"message-dump":             "MESSAGE_DUMP",
+	"ledger-fallback":          "LEDGER_FALLBACK",
+	"queue-offline":            "QUEUE_OFFLINE",
+	"service-paths":            "SERVICE_PATHS",
+	"agent-pools":              "AGENT_POOLS",
+	"protocol-comment":         "PROTOCOL_COMMENT",
 }
 
 // startAgencyCmd represents the agency start subcommand
@@ -188,6 +200,18 @@ func init() {
 	flags.DurationVar(&aCmd.RegisterBackupInterval, "register-backup-interval", registerBackupInterval, flagInfo("Duration between handshake registry backups", AgencyCmd.Name(), agencyStartEnvs["register-backup-interval"]))
 	flags.StringVar(&aCmd.WalletBackupPath, "wallet-backup", "", flagInfo("Path for wallet backups", AgencyCmd.Name(), agencyStartEnvs["wallet-backup"]))
 	flags.StringVar(&aCmd.WalletBackupTime, "wallet-backup-time", "04:00", flagInfo("Time to start wallet backups for dirty ones", AgencyCmd.Name(), agencyStartEnvs["wallet-backup-time"]))
+	flags.DurationVar(&aCmd.PSMRetention, "psm-retention", 0, flagInfo("How long archived protocol states are kept, 0 keeps them forever", AgencyCmd.Name(), agencyStartEnvs["psm-retention"]))
+	flags.IntVar(&aCmd.PSMRetentionCount, "psm-retention-count", 0, flagInfo("How many archived protocol states are kept, 0 keeps all", AgencyCmd.Name(), agencyStartEnvs["psm-retention-count"]))
+	flags.DurationVar(&aCmd.CredOfferTTL, "cred-offer-ttl", aCmd.CredOfferTTL, flagInfo("How long a sent credential offer waits the holder, 0 forever", AgencyCmd.Name(), agencyStartEnvs["cred-offer-ttl"]))
+	flags.BoolVar(&aCmd.CredOfferNotify, "cred-offer-notify", false, flagInfo("Send the holder a problem report when its credential offer expires", AgencyCmd.Name(), agencyStartEnvs["cred-offer-notify"]))
+	flags.IntVar(&aCmd.ProofMaxAttrs, "proof-max-attrs", aCmd.ProofMaxAttrs, flagInfo("Max requested attributes of a proof request, 0 no limit", AgencyCmd.Name(), agencyStartEnvs["proof-max-attrs"]))
+	flags.IntVar(&aCmd.ProofMaxPredicates, "proof-max-predicates", aCmd.ProofMaxPredicates, flagInfo("Max requested predicates of a proof request, 0 no limit", AgencyCmd.Name(), agencyStartEnvs["proof-max-predicates"]))
+	flags.BoolVar(&aCmd.MessageDump, "message-dump", false, flagInfo("Store protocol messages for debugging, never in production", AgencyCmd.Name(), agencyStartEnvs["message-dump"]))
+	flags.BoolVar(&aCmd.LedgerFallback, "ledger-fallback", false, flagInfo("Use cached schemas and cred defs when the ledger is down", AgencyCmd.Name(), agencyStartEnvs["ledger-fallback"]))
+	flags.BoolVar(&aCmd.QueueOffline, "queue-offline", false, flagInfo("Queue messages to new connections while they are offline and resend them later", AgencyCmd.Name(), agencyStartEnvs["queue-offline"]))
+	flags.StringToStringVar(&aCmd.ServicePaths, "service-paths", nil, flagInfo("Protocol family specific URL paths, e.g. present-proof=a2a-proof", AgencyCmd.Name(), agencyStartEnvs["service-paths"]))
+	flags.StringToStringVar(&aCmd.AgentPools, "agent-pools", nil, flagInfo("Ledger pools of the agents by their CA DIDs, e.g. <CA DID>=sovrin-mainnet", AgencyCmd.Name(), agencyStartEnvs["agent-pools"]))
+	flags.StringVar(&aCmd.ProtocolComment, "protocol-comment", "", flagInfo("Default comment template for credential and proof messages, e.g. '{{.Protocol}} for {{.ConnectionName}}'", AgencyCmd.Name(), agencyStartEnvs["protocol-comment"]))
 	flags.IntVar(&aCmd.WalletPoolSize, "wallet-pool", aCmd.WalletPoolSize, flagInfo("Amount wallets open in same time", AgencyCmd.Name(), agencyStartEnvs["wallet-pool"]))
 
 	p := pingAgencyCmd.Flags()
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/findy-network/findy-agent/agent/ssi"
 	"github.com/findy-network/findy-common-go/utils"
 	"github.com/golang/glog"
 	"github.com/lainio/err2"
@@ -44,7 +45,10 @@ Findy agent cli tool
 
 // Execute root
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	// the commands may have kept their wallets open
+	ssi.CloseWallets()
+	if err != nil {
 		// To fix errors printed twice removing the cobra generators next
 		// see: https://github.com/spf13/cobra/issues/304
 		// fmt.Println(err)
@@ -7,12 +7,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/findy-network/findy-agent/agent/accessmgr"
 	"github.com/findy-network/findy-agent/agent/agency"
 	"github.com/findy-network/findy-agent/agent/bus"
 	"github.com/findy-network/findy-agent/agent/cloud"
+	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/handshake"
 	"github.com/findy-network/findy-agent/agent/pool"
 	"github.com/findy-network/findy-agent/agent/psm"
@@ -23,8 +25,10 @@ import (
 	grpcserver "github.com/findy-network/findy-agent/grpc/server"
 	"github.com/findy-network/findy-agent/method"
 	_ "github.com/findy-network/findy-agent/protocol/basicmessage" // protocols needed
-	_ "github.com/findy-network/findy-agent/protocol/connection"
+	"github.com/findy-network/findy-agent/protocol/connection"
+	_ "github.com/findy-network/findy-agent/protocol/discoverfeatures"
 	_ "github.com/findy-network/findy-agent/protocol/issuecredential"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/issuer"
 	_ "github.com/findy-network/findy-agent/protocol/notification"
 	_ "github.com/findy-network/findy-agent/protocol/presentproof"
 	_ "github.com/findy-network/findy-agent/protocol/trustping"
@@ -79,6 +83,24 @@ type Cmd struct {
 	GRPCAdmin      string
 	WalletPoolSize int
 
+	PSMRetention      time.Duration
+	PSMRetentionCount int
+
+	CredOfferTTL    time.Duration
+	CredOfferNotify bool
+	MessageDump     bool
+
+	LedgerFallback bool
+	QueueOffline   bool
+
+	ProofMaxAttrs      int
+	ProofMaxPredicates int
+
+	ProtocolComment string
+
+	ServicePaths map[string]string
+	AgentPools   map[string]string // CA DID to its ledger pool name
+
 	DIDMethod method.Type
 }
 
@@ -118,6 +140,18 @@ var (
 		WalletBackupTime:       "",
 		GRPCAdmin:              "findy-root",
 		WalletPoolSize:         10,
+		PSMRetention:           0,
+		PSMRetentionCount:      0,
+		CredOfferTTL:           0,
+		CredOfferNotify:        false,
+		MessageDump:            false,
+		LedgerFallback:         false,
+		QueueOffline:           false,
+		ProofMaxAttrs:          100,
+		ProofMaxPredicates:     100,
+		ProtocolComment:        "",
+		ServicePaths:           nil,
+		AgentPools:             nil,
 		DIDMethod:              method.TypeSov,
 	}
 )
@@ -127,14 +161,53 @@ func (c *Cmd) Validate() (err error) {
 
 	c.SetMustHaveDefaults()
 
-	assert.NotEmpty(c.HostScheme, "host scheme cannot be empty")
-	assert.That(c.StewardDid == "" || (c.WalletName != "" && c.WalletPwd != ""), "wallet identification cannot be empty")
-	assert.NotEmpty(c.PoolName, "pool name cannot be empty")
-	assert.NotEmpty(c.ServiceName, "service name 2 cannot be empty")
-	assert.NotEmpty(c.HostAddr, "host address cannot be empty")
-	assert.That(c.HostPort != 0, "host port cannot be zero")
-	assert.NotEmpty(c.PsmDB, "psmd database location must be given")
-	assert.NotEmpty(c.HandshakeRegister, "handshake register path cannot be empty")
+	if c.HostScheme == "" {
+		return cmds.NewValidationError("HostScheme", "host scheme cannot be empty")
+	}
+	if c.StewardDid != "" && (c.WalletName == "" || c.WalletPwd == "") {
+		return cmds.NewValidationError("WalletName", "wallet identification cannot be empty")
+	}
+	if c.PoolName == "" {
+		return cmds.NewValidationError("PoolName", "pool name cannot be empty")
+	}
+	if c.ServiceName == "" {
+		return cmds.NewValidationError("ServiceName", "service name 2 cannot be empty")
+	}
+	if _, err := template.New("comment").Parse(c.ProtocolComment); err != nil {
+		return cmds.InvalidField("ProtocolComment", err)
+	}
+	names := map[string]bool{c.ServiceName: true}
+	for family, name := range c.ServicePaths {
+		if name == "" {
+			return cmds.NewValidationError("ServicePaths",
+				"service path of %s cannot be empty", family)
+		}
+		if names[name] {
+			return cmds.NewValidationError("ServicePaths",
+				"service path %s must be unique", name)
+		}
+		names[name] = true
+	}
+	if c.HostAddr == "" {
+		return cmds.NewValidationError("HostAddr", "host address cannot be empty")
+	}
+	if c.HostPort == 0 {
+		return cmds.NewValidationError("HostPort", "host port cannot be zero")
+	}
+	if c.PsmDB == "" {
+		return cmds.NewValidationError("PsmDB", "psmd database location must be given")
+	}
+	if c.HandshakeRegister == "" {
+		return cmds.NewValidationError("HandshakeRegister",
+			"handshake register path cannot be empty")
+	}
+	if c.ProofMaxAttrs < 0 {
+		return cmds.NewValidationError("ProofMaxAttrs", "proof request limits cannot be negative")
+	}
+	if c.ProofMaxPredicates < 0 {
+		return cmds.NewValidationError("ProofMaxPredicates",
+			"proof request limits cannot be negative")
+	}
 	if c.RegisterBackupName == "" {
 		glog.Warning("handshake register backup should be empty in production")
 	}
@@ -146,12 +219,12 @@ func (c *Cmd) Validate() (err error) {
 	}
 	if c.WalletBackupTime != "" {
 		if err := cmds.ValidateTime(c.WalletBackupTime); err != nil {
-			return err
+			return cmds.InvalidField("WalletBackupTime", err)
 		}
 	}
 	if c.EnclaveBackupTime != "" {
 		if err := cmds.ValidateTime(c.EnclaveBackupTime); err != nil {
-			return err
+			return cmds.InvalidField("EnclaveBackupTime", err)
 		}
 	}
 	return nil
@@ -182,6 +255,8 @@ func (c *Cmd) Run() (err error) {
 	c.startBackupTasks()
 	startGrpcServer(c.GRPCTLS, c.GRPCPort, c.TLSCertPath, c.JWTSecret)
 	shutdownCh := server.StartHTTPServer(c.ServerPort)
+	go resumeHandshakes()
+	go setAgentPools(c.AgentPools)
 	<-shutdownCh
 	glog.Infoln("shutdown signaled: signaling gRPC clients: SystemReboot..")
 	bus.BroadcastReboot()
@@ -218,10 +293,70 @@ func (c *Cmd) startBackupTasks() {
 			glog.Warningln("register backup start error:", err)
 		}
 	}
+	if c.PSMRetention != 0 || c.PSMRetentionCount != 0 {
+		glog.V(1).Infoln("PSM retention:", c.PSMRetention, c.PSMRetentionCount)
+		_, err := cron.Every(1).Hour().Do(psm.SweepArchived)
+		if err != nil {
+			glog.Warningln("PSM sweeper start error:", err)
+		}
+	}
+	// the offers sent before the TTL was changed expire still
+	if _, err := cron.Every(1).Minute().Do(issuer.ExpireOffers); err != nil {
+		glog.Warningln("cred offer expiry start error:", err)
+	}
+	// messages queued for offline peers, see comm.SetQueueOffline
+	if _, err := cron.Every(1).Minute().Do(comm.RetryQueued); err != nil {
+		glog.Warningln("queued messages retry start error:", err)
+	}
 
 	cron.StartAsync()
 }
 
+// resumeHandshakes continues the connection protocols which the previous run
+// of the agency left unfinished. The servers must be up to receive the
+// answers.
+func resumeHandshakes() {
+	_, err := connection.ResumeHandshakes(func(DID string) comm.Receiver {
+		if !agency.IsHandlerInThisAgency(DID) {
+			return nil
+		}
+		rcvr, ok := agency.Handler(DID).(comm.Receiver)
+		if !ok {
+			return nil
+		}
+		return rcvr.WorkerEA()
+	})
+	if err != nil {
+		glog.Warningln("resume handshakes error:", err)
+	}
+}
+
+// setAgentPools selects the ledger pools of the agents given in the agency
+// configuration when the registered agents are loaded. The selection is
+// stored to the register, and the agents keep it over the restarts.
+func setAgentPools(pools map[string]string) {
+	if len(pools) == 0 {
+		return
+	}
+	<-agency.Ready.Done()
+	for caDID, poolName := range pools {
+		if !agency.IsHandlerInThisAgency(caDID) {
+			glog.Warningf("agent pool: agent (%s) not in this agency", caDID)
+			continue
+		}
+		ca, ok := agency.Handler(caDID).(*cloud.Agent)
+		if !ok {
+			glog.Warningf("agent pool: (%s) is not a cloud agent", caDID)
+			continue
+		}
+		if err := handshake.SetAgentPool(ca, poolName); err != nil {
+			glog.Warningln("agent pool error:", err)
+			continue
+		}
+		glog.V(1).Infof("agent (%s) uses pool: %s", caDID, poolName)
+	}
+}
+
 func StartAgency(serverCmd *Cmd) (err error) {
 	defer err2.Handle(&err)
 
@@ -313,6 +448,17 @@ func (c *Cmd) setRuntimeSettings() {
 	utils.Settings.SetRegisterBackupInterval(c.RegisterBackupInterval)
 	utils.Settings.SetGRPCAdmin(c.GRPCAdmin)
 	utils.Settings.SetDIDMethod(c.DIDMethod)
+	utils.Settings.SetPSMRetention(c.PSMRetention)
+	utils.Settings.SetPSMRetentionCount(c.PSMRetentionCount)
+	utils.Settings.SetCredOfferTTL(c.CredOfferTTL)
+	utils.Settings.SetCredOfferNotify(c.CredOfferNotify)
+	utils.Settings.SetProofMaxAttrs(c.ProofMaxAttrs)
+	utils.Settings.SetProofMaxPredicates(c.ProofMaxPredicates)
+	utils.Settings.SetMessageDump(c.MessageDump)
+	utils.Settings.SetLedgerFallback(c.LedgerFallback)
+	utils.Settings.SetQueueOffline(c.QueueOffline)
+	utils.Settings.SetProtocolComment(c.ProtocolComment)
+	utils.Settings.SetServicePaths(c.ServicePaths)
 
 	ssi.SetWalletMgrPoolSize(c.WalletPoolSize)
 
@@ -325,6 +471,7 @@ func (c *Cmd) closeAll() {
 	enclave.Close()
 	// add close psm
 	pool.Close()
+	ssi.CloseWallets()
 }
 
 func (c *Cmd) SetMustHaveDefaults() {
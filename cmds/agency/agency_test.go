@@ -1,8 +1,10 @@
 package agency
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/findy-network/findy-agent/cmds"
 	"github.com/lainio/err2/assert"
 )
 
@@ -39,3 +41,15 @@ func TestCmd_BuildOK(t *testing.T) {
 	err := c.Validate()
 	assert.NoError(err)
 }
+
+func TestPingCmd_Validate(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	err := PingCmd{}.Validate()
+	var vErr *cmds.ValidationError
+	assert.That(errors.As(err, &vErr))
+	assert.Equal("BaseAddr", vErr.Field)
+
+	assert.NoError(PingCmd{BaseAddr: "http://localhost:8080"}.Validate())
+}
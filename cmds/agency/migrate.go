@@ -9,7 +9,6 @@ import (
 	"github.com/findy-network/findy-agent/cmds"
 	"github.com/findy-network/findy-agent/enclave"
 	"github.com/lainio/err2"
-	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
 )
 
@@ -27,12 +26,13 @@ type _ /*seedAgent*/ struct {
 	CAVerKey string
 }
 
-func (c MigrateCmd) Validate() (err error) {
-	defer err2.Handle(&err)
-
-	assert.NotEmpty(c.InputReg, "need the input file")
-	assert.NotEmpty(c.OutputReg, "need the output file")
-
+func (c MigrateCmd) Validate() error {
+	if c.InputReg == "" {
+		return cmds.NewValidationError("InputReg", "need the input file")
+	}
+	if c.OutputReg == "" {
+		return cmds.NewValidationError("OutputReg", "need the output file")
+	}
 	return nil
 }
 
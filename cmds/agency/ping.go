@@ -3,7 +3,6 @@ package agency
 import (
 	"bytes"
 	"context"
-	"errors"
 	"io"
 	"time"
 
@@ -24,7 +23,7 @@ type PingCmd struct {
 
 func (c PingCmd) Validate() error {
 	if c.BaseAddr == "" {
-		return errors.New("server url cannot be empty")
+		return cmds.NewValidationError("BaseAddr", "server url cannot be empty")
 	}
 	return nil
 }
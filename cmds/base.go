@@ -26,20 +26,20 @@ type Cmd struct {
 
 func (c Cmd) Validate() error {
 	if c.WalletName == "" {
-		return errors.New("wallet name cannot be empty")
+		return NewValidationError("WalletName", "wallet name cannot be empty")
 	}
 	return c.ValidateWalletKey()
 }
 
 func (c Cmd) ValidateWalletKey() error {
-	return ValidateKey(c.WalletKey, "wallet")
+	return InvalidField("WalletKey", ValidateKey(c.WalletKey, "wallet"))
 }
 
 func (c Cmd) ValidateWalletExistence(should bool) error {
 	exists := ssi.NewRawWalletCfg(c.WalletName, c.WalletKey).Exists()
 	ok := (should && exists) || (!should && !exists)
 	if !ok {
-		return fmt.Errorf("wallet exists: %v", exists)
+		return NewValidationError("WalletName", "wallet exists: %v", exists)
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package cmds
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/lainio/err2/assert"
@@ -17,3 +18,26 @@ func TestValidateTime(t *testing.T) {
 	err = ValidateTime("24:00:00")
 	assert.Error(err)
 }
+
+func TestValidationError(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	err := GrpcCmd{Addr: "localhost", Port: 50051}.Validate()
+	var vErr *ValidationError
+	assert.That(errors.As(err, &vErr))
+	assert.Equal("AdminID", vErr.Field)
+	assert.That(errors.Is(err, ErrInvalid))
+
+	data, err := vErr.JSON()
+	assert.NoError(err)
+	assert.Equal(`{"field":"AdminID","reason":"server admin id cannot be empty"}`,
+		string(data))
+
+	err = Cmd{WalletName: "wallet"}.Validate()
+	assert.That(errors.As(err, &vErr))
+	assert.Equal("WalletKey", vErr.Field)
+	assert.Equal("WalletKey: wallet key cannot be empty", err.Error())
+
+	assert.NoError(InvalidField("Seed", ValidateSeed("")))
+}
@@ -1,7 +1,5 @@
 package cmds
 
-import "errors"
-
 type GrpcCmd struct {
 	TLSPath string
 	Addr    string
@@ -11,13 +9,13 @@ type GrpcCmd struct {
 
 func (c GrpcCmd) Validate() error {
 	if c.Addr == "" {
-		return errors.New("server address cannot be empty")
+		return NewValidationError("Addr", "server address cannot be empty")
 	}
 	if c.AdminID == "" {
-		return errors.New("server admin id cannot be empty")
+		return NewValidationError("AdminID", "server admin id cannot be empty")
 	}
 	if c.Port == 0 {
-		return errors.New("server port cannot be zero")
+		return NewValidationError("Port", "server port cannot be zero")
 	}
 	return nil
 }
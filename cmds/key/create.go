@@ -14,7 +14,7 @@ type CreateCmd struct {
 }
 
 func (c *CreateCmd) Validate() error {
-	return cmds.ValidateSeed(c.Seed)
+	return cmds.InvalidField("Seed", cmds.ValidateSeed(c.Seed))
 }
 
 type CreateResult struct {
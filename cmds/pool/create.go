@@ -1,8 +1,6 @@
 package pool
 
 import (
-	"errors"
-	"fmt"
 	"io"
 	"os"
 
@@ -18,17 +16,17 @@ type CreateCmd struct {
 
 func (c *CreateCmd) Validate() error {
 	if c.Name == "" {
-		return errors.New("pool name cannot be empty")
+		return cmds.NewValidationError("Name", "pool name cannot be empty")
 	}
 	if c.Name == "FINDY_MEM_LEDGER" || c.Name == "FINDY_ECHO_LEDGER" {
-		return fmt.Errorf("%s is not a valid ledger name", c.Name)
+		return cmds.NewValidationError("Name", "%s is not a valid ledger name", c.Name)
 	}
 	if c.Txn == "" {
-		return errors.New("pool genesis file is required")
+		return cmds.NewValidationError("Txn", "pool genesis file is required")
 	}
 	_, err := os.Stat(c.Txn)
 	if os.IsNotExist(err) {
-		return errors.New("pool genesis does not exist")
+		return cmds.NewValidationError("Txn", "pool genesis does not exist")
 	}
 	return nil
 }
@@ -14,7 +14,7 @@ type PingCmd struct {
 
 func (c *PingCmd) Validate() error {
 	if c.Name == "" {
-		return cmds.ErrInvalid
+		return cmds.NewValidationError("Name", "pool name cannot be empty")
 	}
 	return nil
 }
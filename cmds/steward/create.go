@@ -1,7 +1,6 @@
 package steward
 
 import (
-	"errors"
 	"io"
 
 	"github.com/findy-network/findy-agent/agent/async"
@@ -27,10 +26,10 @@ func (c *CreateCmd) Validate() error {
 	}
 
 	if c.PoolName == "" {
-		return errors.New("pool name cannot be empty")
+		return cmds.NewValidationError("PoolName", "pool name cannot be empty")
 	}
 
-	return cmds.ValidateSeed(c.StewardSeed)
+	return cmds.InvalidField("StewardSeed", cmds.ValidateSeed(c.StewardSeed))
 }
 
 func (c *CreateCmd) Exec(w io.Writer) (r cmds.Result, err error) {
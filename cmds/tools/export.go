@@ -1,7 +1,6 @@
 package tools
 
 import (
-	"errors"
 	"io"
 
 	"github.com/findy-network/findy-agent/agent/cloud"
@@ -18,6 +17,11 @@ type ExportCmd struct {
 
 	Filename  string
 	ExportKey string
+
+	// KeepOpen keeps the wallet open after the export for the next commands
+	// run by the same process. The managed wallets are closed with
+	// ssi.CloseWallets when the process exits.
+	KeepOpen bool
 }
 
 func (c ExportCmd) Validate() error {
@@ -31,13 +35,13 @@ func (c ExportCmd) Validate() error {
 	} else {
 		exists := ssi.NewWalletCfg(c.WalletName, c.WalletKey).Exists()
 		if !exists {
-			return errors.New("legacy wallet not exist")
+			return cmds.NewValidationError("WalletName", "legacy wallet not exist")
 		}
 	}
 	if c.Filename == "" {
-		return errors.New("export path cannot be empty")
+		return cmds.NewValidationError("Filename", "export path cannot be empty")
 	}
-	return cmds.ValidateKey(c.ExportKey, "export")
+	return cmds.InvalidField("ExportKey", cmds.ValidateKey(c.ExportKey, "export"))
 }
 
 func (c ExportCmd) Exec(w io.Writer) (r cmds.Result, err error) {
@@ -49,7 +53,9 @@ func (c ExportCmd) Exec(w io.Writer) (r cmds.Result, err error) {
 		wallet = *ssi.NewWalletCfg(c.WalletName, c.WalletKey)
 	}
 	agent.OpenWallet(wallet)
-	defer agent.CloseWallet()
+	if !c.KeepOpen {
+		defer agent.CloseWallet()
+	}
 
 	agent.ExportWallet(c.ExportKey, c.Filename)
 	try.To(agent.Export.Result().Err())
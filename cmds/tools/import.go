@@ -1,8 +1,6 @@
 package tools
 
 import (
-	"errors"
-	"fmt"
 	"io"
 	"os"
 
@@ -26,14 +24,14 @@ func (c ImportCmd) Validate() error {
 		return err
 	}
 	if c.Filename == "" {
-		return errors.New("export path cannot be empty")
+		return cmds.NewValidationError("Filename", "import path cannot be empty")
 	}
 	if err := cmds.ValidateKey(c.Key, "import"); err != nil {
-		return err
+		return cmds.InvalidField("Key", err)
 	}
 	_, err := os.Stat(c.Filename)
 	if os.IsNotExist(err) {
-		return fmt.Errorf("file: %v not exist", c.Filename)
+		return cmds.NewValidationError("Filename", "file: %v not exist", c.Filename)
 	}
 
 	return nil
@@ -0,0 +1,47 @@
+package cmds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ValidationError tells which field of the command is invalid and why. The
+// field is the name of the command struct's field, and the error can be
+// marshaled to JSON for the tools wrapping the commands. It's ErrInvalid for
+// errors.Is.
+type ValidationError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// NewValidationError returns the validation error of the field.
+func NewValidationError(field, format string, a ...any) *ValidationError {
+	return &ValidationError{Field: field, Reason: fmt.Sprintf(format, a...)}
+}
+
+// InvalidField returns the error of the field validator, e.g. ValidateKey, as
+// the validation error of the field. Nil error stays nil.
+func InvalidField(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var vErr *ValidationError
+	if errors.As(err, &vErr) {
+		return NewValidationError(field, "%s", vErr.Reason)
+	}
+	return NewValidationError(field, "%s", err.Error())
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// JSON returns the error in JSON, which makes it a Result as well.
+func (e *ValidationError) JSON() ([]byte, error) {
+	return json.Marshal(e)
+}
@@ -125,6 +125,9 @@ type Pipe interface {
 	Pack(src []byte) (dst []byte, vk string, err error)
 	Unpack(src []byte) (dst []byte, vk string, err error)
 
+	// PackScheme tells how the messages are packed, e.g. authcrypt.
+	PackScheme() string
+
 	// TODO: do we really need this? propably not when we start to use interface,
 	// this was for value object (struct)
 	IsNull() bool
@@ -0,0 +1,149 @@
+package enclave
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/findy-network/findy-common-go/crypto"
+	"github.com/findy-network/findy-common-go/crypto/db"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+	bolt "go.etcd.io/bbolt"
+)
+
+// dumpVersion is the version of the enclave dump format.
+const dumpVersion = 1
+
+// dumpOpenTimeout is how long the dump waits for the sealed box file when
+// the enclave is in use.
+const dumpOpenTimeout = 5 * time.Second
+
+// enclaveDump is the content of the enclave for disaster recovery and
+// migration. The values are decrypted, which is why the dump is never stored
+// or sent as it is but encrypted with the operator key. The indexes are kept
+// hashed, and Hashed tells if they are.
+type enclaveDump struct {
+	Version int
+	Hashed  bool
+	Entries []dumpEntry
+}
+
+type dumpEntry struct {
+	Bucket string
+	Index  []byte
+	Value  []byte
+}
+
+// Export returns the content of the enclave encrypted with the operator key,
+// which is a hex coded 32 bytes AES key like the enclave key. The dump can be
+// imported to another enclave with Import, and the enclaves don't need to
+// have the same key.
+func Export(operatorKey string) (dump []byte, err error) {
+	defer err2.Handle(&err, "export enclave")
+
+	opCipher := try.To1(newOperatorCipher(operatorKey))
+
+	// the managed DB is closed to let us read it. It opens again when used.
+	try.To(db.Close())
+	bdb := try.To1(bolt.Open(sealedBoxFilename, 0600, &bolt.Options{
+		ReadOnly: true,
+		Timeout:  dumpOpenTimeout,
+	}))
+	defer bdb.Close()
+
+	d := enclaveDump{Version: dumpVersion, Hashed: theCipher != nil}
+	try.To(bdb.View(func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			b := tx.Bucket(bucket)
+			if b == nil {
+				continue
+			}
+			err := b.ForEach(func(k, v []byte) error {
+				d.Entries = append(d.Entries, dumpEntry{
+					Bucket: string(bucket),
+					Index:  append(k[:0:0], k...),
+					Value:  decrypt(v),
+				})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	plain := try.To1(json.Marshal(d))
+	defer wipe(plain)
+	for _, e := range d.Entries {
+		wipe(e.Value)
+	}
+	glog.V(1).Infof("enclave exported: %d entries", len(d.Entries))
+	return opCipher.TryEncrypt(plain), nil
+}
+
+// Import adds the content of the enclave dump, see Export, to the enclave.
+// The existing keys of the same indexes are replaced. The values are
+// encrypted with the key of this enclave.
+func Import(dump []byte, operatorKey string) (err error) {
+	defer err2.Handle(&err, "import enclave")
+
+	opCipher := try.To1(newOperatorCipher(operatorKey))
+	plain := try.To1(decryptDump(opCipher, dump))
+	defer wipe(plain)
+
+	var d enclaveDump
+	try.To(json.Unmarshal(plain, &d))
+	assert.Equal(d.Version, dumpVersion, "unsupported enclave dump version")
+	if d.Hashed && theCipher == nil {
+		return errors.New("dump of the sealed enclave needs an enclave key")
+	}
+
+	known := make(map[string]bool, len(buckets))
+	for _, bucket := range buckets {
+		known[string(bucket)] = true
+	}
+	for _, e := range d.Entries {
+		assert.That(known[e.Bucket], "unknown enclave bucket: %s", e.Bucket)
+		index := &db.Data{Data: e.Index}
+		if !d.Hashed {
+			index.Read = hash
+		}
+		try.To(db.AddKeyValueToBucket([]byte(e.Bucket),
+			&db.Data{Data: e.Value, Read: encrypt}, index))
+		wipe(e.Value)
+	}
+	glog.V(1).Infof("enclave imported: %d entries", len(d.Entries))
+	return nil
+}
+
+func newOperatorCipher(operatorKey string) (_ *crypto.Cipher, err error) {
+	k, err := hex.DecodeString(operatorKey)
+	if err != nil || len(k) != 32 {
+		return nil, errors.New("operator key must be 32 bytes in hex")
+	}
+	return crypto.NewCipher(k), nil
+}
+
+// decryptDump decrypts the dump, and the wrong operator key is an error
+// instead of a panic.
+func decryptDump(c *crypto.Cipher, dump []byte) (plain []byte, err error) {
+	defer err2.Handle(&err, func(err error) error {
+		return fmt.Errorf("decrypt dump, check the operator key: %w", err)
+	})
+
+	assert.That(len(dump) > 12, "dump too short")
+	return c.TryDecrypt(dump), nil
+}
+
+// wipe overwrites the plain key material when we don't need it anymore.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,55 @@
+package enclave
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestExportImport(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const (
+		did         = "export_test_did"
+		operatorKey = "4b5e0ed1c2a4d5a8d0e7bd87f8b4f2f5b8a6e9f1c3d2e4a5b6c7d8e9f0a1b2c3"
+		freshFile   = "enclave-import.bolt"
+		freshKey    = "0d5e7e3a8c4f1b2a6d9e0c7b3f5a8e1d2c4b6a8f0e1d3c5b7a9f2e4d6c8b0a1f"
+	)
+	key, err := NewWalletKey("export@email.com")
+	assert.NoError(err)
+	assert.NoError(SetKeysDID(key, did))
+	sec, err := NewWalletMasterSecret(did)
+	assert.NoError(err)
+
+	dump, err := Export(operatorKey)
+	assert.NoError(err)
+	assert.That(!bytes.Contains(dump, []byte(key)), "key in plain text")
+	assert.That(!bytes.Contains(dump, []byte(sec)), "secret in plain text")
+
+	// the fresh enclave with another key
+	Close()
+	_ = os.RemoveAll(freshFile)
+	assert.NoError(InitSealedBox(freshFile, "", freshKey))
+	defer func() {
+		WipeSealedBox()
+		setUp()
+	}()
+	_, err = WalletKeyByDID(did)
+	assert.That(ErrNotExists == err)
+
+	assert.Error(Import(dump, freshKey))
+	assert.NoError(Import(dump, operatorKey))
+
+	k, err := WalletKeyByDID(did)
+	assert.NoError(err)
+	assert.Equal(key, k)
+	k, err = WalletKeyByEmail("export@email.com")
+	assert.NoError(err)
+	assert.Equal(key, k)
+	s, err := WalletMasterSecretByDID(did)
+	assert.NoError(err)
+	assert.Equal(sec, s)
+}
@@ -7,6 +7,7 @@ package enclave
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 
 	"github.com/findy-network/findy-agent/agent/utils"
@@ -21,10 +22,12 @@ import (
 const emailB = "email_bucket"
 const didB = "did_bucket"
 const masterSecretB = "master_secret_bucket"
+const masterSecretsB = "master_secrets_bucket"
 
 const emailBucket = 0
 const didBucket = 1
 const masterSecretBucket = 2
+const masterSecretsBucket = 3
 
 // ErrNotExists is an error for key not exist in the enclave.
 var ErrNotExists = errors.New("key not exists")
@@ -36,6 +39,7 @@ var (
 		[]byte(emailB),
 		[]byte(didB),
 		[]byte(masterSecretB),
+		[]byte(masterSecretsB),
 	}
 
 	theCipher *crypto.Cipher
@@ -133,7 +137,7 @@ func NewWalletMasterSecret(did string) (sec string, err error) {
 		return "", errors.New("master secret already exists")
 	}
 
-	sec = utils.UUID()
+	sec = newMasterSecret()
 
 	try.To(db.AddKeyValueToBucket(buckets[masterSecretBucket],
 		&db.Data{
@@ -149,6 +153,44 @@ func NewWalletMasterSecret(did string) (sec string, err error) {
 	return sec, nil
 }
 
+func newMasterSecret() string {
+	return utils.UUID()
+}
+
+// RotateWalletMasterSecret creates a new master secret for the DID like
+// NewWalletMasterSecret does, and sets it as the current one. The secret is
+// given to register before it's stored, and nothing is stored if register
+// fails. The DID's secrets are stored as one list with a single write, the
+// current secret first, so a crash cannot leave them half rotated. The
+// previous secrets are returned from the newest to the oldest.
+func RotateWalletMasterSecret(
+	did string,
+	register func(sec string) error,
+) (
+	sec string,
+	prevs []string,
+	err error,
+) {
+	defer err2.Handle(&err, "rotate master secret")
+
+	prevs = try.To1(walletMasterSecrets(did))
+	sec = newMasterSecret()
+	try.To(register(sec))
+
+	try.To(db.AddKeyValueToBucket(buckets[masterSecretsBucket],
+		&db.Data{
+			Data: try.To1(json.Marshal(append([]string{sec}, prevs...))),
+			Read: encrypt,
+		},
+		&db.Data{
+			Data: []byte(did),
+			Read: hash,
+		},
+	))
+
+	return sec, prevs, nil
+}
+
 // WalletKeyNotExists returns true if a wallet key is not in the enclave
 // associated by an email.
 func WalletKeyNotExists(email string) bool {
@@ -195,17 +237,53 @@ func WalletKeyByDID(DID string) (key string, err error) {
 
 // WalletMasterSecretByDID retrieves a wallet master secret key by a DID.
 func WalletMasterSecretByDID(DID string) (key string, err error) {
+	secs, err := walletMasterSecrets(DID)
+	if err != nil {
+		return "", err
+	}
+	return secs[0], nil
+}
+
+// WalletPrevMasterSecretsByDID retrieves the master secrets which were in use
+// before the RotateWalletMasterSecret calls, from the newest to the oldest.
+func WalletPrevMasterSecretsByDID(DID string) (keys []string, err error) {
+	secs, err := walletMasterSecrets(DID)
+	if err != nil {
+		return nil, err
+	}
+	if len(secs) == 1 {
+		return nil, ErrNotExists
+	}
+	return secs[1:], nil
+}
+
+// walletMasterSecrets returns the DID's master secrets, the current one first.
+// The secrets of a DID whose secret is never rotated are only in the master
+// secret bucket.
+func walletMasterSecrets(DID string) (keys []string, err error) {
 	value := &db.Data{Write: decrypt}
-	found := try.To1(db.GetKeyValueFromBucket(buckets[masterSecretBucket],
+	found := try.To1(db.GetKeyValueFromBucket(buckets[masterSecretsBucket],
+		&db.Data{
+			Data: []byte(DID),
+			Read: hash,
+		},
+		value))
+	if found {
+		err = json.Unmarshal(value.Data, &keys)
+		return keys, err
+	}
+
+	value = &db.Data{Write: decrypt}
+	found = try.To1(db.GetKeyValueFromBucket(buckets[masterSecretBucket],
 		&db.Data{
 			Data: []byte(DID),
 			Read: hash,
 		},
 		value))
 	if !found {
-		return "", ErrNotExists
+		return nil, ErrNotExists
 	}
-	return string(value.Data), nil
+	return []string{string(value.Data)}, nil
 }
 
 // SetKeysDID is a function to store a wallet key by its DID. We can retrieve a
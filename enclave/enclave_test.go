@@ -1,6 +1,7 @@
 package enclave
 
 import (
+	"errors"
 	"os"
 	"testing"
 
@@ -112,3 +113,46 @@ func TestNewWalletMasterSecret(t *testing.T) {
 	assert.Empty(sec3)
 
 }
+
+func TestRotateWalletMasterSecret(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const did = "rotate_test_did"
+
+	sec, err := NewWalletMasterSecret(did)
+	assert.NoError(err)
+
+	_, err = WalletPrevMasterSecretsByDID(did)
+	assert.That(ErrNotExists == err)
+
+	register := func(string) error { return nil }
+	second, prevs, err := RotateWalletMasterSecret(did, register)
+	assert.NoError(err)
+	assert.NotEmpty(second)
+	assert.DeepEqual([]string{sec}, prevs)
+
+	current, err := WalletMasterSecretByDID(did)
+	assert.NoError(err)
+	assert.Equal(second, current)
+
+	// nothing is stored if the secret cannot be registered
+	_, _, err = RotateWalletMasterSecret(did, func(string) error {
+		return errors.New("wallet failure")
+	})
+	assert.Error(err)
+	current, err = WalletMasterSecretByDID(did)
+	assert.NoError(err)
+	assert.Equal(second, current)
+
+	_, prevs, err = RotateWalletMasterSecret(did, register)
+	assert.NoError(err)
+	assert.DeepEqual([]string{second, sec}, prevs)
+
+	prevs, err = WalletPrevMasterSecretsByDID(did)
+	assert.NoError(err)
+	assert.DeepEqual([]string{second, sec}, prevs)
+
+	_, _, err = RotateWalletMasterSecret("wrong_test_did", register)
+	assert.Error(err)
+}
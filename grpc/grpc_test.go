@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,10 +21,14 @@ import (
 	"time"
 
 	"github.com/findy-network/findy-agent/agent/agency"
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/bus"
 	"github.com/findy-network/findy-agent/agent/cloud"
+	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/didcomm"
 	"github.com/findy-network/findy-agent/agent/endp"
 	"github.com/findy-network/findy-agent/agent/handshake"
+	"github.com/findy-network/findy-agent/agent/pltype"
 	"github.com/findy-network/findy-agent/agent/pool"
 	"github.com/findy-network/findy-agent/agent/psm"
 	"github.com/findy-network/findy-agent/agent/ssi"
@@ -35,7 +40,9 @@ import (
 	_ "github.com/findy-network/findy-agent/protocol/basicmessage"
 	_ "github.com/findy-network/findy-agent/protocol/connection"
 	_ "github.com/findy-network/findy-agent/protocol/issuecredential"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/preview"
 	_ "github.com/findy-network/findy-agent/protocol/presentproof"
+	ppdata "github.com/findy-network/findy-agent/protocol/presentproof/data"
 	_ "github.com/findy-network/findy-agent/protocol/trustping"
 	"github.com/findy-network/findy-agent/server"
 	"github.com/findy-network/findy-common-go/agency/client"
@@ -53,6 +60,8 @@ import (
 	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -661,6 +670,151 @@ func TestCreateSchemaAndCredDef_NoOneRun(t *testing.T) {
 	}
 }
 
+// TestCreateCredDefAndIssue tests that the cred def creation is idempotent and
+// that the created cred def can be used for issuing. It's run only in one test
+// mode like the other schema and creddef tests.
+func TestCreateCredDefAndIssue(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	if testMode != TestModeRunOne {
+		return
+	}
+	allPermissive = true
+	TestSetPermissive(t)
+
+	conn := client.TryOpen(agents[0].DID, baseCfg)
+	defer conn.Close()
+	ctx := context.Background()
+	c := agency2.NewAgentServiceClient(conn)
+
+	ut := time.Now().Unix() - 1558884840
+	r, err := c.CreateSchema(ctx, &agency2.SchemaCreate{
+		Name:       fmt.Sprintf("CRED_DEF_SPEC_SCHEMA_%v", ut),
+		Version:    "1.0",
+		Attributes: []string{"email"},
+	})
+	assert.NoError(err)
+	waitForSchema(t, c, r.ID)
+
+	receiver, ok := agency.Handler(agents[0].DID).(comm.Receiver)
+	assert.That(ok)
+	spec := &grpcserver.CredDefSpec{
+		SchemaID: r.ID,
+		Tag:      "TAG_SPEC",
+	}
+	credDefID, err := grpcserver.CreateCredDef(receiver, spec)
+	assert.NoError(err)
+	assert.NotEmpty(credDefID)
+	waitForCredDef(t, c, credDefID)
+
+	id, err := grpcserver.CreateCredDef(receiver, spec)
+	assert.NoError(err)
+	assert.Equal(credDefID, id)
+
+	connID := agents[0].ConnID[0]
+	ch, err := client.Pairwise{
+		ID:   connID,
+		Conn: conn,
+	}.IssueWithAttrs(ctx, credDefID,
+		&agency2.Protocol_IssuingAttributes{
+			Attributes: []*agency2.Protocol_IssuingAttributes_Attribute{{
+				Name:  "email",
+				Value: strLiteral("email", "", 1),
+			}}})
+	assert.NoError(err)
+	for status := range ch {
+		glog.V(1).Infof("issuing status: %s|%s: %s\n", connID, status.ProtocolID, status.State)
+		assert.Equal(agency2.ProtocolState_OK, status.State)
+	}
+}
+
+// TestCreateSchemaAndCredDefWithSpec tests that an issuer can be bootstrapped
+// with the spec based schema and cred def creation.
+func TestCreateSchemaAndCredDefWithSpec(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	if testMode != TestModeRunOne {
+		return
+	}
+
+	conn := client.TryOpen(agents[0].DID, baseCfg)
+	defer conn.Close()
+	c := agency2.NewAgentServiceClient(conn)
+
+	receiver, ok := agency.Handler(agents[0].DID).(comm.Receiver)
+	assert.That(ok)
+
+	ut := time.Now().Unix() - 1558884840
+	schemaID, err := grpcserver.CreateSchema(receiver, &grpcserver.SchemaSpec{
+		Name:       fmt.Sprintf("SCHEMA_SPEC_%v", ut),
+		Version:    "1.0",
+		Attributes: []string{"email", "name"},
+	})
+	assert.NoError(err)
+	assert.NotEmpty(schemaID)
+	waitForSchema(t, c, schemaID)
+
+	credDefID, err := grpcserver.CreateCredDef(receiver, &grpcserver.CredDefSpec{
+		SchemaID: schemaID,
+		Tag:      "TAG_SCHEMA_SPEC",
+	})
+	assert.NoError(err)
+	assert.NotEmpty(credDefID)
+	waitForCredDef(t, c, credDefID)
+}
+
+func TestGetSchemaAndCredDefByID(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	if testMode != TestModeRunOne {
+		return
+	}
+
+	conn := client.TryOpen(agents[0].DID, baseCfg)
+	defer conn.Close()
+	c := agency2.NewAgentServiceClient(conn)
+
+	receiver, ok := agency.Handler(agents[0].DID).(comm.Receiver)
+	assert.That(ok)
+
+	ut := time.Now().Unix() - 1558884840
+	schemaID, err := grpcserver.CreateSchema(receiver, &grpcserver.SchemaSpec{
+		Name:       fmt.Sprintf("SCHEMA_GET_%v", ut),
+		Version:    "1.0",
+		Attributes: []string{"email", "name"},
+	})
+	assert.NoError(err)
+	waitForSchema(t, c, schemaID)
+
+	sch, err := grpcserver.GetSchema(receiver, &grpcserver.SchemaID{ID: schemaID})
+	assert.NoError(err)
+	assert.Equal(schemaID, sch.ID)
+	assert.Equal("1.0", sch.Version)
+	attrs := append([]string{}, sch.Attributes...)
+	sort.Strings(attrs)
+	assert.DeepEqual([]string{"email", "name"}, attrs)
+
+	credDefID, err := grpcserver.CreateCredDef(receiver, &grpcserver.CredDefSpec{
+		SchemaID: schemaID,
+		Tag:      "TAG_GET",
+	})
+	assert.NoError(err)
+	waitForCredDef(t, c, credDefID)
+
+	cd, err := grpcserver.GetCredDef(receiver, &grpcserver.CredDefID{ID: credDefID})
+	assert.NoError(err)
+	assert.Equal(credDefID, cd.ID)
+	assert.Equal("TAG_GET", cd.Tag)
+	assert.DeepEqual([]string{"email", "name"}, cd.Attributes)
+
+	_, err = grpcserver.GetSchema(receiver,
+		&grpcserver.SchemaID{ID: schemaID + "_not_found"})
+	assert.Equal(codes.NotFound, grpcstatus.Code(err))
+	_, err = grpcserver.GetCredDef(receiver,
+		&grpcserver.CredDefID{ID: credDefID + "_not_found"})
+	assert.Equal(codes.NotFound, grpcstatus.Code(err))
+}
+
 func connect(invitation string, ready chan struct{}) {
 	i := 1
 	ca := agents[i]
@@ -884,6 +1038,21 @@ loop:
 	}
 }
 
+func TestStatusNotFound(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	conn := client.TryOpen(agents[0].DID, baseCfg)
+	defer conn.Close()
+	commClient := agency2.NewProtocolServiceClient(conn)
+	_, err := commClient.Status(context.Background(), &agency2.ProtocolID{
+		TypeID: agency2.Protocol_TRUST_PING,
+		ID:     utils.UUID(),
+	})
+	assert.Error(err)
+	assert.Equal(codes.NotFound, grpcstatus.Code(err))
+}
+
 func TestBasicMessage(t *testing.T) {
 	assert.PushTester(t)
 	defer assert.PopTester()
@@ -986,6 +1155,61 @@ func TestIssue(t *testing.T) {
 	}
 }
 
+func TestCredentialReceivedNotification(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	allPermissive = true
+	if testMode == TestModeRunOne {
+		TestSetPermissive(t)
+	}
+
+	// agents[0].ConnID[0] is the issuer's connection to agents[1]
+	holderKey := bus.AgentKeyType{
+		AgentDID: agents[1].DID,
+		ClientID: utils.UUID(),
+	}
+	notifyCh := bus.WantAllAgentActions.AgentAddListener(holderKey)
+	defer bus.WantAllAgentActions.AgentRmListener(holderKey)
+
+	conn := client.TryOpen(agents[0].DID, baseCfg)
+	defer conn.Close()
+	ctx := context.Background()
+	email := strLiteral("email", "received", 1)
+	ch, err := client.Pairwise{
+		ID:   agents[0].ConnID[0],
+		Conn: conn,
+	}.IssueWithAttrs(ctx, agents[0].CredDefID,
+		&agency2.Protocol_IssuingAttributes{
+			Attributes: []*agency2.Protocol_IssuingAttributes_Attribute{{
+				Name:  "email",
+				Value: email,
+			}}})
+	assert.NoError(err)
+	for status := range ch {
+		assert.Equal(agency2.ProtocolState_OK, status.State)
+	}
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case notify := <-notifyCh:
+			if notify.NotificationType != pltype.CANotifyCredential {
+				continue
+			}
+			cred := notify.CredentialReceived
+			assert.NotNil(cred)
+			assert.Equal(agents[0].CredDefID, cred.CredDefID)
+			assert.NotEmpty(cred.SchemaID)
+			assert.SLen(cred.Attributes, 1)
+			assert.Equal("email", cred.Attributes[0].Name)
+			assert.Equal(email, cred.Attributes[0].Value)
+			return
+		case <-timeout:
+			t.Fatal("credential received notification missing")
+		}
+	}
+}
+
 func TestIssueJSON(t *testing.T) {
 	assert.PushTester(t)
 	defer assert.PopTester()
@@ -1025,6 +1249,146 @@ func TestIssueJSON(t *testing.T) {
 	}
 }
 
+func TestIssueStructured(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	allPermissive = true
+	if testMode == TestModeRunOne {
+		TestSetPermissive(t)
+	}
+
+	type address struct {
+		Street string `json:"street"`
+		Geo    struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		} `json:"geo"`
+	}
+	var addr address
+	addr.Street = strLiteral("street", "", 1)
+	addr.Geo.Lat, addr.Geo.Lon = 65.01, 25.47
+	attr, err := preview.StructuredAttr("email", addr)
+	assert.NoError(err)
+
+	conn := client.TryOpen(agents[0].DID, baseCfg)
+	defer conn.Close()
+	r, err := client.Pairwise{
+		ID:   agents[0].ConnID[0],
+		Conn: conn,
+	}.Issue(context.Background(), agents[0].CredDefID,
+		dto.ToJSON([]didcomm.CredentialAttribute{attr}))
+	assert.NoError(err)
+	for status := range r {
+		assert.Equal(agency2.ProtocolState_OK, status.State)
+	}
+
+	// holder reads the structured value back from its credentials
+	receiver, ok := agency.Handler(agents[1].DID).(comm.Receiver)
+	assert.That(ok)
+	l, err := grpcserver.ListCredentials(receiver, &grpcserver.CredentialFilter{})
+	assert.NoError(err)
+	found := false
+	for _, cred := range l.Credentials {
+		for _, a := range cred.Attributes {
+			if a.Value != attr.Value {
+				continue
+			}
+			var got address
+			assert.NoError(preview.ParseStructured(a.MimeType, a.Value, &got))
+			assert.DeepEqual(got, addr)
+			found = true
+		}
+	}
+	assert.That(found, "structured credential missing")
+}
+
+func TestDumpMessages(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	utils.Settings.SetMessageDump(true)
+	defer utils.Settings.SetMessageDump(false)
+
+	conn := client.TryOpen(agents[0].DID, baseCfg)
+	ctx := context.Background()
+	const content = "basic message to dump"
+	r := try.To1(client.Pairwise{
+		ID:   agents[0].ConnID[0],
+		Conn: conn,
+	}.BasicMessage(ctx, content))
+	var pid string
+	for status := range r {
+		assert.Equal(agency2.ProtocolState_OK, status.State)
+		pid = status.ProtocolID.ID
+	}
+	assert.NoError(conn.Close())
+
+	dumps, err := grpcserver.DumpMessages(&grpcserver.MessageDumpCmd{
+		CADID:      agents[0].DID,
+		ProtocolID: pid,
+	})
+	assert.NoError(err)
+	assert.SLen(dumps.Messages, 1)
+	sent := dumps.Messages[0]
+	assert.Equal(sent.State, "Sending")
+	assert.Equal(aries.ProtocolMsgForType(sent.MessageType),
+		pltype.HandlerMessage)
+	assert.That(strings.Contains(sent.Message, content))
+
+	// the step can be queried by its timestamp
+	dumps, err = grpcserver.DumpMessages(&grpcserver.MessageDumpCmd{
+		CADID:      agents[0].DID,
+		ProtocolID: pid,
+		Timestamp:  sent.Timestamp,
+	})
+	assert.NoError(err)
+	assert.SLen(dumps.Messages, 1)
+	assert.Equal(dumps.Messages[0].Message, sent.Message)
+
+	_, err = grpcserver.DumpMessages(&grpcserver.MessageDumpCmd{
+		CADID:      agents[0].DID,
+		ProtocolID: pid,
+		Timestamp:  sent.Timestamp + 1,
+	})
+	assert.Error(err)
+}
+
+func TestAgentFeatures(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	flags, err := grpcserver.AgentFeatures(&grpcserver.FeatureFlagsCmd{
+		CADID: agents[0].DID,
+		Name:  comm.FeatureAllowSelfAttested,
+		On:    false,
+	})
+	assert.NoError(err)
+	defer func() {
+		assert.NoError(comm.SetFeatures(agents[0].DID, nil))
+	}()
+	assert.DeepEqual(flags.Flags,
+		map[string]bool{comm.FeatureAllowSelfAttested: false})
+
+	// the flag is stored with the agent to the register
+	values, ok := agency.Register.Get(agents[0].DID)
+	assert.That(ok && len(values) >= 5)
+	assert.That(strings.Contains(values[4], comm.FeatureAllowSelfAttested))
+
+	// the other agent isn't affected
+	flags, err = grpcserver.AgentFeatures(&grpcserver.FeatureFlagsCmd{
+		CADID: agents[1].DID,
+	})
+	assert.NoError(err)
+	assert.MLen(flags.Flags, 0)
+	assert.That(comm.FeatureOr(agents[1].DID, comm.FeatureAllowSelfAttested, true))
+
+	_, err = grpcserver.AgentFeatures(&grpcserver.FeatureFlagsCmd{
+		CADID: agents[1].DID,
+		Name:  "no_such_feature",
+	})
+	assert.Error(err)
+}
+
 func TestProposeIssue(t *testing.T) {
 	assert.PushTester(t)
 	defer assert.PopTester()
@@ -1102,6 +1466,39 @@ func TestProposeIssueJSON(t *testing.T) {
 
 	}
 }
+func TestListCredentials(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	// agent with 0 index is issuer -> rest are holders with issued creds
+	for i := 1; i < len(agents); i++ {
+		t.Run(fmt.Sprintf("LIST CREDENTIALS-%d", i), func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			receiver, ok := agency.Handler(agents[i].DID).(comm.Receiver)
+			assert.That(ok)
+
+			filter := &grpcserver.CredentialFilter{}
+			filter.CredDefID = agents[0].CredDefID
+			l, err := grpcserver.ListCredentials(receiver, filter)
+			assert.NoError(err)
+			assert.That(l.Total > 0)
+			assert.SLen(l.Credentials, l.Total)
+			for _, cred := range l.Credentials {
+				assert.Equal(agents[0].CredDefID, cred.CredDefID)
+				assert.NotEmpty(cred.SchemaID)
+				assert.SNotEmpty(cred.Attributes)
+			}
+
+			filter.Limit = 1
+			l, err = grpcserver.ListCredentials(receiver, filter)
+			assert.NoError(err)
+			assert.SLen(l.Credentials, 1)
+		})
+	}
+}
+
 func TestReqProof(t *testing.T) {
 	assert.PushTester(t)
 	defer assert.PopTester()
@@ -1172,6 +1569,215 @@ func TestReqProofJSON(t *testing.T) {
 	}
 }
 
+// TestReqProofMultipleCredentials tests the proof which combines attributes
+// from two credentials of different cred defs.
+func TestReqProofMultipleCredentials(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	allPermissive = true
+	if testMode != TestModeRunOne {
+		return
+	}
+	TestIssue(t)
+
+	conn := client.TryOpen(agents[0].DID, baseCfg)
+	defer conn.Close()
+	c := agency2.NewAgentServiceClient(conn)
+
+	receiver, ok := agency.Handler(agents[0].DID).(comm.Receiver)
+	assert.That(ok)
+
+	ut := time.Now().Unix() - 1558884840
+	schemaID, err := grpcserver.CreateSchema(receiver, &grpcserver.SchemaSpec{
+		Name:       fmt.Sprintf("SCHEMA_MULTI_%v", ut),
+		Version:    "1.0",
+		Attributes: []string{"name"},
+	})
+	assert.NoError(err)
+	waitForSchema(t, c, schemaID)
+	credDefID, err := grpcserver.CreateCredDef(receiver, &grpcserver.CredDefSpec{
+		SchemaID: schemaID,
+		Tag:      "TAG_MULTI",
+	})
+	assert.NoError(err)
+	waitForCredDef(t, c, credDefID)
+
+	ctx := context.Background()
+	pw := client.Pairwise{
+		ID:   agents[0].ConnID[0],
+		Conn: conn,
+	}
+	ch, err := pw.IssueWithAttrs(ctx, credDefID,
+		&agency2.Protocol_IssuingAttributes{
+			Attributes: []*agency2.Protocol_IssuingAttributes_Attribute{{
+				Name:  "name",
+				Value: "multi name",
+			}}})
+	assert.NoError(err)
+	for status := range ch {
+		assert.Equal(agency2.ProtocolState_OK, status.State)
+	}
+
+	// the attributes come from the both credentials of the holder
+	ch, err = pw.ReqProofWithAttrs(ctx, &agency2.Protocol_Proof{
+		Attributes: []*agency2.Protocol_Proof_Attribute{{
+			Name:      "email",
+			CredDefID: agents[0].CredDefID,
+		}, {
+			Name:      "name",
+			CredDefID: credDefID,
+		}},
+	})
+	assert.NoError(err)
+	var pid string
+	for status := range ch {
+		assert.Equal(agency2.ProtocolState_OK, status.State)
+		pid = status.ProtocolID.ID
+	}
+
+	// the verifier's status has the verification result
+	didComm := agency2.NewProtocolServiceClient(conn)
+	ps, err := didComm.Status(ctx, &agency2.ProtocolID{
+		TypeID: agency2.Protocol_PRESENT_PROOF,
+		ID:     pid,
+	})
+	assert.NoError(err)
+	var res ppdata.ProofResult
+	dto.FromJSONStr(ps.GetState().GetInfo(), &res)
+	assert.That(res.Verified)
+	assert.SLen(res.Revealed, 2)
+	values := map[string]string{}
+	for _, attr := range res.Revealed {
+		values[attr.Name] = attr.Value
+	}
+	assert.Equal("multi name", values["name"])
+	assert.NotEmpty(values["email"])
+
+	vp, err := grpcserver.VerifiableProof(receiver, pid)
+	assert.NoError(err)
+	assert.SLen(vp.Identifiers, 2)
+	credDefIDs := map[string]string{}
+	for _, attr := range vp.Revealed {
+		credDefIDs[attr.Name] = attr.CredDefID
+	}
+	assert.Equal(agents[0].CredDefID, credDefIDs["email"])
+	assert.Equal(credDefID, credDefIDs["name"])
+}
+
+func TestPauseAgent(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	allPermissive = true
+	if testMode == TestModeRunOne {
+		TestIssue(t)
+	}
+
+	reqProof := func() (states []*agency2.ProtocolState) {
+		conn := client.TryOpen(agents[0].DID, baseCfg)
+		defer conn.Close()
+
+		attrs := []*agency2.Protocol_Proof_Attribute{{
+			Name:      "email",
+			CredDefID: agents[0].CredDefID,
+		}}
+		r, err := client.Pairwise{
+			ID:   agents[0].ConnID[0],
+			Conn: conn,
+		}.ReqProofWithAttrs(context.Background(),
+			&agency2.Protocol_Proof{Attributes: attrs})
+		assert.NoError(err)
+		for status := range r {
+			states = append(states, status)
+		}
+		return states
+	}
+
+	assert.NoError(grpcserver.Pause(&grpcserver.PauseCmd{
+		CADID: agents[0].DID,
+		Pause: true,
+	}))
+	defer func() {
+		assert.NoError(grpcserver.Pause(&grpcserver.PauseCmd{
+			CADID: agents[0].DID,
+		}))
+	}()
+
+	states := reqProof()
+	assert.SNotEmpty(states)
+	last := states[len(states)-1]
+	assert.Equal(agency2.ProtocolState_ERR, last.State)
+	assert.That(strings.Contains(last.Info, "agent paused"))
+
+	assert.NoError(grpcserver.Pause(&grpcserver.PauseCmd{CADID: agents[0].DID}))
+	for _, status := range reqProof() {
+		assert.Equal(agency2.ProtocolState_OK, status.State)
+	}
+}
+
+func TestCycleWallet(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	if testMode == TestModeRunOne {
+		TestIssue(t)
+	}
+
+	holder := agents[1]
+	receiver, ok := agency.Handler(holder.DID).(comm.Receiver)
+	assert.That(ok)
+
+	filter := &grpcserver.CredentialFilter{}
+	creds, err := grpcserver.ListCredentials(receiver, filter)
+	assert.NoError(err)
+	_, storageH := receiver.WorkerEA().ManagedWallet()
+	conns, err := storageH.Storage().ConnectionStorage().ListConnections()
+	assert.NoError(err)
+
+	cmd := &grpcserver.WalletCycleCmd{CADID: holder.DID}
+	assert.NoError(grpcserver.CycleWallet(cmd))
+
+	l, err := grpcserver.ListCredentials(receiver, filter)
+	assert.NoError(err)
+	assert.Equal(l.Total, creds.Total)
+	connsAfter, err := storageH.Storage().ConnectionStorage().ListConnections()
+	assert.NoError(err)
+	assert.SLen(connsAfter, len(conns))
+	pw, err := receiver.WorkerEA().FindPWByID(holder.ConnID[0])
+	assert.NoError(err)
+	assert.NotNil(pw)
+
+	// the pairwise still works after the cycle
+	conn := client.TryOpen(holder.DID, baseCfg)
+	defer conn.Close()
+	ch, err := client.Pairwise{
+		ID:   holder.ConnID[0],
+		Conn: conn,
+	}.Ping(context.Background())
+	assert.NoError(err)
+	for status := range ch {
+		assert.Equal(agency2.ProtocolState_OK, status.State)
+	}
+
+	// protocol in flight refuses the cycle
+	busy := &psm.PSM{
+		Key: psm.NewStateKey(receiver.WorkerEA(), "wallet-cycle-busy"),
+		States: []psm.State{{
+			Timestamp: time.Now().UnixNano(),
+			Sub:       psm.Sending,
+		}},
+	}
+	assert.NoError(psm.AddPSM(busy))
+	defer func() {
+		assert.NoError(psm.RmPSM(busy))
+	}()
+	err = grpcserver.CycleWallet(cmd)
+	assert.That(errors.Is(err, grpcserver.ErrAgentBusy), "not busy: %v", err)
+
+	// refused cycle doesn't leave the agent paused
+	pauser, ok := agency.Handler(holder.DID).(interface{ Paused() bool })
+	assert.That(ok)
+	assert.That(!pauser.Paused())
+}
+
 func TestProposeProof(t *testing.T) {
 	assert.PushTester(t)
 	defer assert.PopTester()
@@ -1358,6 +1964,67 @@ func TestListenPWStatus(t *testing.T) {
 	assert.That(strings.Contains(res.TheirEndpoint, res.ID))
 }
 
+func TestConnectionEstablishedNotification(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	connAdmin := client.TryOpen("findy-root", baseCfg)
+	agencyClient := pb.NewAgencyServiceClient(connAdmin)
+	oReply := try.To1(agencyClient.Onboard(ctx, &pb.Onboarding{
+		Email: "agent-conn1",
+	}))
+	agent1DID := oReply.Result.CADID
+	oReply = try.To1(agencyClient.Onboard(ctx, &pb.Onboarding{
+		Email: "agent-conn2",
+	}))
+	agent2DID := oReply.Result.CADID
+
+	conn1 := client.TryOpen(agent1DID, baseCfg)
+
+	receiver, ok := agency.Handler(agent1DID).(comm.Receiver)
+	assert.That(ok)
+	listenKey := bus.AgentKeyType{
+		AgentDID: receiver.WDID(),
+		ClientID: utils.UUID(),
+	}
+	notifyCh := bus.WantAllAgentActions.AgentAddListener(listenKey)
+	defer bus.WantAllAgentActions.AgentRmListener(listenKey)
+
+	conn2 := client.TryOpen(agent2DID, baseCfg)
+	c := agency2.NewAgentServiceClient(conn2)
+	id := utils.UUID()
+	r := try.To1(c.CreateInvitation(ctx, &agency2.InvitationBase{ID: id, Label: "agent-conn2"}))
+
+	pw := async.NewPairwise(conn1, id)
+	try.To1(pw.Connection(ctx, r.JSON))
+
+	var notification bus.AgentNotify
+	timeout := time.After(10 * time.Second)
+	for notification.NotificationType != pltype.CANotifyConnection {
+		select {
+		case notification = <-notifyCh:
+		case <-timeout:
+			t.Fatal("connection established notification missing")
+		}
+	}
+	assert.Equal("agent-conn2", notification.Label)
+	assert.That(endp.IsUUID(notification.ConnectionID))
+	protocolType := pltype.ProtocolTypeForFamily(notification.ProtocolFamily)
+	assert.Equal(agency2.Protocol_DIDEXCHANGE, protocolType)
+
+	didComm := agency2.NewProtocolServiceClient(conn1)
+	statusResult := try.To1(didComm.Status(ctx, &agency2.ProtocolID{
+		TypeID: protocolType,
+		ID:     notification.ProtocolID,
+	}))
+	res := statusResult.GetDIDExchange()
+
+	assert.Equal(notification.ConnectionID, res.ID)
+	assert.Equal("agent-conn2", res.TheirLabel)
+}
+
 func BenchmarkIssue(b *testing.B) {
 	if testMode == TestModeRunOne {
 		TestSetPermissive(nil)
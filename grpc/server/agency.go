@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,12 +16,17 @@ import (
 	"github.com/findy-network/findy-agent/agent/psm"
 	"github.com/findy-network/findy-agent/agent/utils"
 	"github.com/findy-network/findy-agent/enclave"
+	"github.com/findy-network/findy-common-go/dto"
 	ops "github.com/findy-network/findy-common-go/grpc/ops/v1"
 	"github.com/findy-network/findy-common-go/jwt"
 	"github.com/golang/glog"
 	"github.com/lainio/err2"
 	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type agencyService struct {
@@ -72,6 +79,128 @@ func (a agencyService) Onboard(
 	}, nil
 }
 
+// PageRequest selects a page of a listing. Limit zero means no limit.
+type PageRequest struct {
+	Offset int
+	Limit  int
+}
+
+// AgentList is one page of the onboarded agents ordered by their names. Total
+// is the amount of all onboarded agents.
+type AgentList struct {
+	Agents []*AgentEntry
+	Total  int
+}
+
+// AgentEntry is the onboarded agent from the agency's register. Loaded tells
+// if the CA is loaded from its seed, and WorkerRunning if its worker EA is
+// started.
+type AgentEntry struct {
+	Name          string
+	RootDID       string
+	CADID         string
+	Loaded        bool
+	WorkerRunning bool
+}
+
+// agentLister is the handler type of the agent list service.
+type agentLister interface {
+	ListAgents(context.Context, *PageRequest) (*AgentList, error)
+}
+
+// agentListServiceDesc describes the operators' agent list service. The ops
+// API doesn't have it, and that's why the page request and the agent list are
+// transferred as JSON strings.
+var agentListServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ops.v1.AgentListService",
+	HandlerType: (*agentLister)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListAgents",
+			Handler:    listAgentsHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "grpc/server/agency.go",
+}
+
+func listAgentsHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		var pageReq PageRequest
+		if v := req.(*wrapperspb.StringValue).GetValue(); v != "" {
+			if err := json.Unmarshal([]byte(v), &pageReq); err != nil {
+				return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+		l, err := srv.(agentLister).ListAgents(ctx, &pageReq)
+		if err != nil {
+			return nil, err
+		}
+		return wrapperspb.String(dto.ToJSON(l)), nil
+	}
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ops.v1.AgentListService/ListAgents",
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func (a agencyService) ListAgents(
+	ctx context.Context,
+	pageReq *PageRequest,
+) (
+	l *AgentList,
+	err error,
+) {
+	user := jwt.User(ctx)
+	if user != a.Root {
+		return nil, grpcstatus.Error(codes.PermissionDenied, "access right")
+	}
+
+	glog.V(1).Infoln("list agents")
+	return ListAgents(pageReq), nil
+}
+
+// ListAgents enumerates the onboarded agents from the agency's register. Nil
+// page request returns all of them.
+func ListAgents(pageReq *PageRequest) *AgentList {
+	if pageReq == nil {
+		pageReq = &PageRequest{}
+	}
+	agents := make([]*AgentEntry, 0, agency.SeedHandlerCount())
+	agency.Register.EnumValues(func(caDID string, values []string) (next bool) {
+		e := &AgentEntry{
+			Name:          values[0],
+			CADID:         caDID,
+			Loaded:        agency.Handler(caDID) != nil,
+			WorkerRunning: comm.ActiveRcvrs.Get(caDID) != nil,
+		}
+		if len(values) > 1 {
+			e.RootDID = values[1]
+		}
+		agents = append(agents, e)
+		return true
+	})
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+
+	return &AgentList{
+		Agents: page(agents, pageReq.Offset, pageReq.Limit),
+		Total:  len(agents),
+	}
+}
+
 func (a agencyService) PSMHook(hook *ops.DataHook, server ops.AgencyService_PSMHookServer) (err error) {
 	defer err2.Catch(err2.Err(func(err error) {
 		glog.Errorf("grpc agent listen error: %s", err)
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/agency"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-common-go/jwt"
+	"github.com/lainio/err2/assert"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestListAgents(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	assert.NoError(agency.Register.Load(""))
+	defer func() { _ = agency.Register.Load("") }()
+	agency.Register.Add("caDID2", "bob", "rootDID2", "verKey2")
+	agency.Register.Add("caDID1", "alice", "rootDID1", "verKey1")
+	agency.Register.Add("caDID3", "carol", "rootDID3", "verKey3")
+
+	s := agencyService{Root: "findy-root"}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		assert.PushTester(t)
+		defer assert.PopTester()
+
+		ctx := jwt.NewContextWithUser(context.Background(), "caDID1")
+		l, err := s.ListAgents(ctx, &PageRequest{})
+		assert.Error(err)
+		assert.Equal(grpcstatus.Code(err), codes.PermissionDenied)
+		assert.Nil(l)
+	})
+	t.Run("authorized", func(t *testing.T) {
+		assert.PushTester(t)
+		defer assert.PopTester()
+
+		ctx := jwt.NewContextWithUser(context.Background(), "findy-root")
+		l, err := s.ListAgents(ctx, &PageRequest{Offset: 1, Limit: 1})
+		assert.NoError(err)
+		assert.Equal(3, l.Total)
+		assert.SLen(l.Agents, 1)
+		assert.Equal("bob", l.Agents[0].Name)
+		assert.Equal("caDID2", l.Agents[0].CADID)
+		assert.Equal("rootDID2", l.Agents[0].RootDID)
+		assert.That(!l.Agents[0].Loaded)
+		assert.That(!l.Agents[0].WorkerRunning)
+	})
+	t.Run("registered handler", func(t *testing.T) {
+		assert.PushTester(t)
+		defer assert.PopTester()
+
+		ctx := jwt.NewContextWithUser(context.Background(), "findy-root")
+		dec := func(in interface{}) error {
+			in.(*wrapperspb.StringValue).Value = `{"Offset":2}`
+			return nil
+		}
+		out, err := listAgentsHandler(s, ctx, dec, nil)
+		assert.NoError(err)
+		var l AgentList
+		dto.FromJSONStr(out.(*wrapperspb.StringValue).GetValue(), &l)
+		assert.Equal(3, l.Total)
+		assert.SLen(l.Agents, 1)
+		assert.Equal("carol", l.Agents[0].Name)
+	})
+}
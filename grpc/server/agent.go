@@ -15,13 +15,11 @@ import (
 	"github.com/findy-network/findy-agent/agent/utils"
 	"github.com/findy-network/findy-agent/agent/vc"
 	"github.com/findy-network/findy-agent/method"
+	"github.com/findy-network/findy-agent/std/didexchange"
 	"github.com/findy-network/findy-common-go/dto"
 	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
 	"github.com/findy-network/findy-common-go/jwt"
 	"github.com/findy-network/findy-common-go/std/didexchange/invitation"
-	"github.com/findy-network/findy-wrapper-go"
-	"github.com/findy-network/findy-wrapper-go/anoncreds"
-	"github.com/findy-network/findy-wrapper-go/ledger"
 	"github.com/golang/glog"
 	"github.com/lainio/err2"
 	"github.com/lainio/err2/assert"
@@ -145,18 +143,12 @@ func (a *agentServer) CreateSchema(
 	caDID, ca := try.To2(ca(ctx))
 	glog.V(1).Infoln(caDID, "-agent create schema:", s.Name)
 
-	sch := &vc.Schema{
-		Name:    s.Name,
-		Version: s.Version,
-		Attrs:   s.Attributes,
-	}
-	defer err2.Handle(&err, "by DID(%v) and wallet(%v)",
-		ca.RootDid().Did(), ca.ID())
-
-	try.To(sch.Create(ca.RootDid().Did()))
-	try.To(sch.ToLedger(ca.Wallet(), ca.RootDid().Did()))
-
-	return &pb.Schema{ID: sch.ValidID()}, nil
+	id := try.To1(CreateSchema(ca, &SchemaSpec{
+		Name:       s.Name,
+		Version:    s.Version,
+		Attributes: s.Attributes,
+	}))
+	return &pb.Schema{ID: id}, nil
 }
 
 func (a *agentServer) CreateCredDef(
@@ -172,20 +164,11 @@ func (a *agentServer) CreateCredDef(
 	glog.V(1).Infoln(caDID, "-agent create creddef:", cdc.Tag,
 		"schema:", cdc.SchemaID)
 
-	defer err2.Handle(&err, "by DID(%v) and wallet(%v)",
-		ca.RootDid().Did(), ca.WorkerEA().ID())
-
-	sch := &vc.Schema{ID: cdc.SchemaID}
-	try.To(sch.FromLedger(ca.RootDid().Did()))
-	rCA := <-anoncreds.IssuerCreateAndStoreCredentialDef(
-		ca.Wallet(), ca.RootDid().Did(), sch.Stored.Str2(),
-		cdc.Tag, findy.NullString, findy.NullString)
-	try.To(rCA.Err())
-
-	cd := rCA.Str2()
-	glog.V(1).Infoln("=== starting legded writer with CA cred def")
-	err = ledger.WriteCredDef(ca.Pool(), ca.Wallet(), ca.RootDid().Did(), cd)
-	return &pb.CredDef{ID: rCA.Str1()}, nil
+	id := try.To1(CreateCredDef(ca, &CredDefSpec{
+		SchemaID: cdc.SchemaID,
+		Tag:      cdc.Tag,
+	}))
+	return &pb.CredDef{ID: id}, nil
 }
 
 func (a *agentServer) GetSchema(
@@ -195,15 +178,15 @@ func (a *agentServer) GetSchema(
 	_ *pb.SchemaData,
 	err error,
 ) {
-	defer err2.Handle(&err, "get schema")
+	defer err2.Handle(&err, ledgerError)
 
 	caDID, ca := try.To2(ca(ctx))
 	rootDID := ca.RootDid().Did()
 	glog.V(1).Infoln(caDID, "-agent get schema:", s.ID)
 	defer err2.Handle(&err, "get schema (%v) by root (%v)", s.ID, rootDID)
 
-	sID, schema := try.To2(ledger.ReadSchema(ca.Pool(), rootDID, s.ID))
-	return &pb.SchemaData{ID: sID, Data: schema}, nil
+	schema, _ := try.To2(vc.ReadSchema(ca.Pool(), rootDID, s.ID))
+	return &pb.SchemaData{ID: s.ID, Data: schema}, nil
 }
 
 func (a *agentServer) GetCredDef(
@@ -213,16 +196,19 @@ func (a *agentServer) GetCredDef(
 	_ *pb.CredDefData,
 	err error,
 ) {
-	defer err2.Handle(&err, "get creddef")
+	defer err2.Handle(&err, ledgerError)
 
 	caDID, ca := try.To2(ca(ctx))
 	glog.V(1).Infoln(caDID, "-agent get creddef:", cd.ID)
 	defer err2.Handle(&err, "get credDef (%v) by root (%v)", cd.ID, ca.RootDid().Did())
 
-	def := try.To1(vc.CredDefFromLedger(ca.RootDid().Did(), cd.ID))
+	def := try.To1(vc.CredDefFromLedger(ca.Pool(), ca.RootDid().Did(), cd.ID))
 	return &pb.CredDefData{ID: cd.ID, Data: def}, nil
 }
 
+// CreateInvitation creates the invitation. The expiration of the base is the
+// Unix time when the invitation expires, and zero means that it doesn't
+// expire.
 func CreateInvitation(
 	receiver comm.Receiver,
 	base *pb.InvitationBase,
@@ -232,7 +218,7 @@ func CreateInvitation(
 ) {
 	defer err2.Handle(&err, "create invitation")
 
-	id := base.ID
+	id := base.GetID()
 
 	// if connection is not given from the caller we generate a new one
 	if id == "" {
@@ -240,10 +226,16 @@ func CreateInvitation(
 		glog.V(4).Infoln("generating connection id:", id)
 	}
 
-	addr := try.To1(preallocatePWDID(receiver, id))
+	var expires time.Time
+	if base.GetExpiration() != 0 {
+		expires = time.Unix(base.GetExpiration(), 0)
+		assert.That(expires.After(time.Now()), "expiration must be in future")
+	}
+
+	addr := try.To1(preallocatePWDID(receiver, id, expires))
 
-	label := base.Label
-	if base.Label == "" {
+	label := base.GetLabel()
+	if label == "" {
 		label = "empty-label"
 	}
 
@@ -259,6 +251,9 @@ func CreateInvitation(
 	jStr := dto.ToJSON(inv)
 	// .. and build a URL which contains the invitation
 	urlStr := try.To1(invitation.Build(inv))
+	if !expires.IsZero() {
+		jStr, urlStr = try.To2(didexchange.WithExpiry(inv, expires))
+	}
 
 	glog.V(5).Infof("Created invitation %s", jStr)
 
@@ -284,7 +279,14 @@ func (a *agentServer) CreateInvitation(
 	return CreateInvitation(receiver, base)
 }
 
-func preallocatePWDID(receiver comm.Receiver, id string) (ep *endp.Addr, err error) {
+func preallocatePWDID(
+	receiver comm.Receiver,
+	id string,
+	expires time.Time,
+) (
+	ep *endp.Addr,
+	err error,
+) {
 	defer err2.Handle(&err)
 
 	glog.V(5).Infoln("========== start pre-alloc:", id)
@@ -301,10 +303,14 @@ func preallocatePWDID(receiver comm.Receiver, id string) (ep *endp.Addr, err err
 	// mark the pre-allocated pairwise DID with connection ID that we find it
 	_, ms := wa.ManagedWallet()
 	store := ms.Storage().ConnectionStorage()
-	try.To(store.SaveConnection(storage.Connection{
+	conn := storage.Connection{
 		ID:    id,
 		MyDID: ourPairwiseDID.Did(),
-	}))
+	}
+	if !expires.IsZero() {
+		conn.InvitationExpires = expires.Unix()
+	}
+	try.To(store.SaveConnection(conn))
 
 	ep.VerKey = ourPairwiseDID.VerKey()
 
@@ -328,6 +334,7 @@ func preallocatePWDID(receiver comm.Receiver, id string) (ep *endp.Addr, err err
 }
 
 func (a *agentServer) Give(ctx context.Context, answer *pb.Answer) (cid *pb.ClientID, err error) {
+	defer err2.Handle(&err, protocolError)
 	defer err2.Handle(&err, "give answer")
 
 	caDID, receiver := try.To2(ca(ctx))
@@ -341,10 +348,9 @@ func (a *agentServer) Give(ctx context.Context, answer *pb.Answer) (cid *pb.Clie
 		Nonce: answer.ID,
 	}))
 
-	prot.Resume(
-		receiver,
-		uniqueTypeID(pb.Protocol_RESUMER, state.FirstState().T.ProtocolType()),
-		answer.ID, answer.Ack)
+	protocolType := state.FirstState().T.ProtocolType()
+	typeID := try.To1(uniqueTypeID(pb.Protocol_RESUMER, protocolType))
+	prot.Resume(receiver, typeID, answer.ID, answer.Ack, answer.Info)
 
 	return &pb.ClientID{ID: answer.ClientID.ID}, nil
 }
@@ -381,6 +387,9 @@ loop:
 				break loop
 			}
 			assert.That(clientID.ID == notify.ClientID)
+			if _, ok := notificationTypeID[notify.NotificationType]; !ok {
+				continue
+			}
 			agentStatus := processNofity(notify)
 			agentStatus.ClientID.ID = notify.ClientID
 			try.To(server.Send(agentStatus))
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"github.com/findy-network/findy-agent/agent/comm"
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// ConnectionStatus is the connection with its health, which tells UIs if the
+// other end is reachable, see comm.ConnectionHealth.
+type ConnectionStatus struct {
+	storage.Connection
+	Health comm.Health
+}
+
+// ConnectionList is the connections of the caller.
+type ConnectionList struct {
+	Connections []ConnectionStatus
+}
+
+// GetConnection returns the connection with its health. The caller must own
+// the connection, if it has an owner.
+func GetConnection(
+	receiver comm.Receiver,
+	caDID string,
+	connID string,
+) (
+	s *ConnectionStatus,
+	err error,
+) {
+	defer err2.Handle(&err)
+
+	conn, err := receiver.WorkerEA().FindPWByID(connID)
+	if err != nil || conn == nil {
+		return nil, grpcstatus.Errorf(codes.NotFound,
+			"connection (%s) not found", connID)
+	}
+	return &ConnectionStatus{
+		Connection: *conn,
+		Health:     comm.ConnectionHealth(conn.ID),
+	}, nil
+}
+
+// ListConnections returns the connections of the caller with their health.
+// The connections without the owner are listed to every caller.
+func ListConnections(
+	receiver comm.Receiver,
+	caDID string,
+) (
+	l *ConnectionList,
+	err error,
+) {
+	defer err2.Handle(&err)
+
+	_, mgdStorage := receiver.WorkerEA().ManagedWallet()
+	conns := try.To1(mgdStorage.Storage().ConnectionStorage().ListConnections())
+
+	l = &ConnectionList{Connections: make([]ConnectionStatus, 0, len(conns))}
+	for _, conn := range conns {
+		l.Connections = append(l.Connections, ConnectionStatus{
+			Connection: conn,
+			Health:     comm.ConnectionHealth(conn.ID),
+		})
+	}
+	return l, nil
+}
+
+// ConnectionAutoResponse sets how the proof and credential protocol steps of
+// the connection are responded, see storage.AutoResponseAccept. The empty
+// AutoResponse uses the agent's setting.
+type ConnectionAutoResponse struct {
+	ID           string
+	AutoResponse string
+}
+
+// SetAutoResponse sets the auto response of the connection. The caller must
+// own the connection, if it has an owner.
+func SetAutoResponse(
+	receiver comm.Receiver,
+	caDID string,
+	ar *ConnectionAutoResponse,
+) (
+	err error,
+) {
+	defer err2.Handle(&err)
+
+	conn, err := receiver.WorkerEA().FindPWByID(ar.ID)
+	if err != nil || conn == nil {
+		return grpcstatus.Errorf(codes.NotFound,
+			"connection (%s) not found", ar.ID)
+	}
+	if err := comm.SetAutoResponse(receiver.WorkerEA(), ar.ID,
+		ar.AutoResponse); err != nil {
+		return grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
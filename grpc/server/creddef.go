@@ -0,0 +1,38 @@
+package server
+
+import (
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/vc"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+// CredDefSpec is the issuer's cred def to be created for the schema.
+type CredDefSpec struct {
+	SchemaID          string
+	Tag               string
+	SupportRevocation bool
+}
+
+// CreateCredDef creates the cred def to the receiver's wallet and writes it to
+// the ledger. It returns the cred def ID. Creating the same cred def again
+// returns the ID of the existing one.
+func CreateCredDef(
+	receiver comm.Receiver,
+	spec *CredDefSpec,
+) (
+	id string,
+	err error,
+) {
+	defer err2.Handle(&err, "create cred def")
+
+	assert.NotEmpty(spec.SchemaID, "schema ID missing")
+
+	rootDID := receiver.RootDid().Did()
+	pool := receiver.Pool()
+	sch := &vc.Schema{ID: spec.SchemaID}
+	try.To(sch.FromLedger(pool, rootDID))
+	return vc.CreateCredDef(pool, receiver.Wallet(), rootDID, sch, spec.Tag,
+		spec.SupportRevocation)
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/agent/vc"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// CredentialFilter selects the holder's credentials for ListCredentials. Limit
+// zero means no limit.
+type CredentialFilter struct {
+	storage.CredentialFilter
+	Offset int
+	Limit  int
+}
+
+// CredentialList is one page of the holder's credentials. Total is the amount
+// of credentials matching the filter.
+type CredentialList struct {
+	Credentials []*Credential
+	Total       int
+}
+
+type Credential struct {
+	storage.Credential
+	Revocable bool
+}
+
+// ListCredentials lists credentials in the wallet of the receiver's worker EA,
+// which is the holder in the issuing protocol. The anoncreds prover searches
+// the wallet by the cred defs of the stored credentials and by the cred def or
+// the schema of the filter, which finds also the credentials received before
+// the credential storage. Credentials are ordered by their IDs to keep paging
+// stable.
+func ListCredentials(
+	receiver comm.Receiver,
+	filter *CredentialFilter,
+) (
+	l *CredentialList,
+	err error,
+) {
+	defer err2.Handle(&err, "list credentials")
+
+	_, mgdStorage := receiver.WorkerEA().ManagedWallet()
+	stored := try.To1(mgdStorage.Storage().CredentialStorage().
+		ListCredentials(storage.CredentialFilter{}))
+
+	credDefIDs := make(map[string]struct{}, len(stored)+1)
+	for _, cred := range stored {
+		credDefIDs[cred.CredDefID] = struct{}{}
+	}
+	var schemaIDs []string
+	switch {
+	case filter.CredDefID != "":
+		credDefIDs[filter.CredDefID] = struct{}{}
+	case filter.SchemaID != "":
+		schemaIDs = append(schemaIDs, filter.SchemaID)
+	}
+	walletCreds := try.To1(walletCredentials(receiver, credDefIDs, schemaIDs))
+	creds := mergeCredentials(walletCreds, stored, filter.CredentialFilter)
+
+	l = &CredentialList{Total: len(creds)}
+	creds = page(creds, filter.Offset, filter.Limit)
+	l.Credentials = make([]*Credential, len(creds))
+	for i, cred := range creds {
+		l.Credentials[i] = &Credential{
+			Credential: cred,
+			Revocable:  cred.RevRegID != "",
+		}
+	}
+	return l, nil
+}
+
+// mergeCredentials merges the credentials found from the wallet with the
+// stored ones, which have the tag and the attribute types, and returns the
+// ones matching the filter ordered by their IDs.
+func mergeCredentials(
+	walletCreds []anoncreds.CredentialInfo,
+	stored []storage.Credential,
+	filter storage.CredentialFilter,
+) []storage.Credential {
+	byID := make(map[string]storage.Credential, len(walletCreds)+len(stored))
+	for _, info := range walletCreds {
+		cred := storage.Credential{
+			ID:         info.Referent,
+			CredDefID:  info.CredDefID,
+			SchemaID:   info.SchemaID,
+			RevRegID:   info.RevRegID,
+			CredRevID:  info.CredRevID,
+			Attributes: make([]storage.CredentialAttribute, 0, len(info.Attrs)),
+		}
+		for name, value := range info.Attrs {
+			cred.Attributes = append(cred.Attributes,
+				storage.CredentialAttribute{Name: name, Value: value})
+		}
+		sort.Slice(cred.Attributes, func(i, j int) bool {
+			return cred.Attributes[i].Name < cred.Attributes[j].Name
+		})
+		byID[cred.ID] = cred
+	}
+	for _, cred := range stored {
+		byID[cred.ID] = cred
+	}
+
+	creds := make([]storage.Credential, 0, len(byID))
+	for _, cred := range byID {
+		if filter.Match(cred) {
+			creds = append(creds, cred)
+		}
+	}
+	sort.Slice(creds, func(i, j int) bool { return creds[i].ID < creds[j].ID })
+	return creds
+}
+
+// walletCredentials searches the credentials of the cred defs and the schemas
+// from the wallet of the receiver's worker EA. The anoncreds prover searches
+// the credentials by their attributes, and that's why the attribute names are
+// read from the ledger. Tests can replace it.
+var walletCredentials = func(
+	receiver comm.Receiver,
+	credDefIDs map[string]struct{},
+	schemaIDs []string,
+) (
+	creds []anoncreds.CredentialInfo,
+	err error,
+) {
+	defer err2.Handle(&err, "wallet credentials")
+
+	wa := receiver.WorkerEA()
+	DID := wa.MyDID().Did()
+	for credDefID := range credDefIDs {
+		cd := try.To1(vc.CredDefFromLedger(wa.Pool(), DID, credDefID))
+		var credDef struct {
+			Value struct {
+				Primary struct {
+					R map[string]interface{} `json:"r"`
+				} `json:"primary"`
+			} `json:"value"`
+		}
+		dto.FromJSONStr(cd, &credDef)
+		names := make([]string, 0, len(credDef.Value.Primary.R))
+		for name := range credDef.Value.Primary.R {
+			if name != "master_secret" {
+				names = append(names, name)
+			}
+		}
+		found := try.To1(searchCredentials(wa.Wallet(), names,
+			anoncreds.Filter{CredDefID: credDefID}))
+		creds = append(creds, found...)
+	}
+	for _, schemaID := range schemaIDs {
+		sch, _ := try.To2(vc.ReadSchema(wa.Pool(), DID, schemaID))
+		var schema struct {
+			AttrNames []string `json:"attrNames"`
+		}
+		dto.FromJSONStr(sch, &schema)
+		found := try.To1(searchCredentials(wa.Wallet(), schema.AttrNames,
+			anoncreds.Filter{SchemaID: schemaID}))
+		creds = append(creds, found...)
+	}
+	return creds, nil
+}
+
+const credentialFetchCount = 100
+
+// searchCredentials fetches all of the wallet's credentials which have the
+// attribute and match the restriction. Every credential of a cred def has all
+// of its attributes, which means that any of the names will do.
+func searchCredentials(
+	w int,
+	names []string,
+	restriction anoncreds.Filter,
+) (
+	creds []anoncreds.CredentialInfo,
+	err error,
+) {
+	defer err2.Handle(&err, "search credentials")
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	const ref = "attr_referent_1"
+	proofReq := anoncreds.ProofRequest{
+		Name:    "ListCredentials",
+		Version: "0.1",
+		Nonce:   utils.NewNonceStr(),
+		RequestedAttributes: map[string]anoncreds.AttrInfo{
+			ref: {
+				Name:         names[0],
+				Restrictions: []anoncreds.Filter{restriction},
+			},
+		},
+		RequestedPredicates: map[string]anoncreds.PredicateInfo{},
+	}
+	r := <-anoncreds.ProverSearchCredentialsForProofReq(w,
+		dto.ToJSON(proofReq), findy.NullString)
+	try.To(r.Err())
+	searchHandle := r.Handle()
+	defer func() {
+		r := <-anoncreds.ProverCloseCredentialsSearchForProofReq(searchHandle)
+		if r.Err() != nil {
+			glog.Warningln("close credential search:", r.Err())
+		}
+	}()
+
+	for {
+		r := <-anoncreds.ProverFetchCredentialsForProofReq(searchHandle, ref,
+			credentialFetchCount)
+		try.To(r.Err())
+		fetched := make([]anoncreds.Credentials, 0, credentialFetchCount)
+		dto.FromJSONStr(r.Str1(), &fetched)
+		for _, c := range fetched {
+			creds = append(creds, c.CredInfo)
+		}
+		if len(fetched) < credentialFetchCount {
+			return creds, nil
+		}
+	}
+}
+
+// page returns the items of the page. Limit zero means no limit.
+func page[T any](items []T, offset, limit int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"testing"
+
+	storage "github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+)
+
+func TestMergeCredentials(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	// the credentials received before the credential storage are only in
+	// the wallet
+	walletCreds := []anoncreds.CredentialInfo{
+		{
+			Referent:  "old",
+			CredDefID: "cred-def",
+			SchemaID:  "schema",
+			Attrs:     map[string]string{"name": "Alice", "email": "a@example.com"},
+		},
+		{
+			Referent:  "new",
+			CredDefID: "cred-def",
+			SchemaID:  "schema",
+			Attrs:     map[string]string{"name": "Bob"},
+		},
+		{
+			Referent:  "other",
+			CredDefID: "other-cred-def",
+			RevRegID:  "rev-reg",
+			CredRevID: "1",
+		},
+	}
+	stored := []storage.Credential{{
+		ID:        "new",
+		CredDefID: "cred-def",
+		SchemaID:  "schema",
+		Tag:       "work",
+		Attributes: []storage.CredentialAttribute{
+			{Name: "name", Value: "Bob", MimeType: "text/plain"},
+		},
+	}}
+
+	creds := mergeCredentials(walletCreds, stored, storage.CredentialFilter{})
+	assert.SLen(creds, 3)
+	assert.Equal(creds[0].ID, "new")
+	assert.Equal(creds[0].Tag, "work")
+	assert.Equal(creds[0].Attributes[0].MimeType, "text/plain")
+	assert.Equal(creds[1].ID, "old")
+	assert.DeepEqual(creds[1].Attributes, []storage.CredentialAttribute{
+		{Name: "email", Value: "a@example.com"},
+		{Name: "name", Value: "Alice"},
+	})
+	assert.Equal(creds[2].ID, "other")
+	assert.Equal(creds[2].RevRegID, "rev-reg")
+	assert.Equal(creds[2].CredRevID, "1")
+
+	creds = mergeCredentials(walletCreds, stored,
+		storage.CredentialFilter{CredDefID: "cred-def"})
+	assert.SLen(creds, 2)
+
+	creds = mergeCredentials(walletCreds, stored,
+		storage.CredentialFilter{Tag: "work"})
+	assert.SLen(creds, 1)
+	assert.Equal(creds[0].ID, "new")
+}
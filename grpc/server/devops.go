@@ -7,11 +7,15 @@ import (
 	"fmt"
 
 	agencyServer "github.com/findy-network/findy-agent/agent/agency"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/handshake"
+	"github.com/findy-network/findy-agent/agent/psm"
 	"github.com/findy-network/findy-agent/agent/utils"
 	agency "github.com/findy-network/findy-common-go/grpc/ops/v1"
 	"github.com/findy-network/findy-common-go/jwt"
 	"github.com/golang/glog"
 	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
 )
 
@@ -44,3 +48,205 @@ func (d devOpsServer) Enter(ctx context.Context, cmd *agency.Cmd) (cr *agency.Cm
 	}
 	return cmdReturn, nil
 }
+
+// PauseCmd pauses or resumes the cloud agent of the CADID for the maintenance,
+// or the whole agency when the CADID is empty. The paused agents don't start
+// new protocols, but they complete the running ones.
+type PauseCmd struct {
+	CADID string
+	Pause bool
+}
+
+type pauser interface {
+	Pause()
+	Resume()
+}
+
+// Pause executes the pause command.
+func Pause(cmd *PauseCmd) (err error) {
+	defer err2.Handle(&err, "pause")
+
+	if cmd.CADID == "" {
+		comm.SetAllPaused(cmd.Pause)
+		glog.V(1).Infoln("agency paused:", cmd.Pause)
+		return nil
+	}
+	if !agencyServer.IsHandlerInThisAgency(cmd.CADID) {
+		return fmt.Errorf("handler (%s) is not in this agency", cmd.CADID)
+	}
+	agent, ok := agencyServer.Handler(cmd.CADID).(pauser)
+	assert.That(ok, "agent (%s) cannot be paused", cmd.CADID)
+	if cmd.Pause {
+		agent.Pause()
+	} else {
+		agent.Resume()
+	}
+	return nil
+}
+
+// WalletCycleCmd closes and reopens the worker wallet of the cloud agent of the
+// CADID, e.g. to recover from a storage failure without restarting the
+// agency.
+type WalletCycleCmd struct {
+	CADID string
+}
+
+// ErrAgentBusy is returned when the agent has protocols in flight, and its
+// wallet cannot be cycled.
+var ErrAgentBusy = errors.New("agent busy")
+
+type walletCycler interface {
+	pauser
+	Paused() bool
+	WorkerEA() comm.Receiver
+	CycleWallet() error
+}
+
+// CycleWallet executes the wallet cycle command. The agent is paused for the
+// cycle, and the command is refused with ErrAgentBusy if the agent still has
+// protocols in flight.
+func CycleWallet(cmd *WalletCycleCmd) (err error) {
+	defer err2.Handle(&err, "cycle wallet")
+
+	if !agencyServer.IsHandlerInThisAgency(cmd.CADID) {
+		return fmt.Errorf("handler (%s) is not in this agency", cmd.CADID)
+	}
+	agent, ok := agencyServer.Handler(cmd.CADID).(walletCycler)
+	assert.That(ok, "agent (%s) wallet cannot be cycled", cmd.CADID)
+
+	if !agent.Paused() {
+		agent.Pause()
+		defer agent.Resume()
+	}
+	wDID := agent.WorkerEA().MyDID().Did()
+	inFlight := try.To1(psm.FindPSMs(func(m *psm.PSM) bool {
+		return m.Key.DID == wDID && m.InFlight()
+	}))
+	if len(inFlight) > 0 {
+		return fmt.Errorf("%w: %d protocols in flight", ErrAgentBusy,
+			len(inFlight))
+	}
+	try.To(agent.CycleWallet())
+	glog.V(1).Infoln("agent wallet cycled:", cmd.CADID)
+	return nil
+}
+
+// MessageDumpCmd queries the stored messages of the protocol of the cloud
+// agent of the CADID. All of the messages are returned if the Timestamp of
+// the PSM state isn't given. The messages are stored only when the message
+// dump is enabled in the agency.
+type MessageDumpCmd struct {
+	CADID      string
+	ProtocolID string
+	Timestamp  int64
+}
+
+// MessageDump is the plain DIDComm message sent or received in the PSM
+// state. The fields which may include key material are redacted.
+type MessageDump struct {
+	Timestamp   int64
+	State       string
+	MessageType string
+	Message     string
+}
+
+// MessageDumps is the result of MessageDumpCmd in the order of the states.
+type MessageDumps struct {
+	Messages []*MessageDump
+}
+
+// DumpMessages executes the message dump command.
+func DumpMessages(cmd *MessageDumpCmd) (_ *MessageDumps, err error) {
+	defer err2.Handle(&err, "dump messages")
+
+	if !agencyServer.IsHandlerInThisAgency(cmd.CADID) {
+		return nil, fmt.Errorf("handler (%s) is not in this agency", cmd.CADID)
+	}
+	receiver, ok := agencyServer.Handler(cmd.CADID).(comm.Receiver)
+	assert.That(ok, "agent (%s) isn't a receiver", cmd.CADID)
+
+	key := psm.NewStateKey(receiver.WorkerEA(), cmd.ProtocolID)
+	m := try.To1(psm.GetPSM(key))
+	raw := try.To1(psm.GetRawMsgs(key))
+
+	dumps := &MessageDumps{}
+	for _, s := range m.States {
+		if cmd.Timestamp != 0 && s.Timestamp != cmd.Timestamp {
+			continue
+		}
+		var msg []byte
+		if raw != nil {
+			msg = raw.Msgs[s.Timestamp]
+		}
+		if msg == nil {
+			continue
+		}
+		dumps.Messages = append(dumps.Messages, &MessageDump{
+			Timestamp:   s.Timestamp,
+			State:       s.Sub.String(),
+			MessageType: s.PLInfo.Type,
+			Message:     string(msg),
+		})
+	}
+	if cmd.Timestamp != 0 && len(dumps.Messages) == 0 {
+		return nil, fmt.Errorf("no message stored for state %d", cmd.Timestamp)
+	}
+	return dumps, nil
+}
+
+// FeatureFlagsCmd reads or sets the feature flags of the cloud agent of the
+// CADID, see comm.FeatureAutoAcceptProofs for the flags. The flag of the Name
+// is set to On if the Name is given, otherwise the flags are only read.
+type FeatureFlagsCmd struct {
+	CADID string
+	Name  string
+	On    bool
+}
+
+// FeatureFlags are the feature flags set for the agent. The flags which
+// aren't set use the agency's default behavior.
+type FeatureFlags struct {
+	CADID string
+	Flags map[string]bool
+}
+
+// AgentFeatures executes the feature flags command. The flags are stored with
+// the agent to the register.
+func AgentFeatures(cmd *FeatureFlagsCmd) (_ *FeatureFlags, err error) {
+	defer err2.Handle(&err, "agent features")
+
+	if !agencyServer.IsHandlerInThisAgency(cmd.CADID) {
+		return nil, fmt.Errorf("handler (%s) is not in this agency", cmd.CADID)
+	}
+	flags := comm.Features(cmd.CADID)
+	if cmd.Name != "" {
+		flags = try.To1(handshake.SetAgentFeature(cmd.CADID, cmd.Name, cmd.On))
+		glog.V(1).Infof("agent (%s) feature %s: %v", cmd.CADID, cmd.Name,
+			cmd.On)
+	}
+	return &FeatureFlags{CADID: cmd.CADID, Flags: flags}, nil
+}
+
+// VerbosityCmd sets the logging level of the cloud agent of the CADID for
+// debugging one tenant without raising the global verbosity, see utils.V. Zero
+// Level removes the override.
+type VerbosityCmd struct {
+	CADID string
+	Level int32
+}
+
+// SetVerbosity executes the verbosity command. The level is set to the worker
+// agent of the CA, because it runs the protocols.
+func SetVerbosity(cmd *VerbosityCmd) (err error) {
+	defer err2.Handle(&err, "set verbosity")
+
+	if !agencyServer.IsHandlerInThisAgency(cmd.CADID) {
+		return fmt.Errorf("handler (%s) is not in this agency", cmd.CADID)
+	}
+	receiver, ok := agencyServer.Handler(cmd.CADID).(comm.Receiver)
+	assert.That(ok, "agent (%s) isn't a receiver", cmd.CADID)
+
+	utils.SetVerbosity(receiver.WorkerEA().MyDID().Did(), glog.Level(cmd.Level))
+	glog.Infof("agent (%s) verbosity: %d", cmd.CADID, cmd.Level)
+	return nil
+}
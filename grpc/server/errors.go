@@ -0,0 +1,43 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+var protocolErrorCodes = map[comm.ErrorCode]codes.Code{
+	comm.ErrUnsupportedMessage: codes.Unimplemented,
+	comm.ErrValidationFailed:   codes.InvalidArgument,
+	comm.ErrSARejected:         codes.Aborted,
+	comm.ErrTransportFailed:    codes.Unavailable,
+	comm.ErrAgentPaused:        codes.FailedPrecondition,
+}
+
+// protocolError maps the protocol error to the gRPC status with a matching
+// code. The status message starts with the protocol error code, which lets
+// clients tell apart the errors sharing the gRPC code. Other errors are
+// returned as is.
+func protocolError(err error) error {
+	var pErr *comm.ProtocolError
+	if !errors.As(err, &pErr) {
+		return err
+	}
+	grpcCode, ok := protocolErrorCodes[pErr.Code]
+	if !ok {
+		grpcCode = codes.Unknown
+	}
+	return grpcstatus.Error(grpcCode, pErr.Error())
+}
+
+// notFoundError maps the error of a missing PSM to the gRPC NotFound status.
+// Other errors are returned as is.
+func notFoundError(err error) error {
+	if errors.Is(err, psm.ErrNotFound) {
+		return grpcstatus.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
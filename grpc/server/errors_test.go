@@ -0,0 +1,124 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/psm"
+	_ "github.com/findy-network/findy-agent/protocol/presentproof"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/lainio/err2/assert"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+func TestProtocolError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"unsupported", comm.NewProtocolError(comm.ErrUnsupportedMessage, "no handler"),
+			codes.Unimplemented},
+		{"validation", fmt.Errorf("start: %w",
+			comm.WrapProtocolError(comm.ErrValidationFailed, errors.New("attrs missing"))),
+			codes.InvalidArgument},
+		{"sa rejected", comm.NewProtocolError(comm.ErrSARejected, "declined"),
+			codes.Aborted},
+		{"transport", comm.WrapProtocolError(comm.ErrTransportFailed, errors.New("timeout")),
+			codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			pCode, ok := comm.ProtocolErrorCode(tt.err)
+			assert.That(ok)
+			s, ok := grpcstatus.FromError(protocolError(tt.err))
+			assert.That(ok)
+			assert.Equal(s.Code(), tt.code)
+			assert.That(strings.HasPrefix(s.Message(), string(pCode)), s.Message())
+		})
+	}
+
+	// other errors aren't touched
+	err := errors.New("plain")
+	assert.Equal(protocolError(err), err)
+}
+
+func TestProtocolError_TaskValidation(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	// the task creator's assert must return an error instead of failing the
+	// test
+	old := assert.SetDefault(assert.Production)
+	_, err := taskFrom(&pb.Protocol{
+		TypeID:       pb.Protocol_PRESENT_PROOF,
+		Role:         pb.Protocol_INITIATOR,
+		ConnectionID: "conn",
+		StartMsg: &pb.Protocol_PresentProof{
+			PresentProof: &pb.Protocol_PresentProofMsg{},
+		},
+	})
+	assert.SetDefault(old)
+	assert.Error(err)
+	s, _ := grpcstatus.FromError(protocolError(err))
+	assert.Equal(s.Code(), codes.InvalidArgument)
+	assert.That(strings.HasPrefix(s.Message(), string(comm.ErrValidationFailed)))
+}
+
+func TestProtocolError_UnsupportedMessage(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	pl := aries.PayloadCreator.New(didcomm.PayloadInit{
+		ID:   "id",
+		Type: "https://didcomm.org/unknown-protocol/1.0/message",
+	})
+	err := comm.Proc.Process(comm.Packet{Payload: pl})
+	assert.Error(err)
+	s, _ := grpcstatus.FromError(protocolError(err))
+	assert.Equal(s.Code(), codes.Unimplemented)
+}
+
+func TestProtocolError_UnsupportedRole(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	typeID, err := uniqueTypeID(pb.Protocol_RESUMER, pb.Protocol_DIDEXCHANGE)
+	assert.Error(err)
+	assert.Empty(typeID)
+	s, _ := grpcstatus.FromError(protocolError(err))
+	assert.Equal(s.Code(), codes.InvalidArgument)
+
+	_, err = taskFrom(&pb.Protocol{
+		TypeID: pb.Protocol_TRUST_PING,
+		Role:   pb.Protocol_RESUMER,
+	})
+	assert.Error(err)
+	s, _ = grpcstatus.FromError(protocolError(err))
+	assert.Equal(s.Code(), codes.InvalidArgument)
+
+	typeID, err = uniqueTypeID(pb.Protocol_RESUMER, pb.Protocol_PRESENT_PROOF)
+	assert.NoError(err)
+	assert.NotEmpty(typeID)
+}
+
+func TestNotFoundError(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	err := fmt.Errorf("status: %w", psm.ErrNotFound)
+	assert.Equal(grpcstatus.Code(notFoundError(err)), codes.NotFound)
+
+	// other errors aren't touched
+	err = errors.New("plain")
+	assert.Equal(notFoundError(err), err)
+}
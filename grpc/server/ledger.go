@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/vc"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/preview"
+	"github.com/findy-network/findy-wrapper-go/plugin"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// SchemaID and CredDefID identify the ledger objects to read.
+type SchemaID struct {
+	ID string
+}
+
+type CredDefID struct {
+	ID string
+}
+
+// LedgerSchema is the schema read from the ledger. Stale tells that the ledger
+// couldn't be reached and the schema is from the cache.
+type LedgerSchema struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	Attributes []string `json:"attrNames"`
+	SeqNo      uint64   `json:"seqNo"`
+	Stale      bool     `json:"-"`
+}
+
+// LedgerCredDef is the cred def read from the ledger. SchemaID is the ledger
+// sequence number of the schema, which is how the cred def refers it.
+// Attributes are in the anoncreds' canonical form. Stale is like in
+// LedgerSchema.
+type LedgerCredDef struct {
+	ID         string `json:"id"`
+	SchemaID   string `json:"schemaId"`
+	Type       string `json:"type"`
+	Tag        string `json:"tag"`
+	Attributes []string
+	Stale      bool `json:"-"`
+}
+
+// GetSchema reads the schema from the ledger. An unknown schema is reported
+// with the gRPC not found status.
+func GetSchema(receiver comm.Receiver, id *SchemaID) (s *LedgerSchema, err error) {
+	defer err2.Handle(&err, ledgerError)
+
+	assert.NotEmpty(id.ID, "schema ID missing")
+
+	data, stale := try.To2(vc.ReadSchema(receiver.Pool(), receiver.RootDid().Did(),
+		id.ID))
+	s = new(LedgerSchema)
+	try.To(json.Unmarshal([]byte(data), s))
+	s.Stale = stale
+	return s, nil
+}
+
+// GetCredDef reads the cred def from the ledger, or from its cache, if the
+// agency has it. An unknown cred def is reported with the gRPC not found
+// status.
+func GetCredDef(receiver comm.Receiver, id *CredDefID) (cd *LedgerCredDef, err error) {
+	defer err2.Handle(&err, ledgerError)
+
+	assert.NotEmpty(id.ID, "cred def ID missing")
+
+	data, stale := try.To2(vc.ReadCredDef(receiver.Pool(), receiver.RootDid().Did(),
+		id.ID))
+	cd = new(LedgerCredDef)
+	try.To(json.Unmarshal([]byte(data), cd))
+	cd.Attributes = try.To1(preview.SchemaAttrs(data))
+	cd.Stale = stale
+	return cd, nil
+}
+
+// ledgerError maps the missing ledger object to the gRPC not found status.
+func ledgerError(err error) error {
+	if errors.Is(err, plugin.ErrNotExist) {
+		return grpcstatus.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/protocol/presentproof/data"
+	"github.com/findy-network/findy-agent/std/presentproof"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+// VerifiableProof returns the parsed proof of the present proof protocol for
+// the verifier's custom checks, e.g. when the proof waits the controller's
+// approval.
+func VerifiableProof(
+	receiver comm.Receiver,
+	protocolID string,
+) (
+	vp *presentproof.VerifiableProof,
+	err error,
+) {
+	defer err2.Handle(&err, "verifiable proof")
+
+	key := psm.NewStateKey(receiver.WorkerEA(), protocolID)
+	rep := try.To1(data.GetPresentProofRep(key))
+	assert.That(rep != nil, "present proof (%s) not found", protocolID)
+
+	return rep.VerifiableProof()
+}
@@ -30,7 +30,7 @@ func (s *didCommServer) Run(
 			State: pb.ProtocolState_ERR,
 		}
 		try.Out(server.Send(status)).Logf("error sending response")
-		return err
+		return protocolError(err)
 	})
 
 	glog.V(3).Infoln("run() call")
@@ -45,64 +45,91 @@ func (s *didCommServer) Run(
 	statusChan := bus.WantAll.AddListener(key)
 	userActionChan := bus.WantUserActions.AddListener(key)
 
-	prot.FindAndStartTask(receiver, task)
+	if err := prot.FindAndStartTask(receiver, task); err != nil {
+		bus.WantAll.RmListener(key)
+		bus.WantUserActions.RmListener(key)
+		return err
+	}
+
+	statusCode, done, err := waitProtocol(server.Context(), task.ID(),
+		statusChan, userActionChan, server.Send)
+	bus.WantAll.RmListener(key)
+	bus.WantUserActions.RmListener(key)
+	try.To(err)
+	if !done {
+		glog.V(1).Infoln("client left, stop waiting protocol:", task.ID())
+		return nil
+	}
+	glog.V(3).Infoln("out from grpc state:", statusCode)
+
+	status := &pb.ProtocolState{
+		ProtocolID: &pb.ProtocolID{ID: task.ID()},
+		State:      statusCode,
+	}
+	try.To(server.Send(status))
+
+	return nil
+}
+
+// waitProtocol waits the protocol to end and sends its WAIT_ACTION states with
+// the send function. It returns the protocol's final state. If the ctx is done
+// before the protocol, i.e. the client is cancelled or its deadline passed,
+// done is false. The protocol itself continues.
+func waitProtocol(
+	ctx context.Context,
+	protocolID string,
+	statusChan, userActionChan bus.StateChan,
+	send func(*pb.ProtocolState) error,
+) (
+	statusCode pb.ProtocolState_State,
+	done bool,
+	err error,
+) {
+	defer err2.Handle(&err)
 
-	var statusCode pb.ProtocolState_State
-loop:
 	for {
 		select {
 		case status := <-statusChan:
-			glog.V(3).Infof("grpc %s state in %s", status, task.ID())
+			glog.V(3).Infof("grpc %s state in %s", status, protocolID)
 			switch status {
 			case psm.SystemReboot:
 				glog.V(1).Info("system reboot notify, break out")
-				break loop
+				return statusCode, true, nil
 			case psm.ReadyACK, psm.ACK:
-				statusCode = pb.ProtocolState_OK
-				break loop
+				return pb.ProtocolState_OK, true, nil
 			case psm.ReadyNACK, psm.NACK:
-				statusCode = pb.ProtocolState_NACK
-				break loop
+				return pb.ProtocolState_NACK, true, nil
 			case psm.Failure:
-				statusCode = pb.ProtocolState_ERR
-				break loop
+				return pb.ProtocolState_ERR, true, nil
 			}
 		case status := <-userActionChan:
 			switch status {
 			case psm.SystemReboot:
 				glog.V(1).Info("system reboot notify, break out")
-				break loop
+				return statusCode, true, nil
 			case psm.Waiting:
 				glog.V(1).Infoln("waiting arrived")
-				status := &pb.ProtocolState{
-					ProtocolID: &pb.ProtocolID{ID: task.ID()},
+				try.To(send(&pb.ProtocolState{
+					ProtocolID: &pb.ProtocolID{ID: protocolID},
 					State:      pb.ProtocolState_WAIT_ACTION,
-				}
-				try.To(server.Send(status))
+				}))
 			}
+		case <-ctx.Done():
+			glog.V(1).Infoln("ctx.Done() received, returning")
+			return statusCode, false, nil
 		}
 	}
-	glog.V(3).Infoln("out from grpc state:", statusCode)
-	bus.WantAll.RmListener(key)
-	bus.WantUserActions.RmListener(key)
-
-	status := &pb.ProtocolState{
-		ProtocolID: &pb.ProtocolID{ID: task.ID()},
-		State:      statusCode,
-	}
-	try.To(server.Send(status))
-
-	return nil
 }
 
 func (s *didCommServer) Resume(ctx context.Context, state *pb.ProtocolState) (pid *pb.ProtocolID, err error) {
-	defer err2.Handle(&err)
+	defer err2.Handle(&err, protocolError)
 
 	caDID, receiver := try.To2(ca(ctx))
 	glog.V(1).Infoln(caDID, "-agent Resume protocol:", state.ProtocolID.TypeID, state.ProtocolID.ID)
 
-	prot.Resume(receiver, uniqueTypeID(state.ProtocolID.Role, state.ProtocolID.TypeID),
-		state.ProtocolID.ID, state.GetState() == pb.ProtocolState_ACK)
+	typeID := try.To1(uniqueTypeID(state.ProtocolID.Role, state.ProtocolID.TypeID))
+	ack := state.GetState() == pb.ProtocolState_ACK
+	prot.Resume(receiver, typeID, state.ProtocolID.ID, ack, state.GetInfo())
 
 	return state.ProtocolID, nil
 }
@@ -120,17 +147,19 @@ func (s *didCommServer) Release(ctx context.Context, id *pb.ProtocolID) (ps *pb.
 }
 
 func (s *didCommServer) Start(ctx context.Context, protocol *pb.Protocol) (pid *pb.ProtocolID, err error) {
-	defer err2.Handle(&err)
+	defer err2.Handle(&err, protocolError)
 
 	caDID, receiver := try.To2(ca(ctx))
 	task := try.To1(taskFrom(protocol))
 	glog.V(1).Infoln(caDID, "-agent starts protocol:", protocol.TypeID)
-	prot.FindAndStartTask(receiver, task)
+	try.To(prot.FindAndStartTask(receiver, task))
 	return &pb.ProtocolID{ID: task.ID()}, nil
 }
 
+// Status returns the protocol's status. An unknown protocol ID is returned as
+// NotFound, which lets clients tell it apart from a running protocol.
 func (s *didCommServer) Status(ctx context.Context, id *pb.ProtocolID) (ps *pb.ProtocolStatus, err error) {
-	defer err2.Handle(&err)
+	defer err2.Handle(&err, notFoundError)
 
 	caDID, receiver := try.To2(ca(ctx))
 	key := psm.NewStateKey(receiver.WorkerEA(), id.ID)
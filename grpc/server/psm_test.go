@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/findy-network/findy-agent/agent/bus"
+	"github.com/findy-network/findy-agent/agent/psm"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/lainio/err2/assert"
+)
+
+func TestWaitProtocol(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	statusChan := make(bus.StateChan)
+	userActionChan := make(bus.StateChan)
+	sent := make([]*pb.ProtocolState, 0, 1)
+	send := func(s *pb.ProtocolState) error {
+		sent = append(sent, s)
+		return nil
+	}
+
+	go func() {
+		userActionChan <- psm.Waiting
+		statusChan <- psm.ReadyACK
+	}()
+	code, done, err := waitProtocol(context.Background(), "id",
+		statusChan, userActionChan, send)
+	assert.NoError(err)
+	assert.That(done)
+	assert.Equal(pb.ProtocolState_OK, code)
+	assert.SLen(sent, 1)
+	assert.Equal(pb.ProtocolState_WAIT_ACTION, sent[0].State)
+}
+
+func TestWaitProtocol_Cancel(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, done, err := waitProtocol(ctx, "id", make(bus.StateChan),
+		make(bus.StateChan), func(*pb.ProtocolState) error { return nil })
+	assert.NoError(err)
+	assert.That(!done)
+	assert.That(time.Since(start) < time.Second)
+}
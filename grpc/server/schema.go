@@ -0,0 +1,58 @@
+package server
+
+import (
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/vc"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+// SchemaSpec is the issuer's schema to be registered to the ledger before its
+// cred defs can be created with CredDefSpec.
+type SchemaSpec struct {
+	Name       string
+	Version    string
+	Attributes []string
+}
+
+// CreateSchema creates the schema by the receiver's root DID and writes it to
+// the ledger. It returns the schema ID. The attribute names must be unique and
+// non-empty.
+func CreateSchema(
+	receiver comm.Receiver,
+	spec *SchemaSpec,
+) (
+	id string,
+	err error,
+) {
+	defer err2.Handle(&err, "create schema")
+
+	try.To(validateSchema(spec))
+
+	rootDID := receiver.RootDid().Did()
+	sch := &vc.Schema{
+		Name:    spec.Name,
+		Version: spec.Version,
+		Attrs:   spec.Attributes,
+	}
+	try.To(sch.Create(rootDID))
+	try.To(sch.ToLedger(receiver.Pool(), receiver.Wallet(), rootDID))
+	return sch.ValidID(), nil
+}
+
+func validateSchema(spec *SchemaSpec) (err error) {
+	defer err2.Handle(&err)
+
+	assert.NotEmpty(spec.Name, "schema name missing")
+	assert.NotEmpty(spec.Version, "schema version missing")
+	assert.SNotEmpty(spec.Attributes, "schema attributes missing")
+
+	names := make(map[string]bool, len(spec.Attributes))
+	for _, name := range spec.Attributes {
+		assert.NotEmpty(name, "schema attribute name missing")
+		assert.That(!names[name], "schema attribute (%s) not unique", name)
+		names[name] = true
+	}
+	return nil
+}
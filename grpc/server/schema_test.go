@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs []string
+		ok    bool
+	}{
+		{"valid", []string{"email", "name"}, true},
+		{"no attributes", nil, false},
+		{"empty name", []string{"email", ""}, false},
+		{"duplicate", []string{"email", "name", "email"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			// validation asserts must return errors instead of failing
+			old := assert.SetDefault(assert.Production)
+			err := validateSchema(&SchemaSpec{
+				Name:       "test_schema",
+				Version:    "1.0",
+				Attributes: tt.attrs,
+			})
+			assert.SetDefault(old)
+			if tt.ok {
+				assert.NoError(err)
+			} else {
+				assert.Error(err)
+			}
+		})
+	}
+}
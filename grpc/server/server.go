@@ -1,5 +1,30 @@
 /*
 Package server is a playground where we have tried gRPC interface for CA API.
+
+# Info fields
+
+The agency gRPC API doesn't have fields for all of the protocol data, and the
+Info fields of the ProtocolState carry it instead. This is the one place where
+their content is specified. The content depends on the protocol and on the
+direction:
+
+The ProtocolState.Info of the ProtocolStatus returned by Status:
+  - PRESENT_PROOF, the verifier: the JSON of the data.ProofResult of
+    protocol/presentproof/data, when the proof is received. It has the
+    verification result, the revealed attributes and the predicates.
+  - TRUST_PING, both roles: the JSON of the trustping.Status.
+  - Other protocols leave it empty.
+
+The ProtocolState.Info given to Resume, and the Answer.Info given to Give,
+i.e. the user action:
+  - ISSUE_CREDENTIAL, the holder: the tag of the accepted credential as plain
+    text, see storage.Credential.Tag.
+  - PRESENT_PROOF, the verifier of the proposal: the JSON built by
+    verifier.NewCounterRequest counters the proposal with its proof request.
+    Any other info accepts the proposal as it is.
+  - Other protocols ignore it.
+
+The ProtocolState.Info of the ERR state sent by Run is the error message.
 */
 package server
 
@@ -9,6 +34,7 @@ import (
 
 	"github.com/findy-network/findy-agent-auth/acator/grpcenclave/rpcserver"
 	"github.com/findy-network/findy-agent/agent/agency"
+	"github.com/findy-network/findy-agent/agent/cloud"
 	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/pltype"
 	"github.com/findy-network/findy-agent/agent/prot"
@@ -22,6 +48,8 @@ import (
 	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 )
 
 var Server *grpc.Server
@@ -38,7 +66,9 @@ func Serve(conf *rpc.ServerCfg) {
 		pb.RegisterAgentServiceServer(s, &agentServer{})
 
 		root := utils.Settings.GRPCAdmin()
-		ops.RegisterAgencyServiceServer(s, &agencyService{Root: root})
+		agencySrv := &agencyService{Root: root}
+		ops.RegisterAgencyServiceServer(s, agencySrv)
+		s.RegisterService(&agentListServiceDesc, agencySrv)
 		ops.RegisterDevOpsServiceServer(s, &devOpsServer{Root: root})
 
 		try.To(rpcserver.RegisterAuthnServer(s))
@@ -56,7 +86,7 @@ func taskFrom(protocol *pb.Protocol) (t comm.Task, err error) {
 
 	header := &comm.TaskHeader{
 		TaskID:       utils.UUID(),
-		TypeID:       uniqueTypeID(protocol.Role, protocol.TypeID),
+		TypeID:       try.To1(uniqueTypeID(protocol.Role, protocol.TypeID)),
 		ProtocolRole: protocol.GetRole(),
 		ConnID:       protocol.GetConnectionID(),
 		Method:       utils.Settings.DIDMethod(),
@@ -64,6 +94,8 @@ func taskFrom(protocol *pb.Protocol) (t comm.Task, err error) {
 	return prot.CreateTask(header, protocol)
 }
 
+// notificationTypeID maps the agent's notifications to the gRPC API. The ones
+// which aren't here, e.g. the new connection, are internal to the agency.
 var notificationTypeID = map[string]pb.Notification_Type{
 	pltype.CANotifyStatus:                 pb.Notification_STATUS_UPDATE,
 	pltype.CANotifyUserAction:             pb.Notification_PROTOCOL_PAUSED,
@@ -81,14 +113,18 @@ var questionTypeID = map[string]pb.Question_Type{
 	pltype.SAPresentProofAcceptValues:     pb.Question_PROOF_VERIFY_WAITS,
 }
 
-// uniqueTypeID returns safely (asserts) agency's internal type ID for protocol
-// starting.
-func uniqueTypeID(role pb.Protocol_Role, id pb.Protocol_Type) string {
+// uniqueTypeID returns agency's internal type ID for protocol starting. An
+// unsupported role and type combination is a validation error, which is
+// returned to the gRPC caller as InvalidArgument.
+func uniqueTypeID(role pb.Protocol_Role, id pb.Protocol_Type) (string, error) {
 	i := int32(10*role) + int32(id)
 	glog.V(5).Infoln("unique id:", i, typeID[i])
 	s, ok := typeID[i]
-	assert.That(ok, "cannot find typeid for (%d+%d)", role, id)
-	return s
+	if !ok {
+		return "", comm.NewProtocolError(comm.ErrValidationFailed,
+			fmt.Sprintf("role %s isn't supported for protocol %s", role, id))
+	}
+	return s, nil
 }
 
 // TODO: Should we shift for `role` and consider what happens when w3c protocols
@@ -127,5 +163,12 @@ func ca(ctx context.Context) (caDID string, r comm.Receiver, err error) {
 	if !ok {
 		return "", nil, fmt.Errorf("no ca did (%s)", caDID)
 	}
+	// the worker is started here that its failure is reported cleanly
+	if cloudCA, ok := rcvr.(*cloud.Agent); ok {
+		if _, err := cloudCA.WEA(); err != nil {
+			glog.Errorln("cannot start worker agent:", err)
+			return "", nil, grpcstatus.Error(codes.Internal, err.Error())
+		}
+	}
 	return caDID, rcvr, nil
 }
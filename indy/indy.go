@@ -54,6 +54,10 @@ func (i *Indy) CredentialStorage() api.CredentialStorage {
 	panic("not implemented") // TODO: Implement
 }
 
+func (i *Indy) MessageQueueStorage() api.MessageQueueStorage {
+	panic("not implemented") // TODO: Implement
+}
+
 func (i *Indy) OurPackager() api.Packager {
 	return i.packager
 }
@@ -95,7 +95,10 @@ func (p *Packager) PackMessage(envelope *transport.Envelope) (b []byte, err erro
 	assert.That(toDID != "")
 
 	toVerKey := p.didStrToVerKey(toDID)
-	senderKey := p.didStrToVerKey(string(envelope.FromKey))
+	senderKey := findy.NullString // anoncrypt
+	if len(envelope.FromKey) > 0 {
+		senderKey = p.didStrToVerKey(string(envelope.FromKey))
+	}
 
 	if glog.V(5) {
 		glog.Infof("<== Pack: %s, %s", envelope.FromKey, senderKey)
@@ -97,6 +97,9 @@ func NewFromDID(
 
 	switch DIDType(didStr[0]) {
 	case TypePeer:
+		if len(didStr) == 1 && IsPeer2(didStr[0]) {
+			return NewPeerFromPeer2(hStorage, didStr[0])
+		}
 		assert.SLen(didStr, 2)
 		return NewPeerFromDoc(hStorage, didStr[1])
 	case TypeKey:
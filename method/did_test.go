@@ -13,6 +13,7 @@ import (
 	"github.com/findy-network/findy-agent/agent/utils"
 	"github.com/findy-network/findy-agent/method"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/transport"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
 )
@@ -94,6 +95,56 @@ func TestPeer_Route(t *testing.T) {
 	}
 }
 
+func TestPeer2(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const addr = "https://www.address.com"
+	didIn, err := agent2.NewDID(method.TypePeer, addr)
+	assert.NoError(err)
+	assert.That(method.IsPeer2(didIn.URI()), "not numalgo 2: %s", didIn.URI())
+	assert.Equal(method.DIDType(didIn.URI()), method.TypePeer)
+
+	// the doc is resolved from the DID only
+	doc, err := method.ResolvePeer2(didIn.URI())
+	assert.NoError(err)
+	assert.Equal(doc.ID, didIn.URI())
+	assert.DeepEqual(doc.VerificationMethod[0].Value, didIn.DOC().(*did.Doc).VerificationMethod[0].Value)
+	_, err = method.ResolvePeer2("did:peer:2.Xabc")
+	assert.Error(err)
+
+	out, err := agent.NewOutDID(didIn.URI())
+	assert.NoError(err)
+	assert.Equal(out.URI(), didIn.URI())
+	assert.Equal(out.VerKey(), didIn.VerKey())
+	ae, err := out.AEndp()
+	assert.NoError(err)
+	assert.Equal(ae.Endp, addr)
+	assert.Equal(ae.Key, didIn.VerKey())
+
+	// the routing keys are in the DID as well
+	route, _ := agent.NewDID(method.TypeKey, "")
+	routed, err := agent2.NewDID(method.TypePeer, addr, route.VerKey())
+	assert.NoError(err)
+	routedOut, err := agent.NewOutDID(routed.URI())
+	assert.NoError(err)
+	assert.SLen(routedOut.Route(), 1)
+	assert.Equal(routedOut.Route()[0], route.URI())
+
+	// the DID and its doc are stored to the wallet
+	loaded := agent.LoadDID(didIn.URI())
+	assert.Equal(loaded.VerKey(), didIn.VerKey())
+
+	// the pipe packs for the peer DID which unpacks it in its own wallet
+	me, _ := agent.NewDID(method.TypePeer, addr)
+	message := []byte("message")
+	packed, _, err := sec.Pipe{In: me, Out: out}.Pack(message)
+	assert.NoError(err)
+	unpacked, _, err := sec.Pipe{In: didIn, Out: me}.Unpack(packed)
+	assert.NoError(err)
+	assert.DeepEqual(unpacked, message)
+}
+
 func TestMethodString(t *testing.T) {
 	tests := []struct {
 		did, method string
@@ -2,6 +2,7 @@ package method
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/findy-network/findy-agent/agent/managed"
 	"github.com/findy-network/findy-agent/agent/service"
@@ -9,11 +10,9 @@ import (
 	"github.com/findy-network/findy-agent/core"
 	"github.com/findy-network/findy-agent/std/common"
 	"github.com/golang/glog"
-	"github.com/hyperledger/aries-framework-go/component/models/did/endpoint"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/vdr/fingerprint"
-	"github.com/hyperledger/aries-framework-go/pkg/vdr/peer"
 	"github.com/lainio/err2"
 	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
@@ -57,27 +56,8 @@ func NewPeer(
 	kid, pk := try.To2(keys.CreateAndExportPubKeyBytes(kms.ED25519))
 	kh := try.To1(keys.PubKeyBytesToHandle(pk, kms.ED25519))
 
-	key := did.VerificationMethod{
-		ID:         "1",
-		Type:       "Ed25519VerificationKey2018",
-		Controller: "",
-		Value:      pk,
-	}
-	doc := try.To1(peer.NewDoc(
-		[]did.VerificationMethod{key},
-		did.WithAuthentication([]did.Verification{{
-			VerificationMethod: key,
-			Relationship:       0,
-			Embedded:           true,
-		}}),
-		did.WithService([]did.Service{{
-			ID:              "didcomm",
-			Type:            "did-communication",
-			Priority:        0,
-			RecipientKeys:   []string{base58.Encode(pk)},
-			ServiceEndpoint: endpoint.NewDIDCommV1Endpoint(args[0]),
-		}}),
-	))
+	// args are the endpoint and its routing keys
+	doc := try.To1(NewPeer2Doc(pk, args[0], args[1:]...))
 
 	return Peer{Base{handle: hStorage, kid: kid, pk: pk, vkh: kh, doc: doc}}, nil
 }
@@ -101,35 +81,34 @@ func NewPeerFromDoc(
 	return Peer{Base{handle: hStorage, kid: "", pk: pk, vkh: kh, doc: doc}}, nil
 }
 
+// NewPeerFromPeer2 is like NewPeerFromDoc but the doc is resolved from the
+// numalgo 2 DID itself.
+func NewPeerFromPeer2(
+	hStorage managed.Wallet,
+	didStr string,
+) (
+	id core.DID,
+	err error,
+) {
+	defer err2.Handle(&err, "new did:peer from did:peer:2")
+
+	doc := try.To1(ResolvePeer2(didStr))
+	pk := doc.VerificationMethod[0].Value
+	keys := hStorage.Storage().KMS()
+	kh := try.To1(keys.PubKeyBytesToHandle(pk, kms.ED25519))
+
+	return Peer{Base{handle: hStorage, kid: "", pk: pk, vkh: kh, doc: doc}}, nil
+}
+
 func (p Peer) NewDoc(ae service.Addr) core.DIDDoc {
 	if p.doc != nil {
 		return p.doc
 	}
 
 	myAE, _ := p.AEndp()
-	assert.That(ae == myAE)
+	assert.Equal(ae.Endp, myAE.Endp)
 
-	key := did.VerificationMethod{
-		ID:         "1",
-		Type:       "Ed25519VerificationKey2018",
-		Controller: "",
-		Value:      p.pk,
-	}
-	doc := try.To1(peer.NewDoc(
-		[]did.VerificationMethod{key},
-		did.WithAuthentication([]did.Verification{{
-			VerificationMethod: key,
-			Relationship:       0,
-			Embedded:           true,
-		}}),
-		did.WithService([]did.Service{{
-			ID:              "didcomm",
-			Type:            "did-communication",
-			Priority:        0,
-			RecipientKeys:   []string{base58.Encode(p.pk)},
-			ServiceEndpoint: endpoint.NewDIDCommV1Endpoint(ae.Endp),
-		}}),
-	))
+	doc := try.To1(NewPeer2Doc(p.pk, ae.Endp))
 	return doc
 }
 
@@ -195,6 +174,39 @@ func (p Peer) AEndp() (ae service.Addr, err error) {
 	}, nil
 }
 
+// Endpoints returns the access points of the DID: the first service and the
+// rest of the services of the same type, which are its alternates. The weight
+// of the endpoint is read from the service's 'weight' property.
+func (p Peer) Endpoints() (addrs []service.WeightedAddr, err error) {
+	defer err2.Handle(&err)
+
+	assert.That(p.doc != nil)
+	srvs := common.Services(p.doc)
+	assert.SNotEmpty(srvs, "no service in DID doc")
+	for _, srv := range srvs {
+		uri, err := srv.ServiceEndpoint.URI()
+		if fmt.Sprint(srv.Type) != fmt.Sprint(srvs[0].Type) || err != nil ||
+			len(srv.RecipientKeys) == 0 {
+			continue
+		}
+		addrs = append(addrs, service.WeightedAddr{
+			Addr:   service.Addr{Endp: uri, Key: srv.RecipientKeys[0]},
+			Weight: serviceWeight(srv),
+		})
+	}
+	return addrs, nil
+}
+
+// serviceWeight returns the 'weight' property of the service, and zero if it
+// isn't a positive number.
+func serviceWeight(srv did.Service) int {
+	w, ok := srv.Properties["weight"].(float64)
+	if !ok || w < 1 {
+		return 0
+	}
+	return int(w)
+}
+
 func (p Peer) SavePairwiseForDID(mStorage managed.Wallet, theirDID core.DID,
 	pw core.PairwiseMeta) {
 	defer err2.Catch(err2.Err(func(err error) {
@@ -217,30 +229,9 @@ func (p Peer) SavePairwiseForDID(mStorage managed.Wallet, theirDID core.DID,
 	try.To(mStorage.Storage().ConnectionStorage().SaveConnection(*connection))
 }
 
+// NewDoc returns the did:peer doc of the base58 key and the endpoint.
 func NewDoc(pk, addr string) (d *did.Doc, err error) {
 	defer err2.Handle(&err)
 
-	pubKey := try.To1(base58.Decode(pk))
-
-	key := did.VerificationMethod{
-		ID:         "1",
-		Type:       "Ed25519VerificationKey2018",
-		Controller: "",
-		Value:      pubKey,
-	}
-	return try.To1(peer.NewDoc(
-		[]did.VerificationMethod{key},
-		did.WithAuthentication([]did.Verification{{
-			VerificationMethod: key,
-			Relationship:       0,
-			Embedded:           true,
-		}}),
-		did.WithService([]did.Service{{
-			ID:              "didcomm",
-			Type:            "did-communication",
-			Priority:        0,
-			RecipientKeys:   []string{pk},
-			ServiceEndpoint: endpoint.NewDIDCommV1Endpoint(addr),
-		}}),
-	)), nil
+	return NewPeer2Doc(try.To1(base58.Decode(pk)), addr)
 }
@@ -0,0 +1,167 @@
+package method
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/component/models/did/endpoint"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/fingerprint"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+	"github.com/mr-tron/base58"
+)
+
+// The did:peer numalgo 2 DIDs have their keys and services in the DID itself,
+// which is why their docs can be resolved without the ledger or the other
+// end. See https://identity.foundation/peer-did-method-spec/#method-2-multiple-inception-key-without-doc
+const (
+	peer2Prefix = "did:peer:2"
+
+	peer2Authentication = 'V'
+	peer2KeyAgreement   = 'E'
+	peer2Service        = 'S'
+
+	peer2DIDCommV1 = "did-communication"
+)
+
+// peer2Abbreviations are the abbreviations of the service types which the
+// numalgo 2 DIDs use.
+var peer2Abbreviations = map[string]string{
+	"dm": "DIDCommMessaging",
+}
+
+// peer2ServiceJSON is the abbreviated form of the DID doc service.
+type peer2ServiceJSON struct {
+	Type        string   `json:"t"`
+	Endpoint    any      `json:"s"`
+	RoutingKeys []string `json:"r,omitempty"`
+	Accept      []string `json:"a,omitempty"`
+}
+
+// IsPeer2 tells if the DID is did:peer of numalgo 2.
+func IsPeer2(didStr string) bool {
+	return strings.HasPrefix(didStr, peer2Prefix+".")
+}
+
+// NewPeer2Doc returns the DID doc of the numalgo 2 did:peer which has the
+// Ed25519 key and the DIDComm V1 service. The routing keys are base58 encoded
+// like in the other docs of the agency.
+func NewPeer2Doc(pk []byte, addr string, routingKeys ...string) (doc *did.Doc, err error) {
+	defer err2.Handle(&err, "new did:peer:2 doc")
+
+	rKeys := make([]string, len(routingKeys))
+	for i, rk := range routingKeys {
+		rKeys[i], _ = fingerprint.CreateDIDKey(try.To1(base58.Decode(rk)))
+	}
+	srv := try.To1(json.Marshal(peer2ServiceJSON{
+		Type:        peer2DIDCommV1,
+		Endpoint:    addr,
+		RoutingKeys: rKeys,
+	}))
+	didStr := fmt.Sprintf("%s.%c%s.%c%s", peer2Prefix,
+		peer2Authentication,
+		fingerprint.KeyFingerprint(fingerprint.ED25519PubKeyMultiCodec, pk),
+		peer2Service,
+		base64.RawURLEncoding.EncodeToString(srv))
+	return ResolvePeer2(didStr)
+}
+
+// ResolvePeer2 returns the DID doc of the numalgo 2 did:peer. The recipient
+// keys of the DIDComm V1 services are the authentication keys.
+func ResolvePeer2(didStr string) (doc *did.Doc, err error) {
+	defer err2.Handle(&err, "resolve %s", didStr)
+
+	assert.That(IsPeer2(didStr), "not did:peer:2")
+
+	var (
+		vms      []did.VerificationMethod
+		auths    []did.Verification
+		services []peer2ServiceJSON
+	)
+	for _, elem := range strings.Split(strings.TrimPrefix(didStr, peer2Prefix+"."), ".") {
+		assert.That(len(elem) > 1, "empty did:peer:2 element")
+		value := elem[1:]
+		switch elem[0] {
+		case peer2Authentication:
+			pk, code := try.To2(fingerprint.PubKeyFromFingerprint(value))
+			assert.Equal(code, uint64(fingerprint.ED25519PubKeyMultiCodec),
+				"only Ed25519 authentication keys are supported")
+			vm := did.VerificationMethod{
+				ID:         fmt.Sprintf("#key-%d", len(vms)+1),
+				Type:       "Ed25519VerificationKey2018",
+				Controller: didStr,
+				Value:      pk,
+			}
+			vms = append(vms, vm)
+			auths = append(auths, did.Verification{
+				VerificationMethod: vm,
+				Relationship:       did.Authentication,
+				Embedded:           true,
+			})
+		case peer2KeyAgreement:
+			// DIDComm V1 uses the authentication keys for the encryption
+			continue
+		case peer2Service:
+			var srv peer2ServiceJSON
+			data := try.To1(base64.RawURLEncoding.DecodeString(
+				strings.TrimRight(value, "=")))
+			try.To(json.Unmarshal(data, &srv))
+			services = append(services, srv)
+		default:
+			return nil, fmt.Errorf("unsupported did:peer:2 purpose: %c", elem[0])
+		}
+	}
+	assert.SNotEmpty(vms, "no authentication key")
+
+	recipientKeys := make([]string, len(vms))
+	for i, vm := range vms {
+		recipientKeys[i] = base58.Encode(vm.Value)
+	}
+	didServices := make([]did.Service, len(services))
+	for i, srv := range services {
+		didServices[i] = try.To1(srv.service(i, recipientKeys))
+	}
+
+	doc = did.BuildDoc(
+		did.WithVerificationMethod(vms),
+		did.WithAuthentication(auths),
+		did.WithService(didServices),
+	)
+	doc.ID = didStr
+	return doc, nil
+}
+
+func (s peer2ServiceJSON) service(i int, recipientKeys []string) (_ did.Service, err error) {
+	defer err2.Handle(&err, "did:peer:2 service")
+
+	srvType := s.Type
+	if full, ok := peer2Abbreviations[srvType]; ok {
+		srvType = full
+	}
+	var uri string
+	switch ep := s.Endpoint.(type) {
+	case string:
+		uri = ep
+	case map[string]any: // the newer form has the URI in the object
+		uri, _ = ep["uri"].(string)
+	default:
+		return did.Service{}, fmt.Errorf("unsupported service endpoint %v", ep)
+	}
+	routingKeys := make([]string, len(s.RoutingKeys))
+	for j, rk := range s.RoutingKeys {
+		didKey, _, _ := strings.Cut(rk, "#")
+		routingKeys[j] = base58.Encode(try.To1(fingerprint.PubKeyFromDIDKey(didKey)))
+	}
+	return did.Service{
+		ID:              fmt.Sprintf("#service-%d", i),
+		Type:            srvType,
+		RecipientKeys:   recipientKeys,
+		RoutingKeys:     routingKeys,
+		ServiceEndpoint: endpoint.NewDIDCommV1Endpoint(uri),
+		Accept:          s.Accept,
+	}, nil
+}
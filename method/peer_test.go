@@ -0,0 +1,54 @@
+package method
+
+import (
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+const relayDoc = `{
+  "@context": ["https://w3id.org/did/v1"],
+  "id": "did:peer:1zQmRelays",
+  "service": [
+    {
+      "id": "#relay1",
+      "type": "did-communication",
+      "recipientKeys": ["8HH5gYEeNc3z7PYXmd54d4x6qAfCNrqQqEB3nS7Zfu7K"],
+      "serviceEndpoint": "http://relay1"
+    },
+    {
+      "id": "#relay2",
+      "type": "did-communication",
+      "recipientKeys": ["8HH5gYEeNc3z7PYXmd54d4x6qAfCNrqQqEB3nS7Zfu7K"],
+      "serviceEndpoint": "http://relay2",
+      "weight": 3
+    },
+    {
+      "id": "#other",
+      "type": "LinkedDomains",
+      "serviceEndpoint": "http://other"
+    }
+  ]
+}`
+
+func TestPeer_Endpoints(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	doc := try.To1(did.ParseDocument([]byte(relayDoc)))
+	p := Peer{Base{doc: doc}}
+
+	addrs, err := p.Endpoints()
+	assert.NoError(err)
+	assert.SLen(addrs, 2)
+	assert.Equal(addrs[0].Endp, "http://relay1")
+	assert.Equal(addrs[0].Weight, 0)
+	assert.Equal(addrs[1].Endp, "http://relay2")
+	assert.Equal(addrs[1].Weight, 3)
+
+	ae, err := p.AEndp()
+	assert.NoError(err)
+	assert.Equal(ae, addrs[0].Addr)
+}
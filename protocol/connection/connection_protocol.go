@@ -4,6 +4,7 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/findy-network/findy-agent/agent/aries"
 	"github.com/findy-network/findy-agent/agent/comm"
@@ -84,7 +85,9 @@ func createConnectionTask(
 		// Let's let invitation package translate incoming invitation. It will
 		// handle two different type formats even the field name ends with
 		// JSON.
-		inv = try.To1(invitation.Translate(protocol.GetDIDExchange().GetInvitationJSON()))
+		invStr := protocol.GetDIDExchange().GetInvitationJSON()
+		try.To(didexchange.CheckExpiry(invStr, time.Now()))
+		inv = try.To1(invitation.Translate(invStr))
 
 		header.TaskID = inv.ID()
 		label = protocol.GetDIDExchange().GetLabel()
@@ -130,26 +133,26 @@ func startConnectionProtocol(ca comm.Receiver, task comm.Task) {
 	})
 
 	didMethod := task.DIDMethod()
+	if prefersV11(deTask.Invitation) {
+		didMethod = method.TypePeer // DID Exchange 1.1 exchanges peer DIDs
+	}
 	caller := try.To1(ssiWA.NewDID(didMethod, meAddr.Address())) // Create a new DID for our end
 
 	addToSovCacheIf(ssiWA, caller)
 
 	// Save needed data to PSM related Pairwise Representative
 	pwr := &pairwiseRep{
-		StateKey:   psm.StateKey{DID: me, Nonce: deTask.ID()},
-		Name:       deTask.ID(),
-		TheirLabel: deTask.Invitation.Label(),
-		Caller:     didRep{DID: caller.Did(), VerKey: caller.VerKey(), My: true},
-		Callee:     didRep{},
+		StateKey:      psm.StateKey{DID: me, Nonce: deTask.ID()},
+		Name:          deTask.ID(),
+		TheirLabel:    deTask.Invitation.Label(),
+		Caller:        didRep{DID: caller.Did(), VerKey: caller.VerKey(), My: true},
+		Callee:        didRep{},
+		InvitationKey: deTask.ReceiverEndp().Key,
 	}
 	try.To(psm.AddRep(pwr))
 
 	// Create secure pipe to send payload to other end of the new PW
-	receiverKey := task.ReceiverEndp().Key
-	receiverKeys := buildRouting(task.ReceiverEndp().Endp, receiverKey,
-		deTask.Invitation.Services()[0].RoutingKeysAsB58(), didMethod)
-	callee := try.To1(wa.NewOutDID(receiverKeys...))
-	secPipe := sec.Pipe{In: caller, Out: callee}
+	secPipe := try.To1(invitationPipe(wa, deTask, caller))
 	wa.AddPipeToPWMap(secPipe, pwr.Name)
 
 	// Create payload to send
@@ -157,7 +160,7 @@ func startConnectionProtocol(ca comm.Receiver, task comm.Task) {
 
 	// Update PSM state, and send the payload to other end
 	try.To(prot.UpdatePSM(me, connectionID, task, opl, state))
-	try.To(comm.SendPL(secPipe, task, opl))
+	try.To(sendPending(pwr, secPipe, task, opl))
 
 	// Sending went OK, update PSM once again
 	reqMsg := opl.FieldObj().(didexchange.PwMsg)
@@ -165,6 +168,33 @@ func startConnectionProtocol(ca comm.Receiver, task comm.Task) {
 	try.To(prot.UpdatePSM(me, connectionID, task, wpl, state))
 }
 
+// prefersV11 tells if the out-of-band invitation selects DID Exchange 1.1.
+func prefersV11(inv invitation.Invitation) bool {
+	return inv.Version() == invitation.DIDExchangeVersionV1 &&
+		didexchange.PrefersV11(inv.HandshakeProtocols())
+}
+
+// invitationPipe builds the secure pipe from our new DID to the endpoint of
+// the invitation, which is set to the task. The other end knows only its
+// invitation key, which is why the pipe anoncrypts. It's replaced with the
+// authcrypting pipe when the DIDs are exchanged, see AddToPWMap.
+func invitationPipe(
+	wa comm.Receiver,
+	deTask *taskDIDExchange,
+	caller core.DID,
+) (
+	_ sec.Pipe,
+	err error,
+) {
+	defer err2.Handle(&err, "invitation pipe")
+
+	receiverKeys := buildRouting(deTask.ReceiverEndp().Endp,
+		deTask.ReceiverEndp().Key,
+		deTask.Invitation.Services()[0].RoutingKeysAsB58(), deTask.DIDMethod())
+	callee := try.To1(wa.NewOutDID(receiverKeys...))
+	return sec.Pipe{In: caller, Out: callee, Scheme: sec.SchemeAnoncrypt}, nil
+}
+
 func addToSovCacheIf(ssiWA ssi.Agent, caller core.DID) {
 	d, ok := caller.(*ssi.DID)
 	if ok {
@@ -213,6 +243,7 @@ func handleConnectionRequest(packet comm.Packet) (err error) {
 
 	safeThreadID := ipl.ThreadID()
 	connectionID := cnxAddr.ConnID
+	try.To(checkInvitationExpiry(receiver, connectionID, time.Now()))
 
 	reqMsg := ipl.MsgHdr().(didexchange.PwMsg)
 
@@ -280,12 +311,10 @@ func handleConnectionRequest(packet comm.Packet) (err error) {
 	try.To(psm.AddRep(pwr))
 
 	// SAVE ENDPOINT to wallet
-	try.To(saveConnectionEndpoint(managedStorage(receiver), connectionID, callerAddress))
+	try.To(saveConnectionEndpoint(receiver, connectionID, callerAddress))
 
-	pipe := sec.Pipe{
-		In:  calleePw.Callee, // This is us
-		Out: caller,          // This is the other end, who sent the Request
-	}
+	// calleePw.Callee is us, and caller is the other end who sent the Request
+	pipe := sec.NewPipe(calleePw.Callee, caller)
 
 	caller.SetAEndp(callerEP)
 	receiver.AddToPWMap(calleePw.Callee, caller, connectionID) // to access PW later, map it
@@ -293,7 +322,7 @@ func handleConnectionRequest(packet comm.Packet) (err error) {
 	// build the response payload, update PSM, and send the PL with sec.Pipe
 	opl, state := try.To2(reqMsg.PayloadToSend("", calleePw.Callee))
 	try.To(prot.UpdatePSM(meDID, connectionID, task, opl, state))
-	try.To(comm.SendPL(pipe, task, opl))
+	try.To(sendPending(pwr, pipe, task, opl))
 
 	// update the PSM
 	respMsg := opl.FieldObj().(didexchange.PwMsg)
@@ -335,6 +364,9 @@ func handleConnectionResponse(packet comm.Packet) (err error) {
 	var callee core.DID
 	if method.TypePeer == utils.Settings.DIDMethod() {
 		callee = receiver.LoadDID(respMsg.Did())
+	} else if method.DIDType(respMsg.Did()) == method.TypePeer {
+		callee = try.To1(receiver.NewOutDID(respMsg.Did(),
+			string(try.To1(respMsg.DIDDocument().MarshalJSON()))))
 	} else { // default method is did:sov:
 		callee = ssi.NewDid(respMsg.Did(), respMsg.VerKey())
 	}
@@ -344,6 +376,9 @@ func handleConnectionResponse(packet comm.Packet) (err error) {
 	// todo: send NACK here if fails
 	// NOTE: verify can be done only after their DID is stored to KMS
 	try.To(respMsg.Verify(callee))
+	if rotateMsg, ok := respMsg.(didexchange.RotateMsg); ok {
+		try.To(rotateMsg.VerifyRotate(callee, pwr.InvitationKey))
+	}
 
 	pwName := pwr.Name
 	route := respMsg.RoutingKeys()
@@ -354,7 +389,7 @@ func handleConnectionResponse(packet comm.Packet) (err error) {
 
 	// SAVE ENDPOINT to wallet
 	calleeEndp := endp.NewAddrFromPublic(respEndp)
-	try.To(saveConnectionEndpoint(managedStorage(receiver), pwName, calleeEndp.Address()))
+	try.To(saveConnectionEndpoint(receiver, pwName, calleeEndp.Address()))
 
 	// Save Rep and PSM
 	newPwr := &pairwiseRep{
@@ -372,13 +407,10 @@ func handleConnectionResponse(packet comm.Packet) (err error) {
 	opl, state := try.To2(respMsg.PayloadToSend("", nil))
 	wpl := opl
 	if !state.IsReady() {
-		pipe := sec.Pipe{
-			In:  caller,
-			Out: callee,
-		}
+		pipe := sec.NewPipe(caller, callee)
 
 		try.To(prot.UpdatePSM(meDID, connectionID, task, opl, state))
-		try.To(comm.SendPL(pipe, task, opl))
+		try.To(sendPending(newPwr, pipe, task, opl))
 
 		// Sending went OK, update PSM once again
 		completeMsg := opl.FieldObj().(didexchange.PwMsg)
@@ -389,8 +421,27 @@ func handleConnectionResponse(packet comm.Packet) (err error) {
 	return nil
 }
 
-func saveConnectionEndpoint(mgdStorage managed.Wallet, connectionID, theirEndpoint string) error {
-	store := mgdStorage.Storage().ConnectionStorage()
+// checkInvitationExpiry returns didexchange.ErrInvitationExpired if our
+// invitation of the connection is expired at the moment.
+func checkInvitationExpiry(
+	receiver comm.Receiver,
+	connectionID string,
+	now time.Time,
+) error {
+	store := managedStorage(receiver).Storage().ConnectionStorage()
+	connection, err := store.GetConnection(connectionID)
+	if err != nil || connection.InvitationExpires == 0 {
+		return nil
+	}
+	return didexchange.CheckExpiryTime(
+		time.Unix(connection.InvitationExpires, 0).UTC(), now)
+}
+
+// saveConnectionEndpoint saves their endpoint to the connection. The outbound
+// queue of the connection is turned on if the agency queues messages for
+// offline peers.
+func saveConnectionEndpoint(receiver comm.Receiver, connectionID, theirEndpoint string) error {
+	store := managedStorage(receiver).Storage().ConnectionStorage()
 	connection, _ := store.GetConnection(connectionID)
 	if connection == nil {
 		connection = &storage.Connection{
@@ -398,7 +449,13 @@ func saveConnectionEndpoint(mgdStorage managed.Wallet, connectionID, theirEndpoi
 		}
 	}
 	connection.TheirEndpoint = theirEndpoint
-	return store.SaveConnection(*connection)
+	if err := store.SaveConnection(*connection); err != nil {
+		return err
+	}
+	if utils.Settings.QueueOffline() {
+		return comm.SetQueueOffline(receiver, connectionID, true)
+	}
+	return nil
 }
 
 func fillPairwiseStatus(workerDID string, taskID string, ps *pb.ProtocolStatus) *pb.ProtocolStatus {
@@ -1,11 +1,15 @@
 package connection
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,7 +23,9 @@ import (
 	"github.com/findy-network/findy-agent/agent/ssi"
 	storage "github.com/findy-network/findy-agent/agent/storage/api"
 	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/core"
 	"github.com/findy-network/findy-agent/method"
+	"github.com/findy-network/findy-agent/std/common"
 	"github.com/findy-network/findy-agent/std/didexchange"
 	v1 "github.com/findy-network/findy-common-go/grpc/agency/v1"
 	"github.com/findy-network/findy-common-go/std/didexchange/invitation"
@@ -341,7 +347,336 @@ func TestConnectionInvitor(t *testing.T) {
 
 			assert.NoError(responseMsg.Verify(theirDID))
 
+			_, err = theirAgent.ConnectionStorage().GetConnection(endpointConnID)
+			assert.NoError(err)
+
 		})
 	}
 
 }
+
+// Simulates invitor role which is stopped before the response is sent
+func TestConnectionInvitor_Resume(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const didMethod = method.TypeSov
+	ourAgent := createAgent("our-resume")
+	theirAgent := createAgent("their-resume")
+
+	ourDID := try.To1(ourAgent.NewDID(didMethod, "000000000000000000000000Steward1"))
+	ourDID.SetAEndp(service.Addr{Endp: "http://example.com", Key: ourDID.VerKey()})
+	theirDID := try.To1(theirAgent.NewDID(didMethod, "000000000000000000000000Trustee1"))
+	outDID := try.To1(theirAgent.NewOutDID(ourDID.String(), ourDID.VerKey()))
+
+	mockReceiver := NewMockReceiverMock(ctrl)
+	mockReceiver.EXPECT().MyDID().Return(theirDID)
+	mockReceiver.EXPECT().FindPWByID(endpointConnID).Return(&storage.Connection{
+		MyDID: theirDID.String(),
+	}, nil)
+	mockReceiver.EXPECT().LoadDID(theirDID.String()).Return(theirDID)
+	mockReceiver.EXPECT().NewOutDID(ourDID.String(), ourDID.VerKey()).Return(outDID, nil)
+	mockReceiver.EXPECT().AddDIDCache(outDID).Return()
+	mockReceiver.EXPECT().ManagedWallet().AnyTimes().Return(theirAgent.WalletH, theirAgent.StorageH)
+	mockReceiver.EXPECT().AddToPWMap(theirDID, outDID, endpointConnID).Return(sec.Pipe{In: outDID, Out: theirDID})
+
+	// the agency stops in the middle of sending the response
+	comm.SendAndWaitReq = func(string, io.Reader, time.Duration) ([]byte, error) {
+		return nil, fmt.Errorf("agency stopped")
+	}
+	err := handleConnectionRequest(comm.Packet{
+		Payload:  aries.PayloadCreator.NewFromData(readJSONFromFile("./test_data/v1/request-findy.json")),
+		Receiver: mockReceiver,
+		Address:  endpoint,
+	})
+	comm.SendAndWaitReq = sendAndWaitHTTPRequest
+	assert.Error(err)
+
+	key := psm.StateKey{DID: theirDID.Did(), Nonce: endpointConnID}
+	m := try.To1(psm.GetPSM(key))
+	assert.Equal(m.LastState().Sub, psm.Sending)
+	pwr := try.To1(getPairwiseRep(key))
+	assert.SNotEmpty(pwr.Pending)
+
+	// after the restart the connection's pipe is loaded from the storage
+	restarted := NewMockReceiverMock(ctrl)
+	restarted.EXPECT().PwPipe(endpointConnID).Return(sec.Pipe{In: theirDID, Out: outDID}, nil)
+
+	n, err := ResumeHandshakes(func(DID string) comm.Receiver {
+		assert.Equal(DID, theirDID.Did())
+		return restarted
+	})
+	assert.NoError(err)
+	assert.Equal(n, 1)
+
+	pipe := sec.Pipe{In: ourDID, Out: theirDID}
+	unpacked, _, _ := pipe.Unpack(httpPayload)
+	httpPayload = []byte{}
+	responsePl := aries.PayloadCreator.NewFromData(unpacked)
+	assert.Equal(responsePl.Type(), pltype.DIDOrgAriesDIDExchangeResponse)
+	assert.Equal(responsePl.ThreadID(), endpointConnID)
+	assert.NoError(responsePl.FieldObj().(didexchange.PwMsg).Verify(theirDID))
+
+	m = try.To1(psm.GetPSM(key))
+	assert.That(m.IsReady(), "handshake not ready: %s", m.LastState().Sub)
+	pwr = try.To1(getPairwiseRep(key))
+	assert.SLen(pwr.Pending, 0)
+
+	// nothing left to resume
+	n, err = ResumeHandshakes(func(string) comm.Receiver { return restarted })
+	assert.NoError(err)
+	assert.Equal(n, 0)
+}
+
+func TestConnectionInvitor_Expired(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	theirAgent := createAgent("their-expired")
+	theirDID := try.To1(theirAgent.NewDID(method.TypeSov, ""))
+
+	// our invitation of the connection has expired before the request
+	store := theirAgent.StorageH.Storage().ConnectionStorage()
+	try.To(store.SaveConnection(storage.Connection{
+		ID:                endpointConnID,
+		MyDID:             theirDID.Did(),
+		InvitationExpires: time.Now().Add(-time.Minute).Unix(),
+	}))
+
+	mockReceiver := NewMockReceiverMock(ctrl)
+	mockReceiver.EXPECT().MyDID().AnyTimes().Return(theirDID)
+	mockReceiver.EXPECT().ManagedWallet().AnyTimes().Return(theirAgent.WalletH, theirAgent.StorageH)
+
+	err := handleConnectionRequest(comm.Packet{
+		Payload:  aries.PayloadCreator.NewFromData(readJSONFromFile("./test_data/v1/request-findy.json")),
+		Receiver: mockReceiver,
+		Address:  endpoint,
+	})
+	assert.That(errors.Is(err, didexchange.ErrInvitationExpired))
+
+	// the request is handled before the expiry
+	assert.NoError(checkInvitationExpiry(mockReceiver, endpointConnID,
+		time.Now().Add(-2*time.Minute)))
+}
+
+func TestCreateConnectionTask_Expiry(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	inv := try.To1(invitation.Translate(
+		string(readJSONFromFile("./test_data/v0/invitation-findy.json"))))
+	newTask := func(invStr string) (comm.Task, error) {
+		return createConnectionTask(
+			&comm.TaskHeader{TypeID: pltype.CAPairwiseCreate, Method: method.TypeSov},
+			&v1.Protocol{
+				StartMsg: &v1.Protocol_DIDExchange{
+					DIDExchange: &v1.Protocol_DIDExchangeMsg{
+						InvitationJSON: invStr,
+					},
+				},
+			})
+	}
+
+	_, expired := try.To2(didexchange.WithExpiry(inv, time.Now().Add(-time.Minute)))
+	_, err := newTask(expired)
+	assert.That(errors.Is(err, didexchange.ErrInvitationExpired))
+
+	_, valid := try.To2(didexchange.WithExpiry(inv, time.Now().Add(time.Hour)))
+	task, err := newTask(valid)
+	assert.NoError(err)
+	assert.Equal(task.ID(), inv.ID())
+}
+
+// Simulates requestor role when the invitor is behind a mediator
+func TestInvitationPipe_Mediated(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const didMethod = method.TypeSov
+	ourAgent := createAgent("our-mediated")
+	theirAgent := createAgent("their-mediated")
+	mediatorAgent := createAgent("mediator-mediated")
+
+	ourDID := try.To1(ourAgent.NewDID(didMethod, "000000000000000000000000Steward1"))
+	theirDID := try.To1(theirAgent.NewDID(didMethod, "000000000000000000000000Steward2"))
+	mediatorDID := try.To1(mediatorAgent.NewDID(didMethod, "000000000000000000000000Trustee1"))
+
+	invJSON := try.To1(json.Marshal(invitation.V0{
+		Type:            pltype.AriesConnectionInvitation,
+		ID:              endpointConnID,
+		Label:           "mediated",
+		ServiceEndpoint: "http://mediator.example.com",
+		RecipientKeys:   []string{theirDID.VerKey()},
+		RoutingKeys:     []string{mediatorDID.VerKey()},
+	}))
+	task := try.To1(createConnectionTask(
+		&comm.TaskHeader{TypeID: pltype.CAPairwiseCreate, Method: didMethod},
+		&v1.Protocol{
+			StartMsg: &v1.Protocol_DIDExchange{
+				DIDExchange: &v1.Protocol_DIDExchangeMsg{
+					InvitationJSON: string(invJSON),
+				},
+			},
+		},
+	))
+	deTask := task.(*taskDIDExchange)
+	deTask.SetReceiverEndp(service.Addr{
+		Endp: deTask.Invitation.Services()[0].ServiceEndpoint,
+		Key:  deTask.Invitation.Services()[0].RecipientKeysAsB58()[0],
+	})
+
+	mockReceiver := NewMockReceiverMock(ctrl)
+	mockReceiver.EXPECT().NewOutDID(didMethod.DIDString(), theirDID.VerKey(),
+		mediatorDID.VerKey()).Return(ourAgent.NewOutDID(didMethod.DIDString(),
+		theirDID.VerKey(), mediatorDID.VerKey()))
+
+	pipe := try.To1(invitationPipe(mockReceiver, deTask, ourDID))
+	assert.SLen(pipe.Out.Route(), 1)
+
+	msg := []byte(`{"@type":"test","content":"through the mediator"}`)
+	packed, _, err := pipe.Pack(msg)
+	assert.NoError(err)
+
+	// the mediator can open only the forward message
+	fwdData, _, err := sec.Pipe{In: mediatorDID, Out: ourDID}.Unpack(packed)
+	assert.NoError(err)
+	var fwd common.Forward
+	assert.NoError(json.Unmarshal(fwdData, &fwd))
+	assert.Equal(fwd.Type, pltype.RoutingForward)
+	assert.Equal(fwd.To, theirDID.VerKey())
+
+	// .. which it forwards to the invitor
+	unpacked, _, err := sec.Pipe{In: theirDID, Out: ourDID}.Unpack(
+		try.To1(json.Marshal(fwd.Msg)))
+	assert.NoError(err)
+	assert.Equal(string(unpacked), string(msg))
+}
+
+// Simulates both roles of the DID Exchange 1.1 handshake where the requester
+// uses a peer DID and the invitor rotates the invitation key in the response
+func TestConnection_DIDExchange11(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ourAgent := createAgent("our-v11")
+	theirAgent := createAgent("their-v11")
+
+	ourDID := try.To1(ourAgent.NewDID(method.TypePeer, endpointStr))
+	theirDID := try.To1(theirAgent.NewDID(method.TypeSov, "000000000000000000000000Steward2"))
+	theirDID.SetAEndp(service.Addr{Endp: "http://example.com", Key: theirDID.VerKey()})
+
+	// 1. the requester sends the 1.1 request with its peer DID
+	task := try.To1(createConnectionTask(
+		&comm.TaskHeader{TypeID: pltype.CAPairwiseCreate, Method: method.TypeSov},
+		&v1.Protocol{
+			StartMsg: &v1.Protocol_DIDExchange{
+				DIDExchange: &v1.Protocol_DIDExchangeMsg{
+					InvitationJSON: string(readJSONFromFile("./test_data/v1/invitation-findy-11.json")),
+				},
+			},
+		},
+	))
+	requester := NewMockReceiverMock(ctrl)
+	requester.EXPECT().CAEndp(task.ID()).Return(endpoint)
+	requester.EXPECT().WDID().Return(ourDID.Did())
+	requester.EXPECT().WorkerEA().Return(requester)
+	requester.EXPECT().NewDID(method.TypePeer, endpointStr).Return(ourDID, nil)
+	requester.EXPECT().NewOutDID(method.TypeSov.DIDString(), theirDID.VerKey()).Return(
+		ourAgent.NewOutDID(method.TypeSov.DIDString(), theirDID.VerKey()))
+	requester.EXPECT().AddPipeToPWMap(gomock.Any(), gomock.Any()).Return()
+
+	startConnectionProtocol(requester, task)
+
+	// the request goes to the invitation key without the sender
+	assert.Equal(packAlg(httpPayload), "Anoncrypt")
+	unpacked, _, err := sec.Pipe{In: theirDID, Out: ourDID}.Unpack(httpPayload)
+	assert.NoError(err)
+	httpPayload = []byte{}
+	requestPl := aries.PayloadCreator.NewFromData(unpacked)
+	assert.Equal(requestPl.Type(), pltype.DIDOrgAriesDIDExchangeRequest11)
+	assert.Equal(requestPl.ThreadID(), endpointConnID)
+	assert.Equal(requestPl.FieldObj().(didexchange.PwMsg).Did(), ourDID.Did())
+
+	// 2. the invitor answers with the 1.1 response which has did_rotate
+	invitor := NewMockReceiverMock(ctrl)
+	invitor.EXPECT().MyDID().AnyTimes().Return(theirDID)
+	invitor.EXPECT().FindPWByID(endpointConnID).Return(&storage.Connection{
+		MyDID: theirDID.String(),
+	}, nil)
+	invitor.EXPECT().LoadDID(theirDID.String()).Return(theirDID)
+	invitor.EXPECT().NewOutDID(ourDID.Did(), gomock.Any()).DoAndReturn(
+		func(didInfo ...string) (core.DID, error) {
+			return theirAgent.NewOutDID(didInfo...)
+		})
+	invitor.EXPECT().ManagedWallet().AnyTimes().Return(theirAgent.WalletH, theirAgent.StorageH)
+	invitor.EXPECT().AddToPWMap(theirDID, gomock.Any(), endpointConnID).DoAndReturn(
+		func(me, you core.DID, _ string) sec.Pipe {
+			return sec.Pipe{In: me, Out: you}
+		})
+
+	assert.NoError(handleConnectionRequest(comm.Packet{
+		Payload:  requestPl,
+		Receiver: invitor,
+		Address:  endpoint,
+	}))
+
+	assert.Equal(packAlg(httpPayload), "Authcrypt")
+	unpacked, _, err = sec.Pipe{In: ourDID, Out: theirDID}.Unpack(httpPayload)
+	assert.NoError(err)
+	httpPayload = []byte{}
+	responsePl := aries.PayloadCreator.NewFromData(unpacked)
+	assert.Equal(responsePl.Type(), pltype.DIDOrgAriesDIDExchangeResponse11)
+	assert.Equal(responsePl.ThreadID(), endpointConnID)
+	rotateMsg, ok := responsePl.FieldObj().(didexchange.RotateMsg)
+	assert.That(ok, "response is not rotate message")
+	assert.NoError(rotateMsg.VerifyRotate(theirDID, theirDID.VerKey()))
+	assert.Error(rotateMsg.VerifyRotate(theirDID, ourDID.VerKey()))
+
+	// 3. the requester verifies the rotation and completes the handshake
+	requester.EXPECT().MyDID().AnyTimes().Return(ourDID)
+	requester.EXPECT().LoadDID(ourDID.Did()).Return(ourDID)
+	requester.EXPECT().ManagedWallet().AnyTimes().Return(ourAgent.WalletH, ourAgent.StorageH)
+	requester.EXPECT().AddToPWMap(ourDID, gomock.Any(), endpointConnID).DoAndReturn(
+		func(me, you core.DID, _ string) sec.Pipe {
+			return sec.Pipe{In: me, Out: you}
+		})
+
+	assert.NoError(handleConnectionResponse(comm.Packet{
+		Payload:  responsePl,
+		Receiver: requester,
+		Address:  endpoint,
+	}))
+
+	assert.Equal(packAlg(httpPayload), "Authcrypt")
+	unpacked, _, err = sec.Pipe{In: theirDID, Out: ourDID}.Unpack(httpPayload)
+	assert.NoError(err)
+	httpPayload = []byte{}
+	completePl := aries.PayloadCreator.NewFromData(unpacked)
+	assert.Equal(completePl.Type(), pltype.DIDOrgAriesDIDExchangeComplete11)
+	assert.Equal(completePl.ThreadID(), endpointConnID)
+}
+
+// packAlg returns the alg of the RFC 0019 envelope's protected header.
+func packAlg(packed []byte) string {
+	var env struct {
+		Protected string `json:"protected"`
+	}
+	try.To(json.Unmarshal(packed, &env))
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	protected := strings.TrimRight(env.Protected, "=")
+	try.To(json.Unmarshal(try.To1(base64.RawURLEncoding.DecodeString(protected)), &header))
+	return header.Alg
+}
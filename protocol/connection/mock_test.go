@@ -329,6 +329,21 @@ func (mr *MockReceiverMockMockRecorder) Pool() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pool", reflect.TypeOf((*MockReceiverMock)(nil).Pool))
 }
 
+// PrevMasterSecrets mocks base method.
+func (m *MockReceiverMock) PrevMasterSecrets() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrevMasterSecrets")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrevMasterSecrets indicates an expected call of PrevMasterSecrets.
+func (mr *MockReceiverMockMockRecorder) PrevMasterSecrets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrevMasterSecrets", reflect.TypeOf((*MockReceiverMock)(nil).PrevMasterSecrets))
+}
+
 // PwPipe mocks base method.
 func (m *MockReceiverMock) PwPipe(pw string) (sec.Pipe, error) {
 	m.ctrl.T.Helper()
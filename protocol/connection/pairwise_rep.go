@@ -16,6 +16,13 @@ type pairwiseRep struct {
 	TheirLabel string
 	Caller     didRep
 	Callee     didRep
+
+	// InvitationKey is the recipient key of the invitation we answered. The
+	// DID Exchange 1.1 response is signed with it.
+	InvitationKey string
+
+	// Pending is the handshake message which is being sent, see sendPending
+	Pending []byte
 }
 
 func init() {
@@ -0,0 +1,115 @@
+package connection
+
+import (
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/prot"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/sec"
+	"github.com/findy-network/findy-agent/std/didexchange"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+// sendPending sends the handshake message. The message is saved to the
+// pairwise rep until the sending succeeds. That lets ResumeHandshakes send it
+// again if the agency stops in the middle of the handshake.
+func sendPending(
+	pwr *pairwiseRep,
+	pipe sec.Pipe,
+	task comm.Task,
+	opl didcomm.Payload,
+) (err error) {
+	defer err2.Handle(&err, "send pending")
+
+	pwr.Pending = opl.JSON()
+	try.To(psm.AddRep(pwr))
+
+	try.To(comm.SendPL(pipe, task, opl))
+
+	pwr.Pending = nil
+	return psm.AddRep(pwr)
+}
+
+// ResumeHandshakes continues the connection protocols which were left in the
+// middle of sending a handshake message, e.g. because the agency was stopped.
+// The worker function returns the worker agent of the PSM's DID, or nil if the
+// agent isn't in this agency. It's meant to be called at startup when the
+// agency can receive the answers. It returns the amount of the resumed
+// handshakes.
+func ResumeHandshakes(worker func(DID string) comm.Receiver) (n int, err error) {
+	defer err2.Handle(&err, "resume handshakes")
+
+	interrupted := try.To1(psm.FindPSMs(func(m *psm.PSM) bool {
+		s := m.LastState()
+		return s != nil && s.Sub.Pure() == psm.Sending && isHandshake(m)
+	}))
+	for _, m := range interrupted {
+		wa := worker(m.Key.DID)
+		if wa == nil {
+			glog.Warningf("handshake (%s) agent not found", m.Key)
+			continue
+		}
+		if err := resumeHandshake(wa, m); err != nil {
+			glog.Warningf("handshake (%s) not resumed: %v", m.Key, err)
+			continue
+		}
+		n++
+	}
+	glog.V(1).Infoln("handshakes resumed:", n)
+	return n, nil
+}
+
+func isHandshake(m *psm.PSM) bool {
+	switch m.Protocol() {
+	case pltype.ProtocolConnection, pltype.AriesProtocolConnection,
+		pltype.AriesProtocolDIDExchange:
+		return true
+	}
+	return false
+}
+
+// resumeHandshake sends the pending message of the handshake again and moves
+// the PSM to wait the next message like the interrupted handler would have.
+func resumeHandshake(wa comm.Receiver, m *psm.PSM) (err error) {
+	defer err2.Handle(&err)
+
+	pwr := try.To1(getPairwiseRep(m.Key))
+	assert.SNotEmpty(pwr.Pending, "handshake message missing")
+	task := m.PresentTask()
+	opl := aries.PayloadCreator.NewFromData(pwr.Pending)
+
+	pipe := try.To1(resumePipe(wa, pwr, task))
+	glog.V(1).Infof("resuming handshake (%s) with %s", m.Key, opl.Type())
+	try.To(sendPending(pwr, pipe, task, opl))
+
+	pwMsg, ok := opl.FieldObj().(didexchange.PwMsg)
+	assert.That(ok, "handshake message type (%s) mismatch", opl.Type())
+	wpl, state := pwMsg.PayloadToWait()
+	return prot.UpdatePSM(m.Key.DID, m.ConnID, task, wpl, state)
+}
+
+// resumePipe returns the pipe of the connection. The connection isn't saved
+// before the response when we are the one who sends the request, and then the
+// pipe is built again from the invitation.
+func resumePipe(
+	wa comm.Receiver,
+	pwr *pairwiseRep,
+	task comm.Task,
+) (
+	_ sec.Pipe,
+	err error,
+) {
+	defer err2.Handle(&err)
+
+	if pipe, err := wa.PwPipe(pwr.Name); err == nil {
+		return pipe, nil
+	}
+	deTask, ok := task.(*taskDIDExchange)
+	assert.That(ok, "connection (%s) not found", pwr.Name)
+	return invitationPipe(wa, deTask, wa.LoadDID(pwr.Caller.DID))
+}
@@ -0,0 +1,62 @@
+package discoverfeatures
+
+import (
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+const bucketType = psm.BucketDiscoverFeatures
+
+// discoverFeaturesRep is the querier's state of the protocol. Protocols are
+// the protocol IDs disclosed by the other end.
+type discoverFeaturesRep struct {
+	psm.StateKey
+	ConnID    string
+	Query     string
+	Protocols []string
+}
+
+// connectionNonce is the key nonce of the connection's latest disclosure. It
+// cannot be mixed with the thread IDs of the protocol.
+func connectionNonce(connID string) string {
+	return "connection/" + connID
+}
+
+func init() {
+	psm.Creator.Add(bucketType, NewDiscoverFeaturesRep)
+}
+
+func NewDiscoverFeaturesRep(d []byte) psm.Rep {
+	p := &discoverFeaturesRep{}
+	dto.FromGOB(d, p)
+	return p
+}
+
+func (p *discoverFeaturesRep) Key() psm.StateKey {
+	return p.StateKey
+}
+
+func (p *discoverFeaturesRep) Data() []byte {
+	return dto.ToGOB(p)
+}
+
+func (p *discoverFeaturesRep) Type() byte {
+	return bucketType
+}
+
+func getDiscoverFeaturesRep(workerDID, taskID string) (rep *discoverFeaturesRep, err error) {
+	defer err2.Handle(&err)
+
+	res := try.To1(psm.GetRep(bucketType, psm.StateKey{
+		DID:   workerDID,
+		Nonce: taskID,
+	}))
+
+	dfRep, ok := res.(*discoverFeaturesRep)
+	assert.That(ok, "discover features type mismatch")
+
+	return dfRep, nil
+}
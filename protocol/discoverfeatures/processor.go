@@ -0,0 +1,208 @@
+// Package discoverfeatures implements the Aries Discover Features protocol.
+// The agent answers queries with the protocols it has processors for, and it
+// can query the same from its connections.
+package discoverfeatures
+
+import (
+	"encoding/gob"
+	"slices"
+	"strings"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/prot"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/std/discoverfeatures"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+type taskDiscoverFeatures struct {
+	comm.TaskBase
+	Query string
+}
+
+var discoverFeaturesProcessor = comm.ProtProc{
+	Creator: createDiscoverFeaturesTask,
+	Starter: startDiscoverFeatures,
+	Handlers: map[string]comm.HandlerFunc{
+		pltype.HandlerQuery:    handleQuery,
+		pltype.HandlerDisclose: handleDisclose,
+	},
+	FillStatus: fillDiscoverFeaturesStatus,
+}
+
+func init() {
+	gob.Register(&taskDiscoverFeatures{})
+	prot.AddCreator(pltype.ProtocolDiscoverFeatures, discoverFeaturesProcessor)
+	prot.AddStarter(pltype.CADiscoverFeatures, discoverFeaturesProcessor)
+	prot.AddStatusProvider(pltype.ProtocolDiscoverFeatures, discoverFeaturesProcessor)
+	comm.Proc.Add(pltype.ProtocolDiscoverFeatures, discoverFeaturesProcessor)
+}
+
+// Query starts the protocol by sending the query to the connection. The
+// disclosed protocols can be read with Disclosed when the protocol is ready.
+func Query(receiver comm.Receiver, connID, query string) (protocolID string, err error) {
+	defer err2.Handle(&err, "discover features")
+
+	assert.NotEmpty(connID, "connection ID missing")
+	if query == "" {
+		query = pltype.DIDOrgAries + "/*"
+	}
+	task := &taskDiscoverFeatures{
+		TaskBase: comm.TaskBase{TaskHeader: comm.TaskHeader{
+			TaskID:       utils.UUID(),
+			TypeID:       pltype.CADiscoverFeatures,
+			ProtocolRole: pb.Protocol_INITIATOR,
+			ConnID:       connID,
+			Method:       utils.Settings.DIDMethod(),
+		}},
+		Query: query,
+	}
+	try.To(prot.FindAndStartTask(receiver, task))
+	return task.ID(), nil
+}
+
+// Disclosed returns the protocol IDs the other end disclosed in the protocol.
+func Disclosed(receiver comm.Receiver, protocolID string) (pids []string, err error) {
+	defer err2.Handle(&err, "disclosed features")
+
+	rep := try.To1(getDiscoverFeaturesRep(receiver.WDID(), protocolID))
+	return rep.Protocols, nil
+}
+
+// Protocols returns the IDs of the protocols we implement which match the
+// query. A query ending with '*' matches all the IDs with the same prefix. The
+// IDs are in the query's namespace when it uses the legacy Aries one.
+func Protocols(query string) []string {
+	ns := pltype.DIDOrgAries
+	if strings.HasPrefix(query, pltype.Aries) {
+		ns = pltype.Aries
+	}
+	prefix, wildcard := strings.CutSuffix(query, "*")
+
+	var pids []string
+	for _, protocol := range comm.Proc.Protocols() {
+		for _, version := range comm.Proc.Versions(protocol) {
+			pid := ns + "/" + protocol + "/" + version
+			if pid == query || (wildcard && strings.HasPrefix(pid, prefix)) {
+				pids = append(pids, pid)
+			}
+		}
+	}
+	return pids
+}
+
+// Supports tells if the connection has disclosed the protocol ID in the
+// latest discover features protocol we have run with it. The protocol IDs are
+// in the didcomm.org namespace, e.g. https://didcomm.org/present-proof/2.0.
+func Supports(receiver comm.Receiver, connID, pid string) bool {
+	rep, err := getDiscoverFeaturesRep(receiver.WDID(), connectionNonce(connID))
+	if err != nil {
+		return false
+	}
+	return slices.Contains(rep.Protocols, pid)
+}
+
+func createDiscoverFeaturesTask(header *comm.TaskHeader, _ *pb.Protocol) (t comm.Task, err error) {
+	defer err2.Handle(&err, "createDiscoverFeaturesTask")
+
+	glog.V(1).Infof("Create task for DiscoverFeatures with connection id %s", header.ConnID)
+
+	return &taskDiscoverFeatures{
+		TaskBase: comm.TaskBase{TaskHeader: *header},
+	}, nil
+}
+
+func startDiscoverFeatures(ca comm.Receiver, t comm.Task) {
+	defer err2.Catch()
+
+	try.To(prot.StartPSM(prot.Initial{
+		SendNext:    pltype.DiscoverFeaturesQuery,
+		WaitingNext: pltype.DiscoverFeaturesDisclose,
+		Ca:          ca,
+		T:           t,
+		Setup: func(key psm.StateKey, om didcomm.MessageHdr) (err error) {
+			defer err2.Handle(&err)
+
+			dfTask, ok := t.(*taskDiscoverFeatures)
+			assert.That(ok)
+
+			try.To(psm.AddRep(&discoverFeaturesRep{
+				StateKey: key,
+				ConnID:   dfTask.ConnectionID(),
+				Query:    dfTask.Query,
+			}))
+
+			msg := om.FieldObj().(*discoverfeatures.Query)
+			msg.Query = dfTask.Query
+			return nil
+		},
+	}))
+}
+
+func handleQuery(packet comm.Packet) (err error) {
+	return prot.ExecPSM(prot.Transition{
+		Packet:      packet,
+		SendNext:    pltype.DiscoverFeaturesDisclose,
+		WaitingNext: pltype.Terminate,
+		InOut: func(_ string, im, om didcomm.MessageHdr) (ack bool, err error) {
+			defer err2.Handle(&err, "discover features query")
+
+			query := im.FieldObj().(*discoverfeatures.Query)
+			pids := Protocols(query.Query)
+			glog.V(3).Infof("-- query '%s' matches %d protocols", query.Query, len(pids))
+
+			disclose := om.FieldObj().(*discoverfeatures.Disclose)
+			disclose.Protocols = make([]discoverfeatures.Protocol, len(pids))
+			for i, pid := range pids {
+				disclose.Protocols[i] = discoverfeatures.Protocol{PID: pid}
+			}
+			return true, nil
+		},
+	})
+}
+
+func handleDisclose(packet comm.Packet) (err error) {
+	return prot.ExecPSM(prot.Transition{
+		Packet:      packet,
+		SendNext:    pltype.Terminate,
+		WaitingNext: pltype.Terminate,
+		InOut: func(_ string, im, _ didcomm.MessageHdr) (ack bool, err error) {
+			defer err2.Handle(&err, "discover features disclose")
+
+			key := psm.StateKey{
+				DID:   packet.Receiver.MyDID().Did(),
+				Nonce: im.Thread().ID,
+			}
+			rep := try.To1(getDiscoverFeaturesRep(key.DID, key.Nonce))
+
+			disclose := im.FieldObj().(*discoverfeatures.Disclose)
+			rep.Protocols = make([]string, len(disclose.Protocols))
+			for i, p := range disclose.Protocols {
+				rep.Protocols[i] = p.PID
+			}
+			try.To(psm.AddRep(rep))
+
+			// the latest disclosure of the connection is for Supports
+			if rep.ConnID != "" {
+				connRep := *rep
+				connRep.Nonce = connectionNonce(rep.ConnID)
+				try.To(psm.AddRep(&connRep))
+			}
+			return true, nil
+		},
+	})
+}
+
+// fillDiscoverFeaturesStatus leaves the status as is, because there isn't a
+// status type for the protocol in the gRPC API. Use Disclosed instead.
+func fillDiscoverFeaturesStatus(_ string, _ string, ps *pb.ProtocolStatus) *pb.ProtocolStatus {
+	assert.That(ps != nil)
+	return ps
+}
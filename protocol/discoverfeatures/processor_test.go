@@ -0,0 +1,55 @@
+package discoverfeatures_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/protocol/discoverfeatures"
+	_ "github.com/findy-network/findy-agent/protocol/issuecredential"
+	_ "github.com/findy-network/findy-agent/protocol/presentproof"
+	"github.com/lainio/err2/assert"
+)
+
+func TestProtocols(t *testing.T) {
+	const (
+		presentProof    = pltype.DIDOrgAries + "/present-proof/1.0"
+		presentProofV2  = pltype.DIDOrgAries + "/present-proof/2.0"
+		issueCredential = pltype.DIDOrgAries + "/issue-credential/1.0"
+		issueCredV2     = pltype.DIDOrgAries + "/issue-credential/2.0"
+		discover        = pltype.DIDOrgAries + "/discover-features/1.0"
+	)
+	tests := []struct {
+		name     string
+		query    string
+		want     []string
+		wantNone []string
+	}{
+		{"all", pltype.DIDOrgAries + "/*",
+			[]string{presentProof, presentProofV2, issueCredential, issueCredV2, discover}, nil},
+		{"prefix", pltype.DIDOrgAries + "/issue*",
+			[]string{issueCredential, issueCredV2}, []string{presentProof, discover}},
+		{"exact", presentProof,
+			[]string{presentProof}, []string{presentProofV2, issueCredential, discover}},
+		{"version", presentProofV2,
+			[]string{presentProofV2}, []string{presentProof}},
+		{"legacy namespace", pltype.Aries + "/present-proof/*",
+			[]string{pltype.Aries + "/present-proof/1.0"}, []string{presentProof, presentProofV2}},
+		{"unknown", pltype.DIDOrgAries + "/unknown/1.0",
+			nil, []string{presentProof, issueCredential, discover}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			pids := discoverfeatures.Protocols(tt.query)
+			for _, pid := range tt.want {
+				assert.That(slices.Contains(pids, pid), "%s missing", pid)
+			}
+			for _, pid := range tt.wantNone {
+				assert.ThatNot(slices.Contains(pids, pid), "%s disclosed", pid)
+			}
+		})
+	}
+}
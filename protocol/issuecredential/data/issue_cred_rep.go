@@ -1,13 +1,17 @@
 package data
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/didcomm"
 	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/storage/api"
+	"github.com/findy-network/findy-agent/agent/vc"
 	"github.com/findy-network/findy-common-go/dto"
 	"github.com/findy-network/findy-wrapper-go"
 	"github.com/findy-network/findy-wrapper-go/anoncreds"
-	"github.com/findy-network/findy-wrapper-go/ledger"
 	"github.com/lainio/err2"
 	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
@@ -24,6 +28,24 @@ type IssueCredRep struct {
 	CredReqMeta string
 	Values      string
 	Attributes  []didcomm.CredentialAttribute
+
+	// NotBefore and NotAfter are the credential validity window (Unix
+	// seconds) parsed from the reserved attributes. Nil means not set.
+	NotBefore *int64
+	NotAfter  *int64
+
+	// Tag is the holder's category for the received credential, and CredID
+	// is the ID given by the wallet when the credential is stored.
+	Tag    string
+	CredID string
+
+	// V2 tells that the protocol is run with the 2.0 messages.
+	V2 bool
+
+	// OfferExpiry is the PSM timestamp after which the issuer doesn't wait
+	// the holder to answer the offer anymore. Zero means never.
+	OfferExpiry  int64
+	OfferExpired bool
 }
 
 func init() {
@@ -48,6 +70,20 @@ func (rep *IssueCredRep) Type() byte {
 	return bucketType
 }
 
+// SetOfferValidity sets the offer to expire after the duration from now by the
+// PSM clock. Zero means that the offer never expires.
+func (rep *IssueCredRep) SetOfferValidity(validFor time.Duration) {
+	rep.OfferExpiry = 0
+	if validFor != 0 {
+		rep.OfferExpiry = psm.Now().Add(validFor).UnixNano()
+	}
+}
+
+// OfferExpiredAt tells if the offer is expired at the PSM timestamp.
+func (rep *IssueCredRep) OfferExpiredAt(ts int64) bool {
+	return rep.OfferExpired || rep.OfferExpiry != 0 && ts > rep.OfferExpiry
+}
+
 // BuildCredRequest builds credential request which is PROVER/HOLDER SIDE
 // action.
 func (rep *IssueCredRep) BuildCredRequest(packet comm.Packet) (cr string, err error) {
@@ -58,7 +94,7 @@ func (rep *IssueCredRep) BuildCredRequest(packet comm.Packet) (cr string, err er
 	masterSecID := try.To1(a.MasterSecret())
 
 	// Get CRED DEF from the ledger
-	_, rep.CredDef = try.To2(ledger.ReadCredDef(a.Pool(), a.RootDid().Did(), rep.CredDefID))
+	rep.CredDef = try.To1(vc.CredDefFromLedger(a.Pool(), a.RootDid().Did(), rep.CredDefID))
 
 	defer err2.Handle(&err, "build request from cred def ID: %v", rep.CredDefID)
 
@@ -87,11 +123,62 @@ func (rep *IssueCredRep) IssuerBuildCred(packet comm.Packet, credReq string) (c
 }
 
 // StoreCred saves the credential to wallet which is prover/holder side action.
-func (rep *IssueCredRep) StoreCred(packet comm.Packet, cred string) error {
+// The credential's metadata including the Tag is saved to agent storage.
+func (rep *IssueCredRep) StoreCred(packet comm.Packet, cred string) (err error) {
+	defer err2.Handle(&err, "store cred")
+
 	a := packet.Receiver
 	w := a.Wallet()
 	r := <-anoncreds.ProverStoreCredential(w, findy.NullString, rep.CredReqMeta, cred, rep.CredDef, findy.NullString)
-	return r.Err()
+	try.To(r.Err())
+	rep.CredID = r.Str1()
+
+	_, storage := a.ManagedWallet()
+	try.To(storage.Storage().CredentialStorage().SaveCredential(
+		rep.credential(cred)))
+	return nil
+}
+
+// credential builds the holder side metadata of the stored credential.
+func (rep *IssueCredRep) credential(cred string) api.Credential {
+	var meta struct {
+		MasterSecret string `json:"master_secret_name"`
+	}
+	dto.FromJSONStr(rep.CredReqMeta, &meta)
+	var ids struct {
+		SchemaID  string `json:"schema_id"`
+		RevRegID  string `json:"rev_reg_id"`
+		Signature struct {
+			RCredential *struct {
+				I uint32 `json:"i"`
+			} `json:"r_credential"`
+		} `json:"signature"`
+	}
+	dto.FromJSONStr(cred, &ids)
+	var credRevID string
+	if ids.RevRegID != "" && ids.Signature.RCredential != nil {
+		credRevID = strconv.FormatUint(uint64(ids.Signature.RCredential.I), 10)
+	}
+
+	attrs := make([]api.CredentialAttribute, len(rep.Attributes))
+	for i, attr := range rep.Attributes {
+		attrs[i] = api.CredentialAttribute{
+			Name:     attr.Name,
+			Value:    attr.Value,
+			MimeType: attr.MimeType,
+			DataType: attr.DataType,
+		}
+	}
+	return api.Credential{
+		ID:           rep.CredID,
+		CredDefID:    rep.CredDefID,
+		SchemaID:     ids.SchemaID,
+		RevRegID:     ids.RevRegID,
+		Tag:          rep.Tag,
+		Attributes:   attrs,
+		CredRevID:    credRevID,
+		MasterSecret: meta.MasterSecret,
+	}
 }
 
 func GetIssueCredRep(key psm.StateKey) (rep *IssueCredRep, err error) {
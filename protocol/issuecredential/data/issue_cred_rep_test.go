@@ -0,0 +1,39 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/lainio/err2/assert"
+)
+
+func TestIssueCredRep_credential(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	rep := &IssueCredRep{
+		CredDefID:   "cred-def-id",
+		CredID:      "cred-id",
+		Tag:         "tag",
+		CredReqMeta: `{"master_secret_name":"master-sec"}`,
+		Attributes: []didcomm.CredentialAttribute{
+			{Name: "name", Value: "Alice", MimeType: "text/plain"},
+			{Name: "age", Value: "24", DataType: "integer"},
+		},
+	}
+	cred := rep.credential(`{"schema_id":"schema-id","rev_reg_id":""}`)
+
+	assert.Equal(cred.ID, "cred-id")
+	assert.Equal(cred.SchemaID, "schema-id")
+	assert.SLen(cred.Attributes, 2)
+	assert.Equal(cred.Attributes[0].MimeType, "text/plain")
+	assert.Equal(cred.Attributes[1].DataType, "integer")
+	assert.Empty(cred.CredRevID)
+	assert.Equal(cred.MasterSecret, "master-sec")
+
+	// the revocation index is read from the revocation signature
+	cred = rep.credential(`{"schema_id":"schema-id","rev_reg_id":"rev-reg-id",
+		"signature":{"p_credential":{},"r_credential":{"i":7}}}`)
+	assert.Equal(cred.RevRegID, "rev-reg-id")
+	assert.Equal(cred.CredRevID, "7")
+}
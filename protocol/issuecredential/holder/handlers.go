@@ -33,18 +33,22 @@ func HandleCredentialOffer(packet comm.Packet) (err error) {
 
 	// Do we have a Rep?
 	if rep == nil {
-		rep := &data.IssueCredRep{
+		rep = &data.IssueCredRep{
 			StateKey: key,
 		}
-		try.To(psm.AddRep(rep))
 	}
+	// we answer with the same version the issuer uses
+	v2 := issuecredential.IsV2(packet.Payload.Type())
+	rep.V2 = v2
+	try.To(psm.AddRep(rep))
 
-	sendNext, waitingNext := checkAutoPermission(packet)
+	sendNext, waitingNext := checkAutoPermission(packet, v2)
 
 	return prot.ExecPSM(prot.Transition{
 		Packet:      packet,
 		SendNext:    sendNext,
 		WaitingNext: waitingNext,
+		SendOnNACK:  issuecredential.Versioned(pltype.IssueCredentialNACK, v2),
 		TaskHeader:  &comm.TaskHeader{UserActionPLType: pltype.CANotifyUserAction},
 		InOut: func(_ string, im, om didcomm.MessageHdr) (ack bool, err error) {
 			defer err2.Handle(&err, "cred offer ask user (%v)",
@@ -75,8 +79,7 @@ func HandleCredentialOffer(packet comm.Packet) (err error) {
 			req, autoAccept := om.FieldObj().(*issuecredential.Request)
 			if autoAccept {
 				credRq := try.To1(rep.BuildCredRequest(packet))
-				req.RequestsAttach =
-					issuecredential.NewRequestAttach([]byte(credRq))
+				issuecredential.SetRequestAttach(req, []byte(credRq))
 			}
 
 			// Save the rep with the offer and with the request if
@@ -88,6 +91,10 @@ func HandleCredentialOffer(packet comm.Packet) (err error) {
 	})
 }
 
+// continuePSM continues the protocol after the user action, which tests can
+// replace.
+var continuePSM = prot.ContinuePSM
+
 // todo lapi: im message is old legacy api type!!
 
 // userActionCredential is called when Holder has received a Cred_Offer and it's
@@ -95,12 +102,22 @@ func HandleCredentialOffer(packet comm.Packet) (err error) {
 func UserActionCredential(ca comm.Receiver, im didcomm.Msg) {
 	defer err2.Catch()
 
-	try.To(prot.ContinuePSM(prot.Again{
+	repK := psm.NewStateKey(ca, im.Thread().ID)
+	rep := try.To1(data.GetIssueCredRep(repK))
+	v2 := rep.V2
+
+	// the user can tag the credential she accepts, the tag is saved with it
+	if im.Ready() && im.Info() != "" {
+		rep.Tag = im.Info()
+		try.To(psm.AddRep(rep))
+	}
+
+	try.To(continuePSM(prot.Again{
 		CA:          ca,
 		InMsg:       im,
-		SendNext:    pltype.IssueCredentialRequest,
-		WaitingNext: pltype.IssueCredentialACK,
-		SendOnNACK:  pltype.IssueCredentialNACK,
+		SendNext:    issuecredential.Versioned(pltype.IssueCredentialRequest, v2),
+		WaitingNext: issuecredential.Versioned(pltype.IssueCredentialACK, v2),
+		SendOnNACK:  issuecredential.Versioned(pltype.IssueCredentialNACK, v2),
 		Transfer: func(wa comm.Receiver, im, om didcomm.MessageHdr) (ack bool, err error) {
 			defer err2.Handle(&err, "issuing user action handler")
 
@@ -120,18 +137,17 @@ func UserActionCredential(ca comm.Receiver, im didcomm.Msg) {
 
 			try.To(psm.AddRep(rep))
 			req := om.FieldObj().(*issuecredential.Request)
-			req.RequestsAttach =
-				issuecredential.NewRequestAttach([]byte(credRq))
+			issuecredential.SetRequestAttach(req, []byte(credRq))
 
 			return true, nil
 		},
 	}))
 }
 
-func checkAutoPermission(packet comm.Packet) (next string, wait string) {
-	if packet.Receiver.AutoPermission() {
-		next = pltype.IssueCredentialRequest
-		wait = pltype.IssueCredentialIssue
+func checkAutoPermission(packet comm.Packet, v2 bool) (next string, wait string) {
+	if comm.ConnectionAutoAccept(packet.Receiver, packet.Address.ConnID, "") {
+		next = issuecredential.Versioned(pltype.IssueCredentialRequest, v2)
+		wait = issuecredential.Versioned(pltype.IssueCredentialIssue, v2)
 	} else {
 		next = pltype.Nothing
 		wait = pltype.IssueCredentialUserAction
@@ -141,9 +157,10 @@ func checkAutoPermission(packet comm.Packet) (next string, wait string) {
 
 // HandleCredentialIssue is protocol function for CRED_ISSUE for prover/holder.
 func HandleCredentialIssue(packet comm.Packet) (err error) {
+	v2 := issuecredential.IsV2(packet.Payload.Type())
 	return prot.ExecPSM(prot.Transition{
 		Packet:      packet,
-		SendNext:    pltype.IssueCredentialACK,
+		SendNext:    issuecredential.Versioned(pltype.IssueCredentialACK, v2),
 		WaitingNext: pltype.Terminate, // no next state, we are fine
 
 		InOut: func(_ string, im, om didcomm.MessageHdr) (ack bool, err error) {
@@ -156,6 +173,7 @@ func HandleCredentialIssue(packet comm.Packet) (err error) {
 			rep := try.To1(data.GetIssueCredRep(repK))
 			cred := try.To1(issuecredential.CredentialAttach(issue))
 			try.To(rep.StoreCred(packet, string(cred)))
+			try.To(psm.AddRep(rep))
 
 			outAck := om.FieldObj().(*common.Ack)
 			outAck.Status = "OK"
@@ -0,0 +1,71 @@
+package holder
+
+import (
+	"os"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/prot"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/ssi"
+	"github.com/findy-network/findy-agent/core"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/data"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+func TestMain(m *testing.M) {
+	try.To(psm.Open("MEMORY_holder_data.bolt"))
+	code := m.Run()
+	psm.Close()
+	os.Exit(code)
+}
+
+type holderRcvr struct {
+	comm.Receiver
+}
+
+func (r *holderRcvr) MyDID() core.DID {
+	return ssi.NewDid("holderDID", "verkey")
+}
+
+func TestUserActionCredential_Tag(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	continued := 0
+	defer func(f func(prot.Again) error) { continuePSM = f }(continuePSM)
+	continuePSM = func(prot.Again) error {
+		continued++
+		return nil
+	}
+
+	wa := &holderRcvr{}
+	key := psm.NewStateKey(wa, "offer-id")
+	assert.NoError(psm.AddRep(&data.IssueCredRep{StateKey: key}))
+
+	UserActionCredential(wa, aries.MsgCreator.Create(didcomm.MsgInit{
+		Ready: true,
+		Info:  "work",
+		ID:    "offer-id",
+		Nonce: "offer-id",
+	}).(didcomm.Msg))
+	assert.Equal(continued, 1)
+	rep, err := data.GetIssueCredRep(key)
+	assert.NoError(err)
+	assert.Equal(rep.Tag, "work")
+
+	// declined credentials aren't tagged
+	assert.NoError(psm.AddRep(&data.IssueCredRep{StateKey: key}))
+	UserActionCredential(wa, aries.MsgCreator.Create(didcomm.MsgInit{
+		Info:  "home",
+		ID:    "offer-id",
+		Nonce: "offer-id",
+	}).(didcomm.Msg))
+	assert.Equal(continued, 2)
+	rep, err = data.GetIssueCredRep(key)
+	assert.NoError(err)
+	assert.Equal(rep.Tag, "")
+}
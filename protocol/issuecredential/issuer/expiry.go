@@ -0,0 +1,115 @@
+package issuer
+
+import (
+	"strings"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/prot"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/data"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/golang/glog"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+// OfferExpiredCode is the problem report code sent to the holder when the
+// credential offer expires.
+const OfferExpiredCode = "offer-expired"
+
+// sendOrQueuePL is the sender of the expiry notifications, which tests can
+// replace.
+var sendOrQueuePL = comm.SendOrQueuePL
+
+// ExpireOffers fails the issuer's protocols whose credential offers the
+// holders haven't answered in time, see utils.Settings.CredOfferTTL. The
+// holders are notified if utils.Settings.CredOfferNotify is set. It's meant to be called periodically by
+// the scheduler.
+func ExpireOffers() {
+	defer err2.Catch(err2.Err(func(err error) {
+		glog.Errorln("expire credential offers:", err)
+	}))
+
+	n := try.To1(expireOffers(psm.Timestamp()))
+	if n > 0 {
+		glog.V(1).Infoln("credential offers expired:", n)
+	}
+}
+
+func expireOffers(now int64) (n int, err error) {
+	defer err2.Handle(&err)
+
+	waiting := try.To1(psm.FindPSMs(waitsCredRequest))
+	for _, m := range waiting {
+		rep := try.To1(data.GetIssueCredRep(m.Key))
+		if rep == nil || !rep.OfferExpiredAt(now) {
+			continue
+		}
+		if err := expireOffer(m, rep); err != nil {
+			glog.Errorf("expire credential offer (%s): %v", m.Key.Nonce, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// waitsCredRequest tells if the PSM is issuer's protocol waiting the holder
+// to answer the offer.
+func waitsCredRequest(m *psm.PSM) bool {
+	s := m.LastState()
+	return s != nil &&
+		m.Protocol() == pltype.ProtocolIssueCredential &&
+		s.Sub.Pure() == psm.Waiting &&
+		m.Next() == pltype.HandlerIssueCredentialRequest
+}
+
+func expireOffer(m *psm.PSM, rep *data.IssueCredRep) (err error) {
+	defer err2.Handle(&err)
+
+	glog.V(1).Infoln("credential offer expired:", m.Key.Nonce)
+	rep.OfferExpired = true
+	try.To(psm.AddRep(rep))
+
+	task := m.PresentTask()
+	if utils.Settings.CredOfferNotify() {
+		// the protocol fails anyway, the holder just isn't told about it
+		if err := notifyOfferExpired(m, task); err != nil {
+			glog.Warningf("notify holder of expired offer (%s): %v",
+				m.Key.Nonce, err)
+		}
+	}
+	opl := aries.PayloadCreator.New(didcomm.PayloadInit{
+		ID:   task.ID(),
+		Type: m.LastState().PLInfo.Type,
+	})
+	return prot.UpdatePSM(m.Key.DID, m.ConnID, task, opl, psm.Failure)
+}
+
+// notifyOfferExpired sends the problem report threaded to the protocol to the
+// holder.
+func notifyOfferExpired(m *psm.PSM, task comm.Task) (err error) {
+	defer err2.Handle(&err)
+
+	rcvr := comm.ActiveRcvrs.Get(m.Key.DID)
+	assert.That(rcvr != nil, "agent (%s) not active", m.Key.DID)
+	pipe := try.To1(rcvr.PwPipe(m.ConnID))
+	task.SetReceiverEndp(try.To1(pipe.EA()))
+
+	reportType := pltype.NotificationProblemReport
+	if strings.HasPrefix(m.FirstState().PLInfo.Type, pltype.DIDOrgAries) {
+		reportType = pltype.DIDOrgNotificationProblemReport
+	}
+	msg := aries.MsgCreator.Create(didcomm.MsgInit{
+		Type:   reportType,
+		Info:   OfferExpiredCode,
+		Thread: decorator.NewThread(task.ID(), ""),
+	})
+	opl := aries.PayloadCreator.NewMsg(utils.UUID(), reportType, msg)
+	return sendOrQueuePL(rcvr, m.ConnID, pipe, task, opl)
+}
@@ -0,0 +1,161 @@
+package issuer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/prot"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/sec"
+	"github.com/findy-network/findy-agent/agent/service"
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/core"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/data"
+	"github.com/findy-network/findy-agent/std/common"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+const issuerDID = "issuerDID"
+
+func TestMain(m *testing.M) {
+	try.To(psm.Open("MEMORY_issuer_data.bolt"))
+	code := m.Run()
+	psm.Close()
+	os.Exit(code)
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+type holderRcvr struct {
+	comm.Receiver
+}
+
+func (r *holderRcvr) PwPipe(string) (sec.Pipe, error) {
+	return sec.Pipe{Out: &holderDID{}}, nil
+}
+
+type holderDID struct {
+	core.DID
+}
+
+func (d *holderDID) AEndp() (service.Addr, error) {
+	return service.Addr{Endp: "http://localhost:8080/holder", Key: "verkey"}, nil
+}
+
+// offerSent creates the issuer's PSM which waits the holder to answer the
+// offer.
+func offerSent(t *testing.T, id string, validFor time.Duration) {
+	t.Helper()
+
+	task := &comm.TaskBase{TaskHeader: comm.TaskHeader{
+		TaskID:       id,
+		TypeID:       pltype.CACredOffer,
+		ProtocolRole: pb.Protocol_INITIATOR,
+	}}
+	wpl := aries.PayloadCreator.New(didcomm.PayloadInit{
+		ID:   id,
+		Type: pltype.IssueCredentialRequest,
+	})
+	assert.NoError(prot.UpdatePSM(issuerDID, "conn", task, wpl, psm.Waiting))
+
+	rep := &data.IssueCredRep{
+		StateKey:   psm.StateKey{DID: issuerDID, Nonce: id},
+		Attributes: []didcomm.CredentialAttribute{{Name: "email"}},
+	}
+	rep.SetOfferValidity(validFor)
+	assert.NoError(psm.AddRep(rep))
+}
+
+func TestExpireOffers(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)}
+	defer psm.SetClock(psm.SetClock(fc))
+
+	offerSent(t, "expiring-offer", time.Hour)
+	offerSent(t, "forever-offer", 0)
+
+	n, err := expireOffers(psm.Timestamp())
+	assert.NoError(err)
+	assert.Equal(n, 0)
+
+	fc.now = fc.now.Add(time.Hour + time.Minute)
+	n, err = expireOffers(psm.Timestamp())
+	assert.NoError(err)
+	assert.Equal(n, 1)
+
+	key := psm.StateKey{DID: issuerDID, Nonce: "expiring-offer"}
+	m, err := psm.GetPSM(key)
+	assert.NoError(err)
+	assert.Equal(m.LastState().Sub, psm.Failure)
+	rep, err := data.GetIssueCredRep(key)
+	assert.NoError(err)
+	assert.That(rep.OfferExpired)
+	assert.That(rep.OfferExpiredAt(0))
+
+	m, err = psm.GetPSM(psm.StateKey{DID: issuerDID, Nonce: "forever-offer"})
+	assert.NoError(err)
+	assert.Equal(m.LastState().Sub, psm.Waiting)
+
+	// failed protocols aren't expired again
+	n, err = expireOffers(psm.Timestamp())
+	assert.NoError(err)
+	assert.Equal(n, 0)
+}
+
+func TestExpireOffers_NotifyHolder(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	fc := &fakeClock{now: time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)}
+	defer psm.SetClock(psm.SetClock(fc))
+
+	comm.ActiveRcvrs.Add(issuerDID, &holderRcvr{})
+	defer comm.ActiveRcvrs.Add(issuerDID, nil)
+
+	var sent []didcomm.Payload
+	orig := sendOrQueuePL
+	sendOrQueuePL = func(
+		_ comm.Receiver,
+		connID string,
+		_ sec.Pipe,
+		task comm.Task,
+		opl didcomm.Payload,
+	) error {
+		assert.Equal(connID, "conn")
+		assert.Equal(task.ReceiverEndp().Endp, "http://localhost:8080/holder")
+		sent = append(sent, opl)
+		return nil
+	}
+	defer func() { sendOrQueuePL = orig }()
+
+	utils.Settings.SetCredOfferNotify(true)
+	defer utils.Settings.SetCredOfferNotify(false)
+
+	offerSent(t, "notified-offer", time.Minute)
+
+	fc.now = fc.now.Add(2 * time.Minute)
+	n, err := expireOffers(psm.Timestamp())
+	assert.NoError(err)
+	assert.Equal(n, 1)
+
+	assert.SLen(sent, 1)
+	assert.Equal(sent[0].Type(), pltype.NotificationProblemReport)
+	assert.Equal(sent[0].ThreadID(), "notified-offer")
+	report := sent[0].MsgHdr().FieldObj().(*common.ProblemReport)
+	assert.Equal(report.Description.Code, OfferExpiredCode)
+}
@@ -1,14 +1,18 @@
 package issuer
 
 import (
+	"fmt"
+
 	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/didcomm"
 	"github.com/findy-network/findy-agent/agent/pltype"
 	"github.com/findy-network/findy-agent/agent/prot"
 	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/utils"
 	"github.com/findy-network/findy-agent/protocol/issuecredential/data"
 	"github.com/findy-network/findy-agent/protocol/issuecredential/preview"
 	"github.com/findy-network/findy-agent/std/issuecredential"
+	"github.com/findy-network/findy-common-go/dto"
 	"github.com/findy-network/findy-wrapper-go/anoncreds"
 	"github.com/golang/glog"
 	"github.com/lainio/err2"
@@ -20,7 +24,7 @@ import (
 // sending Cred_Offer.
 func HandleCredentialPropose(packet comm.Packet) (err error) {
 	var sendNext, waitingNext string
-	if packet.Receiver.AutoPermission() {
+	if comm.ConnectionAutoAccept(packet.Receiver, packet.Address.ConnID, "") {
 		sendNext = pltype.IssueCredentialOffer
 		waitingNext = pltype.IssueCredentialRequest
 	} else {
@@ -42,19 +46,9 @@ func HandleCredentialPropose(packet comm.Packet) (err error) {
 
 			prop := im.FieldObj().(*issuecredential.Propose)
 
+			attributes := try.To1(offerAttrs(meDID, prop.CredentialProposal))
 			values := issuecredential.PreviewCredentialToCodedValues(
-				prop.CredentialProposal)
-
-			attributes := make([]didcomm.CredentialAttribute, 0)
-			for _, attr := range prop.CredentialProposal.Attributes {
-				attributes = append(attributes, didcomm.CredentialAttribute{
-					Name:     attr.Name,
-					Value:    attr.Value,
-					MimeType: attr.MimeType,
-				})
-			}
-
-			// TODO: support changing values
+				issuecredential.NewPreviewCredential(dto.ToJSON(attributes)))
 
 			r := <-anoncreds.IssuerCreateCredentialOffer(
 				wa.Wallet(), prop.CredDefID)
@@ -68,14 +62,14 @@ func HandleCredentialPropose(packet comm.Packet) (err error) {
 				Values:     values, // important! saved for Req handling
 				Attributes: attributes,
 			}
+			rep.SetOfferValidity(utils.Settings.CredOfferTTL())
 			try.To(psm.AddRep(rep))
 
 			offer, autoAccept := om.FieldObj().(*issuecredential.Offer)
 			if autoAccept {
-				offer.OffersAttach =
-					issuecredential.NewOfferAttach([]byte(credOffer))
 				offer.CredentialPreview =
 					issuecredential.NewPreviewCredentialRaw(values)
+				issuecredential.SetOfferAttach(offer, []byte(credOffer))
 				offer.Comment = values // todo: for legacy tests
 				preview.StoreCredPreview(&offer.CredentialPreview, rep)
 			}
@@ -85,6 +79,31 @@ func HandleCredentialPropose(packet comm.Packet) (err error) {
 	})
 }
 
+// offerAttrs returns the attributes we offer for the holder's proposal. The
+// offered values are the proposed ones overridden by the agent's registered
+// transformations, see RegisterTransform.
+func offerAttrs(
+	agentDID string,
+	proposal issuecredential.PreviewCredential,
+) (
+	offered []didcomm.CredentialAttribute,
+	err error,
+) {
+	offered = make([]didcomm.CredentialAttribute, 0, len(proposal.Attributes))
+	for _, attr := range proposal.Attributes {
+		offered = append(offered, didcomm.CredentialAttribute{
+			Name:     attr.Name,
+			Value:    attr.Value,
+			MimeType: attr.MimeType,
+			DataType: attr.DataType,
+		})
+	}
+	if err = transformAttrs(agentDID, offered); err != nil {
+		return nil, err
+	}
+	return offered, nil
+}
+
 // todo lapi: im message is old legacy api type!!
 func ContinueCredentialPropose(ca comm.Receiver, im didcomm.Msg) {
 	defer err2.Catch()
@@ -110,13 +129,16 @@ func ContinueCredentialPropose(ca comm.Receiver, im didcomm.Msg) {
 			rep := try.To1(data.GetIssueCredRep(repK))
 
 			offer := om.FieldObj().(*issuecredential.Offer)
-			offer.OffersAttach =
-				issuecredential.NewOfferAttach([]byte(rep.CredOffer))
 			offer.CredentialPreview =
 				issuecredential.NewPreviewCredentialRaw(rep.Values)
+			issuecredential.SetOfferAttach(offer, []byte(rep.CredOffer))
 			offer.Comment = rep.Values // todo: for legacy tests
 			preview.StoreCredPreview(&offer.CredentialPreview, rep)
 
+			// the offer is valid from the moment it's sent
+			rep.SetOfferValidity(utils.Settings.CredOfferTTL())
+			try.To(psm.AddRep(rep))
+
 			return true, nil
 		},
 	}))
@@ -125,10 +147,11 @@ func ContinueCredentialPropose(ca comm.Receiver, im didcomm.Msg) {
 // HandleCredentialRequest implements the handler for credential request protocol
 // msg. This is Issuer side action.
 func HandleCredentialRequest(packet comm.Packet) (err error) {
+	v2 := issuecredential.IsV2(packet.Payload.Type())
 	return prot.ExecPSM(prot.Transition{
 		Packet:      packet,
-		SendNext:    pltype.IssueCredentialIssue,
-		WaitingNext: pltype.IssueCredentialACK,
+		SendNext:    issuecredential.Versioned(pltype.IssueCredentialIssue, v2),
+		WaitingNext: issuecredential.Versioned(pltype.IssueCredentialACK, v2),
 		InOut: func(_ string, im, om didcomm.MessageHdr) (ack bool, err error) {
 			defer err2.Handle(&err, "cred req")
 
@@ -137,13 +160,16 @@ func HandleCredentialRequest(packet comm.Packet) (err error) {
 			repK := psm.NewStateKey(agent, im.Thread().ID)
 
 			rep := try.To1(data.GetIssueCredRep(repK))
+			if rep.OfferExpiredAt(psm.Timestamp()) {
+				return false, fmt.Errorf("credential offer (%s) expired",
+					im.Thread().ID)
+			}
 			attach := try.To1(issuecredential.RequestAttach(req))
 			credReq := string(attach)
 			cred := try.To1(rep.IssuerBuildCred(packet, credReq))
 
 			issue := om.FieldObj().(*issuecredential.Issue)
-			issue.CredentialsAttach =
-				issuecredential.NewCredentialsAttach([]byte(cred))
+			issuecredential.SetCredentialsAttach(issue, []byte(cred))
 
 			return true, nil
 		},
@@ -0,0 +1,85 @@
+package issuer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/golang/glog"
+)
+
+// Transform transforms the credential attribute value before it's issued,
+// e.g. uppercases a code or hashes a national id. It must be deterministic,
+// because the same value must always give the same credential value.
+type Transform func(value string) (string, error)
+
+// transforms holds the transformations of the agents by the attribute names.
+// The agents are keyed by their DIDs.
+var transforms = struct {
+	sync.RWMutex
+	agents map[string]map[string]Transform
+}{
+	agents: make(map[string]map[string]Transform),
+}
+
+// RegisterTransform registers the transformation of the attribute for the
+// agent's credentials. Nil transformation removes the registered one. It's a
+// hook for the programs which run the agency as a library: the agency API
+// cannot register transformations, and the registrations live only in the
+// process, i.e. they must be registered again after every start.
+func RegisterTransform(wa comm.Receiver, attrName string, f Transform) {
+	registerTransform(wa.MyDID().Did(), attrName, f)
+}
+
+func registerTransform(agentDID, attrName string, f Transform) {
+	transforms.Lock()
+	defer transforms.Unlock()
+
+	attrs := transforms.agents[agentDID]
+	if f == nil {
+		delete(attrs, attrName)
+		return
+	}
+	if attrs == nil {
+		attrs = make(map[string]Transform)
+		transforms.agents[agentDID] = attrs
+	}
+	attrs[attrName] = f
+}
+
+// TransformAttrs applies the agent's registered transformations to the
+// attribute values in place. The transformation is run twice to catch the
+// ones which aren't deterministic. The values aren't logged, because they
+// can be personal data.
+func TransformAttrs(wa comm.Receiver, attrs []didcomm.CredentialAttribute) error {
+	return transformAttrs(wa.MyDID().Did(), attrs)
+}
+
+func transformAttrs(agentDID string, attrs []didcomm.CredentialAttribute) error {
+	transforms.RLock()
+	defer transforms.RUnlock()
+
+	agentTransforms := transforms.agents[agentDID]
+	if len(agentTransforms) == 0 {
+		return nil
+	}
+	for i, attr := range attrs {
+		f, ok := agentTransforms[attr.Name]
+		if !ok {
+			continue
+		}
+		value, err := f(attr.Value)
+		if err != nil {
+			return fmt.Errorf("transform attribute %s: %w", attr.Name, err)
+		}
+		if again, err := f(attr.Value); err != nil || again != value {
+			return fmt.Errorf("transform attribute %s: not deterministic",
+				attr.Name)
+		}
+		glog.V(3).Infof("agent %s: attribute %s transformed", agentDID,
+			attr.Name)
+		attrs[i].Value = value
+	}
+	return nil
+}
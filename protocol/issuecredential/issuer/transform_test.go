@@ -0,0 +1,70 @@
+package issuer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/std/issuecredential"
+	"github.com/lainio/err2/assert"
+)
+
+func TestTransformAttrs(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const agentDID = "transformAgentDID"
+	upper := func(v string) (string, error) { return strings.ToUpper(v), nil }
+	registerTransform(agentDID, "code", upper)
+	defer registerTransform(agentDID, "code", nil)
+
+	attrs := []didcomm.CredentialAttribute{
+		{Name: "code", Value: "ab-123"},
+		{Name: "name", Value: "Alice"},
+	}
+	assert.NoError(transformAttrs(agentDID, attrs))
+	assert.Equal(attrs[0].Value, "AB-123")
+	assert.Equal(attrs[1].Value, "Alice")
+
+	// other agents' attributes aren't transformed
+	other := []didcomm.CredentialAttribute{{Name: "code", Value: "ab-123"}}
+	assert.NoError(transformAttrs("otherAgentDID", other))
+	assert.Equal(other[0].Value, "ab-123")
+
+	// failing and non-deterministic transformations are errors
+	registerTransform(agentDID, "name", func(string) (string, error) {
+		return "", errors.New("bad value")
+	})
+	assert.Error(transformAttrs(agentDID, attrs))
+	n := 0
+	registerTransform(agentDID, "name", func(v string) (string, error) {
+		n++
+		return strings.Repeat(v, n), nil
+	})
+	assert.Error(transformAttrs(agentDID, attrs))
+	registerTransform(agentDID, "name", nil)
+}
+
+func TestOfferAttrs(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const agentDID = "offerAgentDID"
+	registerTransform(agentDID, "level", func(string) (string, error) {
+		return "silver", nil
+	})
+	defer registerTransform(agentDID, "level", nil)
+
+	proposal := issuecredential.PreviewCredential{
+		Attributes: []issuecredential.Attribute{
+			{Name: "name", Value: "Alice"},
+			{Name: "level", Value: "gold"},
+		},
+	}
+	offered, err := offerAttrs(agentDID, proposal)
+	assert.NoError(err)
+	assert.Equal(offered[0].Value, "Alice")
+	assert.Equal(offered[1].Value, "silver")
+	assert.Equal(proposal.Attributes[1].Value, "gold")
+}
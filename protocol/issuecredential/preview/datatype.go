@@ -0,0 +1,50 @@
+package preview
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+)
+
+const (
+	// DataTypeString is the default data type of the credential attributes.
+	DataTypeString = "string"
+
+	// DataTypeInteger marks the attributes which can be used in the proof
+	// predicates. Anoncreds encodes only the 32-bit integers as they are,
+	// which is why the value must fit to it.
+	DataTypeInteger = "integer"
+)
+
+// CheckDataTypes returns an error if an attribute has a data type which we
+// don't recognize, or if its value isn't of the type. The attribute without
+// the data type is a string.
+func CheckDataTypes(attrs []didcomm.CredentialAttribute) error {
+	for _, attr := range attrs {
+		switch attr.DataType {
+		case "", DataTypeString:
+		case DataTypeInteger:
+			if _, err := strconv.ParseInt(attr.Value, 10, 32); err != nil {
+				return fmt.Errorf("attribute %s: value (%s) isn't 32-bit %s",
+					attr.Name, attr.Value, DataTypeInteger)
+			}
+		default:
+			return fmt.Errorf("attribute %s: data type (%s) not supported",
+				attr.Name, attr.DataType)
+		}
+	}
+	return nil
+}
+
+// PredicateAttrs returns the names of the attributes which can be used in the
+// proof predicates according to their data types.
+func PredicateAttrs(attrs []didcomm.CredentialAttribute) []string {
+	names := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.DataType == DataTypeInteger {
+			names = append(names, attr.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,62 @@
+package preview
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/data"
+	"github.com/findy-network/findy-agent/std/issuecredential"
+	"github.com/lainio/err2/assert"
+)
+
+func TestCheckDataTypes(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	assert.NoError(CheckDataTypes([]didcomm.CredentialAttribute{
+		{Name: "email", Value: "email@example.com"},
+		{Name: "name", Value: "Alice", DataType: DataTypeString},
+		{Name: "age", Value: "24", DataType: DataTypeInteger},
+		{Name: "balance", Value: "-100", DataType: DataTypeInteger},
+	}))
+
+	for _, attr := range []didcomm.CredentialAttribute{
+		{Name: "age", Value: "twenty", DataType: DataTypeInteger},
+		{Name: "age", Value: "24.5", DataType: DataTypeInteger},
+		{Name: "age", Value: "4294967296", DataType: DataTypeInteger},
+		{Name: "age", Value: "24", DataType: "float"},
+	} {
+		assert.Error(CheckDataTypes([]didcomm.CredentialAttribute{attr}),
+			attr.Value)
+	}
+}
+
+func TestDataTypeRoundTrip(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	// issuer side: task attributes are sent as a credential preview
+	attrs := []didcomm.CredentialAttribute{
+		{Name: "name", Value: "Alice"},
+		{Name: "age", Value: "24", DataType: DataTypeInteger},
+	}
+	attrsStr, err := json.Marshal(attrs)
+	assert.NoError(err)
+	pc := issuecredential.NewPreviewCredential(string(attrsStr))
+
+	// the offer goes over the wire as JSON
+	offerJSON, err := json.Marshal(issuecredential.Offer{CredentialPreview: pc})
+	assert.NoError(err)
+	var offer issuecredential.Offer
+	assert.NoError(json.Unmarshal(offerJSON, &offer))
+
+	// holder side: the preview is stored to the rep of the credential
+	rep := &data.IssueCredRep{}
+	StoreCredPreview(&offer.CredentialPreview, rep)
+
+	assert.SLen(rep.Attributes, 2)
+	assert.Equal(rep.Attributes[0].DataType, "")
+	assert.Equal(rep.Attributes[1].DataType, DataTypeInteger)
+	assert.DeepEqual(PredicateAttrs(rep.Attributes), []string{"age"})
+}
@@ -0,0 +1,81 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"unicode/utf8"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+)
+
+// PlainMimeType is the default MIME type of the credential attributes.
+const PlainMimeType = "text/plain"
+
+// mimeChecks are the MIME types of the credential attributes which we
+// recognize, and the checks of their values. The binary values are base64
+// encoded, see Aries RFC 0441, and they must start with the magic bytes of the
+// type, because e.g. an image which the holder cannot show makes the
+// credential unusable.
+var mimeChecks = map[string]func(value string) error{
+	PlainMimeType:      checkText,
+	StructuredMimeType: checkJSON,
+	"image/png":        checkBinary([]byte("\x89PNG\r\n\x1a\n")),
+	"image/jpeg":       checkBinary([]byte("\xff\xd8\xff")),
+	"application/pdf":  checkBinary([]byte("%PDF-")),
+}
+
+// CheckMimeTypes returns an error if an attribute has a MIME type which we
+// don't recognize, or if its value doesn't match the MIME type. The attribute
+// without the MIME type is plain text.
+func CheckMimeTypes(attrs []didcomm.CredentialAttribute) error {
+	for _, attr := range attrs {
+		if attr.MimeType == "" {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(attr.MimeType)
+		if err != nil {
+			return fmt.Errorf("attribute %s: MIME type (%s): %w",
+				attr.Name, attr.MimeType, err)
+		}
+		check, ok := mimeChecks[mediaType]
+		if !ok {
+			return fmt.Errorf("attribute %s: MIME type (%s) not supported",
+				attr.Name, attr.MimeType)
+		}
+		if err := check(attr.Value); err != nil {
+			return fmt.Errorf("attribute %s: value isn't %s: %w",
+				attr.Name, mediaType, err)
+		}
+	}
+	return nil
+}
+
+func checkText(value string) error {
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("invalid UTF-8")
+	}
+	return nil
+}
+
+func checkJSON(value string) error {
+	if !json.Valid([]byte(value)) {
+		return fmt.Errorf("invalid JSON")
+	}
+	return nil
+}
+
+func checkBinary(magic []byte) func(value string) error {
+	return func(value string) error {
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("invalid base64: %w", err)
+		}
+		if !bytes.HasPrefix(data, magic) {
+			return fmt.Errorf("content doesn't match the type")
+		}
+		return nil
+	}
+}
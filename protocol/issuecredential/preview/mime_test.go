@@ -0,0 +1,34 @@
+package preview
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/lainio/err2/assert"
+)
+
+func TestCheckMimeTypes(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	png := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\nimage"))
+	assert.NoError(CheckMimeTypes([]didcomm.CredentialAttribute{
+		{Name: "email", Value: "email@example.com"},
+		{Name: "note", Value: "text", MimeType: "text/plain; charset=utf-8"},
+		{Name: "photo", Value: png, MimeType: "image/png"},
+		{Name: "address", Value: `{"city":"Oulu"}`, MimeType: StructuredMimeType},
+	}))
+
+	for _, attr := range []didcomm.CredentialAttribute{
+		{Name: "photo", Value: "not base64!", MimeType: "image/png"},
+		{Name: "photo", Value: png, MimeType: "image/jpeg"},
+		{Name: "photo", Value: png, MimeType: "image/x-unknown"},
+		{Name: "photo", Value: png, MimeType: "image/"},
+		{Name: "note", Value: "\xff", MimeType: PlainMimeType},
+		{Name: "address", Value: `{"city":`, MimeType: StructuredMimeType},
+	} {
+		assert.Error(CheckMimeTypes([]didcomm.CredentialAttribute{attr}),
+			attr.MimeType)
+	}
+}
@@ -0,0 +1,75 @@
+package preview
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// masterSecretAttr is the link secret's key in the cred def's primary key. It
+// isn't a schema attribute.
+const masterSecretAttr = "master_secret"
+
+// SchemaAttrs returns the schema's attribute names from the cred def JSON.
+// The cred def refers the schema only by its ledger sequence number, but its
+// primary public key has a key for every schema attribute, and that's where
+// they are read. The names are in the anoncreds' canonical form, see
+// didcomm.CanonicalAttrName.
+func SchemaAttrs(credDef string) (attrs []string, err error) {
+	defer err2.Handle(&err, "schema attributes")
+
+	var cd struct {
+		Value struct {
+			Primary struct {
+				R map[string]json.RawMessage `json:"r"`
+			} `json:"primary"`
+		} `json:"value"`
+	}
+	try.To(json.Unmarshal([]byte(credDef), &cd))
+	if len(cd.Value.Primary.R) == 0 {
+		return nil, fmt.Errorf("cred def has no attributes")
+	}
+
+	attrs = make([]string, 0, len(cd.Value.Primary.R))
+	for name := range cd.Value.Primary.R {
+		if name != masterSecretAttr {
+			attrs = append(attrs, name)
+		}
+	}
+	sort.Strings(attrs)
+	return attrs, nil
+}
+
+// CheckSchemaAttrs checks that the attributes to issue are exactly the
+// schema's attributes. The returned error lists both the missing and the
+// extra attributes to help the issuer to fix its request.
+func CheckSchemaAttrs(schemaAttrs []string, attrs []didcomm.CredentialAttribute) error {
+	missing := make(map[string]struct{}, len(schemaAttrs))
+	for _, name := range schemaAttrs {
+		missing[didcomm.CanonicalAttrName(name)] = struct{}{}
+	}
+	var extra []string
+	for _, attr := range attrs {
+		name := didcomm.CanonicalAttrName(attr.Name)
+		if _, ok := missing[name]; !ok {
+			extra = append(extra, attr.Name)
+			continue
+		}
+		delete(missing, name)
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return nil
+	}
+
+	missingNames := make([]string, 0, len(missing))
+	for name := range missing {
+		missingNames = append(missingNames, name)
+	}
+	sort.Strings(missingNames)
+	return fmt.Errorf("credential attributes don't match schema: missing %v, extra %v",
+		missingNames, extra)
+}
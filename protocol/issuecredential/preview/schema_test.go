@@ -0,0 +1,58 @@
+package preview
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/lainio/err2/assert"
+)
+
+const testCredDef = `{"ver":"1.0","id":"Th7MpTaRZVRYnPiabds81Y:3:CL:15:tag",
+"schemaId":"15","type":"CL","tag":"tag","value":{"primary":{"n":"1","s":"2",
+"r":{"master_secret":"3","email":"4","not_after":"5"},"rctxt":"6","z":"7"}}}`
+
+func TestSchemaAttrs(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	attrs, err := SchemaAttrs(testCredDef)
+	assert.NoError(err)
+	assert.DeepEqual(attrs, []string{"email", "not_after"})
+
+	_, err = SchemaAttrs(`{"value":{}}`)
+	assert.Error(err)
+}
+
+func TestCheckSchemaAttrs(t *testing.T) {
+	schemaAttrs := []string{"email", "not_after"}
+	tests := []struct {
+		name  string
+		attrs []didcomm.CredentialAttribute
+		ok    bool
+	}{
+		{"match", []didcomm.CredentialAttribute{
+			{Name: "email"}, {Name: "not_after"}}, true},
+		{"canonical names", []didcomm.CredentialAttribute{
+			{Name: "E mail"}, {Name: "Not_After"}}, true},
+		{"missing", []didcomm.CredentialAttribute{{Name: "email"}}, false},
+		{"extra", []didcomm.CredentialAttribute{
+			{Name: "email"}, {Name: "not_after"}, {Name: "name"}}, false},
+		{"typo", []didcomm.CredentialAttribute{
+			{Name: "emial"}, {Name: "not_after"}}, false},
+		{"duplicate", []didcomm.CredentialAttribute{
+			{Name: "email"}, {Name: "email"}, {Name: "not_after"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			err := CheckSchemaAttrs(schemaAttrs, tt.attrs)
+			if tt.ok {
+				assert.NoError(err)
+			} else {
+				assert.Error(err)
+			}
+		})
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"github.com/findy-network/findy-agent/agent/didcomm"
 	"github.com/findy-network/findy-agent/protocol/issuecredential/data"
 	"github.com/findy-network/findy-agent/std/issuecredential"
+	"github.com/golang/glog"
 )
 
-// StoreCredPreview copies credential attribute data to rep object
+// StoreCredPreview copies credential attribute data to rep object. The
+// validity window is parsed from the reserved attributes as well, but
+// malformed values are only logged because it's the issuer's data.
 func StoreCredPreview(preview *issuecredential.PreviewCredential, rep *data.IssueCredRep) {
 	rep.Attributes = make([]didcomm.CredentialAttribute, len(preview.Attributes))
 	for index, value := range preview.Attributes {
@@ -15,6 +18,13 @@ func StoreCredPreview(preview *issuecredential.PreviewCredential, rep *data.Issu
 			Name:     value.Name,
 			Value:    value.Value,
 			MimeType: value.MimeType,
+			DataType: value.DataType,
 		}
 	}
+	_, notBefore, notAfter, err := SplitValidity(rep.Attributes)
+	if err != nil {
+		glog.Warningln("credential validity:", err)
+		return
+	}
+	rep.NotBefore, rep.NotAfter = notBefore, notAfter
 }
@@ -0,0 +1,81 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// StructuredMimeType marks the credential attributes which have structured
+// values, e.g. an address with its fields. Anoncreds values are flat strings,
+// which is why the value is serialized as canonical JSON: object keys are
+// sorted and there is no white space. The canonical string is the raw value
+// of the credential as well, which keeps the preview and the coded values
+// consistent, and verifiers can compare the revealed values as strings.
+const StructuredMimeType = "application/json"
+
+// StructuredAttr returns the credential attribute of the structured value.
+func StructuredAttr(name string, v any) (_ didcomm.CredentialAttribute, err error) {
+	defer err2.Handle(&err, "structured attribute %s", name)
+
+	value := try.To1(canonicalJSON(try.To1(json.Marshal(v))))
+	return didcomm.CredentialAttribute{
+		Name:     name,
+		Value:    value,
+		MimeType: StructuredMimeType,
+	}, nil
+}
+
+// ParseStructured parses the structured value of the credential attribute,
+// see StructuredAttr, to v. It's used by the holder to read the value back.
+func ParseStructured(mimeType, value string, v any) (err error) {
+	defer err2.Handle(&err, "parse structured value")
+
+	if mimeType != StructuredMimeType {
+		return fmt.Errorf("mime type (%s) isn't %s", mimeType, StructuredMimeType)
+	}
+	return json.Unmarshal([]byte(value), v)
+}
+
+// CanonizeStructured converts the structured values of the attributes to
+// canonical JSON in place. Other attributes aren't touched. The issuer calls
+// it before the values are used in the credential.
+func CanonizeStructured(attrs []didcomm.CredentialAttribute) (err error) {
+	defer err2.Handle(&err, "canonize structured values")
+
+	for i, attr := range attrs {
+		if attr.MimeType != StructuredMimeType {
+			continue
+		}
+		v, err := canonicalJSON([]byte(attr.Value))
+		if err != nil {
+			return fmt.Errorf("attribute %s: %w", attr.Name, err)
+		}
+		attrs[i].Value = v
+	}
+	return nil
+}
+
+// canonicalJSON returns the JSON without white space and the object keys
+// sorted. Numbers are kept as they are.
+func canonicalJSON(data []byte) (_ string, err error) {
+	defer err2.Handle(&err)
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v any
+	try.To(d.Decode(&v))
+	if d.More() {
+		return "", fmt.Errorf("data after the JSON value")
+	}
+
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	try.To(e.Encode(v)) // maps are encoded with sorted keys
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
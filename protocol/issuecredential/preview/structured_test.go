@@ -0,0 +1,76 @@
+package preview
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/std/issuecredential"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+)
+
+type address struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+	Geo    struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"geo"`
+}
+
+func TestStructuredAttr(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	var addr address
+	addr.Street = "Main <1>"
+	addr.City = "Oulu"
+	addr.Geo.Lat, addr.Geo.Lon = 65.01, 25.47
+
+	attr, err := StructuredAttr("address", addr)
+	assert.NoError(err)
+	assert.Equal(attr.MimeType, StructuredMimeType)
+	assert.Equal(attr.Value,
+		`{"city":"Oulu","geo":{"lat":65.01,"lon":25.47},"street":"Main <1>"}`)
+
+	var got address
+	assert.NoError(ParseStructured(attr.MimeType, attr.Value, &got))
+	assert.DeepEqual(got, addr)
+	assert.Error(ParseStructured("text/plain", attr.Value, &got))
+}
+
+func TestCanonizeStructured(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	attrs := []didcomm.CredentialAttribute{
+		{Name: "email", Value: `{ "b": 1 }`},
+		{Name: "address", MimeType: StructuredMimeType, Value: `{
+			"street": "Main", "geo": {"lon": 25.470, "lat": 65.01}
+		}`},
+	}
+	assert.NoError(CanonizeStructured(attrs))
+	assert.Equal(attrs[0].Value, `{ "b": 1 }`)
+	assert.Equal(attrs[1].Value,
+		`{"geo":{"lat":65.01,"lon":25.470},"street":"Main"}`)
+
+	// the preview and the coded values have the same canonical value
+	cred := issuecredential.PreviewCredential{
+		Attributes: []issuecredential.Attribute{{
+			Name:     attrs[1].Name,
+			MimeType: attrs[1].MimeType,
+			Value:    attrs[1].Value,
+		}},
+	}
+	coded := make(map[string]anoncreds.CredDefAttr)
+	dto.FromJSONStr(issuecredential.PreviewCredentialToCodedValues(cred), &coded)
+	assert.Equal(coded["address"].Raw, attrs[1].Value)
+
+	for _, value := range []string{`{"a":`, `{"a":1} {"b":2}`} {
+		bad := []didcomm.CredentialAttribute{
+			{Name: "address", MimeType: StructuredMimeType, Value: value},
+		}
+		assert.Error(CanonizeStructured(bad), value)
+	}
+}
@@ -0,0 +1,84 @@
+package preview
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+)
+
+// Credential validity window is carried in reserved credential attributes,
+// because anoncreds credentials don't have separate metadata fields. The
+// values are Unix timestamps in seconds as decimal strings. Anoncreds encodes
+// decimal integers as themselves, which means that verifiers can use
+// predicates (e.g. _findy_not_after >= now) without revealing the exact
+// values. The credential schema must include the attributes for them to be
+// issued.
+//
+// The names are prefixed so that they don't collide with the attributes
+// which schemas already have, e.g. a not_after date. Missing attribute means
+// that the bound isn't set.
+const (
+	AttrNotBefore = "_findy_not_before"
+	AttrNotAfter  = "_findy_not_after"
+)
+
+// IsValidityAttr tells if the attribute name is reserved for the validity
+// window.
+func IsValidityAttr(name string) bool {
+	return name == AttrNotBefore || name == AttrNotAfter
+}
+
+// SplitValidity separates the reserved validity attributes from the rest of
+// the attributes. The bounds are nil if they aren't set. It returns an error
+// if the values cannot be parsed or the window is empty.
+func SplitValidity(attrs []didcomm.CredentialAttribute) (
+	rest []didcomm.CredentialAttribute,
+	notBefore, notAfter *int64,
+	err error,
+) {
+	rest = make([]didcomm.CredentialAttribute, 0, len(attrs))
+	for _, attr := range attrs {
+		if !IsValidityAttr(attr.Name) {
+			rest = append(rest, attr)
+			continue
+		}
+		ts, err := strconv.ParseInt(attr.Value, 10, 64)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("credential %s: %w", attr.Name, err)
+		}
+		if attr.Name == AttrNotBefore {
+			notBefore = &ts
+		} else {
+			notAfter = &ts
+		}
+	}
+	if notBefore != nil && notAfter != nil && *notAfter < *notBefore {
+		return nil, nil, nil, fmt.Errorf("credential %s (%d) before %s (%d)",
+			AttrNotAfter, *notAfter, AttrNotBefore, *notBefore)
+	}
+	return rest, notBefore, notAfter, nil
+}
+
+// AddValidity appends the validity window to attributes as reserved
+// attributes. Unset (nil) bounds are not added.
+func AddValidity(
+	attrs []didcomm.CredentialAttribute,
+	notBefore, notAfter *int64,
+) []didcomm.CredentialAttribute {
+	if notBefore != nil {
+		attrs = append(attrs, didcomm.CredentialAttribute{
+			Name:     AttrNotBefore,
+			Value:    strconv.FormatInt(*notBefore, 10),
+			MimeType: PlainMimeType,
+		})
+	}
+	if notAfter != nil {
+		attrs = append(attrs, didcomm.CredentialAttribute{
+			Name:     AttrNotAfter,
+			Value:    strconv.FormatInt(*notAfter, 10),
+			MimeType: PlainMimeType,
+		})
+	}
+	return attrs
+}
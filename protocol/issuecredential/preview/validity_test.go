@@ -0,0 +1,87 @@
+package preview
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/data"
+	"github.com/findy-network/findy-agent/std/issuecredential"
+	"github.com/lainio/err2/assert"
+)
+
+func TestSplitValidity(t *testing.T) {
+	tests := []struct {
+		name      string
+		attrs     []didcomm.CredentialAttribute
+		notBefore *int64
+		notAfter  *int64
+		restLen   int
+		ok        bool
+	}{
+		{"no validity", []didcomm.CredentialAttribute{{Name: "email", Value: "a@b.c"}}, nil, nil, 1, true},
+		{"both", []didcomm.CredentialAttribute{
+			{Name: "email", Value: "a@b.c"},
+			{Name: AttrNotBefore, Value: "1000"},
+			{Name: AttrNotAfter, Value: "2000"},
+		}, ts(1000), ts(2000), 1, true},
+		{"only not after", []didcomm.CredentialAttribute{{Name: AttrNotAfter, Value: "2000"}}, nil, ts(2000), 0, true},
+		{"epoch", []didcomm.CredentialAttribute{{Name: AttrNotBefore, Value: "0"}}, ts(0), nil, 0, true},
+		{"not a number", []didcomm.CredentialAttribute{{Name: AttrNotBefore, Value: "tomorrow"}}, nil, nil, 0, false},
+		{"schema's own dates", []didcomm.CredentialAttribute{
+			{Name: "not_before", Value: "2023-01-01"},
+			{Name: "not_after", Value: "2023-12-31"},
+		}, nil, nil, 2, true},
+		{"empty window", []didcomm.CredentialAttribute{
+			{Name: AttrNotBefore, Value: "2000"},
+			{Name: AttrNotAfter, Value: "1000"},
+		}, nil, nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			rest, nb, na, err := SplitValidity(tt.attrs)
+			if !tt.ok {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.DeepEqual(nb, tt.notBefore)
+			assert.DeepEqual(na, tt.notAfter)
+			assert.SLen(rest, tt.restLen)
+		})
+	}
+}
+
+func TestValidityRoundTrip(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const notBefore, notAfter = 1672531200, 1704067199
+
+	// issuer side: task attributes are sent as a credential preview
+	attrs := AddValidity([]didcomm.CredentialAttribute{
+		{Name: "email", Value: "a@b.c"},
+	}, ts(notBefore), ts(notAfter))
+	attrsStr, err := json.Marshal(attrs)
+	assert.NoError(err)
+	pc := issuecredential.NewPreviewCredential(string(attrsStr))
+
+	// holder side: the preview is stored to the rep used for the status
+	rep := &data.IssueCredRep{}
+	StoreCredPreview(&pc, rep)
+
+	assert.DeepEqual(rep.NotBefore, ts(notBefore))
+	assert.DeepEqual(rep.NotAfter, ts(notAfter))
+	assert.SLen(rep.Attributes, 3)
+	assert.Equal(rep.Attributes[1].Name, AttrNotBefore)
+	assert.Equal(rep.Attributes[1].Value, "1672531200")
+	assert.Equal(rep.Attributes[2].Name, AttrNotAfter)
+	assert.Equal(rep.Attributes[2].Value, "1704067199")
+}
+
+func ts(t int64) *int64 {
+	return &t
+}
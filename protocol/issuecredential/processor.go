@@ -9,9 +9,13 @@ import (
 	"github.com/findy-network/findy-agent/agent/pltype"
 	"github.com/findy-network/findy-agent/agent/prot"
 	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/agent/vc"
+	"github.com/findy-network/findy-agent/protocol/discoverfeatures"
 	"github.com/findy-network/findy-agent/protocol/issuecredential/data"
 	"github.com/findy-network/findy-agent/protocol/issuecredential/holder"
 	"github.com/findy-network/findy-agent/protocol/issuecredential/issuer"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/preview"
 	"github.com/findy-network/findy-agent/std/issuecredential"
 	"github.com/findy-network/findy-common-go/dto"
 	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
@@ -27,6 +31,15 @@ type taskIssueCredential struct {
 	Comment         string
 	CredentialAttrs []didcomm.CredentialAttribute
 	CredDefID       string
+
+	// NotBefore and NotAfter are the credential validity window as Unix
+	// seconds, nil if not set. They are issued as reserved attributes, see
+	// preview package.
+	NotBefore *int64
+	NotAfter  *int64
+
+	// V2 tells that the protocol is run with the 2.0 messages.
+	V2 bool
 }
 
 type continuatorFunc func(ca comm.Receiver, im didcomm.Msg)
@@ -44,8 +57,13 @@ var issueCredentialProcessor = comm.ProtProc{
 		pltype.HandlerIssueCredentialNACK:    handleCredentialNACK,
 	},
 	FillStatus: fillIssueCredentialStatus,
+	Versions:   []string{"1.0", "2.0"},
 }
 
+// issueCredentialV2 is the protocol ID the connection must disclose before we
+// offer it credentials with the 2.0 messages.
+const issueCredentialV2 = pltype.DIDOrgIssueCredential + "/2.0"
+
 func init() {
 	gob.Register(&taskIssueCredential{})
 	prot.AddCreator(pltype.ProtocolIssueCredential, issueCredentialProcessor)
@@ -61,6 +79,7 @@ func createIssueCredentialTask(header *comm.TaskHeader, protocol *pb.Protocol) (
 
 	var credAttrs []didcomm.CredentialAttribute
 	var credDefID string
+	var notBefore, notAfter *int64
 
 	if protocol != nil {
 		cred := protocol.GetIssueCredential()
@@ -69,35 +88,66 @@ func createIssueCredentialTask(header *comm.TaskHeader, protocol *pb.Protocol) (
 			protocol.GetRole() == pb.Protocol_INITIATOR || protocol.GetRole() == pb.Protocol_ADDRESSEE,
 			"role is needed for issuing protocol")
 
-		if cred.GetAttributesJSON() != "" {
-			dto.FromJSONStr(cred.GetAttributesJSON(), &credAttrs)
-			glog.V(3).Infoln("set cred attrs from json")
-		} else {
-			assert.That(cred.GetAttributes() != nil, "issue credential attributes data missing")
-			credAttrs = make([]didcomm.CredentialAttribute, len(cred.GetAttributes().GetAttributes()))
-			for i, attribute := range cred.GetAttributes().GetAttributes() {
-				credAttrs[i] = didcomm.CredentialAttribute{
-					Name:  attribute.Name,
-					Value: attribute.Value,
-				}
-			}
-			glog.V(3).Infoln("set cred from attrs")
-		}
+		credAttrs = try.To1(credentialAttributes(cred))
 		glog.V(1).Infof(
 			"Create task for IssueCredential with connection id %s, role %s",
 			header.ConnID,
 			protocol.GetRole().String(),
 		)
 		credDefID = cred.CredDefID
+
+		// validity window comes in reserved attributes, see preview pkg
+		credAttrs, notBefore, notAfter = try.To3(
+			preview.SplitValidity(credAttrs))
 	}
 
 	return &taskIssueCredential{
 		TaskBase:        comm.TaskBase{TaskHeader: *header},
 		CredentialAttrs: credAttrs,
 		CredDefID:       credDefID,
+		NotBefore:       notBefore,
+		NotAfter:        notAfter,
 	}, nil
 }
 
+// credentialAttributes returns the attributes of the credential. The AttrFmt
+// oneof selects the format, which means that the JSON and the structured
+// attributes cannot conflict: only the set alternative is read. The attributes
+// are mandatory in both formats.
+func credentialAttributes(
+	cred *pb.Protocol_IssueCredentialMsg,
+) (
+	credAttrs []didcomm.CredentialAttribute,
+	err error,
+) {
+	defer err2.Handle(&err, "credential attributes")
+
+	assert.INotNil(cred.GetAttrFmt(), "issue credential attributes data missing")
+	switch attrFmt := cred.GetAttrFmt().(type) {
+	case *pb.Protocol_IssueCredentialMsg_AttributesJSON:
+		assert.NotEmpty(attrFmt.AttributesJSON, "issue credential attributes JSON empty")
+		try.To(json.Unmarshal([]byte(attrFmt.AttributesJSON), &credAttrs))
+		glog.V(3).Infoln("set cred attrs from json")
+	case *pb.Protocol_IssueCredentialMsg_Attributes:
+		assert.NotNil(attrFmt.Attributes, "issue credential attributes data missing")
+		credAttrs = make([]didcomm.CredentialAttribute, len(attrFmt.Attributes.GetAttributes()))
+		for i, attribute := range attrFmt.Attributes.GetAttributes() {
+			credAttrs[i] = didcomm.CredentialAttribute{
+				Name:  attribute.Name,
+				Value: attribute.Value,
+			}
+		}
+		glog.V(3).Infoln("set cred from attrs")
+	}
+	try.To(preview.CanonizeStructured(credAttrs))
+	try.To(preview.CheckMimeTypes(credAttrs))
+	try.To(preview.CheckDataTypes(credAttrs))
+	return credAttrs, nil
+}
+
+// startPSM starts the protocol's state machine, which tests can replace.
+var startPSM = prot.StartPSM
+
 // startIssueCredentialByPropose starts the Issue Credential Protocol by sending
 // a Propose Message to pairwise identified by t.Message. It sends the protocol
 // message from cloud EA, and saves the received credentials to cloud EA's
@@ -108,23 +158,35 @@ func startIssueCredentialByPropose(ca comm.Receiver, t comm.Task) {
 	credTask, ok := t.(*taskIssueCredential)
 	assert.That(ok)
 
+	// normalize the values before they are encoded to the credential, see
+	// issuer.RegisterTransform
+	try.To(issuer.TransformAttrs(ca.WorkerEA(), credTask.CredentialAttrs))
+	credTask.CredentialAttrs = preview.AddValidity(
+		credTask.CredentialAttrs, credTask.NotBefore, credTask.NotAfter)
+	credTask.Comment = prot.TaskComment(ca, t, pltype.ProtocolIssueCredential,
+		credTask.Comment)
+
 	// ensure that mime type is set - some agent implementations are depending on it
 	for index, attr := range credTask.CredentialAttrs {
 		if attr.MimeType == "" {
-			credTask.CredentialAttrs[index].MimeType = "text/plain"
+			credTask.CredentialAttrs[index].MimeType = preview.PlainMimeType
 		}
 	}
 
 	switch t.Type() {
 	case pltype.CACredOffer: // Send to Holder
-		try.To(prot.StartPSM(prot.Initial{
-			SendNext:    pltype.IssueCredentialOffer,
-			WaitingNext: pltype.IssueCredentialRequest,
+		credTask.V2 = discoverfeatures.Supports(ca, t.ConnectionID(), issueCredentialV2)
+		try.To(startPSM(prot.Initial{
+			SendNext:    issuecredential.Versioned(pltype.IssueCredentialOffer, credTask.V2),
+			WaitingNext: issuecredential.Versioned(pltype.IssueCredentialRequest, credTask.V2),
 			Ca:          ca,
 			T:           t,
 			Setup: func(key psm.StateKey, msg didcomm.MessageHdr) (err error) {
 				defer err2.Handle(&err, "start issuing prot")
 
+				// anoncreds' errors for mismatching attributes are cryptic
+				try.To(checkSchema(ca, credTask))
+
 				r := <-anoncreds.IssuerCreateCredentialOffer(
 					ca.WorkerEA().Wallet(), credTask.CredDefID)
 				try.To(r.Err())
@@ -140,20 +202,25 @@ func startIssueCredentialByPropose(ca comm.Receiver, t comm.Task) {
 					Values:     codedValues,
 					CredOffer:  credOffer,
 					Attributes: credTask.CredentialAttrs,
+					NotBefore:  credTask.NotBefore,
+					NotAfter:   credTask.NotAfter,
+					V2:         credTask.V2,
 				}
+				rep.SetOfferValidity(utils.Settings.CredOfferTTL())
 				try.To(psm.AddRep(rep))
 
 				offer := msg.FieldObj().(*issuecredential.Offer)
 				offer.CredentialPreview = pc
-				offer.OffersAttach = // here we send the indy cred offer
-					issuecredential.NewOfferAttach([]byte(credOffer))
+				offer.Comment = credTask.Comment
+				// here we send the indy cred offer
+				issuecredential.SetOfferAttach(offer, []byte(credOffer))
 
 				return nil
 			},
 		}))
 
 	case pltype.CACredRequest: // Send to Issuer
-		try.To(prot.StartPSM(prot.Initial{
+		try.To(startPSM(prot.Initial{
 			SendNext:    pltype.IssueCredentialPropose,
 			WaitingNext: pltype.IssueCredentialOffer,
 			Ca:          ca,
@@ -174,6 +241,8 @@ func startIssueCredentialByPropose(ca comm.Receiver, t comm.Task) {
 					CredDefID:  credTask.CredDefID,
 					Attributes: credTask.CredentialAttrs,
 					Values:     issuecredential.PreviewCredentialToCodedValues(pc),
+					NotBefore:  credTask.NotBefore,
+					NotAfter:   credTask.NotAfter,
 				}
 				try.To(psm.AddRep(rep))
 				return nil
@@ -182,6 +251,17 @@ func startIssueCredentialByPropose(ca comm.Receiver, t comm.Task) {
 	}
 }
 
+// checkSchema checks that the task's attributes are exactly the attributes of
+// the cred def's schema.
+func checkSchema(ca comm.Receiver, credTask *taskIssueCredential) (err error) {
+	defer err2.Handle(&err, "cred def (%s)", credTask.CredDefID)
+
+	credDef := try.To1(vc.CredDefFromLedger(ca.Pool(), ca.RootDid().Did(),
+		credTask.CredDefID))
+	schemaAttrs := try.To1(preview.SchemaAttrs(credDef))
+	return preview.CheckSchemaAttrs(schemaAttrs, credTask.CredentialAttrs)
+}
+
 // handleCredentialNACK is holder`s protocol function for now.
 func handleCredentialNACK(packet comm.Packet) (err error) {
 	return prot.ExecPSM(prot.Transition{
@@ -0,0 +1,153 @@
+package issuecredential
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/prot"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/ssi"
+	"github.com/findy-network/findy-agent/core"
+	"github.com/findy-network/findy-agent/protocol/issuecredential/issuer"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+func TestMain(m *testing.M) {
+	try.To(psm.Open("MEMORY_issuecredential_data.bolt"))
+	code := m.Run()
+	psm.Close()
+	os.Exit(code)
+}
+
+type issuerRcvr struct {
+	comm.Receiver
+}
+
+func (r *issuerRcvr) WorkerEA() comm.Receiver {
+	return r
+}
+
+func (r *issuerRcvr) MyDID() core.DID {
+	return ssi.NewDid("issuerDID", "verkey")
+}
+
+func (r *issuerRcvr) WDID() string {
+	return "issuerDID"
+}
+
+func TestCreateIssueCredentialTask_AttrFormats(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	create := func(msg *pb.Protocol_IssueCredentialMsg) (*taskIssueCredential, error) {
+		msg.CredDefID = "cred-def-id"
+		task, err := createIssueCredentialTask(&comm.TaskHeader{}, &pb.Protocol{
+			Role:     pb.Protocol_INITIATOR,
+			StartMsg: &pb.Protocol_IssueCredential{IssueCredential: msg},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return task.(*taskIssueCredential), nil
+	}
+
+	// the same attributes in the both formats give the same task
+	fromJSON, err := create(&pb.Protocol_IssueCredentialMsg{
+		AttrFmt: &pb.Protocol_IssueCredentialMsg_AttributesJSON{
+			AttributesJSON: `[{"name":"email","value":"email@example.com"}]`,
+		},
+	})
+	assert.NoError(err)
+	fromAttrs, err := create(&pb.Protocol_IssueCredentialMsg{
+		AttrFmt: &pb.Protocol_IssueCredentialMsg_Attributes{
+			Attributes: &pb.Protocol_IssuingAttributes{
+				Attributes: []*pb.Protocol_IssuingAttributes_Attribute{
+					{Name: "email", Value: "email@example.com"},
+				},
+			},
+		},
+	})
+	assert.NoError(err)
+	assert.SLen(fromJSON.CredentialAttrs, 1)
+	assert.DeepEqual(fromJSON.CredentialAttrs, fromAttrs.CredentialAttrs)
+
+	// the binary values are base64 encoded
+	image, err := create(&pb.Protocol_IssueCredentialMsg{
+		AttrFmt: &pb.Protocol_IssueCredentialMsg_AttributesJSON{
+			AttributesJSON: `[{"name":"photo","mime-type":"image/png","value":"iVBORw0KGgo="}]`,
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(image.CredentialAttrs[0].MimeType, "image/png")
+
+	// the attributes are mandatory, the JSON must be valid, and the values
+	// must match their MIME types
+	old := assert.SetDefault(assert.Production)
+	defer assert.SetDefault(old)
+	for _, msg := range []*pb.Protocol_IssueCredentialMsg{
+		{AttrFmt: &pb.Protocol_IssueCredentialMsg_AttributesJSON{
+			AttributesJSON: `[{"name":"photo","mime-type":"image/png","value":"not an image"}]`,
+		}},
+		{},
+		{AttrFmt: &pb.Protocol_IssueCredentialMsg_AttributesJSON{}},
+		{AttrFmt: &pb.Protocol_IssueCredentialMsg_AttributesJSON{
+			AttributesJSON: `[{"name":"email"`,
+		}},
+	} {
+		_, err = create(msg)
+		assert.Error(err)
+	}
+}
+
+func TestStartIssueCredential_Transform(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	var started []comm.Task
+	defer func(f func(prot.Initial) error) { startPSM = f }(startPSM)
+	startPSM = func(p prot.Initial) error {
+		started = append(started, p.T)
+		return nil
+	}
+
+	// the program running the agency as a library registers the hook
+	ca := &issuerRcvr{}
+	issuer.RegisterTransform(ca, "code", func(v string) (string, error) {
+		return strings.ToUpper(v), nil
+	})
+	defer issuer.RegisterTransform(ca, "code", nil)
+
+	task, err := createIssueCredentialTask(&comm.TaskHeader{
+		TaskID: "transform-id",
+		TypeID: pltype.CACredOffer,
+		ConnID: "conn-id",
+	}, &pb.Protocol{
+		Role: pb.Protocol_INITIATOR,
+		StartMsg: &pb.Protocol_IssueCredential{IssueCredential: &pb.Protocol_IssueCredentialMsg{
+			CredDefID: "cred-def-id",
+			AttrFmt: &pb.Protocol_IssueCredentialMsg_Attributes{
+				Attributes: &pb.Protocol_IssuingAttributes{
+					Attributes: []*pb.Protocol_IssuingAttributes_Attribute{
+						{Name: "code", Value: "ab-123"},
+						{Name: "name", Value: "Alice"},
+					},
+				},
+			},
+		}},
+	})
+	assert.NoError(err)
+
+	// the connection hasn't disclosed its features, which isn't an error
+	old := assert.SetDefault(assert.Production)
+	startIssueCredentialByPropose(ca, task)
+	assert.SetDefault(old)
+	assert.SLen(started, 1)
+	attrs := started[0].(*taskIssueCredential).CredentialAttrs
+	assert.Equal(attrs[0].Value, "AB-123")
+	assert.Equal(attrs[1].Value, "Alice")
+}
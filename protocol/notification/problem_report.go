@@ -15,6 +15,7 @@ import (
 var processor = comm.ProtProc{Starter: startProtocol,
 	Handlers: map[string]comm.HandlerFunc{
 		pltype.HandlerProblemReport: handleProblemReport,
+		pltype.HandlerAck:           handleAck,
 	}}
 
 func init() {
@@ -59,3 +60,15 @@ func handleProblemReport(packet comm.Packet) (err error) {
 		InOut:       tHandler,
 	})
 }
+
+// handleAck handles the ack which we asked with the please ack decorator. The
+// ack is threaded to the protocol whose PSM counts the received acks.
+func handleAck(packet comm.Packet) (err error) {
+	defer err2.Handle(&err, "notification ack")
+
+	ack := packet.Payload.MsgHdr().FieldObj().(*common.Ack)
+	glog.V(3).Infoln("ack received:", ack.Status, "thread:", packet.Payload.ThreadID())
+
+	key := psm.NewStateKey(packet.Receiver, packet.Payload.ThreadID())
+	return prot.AckReceived(key)
+}
@@ -0,0 +1,94 @@
+package data
+
+import (
+	"sort"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+)
+
+// Fulfillment tells which requested attributes and predicates of the proof
+// request the prover can satisfy with its credentials. The prover checks it
+// before creating the proof, if the agent has comm.FeatureRejectUnfulfillable.
+type Fulfillment struct {
+	Items []FulfillmentItem
+}
+
+// FulfillmentItem is the requested attribute or predicate by its referent. The
+// self-attested attribute can be fulfilled as well.
+type FulfillmentItem struct {
+	ID         string
+	Name       string
+	Predicate  bool
+	CanFulfill bool
+}
+
+// Complete tells if the proof can be created.
+func (f *Fulfillment) Complete() bool {
+	return len(f.Missing()) == 0
+}
+
+// Missing returns the items which the prover cannot satisfy.
+func (f *Fulfillment) Missing() []FulfillmentItem {
+	missing := make([]FulfillmentItem, 0, len(f.Items))
+	for _, item := range f.Items {
+		if !item.CanFulfill {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
+
+// CanFulfill evaluates the proof request of the rep against the credentials
+// of the receiver's wallet like CreateProof selects them.
+func (rep *PresentProofRep) CanFulfill(receiver comm.Receiver) (f *Fulfillment, err error) {
+	defer err2.Handle(&err, "can fulfill")
+
+	assert.NotEmpty(rep.ProofReq, "proof request missing")
+
+	var proofReq anoncreds.ProofRequest
+	dto.FromJSONStr(rep.ProofReq, &proofReq)
+	allowSelfAttested := comm.FeatureOr(receiver.MyDID().Did(),
+		comm.FeatureAllowSelfAttested, true)
+	reqCred, _ := rep.processAttributes(receiver.Wallet(), proofReq,
+		allowSelfAttested)
+	return fulfillment(proofReq, reqCred), nil
+}
+
+// fulfillment returns the fulfillment of the proof request by the selected
+// credentials. Attributes come first and the items are sorted by referents.
+func fulfillment(
+	proofReq anoncreds.ProofRequest,
+	reqCred anoncreds.RequestedCredentials,
+) *Fulfillment {
+	items := make([]FulfillmentItem, 0,
+		len(proofReq.RequestedAttributes)+len(proofReq.RequestedPredicates))
+	for ref, info := range proofReq.RequestedAttributes {
+		_, found := reqCred.RequestedAttributes[ref]
+		_, selfAttested := reqCred.SelfAttestedAttributes[ref]
+		items = append(items, FulfillmentItem{
+			ID:         ref,
+			Name:       info.Name,
+			CanFulfill: found || selfAttested,
+		})
+	}
+	for ref, info := range proofReq.RequestedPredicates {
+		_, found := reqCred.RequestedPredicates[ref]
+		items = append(items, FulfillmentItem{
+			ID:         ref,
+			Name:       info.Name,
+			Predicate:  true,
+			CanFulfill: found,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Predicate != items[j].Predicate {
+			return !items[i].Predicate
+		}
+		return items[i].ID < items[j].ID
+	})
+	return &Fulfillment{Items: items}
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+)
+
+func TestFulfillment(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	proofReq := anoncreds.ProofRequest{
+		RequestedAttributes: map[string]anoncreds.AttrInfo{
+			"email_ref": {Name: "email"},
+			"degree_ref": {
+				Name:         "degree",
+				Restrictions: []anoncreds.Filter{{CredDefID: "cred_def_id"}},
+			},
+		},
+		RequestedPredicates: map[string]anoncreds.PredicateInfo{
+			"age_ref": {Name: "age", PType: ">=", PValue: 18},
+		},
+	}
+	// the holder has a credential only for the email
+	reqCred := anoncreds.RequestedCredentials{
+		RequestedAttributes: map[string]anoncreds.RequestedAttrObject{
+			"email_ref": {CredID: "cred1", Revealed: true},
+		},
+	}
+
+	f := fulfillment(proofReq, reqCred)
+	assert.That(!f.Complete())
+	assert.SLen(f.Items, 3)
+	assert.Equal(f.Items[0], FulfillmentItem{ID: "degree_ref", Name: "degree"})
+	assert.Equal(f.Items[1], FulfillmentItem{ID: "email_ref", Name: "email",
+		CanFulfill: true})
+	assert.Equal(f.Items[2], FulfillmentItem{ID: "age_ref", Name: "age",
+		Predicate: true})
+
+	missing := f.Missing()
+	assert.SLen(missing, 2)
+	assert.Equal(missing[0].Name, "degree")
+	assert.Equal(missing[1].Name, "age")
+
+	// self-attested attribute and the predicate credential fill the gap
+	reqCred.SelfAttestedAttributes = map[string]string{"degree_ref": "MSc"}
+	reqCred.RequestedPredicates = map[string]anoncreds.RequestedPredObject{
+		"age_ref": {CredID: "cred2"},
+	}
+	f = fulfillment(proofReq, reqCred)
+	assert.That(f.Complete())
+	assert.SLen(f.Missing(), 0)
+}
@@ -0,0 +1,23 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/findy-network/findy-agent/agent/utils"
+)
+
+// CheckRequestSize checks the amounts of the proof request's attributes and
+// predicates against the limits in utils.Settings. A proof request is costly
+// to prove, which is why oversized requests are rejected before the protocol
+// starts, both when we send them and when we receive them.
+func CheckRequestSize(attrs, predicates int) error {
+	if maxAttrs := utils.Settings.ProofMaxAttrs(); maxAttrs > 0 && attrs > maxAttrs {
+		return fmt.Errorf("proof request has %d attributes, max is %d",
+			attrs, maxAttrs)
+	}
+	if maxPreds := utils.Settings.ProofMaxPredicates(); maxPreds > 0 && predicates > maxPreds {
+		return fmt.Errorf("proof request has %d predicates, max is %d",
+			predicates, maxPreds)
+	}
+	return nil
+}
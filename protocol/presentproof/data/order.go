@@ -0,0 +1,106 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+const requestedAttrsField = "requested_attributes"
+
+// AttrOrder returns the referents of the requested attributes in the order
+// they are declared in the proof request JSON. The JSON object is the only
+// place where the order is kept, because the proof request has the attributes
+// in a map.
+func AttrOrder(reqJSON []byte) (order []string, err error) {
+	defer err2.Handle(&err, "proof request attribute order")
+
+	d := json.NewDecoder(bytes.NewReader(reqJSON))
+	try.To(expectDelim(d, '{'))
+	for d.More() {
+		key := try.To1(d.Token())
+		if key != requestedAttrsField {
+			var skip json.RawMessage
+			try.To(d.Decode(&skip))
+			continue
+		}
+		try.To(expectDelim(d, '{'))
+		for d.More() {
+			ref := try.To1(d.Token())
+			order = append(order, ref.(string))
+			var skip json.RawMessage
+			try.To(d.Decode(&skip))
+		}
+		return order, nil
+	}
+	return nil, nil
+}
+
+// OrderedReferents returns the referents of the attributes in the order. The
+// referents missing from the order come last in sorted order, which keeps the
+// result stable.
+func OrderedReferents[T any](attrs map[string]T, order []string) []string {
+	refs := make([]string, 0, len(attrs))
+	seen := make(map[string]bool, len(attrs))
+	for _, ref := range order {
+		if _, exists := attrs[ref]; exists && !seen[ref] {
+			refs = append(refs, ref)
+			seen[ref] = true
+		}
+	}
+	rest := make([]string, 0, len(attrs)-len(refs))
+	for ref := range attrs {
+		if !seen[ref] {
+			rest = append(rest, ref)
+		}
+	}
+	sort.Strings(rest)
+	return append(refs, rest...)
+}
+
+// OrderAttrs returns the proof request JSON where the requested attributes
+// are declared in the order, see AttrOrder. The JSON encoder writes the map
+// keys sorted, which is why the proof requests are ordered with this before
+// they are sent.
+func OrderAttrs(reqJSON string, order []string) (_ string, err error) {
+	defer err2.Handle(&err, "order proof request attributes")
+
+	var req map[string]json.RawMessage
+	try.To(json.Unmarshal([]byte(reqJSON), &req))
+	raw, exists := req[requestedAttrsField]
+	if !exists {
+		return reqJSON, nil
+	}
+	// the attributes are kept as they are, e.g. their value restrictions
+	var attrs map[string]json.RawMessage
+	try.To(json.Unmarshal(raw, &attrs))
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, ref := range OrderedReferents(attrs, order) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(try.To1(json.Marshal(ref)))
+		buf.WriteByte(':')
+		buf.Write(attrs[ref])
+	}
+	buf.WriteByte('}')
+	req[requestedAttrsField] = buf.Bytes()
+	return string(try.To1(json.Marshal(req))), nil
+}
+
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("JSON %v expected", delim)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+)
+
+func TestOrderAttrs(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	req := &anoncreds.ProofRequest{
+		Name:    "ProofReq",
+		Version: "0.1",
+		Nonce:   "123",
+		RequestedAttributes: map[string]anoncreds.AttrInfo{
+			"attr_referent_1":  {Name: "email"},
+			"attr_referent_2":  {Name: "name"},
+			"attr_referent_10": {Name: "city"},
+			"zip":              {Name: "zip"},
+		},
+		RequestedPredicates: map[string]anoncreds.PredicateInfo{},
+	}
+	order := []string{"zip", "attr_referent_2", "attr_referent_10", "attr_referent_1"}
+
+	reqJSON, err := OrderAttrs(dto.ToJSON(req), order)
+	assert.NoError(err)
+	got, err := AttrOrder([]byte(reqJSON))
+	assert.NoError(err)
+	assert.DeepEqual(got, order)
+
+	// the content is the same
+	var ordered anoncreds.ProofRequest
+	dto.FromJSONStr(reqJSON, &ordered)
+	assert.DeepEqual(ordered, *req)
+
+	// the referents missing from the order come last sorted
+	got, err = AttrOrder([]byte(dto.ToJSON(req)))
+	assert.NoError(err)
+	assert.DeepEqual(OrderedReferents(req.RequestedAttributes, got[:1]),
+		[]string{"attr_referent_1", "attr_referent_10", "attr_referent_2", "zip"})
+	assert.DeepEqual(OrderedReferents(req.RequestedAttributes, []string{"zip", "missing"}),
+		[]string{"zip", "attr_referent_1", "attr_referent_10", "attr_referent_2"})
+
+	_, err = AttrOrder([]byte(`["not an object"]`))
+	assert.Error(err)
+}
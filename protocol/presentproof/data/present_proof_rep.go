@@ -1,14 +1,19 @@
 package data
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+
 	"github.com/findy-network/findy-agent/agent/comm"
 	"github.com/findy-network/findy-agent/agent/didcomm"
 	"github.com/findy-network/findy-agent/agent/psm"
 	"github.com/findy-network/findy-agent/agent/vc"
+	"github.com/findy-network/findy-agent/std/presentproof"
 	"github.com/findy-network/findy-common-go/dto"
-	"github.com/findy-network/findy-wrapper-go"
 	"github.com/findy-network/findy-wrapper-go/anoncreds"
 	"github.com/golang/glog"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/lainio/err2"
 	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
@@ -23,6 +28,8 @@ type PresentProofRep struct {
 	Values     []string // TODO: reserved for indy-WQL
 	WeProposed bool
 	Attributes []didcomm.ProofAttribute
+	Verified   bool // set by the verifier after the proof is verified
+	V2         bool // the protocol is run with the 2.0 messages
 }
 
 func init() {
@@ -47,9 +54,24 @@ func (rep *PresentProofRep) Type() byte {
 	return bucketType
 }
 
+// fetchMax is the page size of the prover's credential search.
 const fetchMax = 2
 
-// CreateProof is PROVER side helper.
+// CheckNotPresented is VERIFIER side helper which returns an error if the
+// presentation to our proof request is already received. The proof is stored
+// to the rep with the PSM, which means that a replayed presentation is
+// rejected also after the agency restart.
+func (rep *PresentProofRep) CheckNotPresented() error {
+	if rep.Proof != "" {
+		return fmt.Errorf("presentation to the proof request (%s) already received",
+			rep.StateKey.Nonce)
+	}
+	return nil
+}
+
+// CreateProof is PROVER side helper. The proof can combine attributes and
+// predicates from several credentials, e.g. when the restrictions of the proof
+// request point at different cred defs.
 func (rep *PresentProofRep) CreateProof(packet comm.Packet, rootDID string) (err error) {
 	defer err2.Handle(&err, "create proof")
 
@@ -62,21 +84,27 @@ func (rep *PresentProofRep) CreateProof(packet comm.Packet, rootDID string) (err
 	var proofReq anoncreds.ProofRequest
 	dto.FromJSONStr(rep.ProofReq, &proofReq)
 
-	reqCred, allCredInfos := rep.processAttributes(w2, proofReq)
+	allowSelfAttested := comm.FeatureOr(packet.Receiver.MyDID().Did(),
+		comm.FeatureAllowSelfAttested, true)
+	reqCred, usedCreds := rep.processAttributes(w2, proofReq, allowSelfAttested)
 	reqCredJSON := dto.ToJSON(reqCred)
 
-	// get schemas and cred defs for all requested attributes from the ledger.
-	foundSchemas := make(map[string]struct{}, len(allCredInfos))
-	foundCredDefs := make(map[string]struct{}, len(allCredInfos))
-	for _, v := range allCredInfos {
-		foundSchemas[v.CredInfo.SchemaID] = struct{}{}
-		foundCredDefs[v.CredInfo.CredDefID] = struct{}{}
+	// get schemas and cred defs of all the credentials used in the proof from
+	// the ledger.
+	foundSchemas := make(map[string]struct{}, len(usedCreds))
+	foundCredDefs := make(map[string]struct{}, len(usedCreds))
+	for _, v := range usedCreds {
+		foundSchemas[v.SchemaID] = struct{}{}
+		foundCredDefs[v.CredDefID] = struct{}{}
 	}
+	glog.V(3).Infof("proof from %d credentials, %d cred defs",
+		len(usedCreds), len(foundCredDefs))
 
-	schemasJSON := try.To1(schemas(rootDID, foundSchemas))
-	credDefsJSON := try.To1(credDefs(rootDID, foundCredDefs))
+	pool := packet.Receiver.Pool()
+	schemasJSON := try.To1(schemas(pool, rootDID, foundSchemas))
+	credDefsJSON := try.To1(credDefs(pool, rootDID, foundCredDefs))
 
-	masterSec := try.To1(packet.Receiver.MasterSecret())
+	masterSec := try.To1(proofMasterSecret(packet.Receiver, usedCreds))
 	r := <-anoncreds.ProverCreateProof(w2, rep.ProofReq, reqCredJSON,
 		masterSec, schemasJSON, credDefsJSON, "{}")
 	try.To(r.Err())
@@ -84,15 +112,76 @@ func (rep *PresentProofRep) CreateProof(packet comm.Packet, rootDID string) (err
 	return nil
 }
 
+// proofMasterSecret returns the master secret the used credentials were
+// requested with. The credentials stored before their master secret was
+// recorded are bound to the oldest secret, i.e. the one created at onboarding.
+func proofMasterSecret(
+	rcvr comm.Receiver,
+	usedCreds map[string]anoncreds.CredentialInfo,
+) (sec string, err error) {
+	defer err2.Handle(&err, "proof master secret")
+
+	current := try.To1(rcvr.MasterSecret())
+	prevs := try.To1(rcvr.PrevMasterSecrets())
+
+	_, managedStorage := rcvr.ManagedWallet()
+	credStorage := managedStorage.Storage().CredentialStorage()
+	credSecrets := make(map[string]string, len(usedCreds))
+	for id := range usedCreds {
+		cred, err := credStorage.GetCredential(id)
+		if errors.Is(err, storage.ErrDataNotFound) {
+			continue
+		}
+		try.To(err)
+		credSecrets[id] = cred.MasterSecret
+	}
+	return selectMasterSecret(usedCreds, credSecrets, current, prevs)
+}
+
+// selectMasterSecret picks the master secret for the proof. credSecrets has
+// the recorded master secrets of the used credentials. All of the credentials
+// of one proof must be bound to the same master secret. The proof without
+// credentials, i.e. only self-attested attributes, uses the current secret.
+func selectMasterSecret(
+	usedCreds map[string]anoncreds.CredentialInfo,
+	credSecrets map[string]string,
+	current string,
+	prevs []string,
+) (sec string, err error) {
+	oldest := current
+	if len(prevs) > 0 {
+		oldest = prevs[len(prevs)-1]
+	}
+	for id := range usedCreds {
+		credSec := credSecrets[id]
+		if credSec == "" {
+			credSec = oldest
+		}
+		if sec != "" && sec != credSec {
+			return "", errors.New("credentials are bound to different master secrets")
+		}
+		sec = credSec
+	}
+	if sec == "" {
+		sec = current
+	}
+	return sec, nil
+}
+
+// processAttributes selects a credential for every requested attribute and
+// predicate. The selected credentials are returned by their referents. The
+// attributes without restrictions and credentials are self-attested if it's
+// allowed.
 func (rep *PresentProofRep) processAttributes(
 	w2 int,
 	proofReq anoncreds.ProofRequest,
+	allowSelfAttested bool,
 ) (
 	anoncreds.RequestedCredentials,
-	[]anoncreds.Credentials,
+	map[string]anoncreds.CredentialInfo,
 ) {
-	// TODO: build from rep.Values, see Go findy-wrapper-go, anoncreds_test.go
-	wql := findy.NullString
+	valueFilters := try.To1(ValueRestrictions([]byte(rep.ProofReq)))
+	wql := ValueQuery(valueFilters)
 	r := <-anoncreds.ProverSearchCredentialsForProofReq(w2, rep.ProofReq, wql)
 	try.To(r.Err())
 	searchHandle := r.Handle()
@@ -103,39 +192,22 @@ func (rep *PresentProofRep) processAttributes(
 		RequestedPredicates:    make(map[string]anoncreds.RequestedPredObject),
 	}
 
-	allCredInfos := make([]anoncreds.Credentials, 0, fetchMax)
+	usedCreds := make(map[string]anoncreds.CredentialInfo)
 
 	// gather cred infos for requested attributes.
 	for attrRef, aInfo := range proofReq.RequestedAttributes {
-		foundAlready := false
-		for {
-			r = <-anoncreds.ProverFetchCredentialsForProofReq(searchHandle,
-				attrRef, fetchMax)
-			try.To(r.Err())
-			credentials := r.Str1()
-			credInfo := make([]anoncreds.Credentials, 0, fetchMax)
-			dto.FromJSONStr(credentials, &credInfo)
-			allCredInfos = append(allCredInfos, credInfo...)
-
-			// we add the first found cred info of the patch to requested
-			// attribute we are processing
-			if len(credInfo) > 0 {
-				foundAlready = true
-				obj := anoncreds.RequestedAttrObject{
-					CredID:    credInfo[0].CredInfo.Referent,
-					Revealed:  true,
-					Timestamp: nil,
-				}
-				reqCred.RequestedAttributes[attrRef] = obj
-			}
-
-			if len(credInfo) == fetchMax {
-				glog.V(1).Info("--- There's more cred infos for attr ---")
-				continue
+		credInfo, found := firstCredential(searchHandle, attrRef,
+			valueFilters[attrRef])
+		if found {
+			usedCreds[credInfo.Referent] = credInfo
+			reqCred.RequestedAttributes[attrRef] = anoncreds.RequestedAttrObject{
+				CredID:    credInfo.Referent,
+				Revealed:  true,
+				Timestamp: nil,
 			}
-			break
 		}
-		selfAttestedNeedsToBeSet := !foundAlready && len(aInfo.Restrictions) == 0
+		selfAttestedNeedsToBeSet := allowSelfAttested && !found &&
+			len(aInfo.Restrictions) == 0
 
 		if selfAttestedNeedsToBeSet {
 			glog.V(1).Info("Self attested attr:", aInfo.Name)
@@ -145,44 +217,56 @@ func (rep *PresentProofRep) processAttributes(
 
 	// gather cred infos for predicated attributes
 	for predicateRef := range proofReq.RequestedPredicates {
-		for {
-			r = <-anoncreds.ProverFetchCredentialsForProofReq(searchHandle,
-				predicateRef, fetchMax)
-			try.To(r.Err())
-			credentials := r.Str1()
-			credInfo := make([]anoncreds.Credentials, 0, fetchMax)
-			dto.FromJSONStr(credentials, &credInfo)
-			allCredInfos = append(allCredInfos, credInfo...)
-
-			// we add the first found cred info of the patch to requested
-			// attribute we are processing
-			if len(credInfo) > 0 {
-				obj := anoncreds.RequestedPredObject{
-					CredID:    credInfo[0].CredInfo.Referent,
-					Timestamp: nil,
-				}
-				reqCred.RequestedPredicates[predicateRef] = obj
+		credInfo, found := firstCredential(searchHandle, predicateRef, nil)
+		if found {
+			usedCreds[credInfo.Referent] = credInfo
+			reqCred.RequestedPredicates[predicateRef] = anoncreds.RequestedPredObject{
+				CredID:    credInfo.Referent,
+				Timestamp: nil,
 			}
-
-			if len(credInfo) == fetchMax {
-				glog.V(1).Info("--- There's more pred infos for attr ---")
-				continue
-			}
-			break
 		}
 	}
 
 	r = <-anoncreds.ProverCloseCredentialsSearchForProofReq(searchHandle)
 	try.To(r.Err())
-	return reqCred, allCredInfos
+	return reqCred, usedCreds
+}
+
+// firstCredential returns the first credential of the search which matches
+// the referent of the proof request. Every referent is searched separately,
+// which lets the attributes come from different credentials. The credential
+// must have the attribute values of the value filters, see ValueRestrictions.
+// The search has the filters as its WQL query, see ValueQuery, and the fetched
+// credentials are checked against them too. The search is fetched fetchMax
+// credentials at a time until a matching one is found or the search runs out.
+func firstCredential(
+	searchHandle int,
+	ref string,
+	filters []ValueFilter,
+) (
+	info anoncreds.CredentialInfo,
+	found bool,
+) {
+	for {
+		r := <-anoncreds.ProverFetchCredentialsForProofReq(searchHandle, ref,
+			fetchMax)
+		try.To(r.Err())
+		credInfo := make([]anoncreds.Credentials, 0, fetchMax)
+		dto.FromJSONStr(r.Str1(), &credInfo)
+		info, found = SelectCredential(credInfo, filters)
+		if found || len(credInfo) < fetchMax {
+			return info, found
+		}
+		glog.V(3).Infof("no matching cred info for (%s) yet, fetching more", ref)
+	}
 }
 
-func credDefs(DID string, credDefIDs map[string]struct{}) (cJSON string, err error) {
+func credDefs(pool int, DID string, credDefIDs map[string]struct{}) (cJSON string, err error) {
 	defer err2.Handle(&err, "cred defs")
 
 	credDefs := make(map[string]map[string]interface{}, len(credDefIDs))
 	for cdID := range credDefIDs {
-		credDef := try.To1(vc.CredDefFromLedger(DID, cdID))
+		credDef := try.To1(vc.CredDefFromLedger(pool, DID, cdID))
 		credDefObject := map[string]interface{}{}
 		dto.FromJSONStr(credDef, &credDefObject)
 		credDefs[cdID] = credDefObject
@@ -191,13 +275,13 @@ func credDefs(DID string, credDefIDs map[string]struct{}) (cJSON string, err err
 	return credDefsJSON, nil
 }
 
-func schemas(DID string, schemaIDs map[string]struct{}) (sJSON string, err error) {
+func schemas(pool int, DID string, schemaIDs map[string]struct{}) (sJSON string, err error) {
 	defer err2.Handle(&err, "get schemas")
 
 	schemas := make(map[string]map[string]interface{}, len(schemaIDs))
 	for schemaID := range schemaIDs {
 		sch := vc.Schema{ID: schemaID}
-		try.To(sch.FromLedger(DID))
+		try.To(sch.FromLedger(pool, DID))
 		schemaObject := map[string]interface{}{}
 		dto.FromJSONStr(sch.LazySchema(), &schemaObject)
 		schemas[schemaID] = schemaObject
@@ -212,18 +296,117 @@ func (rep *PresentProofRep) VerifyProof(packet comm.Packet) (ack bool, err error
 	var proof anoncreds.Proof
 	dto.FromJSONStr(rep.Proof, &proof)
 
-	rootDID := packet.Receiver.RootDid().Did()
+	pool, rootDID := packet.Receiver.Pool(), packet.Receiver.RootDid().Did()
 	schemaIDs := getSchemaIDs(proof.Identifiers)
-	schemasJSON := try.To1(schemas(rootDID, schemaIDs))
+	schemasJSON := try.To1(schemas(pool, rootDID, schemaIDs))
 
 	credDefIDs := getCredDefIDs(proof.Identifiers)
-	credDefsJSON := try.To1(credDefs(rootDID, credDefIDs))
+	credDefsJSON := try.To1(credDefs(pool, rootDID, credDefIDs))
 
 	r := <-anoncreds.VerifierVerifyProof(rep.ProofReq, rep.Proof, schemasJSON, credDefsJSON, "{}", "{}")
 	try.To(r.Err())
 	return r.Yes(), nil
 }
 
+// CheckFeatures checks the proof against the feature flags of the verifier
+// agent, see comm.FeatureAllowSelfAttested and
+// comm.FeatureRequireRevocationCheck. The proof itself is verified with
+// VerifyProof.
+func (rep *PresentProofRep) CheckFeatures(agentDID string) error {
+	var proof anoncreds.Proof
+	dto.FromJSONStr(rep.Proof, &proof)
+
+	if !comm.FeatureOr(agentDID, comm.FeatureAllowSelfAttested, true) &&
+		len(proof.RequestedProof.SelfAttestedAttrs) > 0 {
+		return fmt.Errorf("%d self-attested attributes not allowed",
+			len(proof.RequestedProof.SelfAttestedAttrs))
+	}
+	if comm.FeatureOr(agentDID, comm.FeatureRequireRevocationCheck, false) {
+		for _, id := range proof.Identifiers {
+			if id.RevRegID == "" || id.Timestamp == "" {
+				return fmt.Errorf("credential of cred def (%s) has no revocation state",
+					id.CredDefID)
+			}
+		}
+	}
+	return nil
+}
+
+// ProofResult is the verifier side result of the proof verification.
+type ProofResult struct {
+	Verified   bool
+	Revealed   []RevealedAttribute
+	Predicates []PredicateResult
+}
+
+// RevealedAttribute is the revealed value of the requested attribute. ID is
+// the referent of the attribute in the proof request.
+type RevealedAttribute struct {
+	ID    string
+	Name  string
+	Value string
+}
+
+// PredicateResult tells if the requested predicate was satisfied by the proof.
+type PredicateResult struct {
+	ID        string
+	Name      string
+	PType     string
+	PValue    int
+	Satisfied bool
+}
+
+// Result builds the verification result from the stored proof request and
+// proof. Anoncreds proof includes only the predicates it satisfies, and the
+// proof must be verified for any of them to count. Results are sorted by IDs.
+func (rep *PresentProofRep) Result() *ProofResult {
+	var proofReq anoncreds.ProofRequest
+	dto.FromJSONStr(rep.ProofReq, &proofReq)
+	var proof anoncreds.Proof
+	if rep.Proof != "" {
+		dto.FromJSONStr(rep.Proof, &proof)
+	}
+
+	res := &ProofResult{
+		Verified:   rep.Verified,
+		Revealed:   make([]RevealedAttribute, 0, len(proof.RequestedProof.RevealedAttrs)),
+		Predicates: make([]PredicateResult, 0, len(proofReq.RequestedPredicates)),
+	}
+	for id, attr := range proof.RequestedProof.RevealedAttrs {
+		res.Revealed = append(res.Revealed, RevealedAttribute{
+			ID:    id,
+			Name:  proofReq.RequestedAttributes[id].Name,
+			Value: attr.Raw,
+		})
+	}
+	for id, pred := range proofReq.RequestedPredicates {
+		_, proved := proof.RequestedProof.Predicates[id]
+		res.Predicates = append(res.Predicates, PredicateResult{
+			ID:        id,
+			Name:      pred.Name,
+			PType:     pred.PType,
+			PValue:    pred.PValue,
+			Satisfied: rep.Verified && proved,
+		})
+	}
+	sort.Slice(res.Revealed, func(i, j int) bool {
+		return res.Revealed[i].ID < res.Revealed[j].ID
+	})
+	sort.Slice(res.Predicates, func(i, j int) bool {
+		return res.Predicates[i].ID < res.Predicates[j].ID
+	})
+	return res
+}
+
+// VerifiableProof parses the received proof for custom verification, e.g.
+// by the controller when the proof waits its approval.
+func (rep *PresentProofRep) VerifiableProof() (vp *presentproof.VerifiableProof, err error) {
+	defer err2.Handle(&err)
+
+	assert.NotEmpty(rep.Proof, "proof not received")
+	return presentproof.ParseVerifiableProof([]byte(rep.Proof), []byte(rep.ProofReq))
+}
+
 func getSchemaIDs(identifiers []anoncreds.IdentifiersObj) map[string]struct{} {
 	IDs := make(map[string]struct{}, len(identifiers))
 	for _, v := range identifiers {
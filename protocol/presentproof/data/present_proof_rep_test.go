@@ -0,0 +1,110 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+)
+
+func TestPresentProofRep_Result(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	proofReq := anoncreds.ProofRequest{
+		Name:    "ProofReq",
+		Version: "0.1",
+		RequestedAttributes: map[string]anoncreds.AttrInfo{
+			"attr_referent_1": {Name: "email"},
+			"attr_referent_2": {Name: "name"},
+		},
+		RequestedPredicates: map[string]anoncreds.PredicateInfo{
+			"predicate_1": {Name: "age", PType: ">=", PValue: 18},
+			"predicate_2": {Name: "score", PType: ">", PValue: 100},
+		},
+	}
+	proof := anoncreds.Proof{
+		RequestedProof: anoncreds.RequestedProof{
+			RevealedAttrs: map[string]anoncreds.RevealedAttr{
+				"attr_referent_2": {Raw: "Alice"},
+				"attr_referent_1": {Raw: "alice@example.com"},
+			},
+			Predicates: map[string]interface{}{
+				"predicate_1": map[string]interface{}{"sub_proof_index": 0},
+			},
+		},
+	}
+	rep := &PresentProofRep{
+		ProofReq: dto.ToJSON(proofReq),
+		Proof:    dto.ToJSON(proof),
+		Verified: true,
+	}
+
+	res := rep.Result()
+	assert.That(res.Verified)
+	assert.SLen(res.Revealed, 2)
+	assert.Equal(RevealedAttribute{
+		ID:    "attr_referent_1",
+		Name:  "email",
+		Value: "alice@example.com",
+	}, res.Revealed[0])
+	assert.Equal(RevealedAttribute{
+		ID:    "attr_referent_2",
+		Name:  "name",
+		Value: "Alice",
+	}, res.Revealed[1])
+	assert.SLen(res.Predicates, 2)
+	assert.Equal("age", res.Predicates[0].Name)
+	assert.That(res.Predicates[0].Satisfied)
+	assert.Equal("score", res.Predicates[1].Name)
+	assert.That(!res.Predicates[1].Satisfied)
+
+	rep.Verified = false
+	res = rep.Result()
+	assert.That(!res.Verified)
+	assert.That(!res.Predicates[0].Satisfied)
+}
+
+func TestSelectMasterSecret(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	prevs := []string{"second", "first"}
+	used := map[string]anoncreds.CredentialInfo{"cred1": {}, "cred2": {}}
+
+	// self-attested only proof uses the current secret
+	sec, err := selectMasterSecret(nil, nil, "third", prevs)
+	assert.NoError(err)
+	assert.Equal("third", sec)
+
+	sec, err = selectMasterSecret(used,
+		map[string]string{"cred1": "second", "cred2": "second"}, "third", prevs)
+	assert.NoError(err)
+	assert.Equal("second", sec)
+
+	// credentials without the recorded secret are bound to the oldest one
+	sec, err = selectMasterSecret(used,
+		map[string]string{"cred1": "first"}, "third", prevs)
+	assert.NoError(err)
+	assert.Equal("first", sec)
+	sec, err = selectMasterSecret(used, nil, "first", nil)
+	assert.NoError(err)
+	assert.Equal("first", sec)
+
+	_, err = selectMasterSecret(used,
+		map[string]string{"cred1": "second", "cred2": "third"}, "third", prevs)
+	assert.Error(err)
+}
+
+func TestPresentProofRep_CheckNotPresented(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	rep := &PresentProofRep{ProofReq: "{}"}
+	assert.NoError(rep.CheckNotPresented())
+
+	// replayed presentation
+	rep.Proof = "{}"
+	assert.Error(rep.CheckNotPresented())
+}
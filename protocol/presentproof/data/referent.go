@@ -0,0 +1,70 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	attrReferentPrefix      = "attr_referent_"
+	predicateReferentPrefix = "predicate_"
+)
+
+// NormalizeAttrName returns the attribute name trimmed from surrounding white
+// space. Spaces and non-ASCII characters inside the name are kept as they are,
+// because anoncreds matches the names without white space and case, and the
+// name is shown to the users as given.
+func NormalizeAttrName(name string) (string, error) {
+	if !utf8.ValidString(name) {
+		return "", fmt.Errorf("attribute name (%q) isn't valid UTF-8", name)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("attribute name missing")
+	}
+	return name, nil
+}
+
+// AttrReferent returns a unique referent for the requested attribute at the
+// index. The given ID is used only when it's safe for the referent, i.e. it
+// has only ASCII letters, digits, '_', and '-'.
+func AttrReferent[T any](refs map[string]T, id string, index int) string {
+	return referent(refs, attrReferentPrefix, id, index)
+}
+
+// PredicateReferent returns a unique referent for the requested predicate at
+// the index like AttrReferent.
+func PredicateReferent[T any](refs map[string]T, id string, index int) string {
+	return referent(refs, predicateReferentPrefix, id, index)
+}
+
+func referent[T any](refs map[string]T, prefix, id string, index int) string {
+	ref := id
+	if !safeReferent(ref) {
+		ref = prefix + strconv.Itoa(index+1)
+	}
+	unique := ref
+	for i := 2; ; i++ {
+		if _, exists := refs[unique]; !exists {
+			return unique
+		}
+		unique = ref + "_" + strconv.Itoa(i)
+	}
+}
+
+func safeReferent(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestNormalizeAttrName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		ok   bool
+	}{
+		{"plain", "email", "email", true},
+		{"spaces", " home address ", "home address", true},
+		{"unicode", "syntymäpäivä", "syntymäpäivä", true},
+		{"empty", "  ", "", false},
+		{"invalid utf8", "bad\xff", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer assert.PushTester(t)()
+
+			got, err := NormalizeAttrName(tt.in)
+			if !tt.ok {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestAttrReferent(t *testing.T) {
+	defer assert.PushTester(t)()
+
+	refs := make(map[string]bool)
+	add := func(id string, index int) string {
+		ref := AttrReferent(refs, id, index)
+		refs[ref] = true
+		return ref
+	}
+	assert.Equal(add("", 0), "attr_referent_1")
+	assert.Equal(add("email_ref", 1), "email_ref")
+	assert.Equal(add("home address", 2), "attr_referent_3")
+	assert.Equal(add("päivä", 3), "attr_referent_4")
+	assert.Equal(add("attr_referent_1", 4), "attr_referent_1_2")
+	assert.Equal(add("", 0), "attr_referent_1_3")
+	assert.MLen(refs, 6)
+
+	assert.Equal(PredicateReferent(map[string]int{}, "age over", 0), "predicate_1")
+}
@@ -0,0 +1,183 @@
+package data
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// The value restrictions of the requested attributes require the credential
+// of the attribute to have the attribute values, e.g. country is FI, without
+// revealing them. They are WQL restrictions of anoncreds, which is why they
+// are added to the proof request JSON: the restriction filters of the wrapper
+// don't have them.
+const (
+	attrTagPrefix      = "attr::"
+	attrValueTagSuffix = "::value"
+)
+
+// ValueFilter is the attribute values of one restriction filter by attribute
+// name.
+type ValueFilter map[string]string
+
+// AddValueRestrictions returns the proof request JSON where the requested
+// attributes have the value restrictions by referent. The values are set to
+// every restriction filter of the attribute, because the filters are OR'ed.
+func AddValueRestrictions(
+	reqJSON string,
+	values map[string]ValueFilter,
+) (
+	_ string,
+	err error,
+) {
+	defer err2.Handle(&err, "add value restrictions")
+
+	if len(values) == 0 {
+		return reqJSON, nil
+	}
+	var req map[string]json.RawMessage
+	try.To(json.Unmarshal([]byte(reqJSON), &req))
+	var attrs map[string]map[string]json.RawMessage
+	try.To(json.Unmarshal(req[requestedAttrsField], &attrs))
+
+	for ref, filter := range values {
+		attr, exists := attrs[ref]
+		if !exists || len(filter) == 0 {
+			continue
+		}
+		// the filters may have WQL operators, which is why they are raw
+		var restrictions []map[string]json.RawMessage
+		if raw, ok := attr["restrictions"]; ok {
+			try.To(json.Unmarshal(raw, &restrictions))
+		}
+		if len(restrictions) == 0 {
+			restrictions = []map[string]json.RawMessage{{}}
+		}
+		for _, r := range restrictions {
+			for name, value := range filter {
+				r[attrTagPrefix+didcomm.CanonicalAttrName(name)+attrValueTagSuffix] =
+					try.To1(json.Marshal(value))
+			}
+		}
+		attr["restrictions"] = try.To1(json.Marshal(restrictions))
+	}
+	req[requestedAttrsField] = try.To1(json.Marshal(attrs))
+	return string(try.To1(json.Marshal(req))), nil
+}
+
+// ValueRestrictions returns the value filters of the requested attributes by
+// referent. The attributes without value restrictions are left out.
+func ValueRestrictions(reqJSON []byte) (filters map[string][]ValueFilter, err error) {
+	defer err2.Handle(&err, "value restrictions")
+
+	var req struct {
+		RequestedAttributes map[string]struct {
+			Restrictions []map[string]json.RawMessage `json:"restrictions"`
+		} `json:"requested_attributes"`
+	}
+	try.To(json.Unmarshal(reqJSON, &req))
+
+	filters = make(map[string][]ValueFilter)
+	for ref, attr := range req.RequestedAttributes {
+		var refFilters []ValueFilter
+		for _, r := range attr.Restrictions {
+			f := make(ValueFilter)
+			for tag, raw := range r {
+				name, ok := strings.CutPrefix(tag, attrTagPrefix)
+				if !ok {
+					continue
+				}
+				var value string
+				name, ok = strings.CutSuffix(name, attrValueTagSuffix)
+				if ok && json.Unmarshal(raw, &value) == nil {
+					f[name] = value
+				}
+			}
+			refFilters = append(refFilters, f)
+		}
+		if hasValues(refFilters) {
+			filters[ref] = refFilters
+		}
+	}
+	return filters, nil
+}
+
+// ValueQuery returns the extra WQL query of the prover's credential search for
+// the value filters by referent, which lets the wallet skip the credentials
+// without the values. The filters of a referent are OR'ed like in the proof
+// request. No filters return the null query.
+func ValueQuery(filters map[string][]ValueFilter) string {
+	if len(filters) == 0 {
+		return findy.NullString
+	}
+	query := make(map[string]map[string][]map[string]string, len(filters))
+	for ref, refFilters := range filters {
+		or := make([]map[string]string, 0, len(refFilters))
+		for _, f := range refFilters {
+			tags := make(map[string]string, len(f))
+			for name, value := range f {
+				tags[attrTagPrefix+didcomm.CanonicalAttrName(name)+attrValueTagSuffix] = value
+			}
+			or = append(or, tags)
+		}
+		query[ref] = map[string][]map[string]string{"$or": or}
+	}
+	return dto.ToJSON(query)
+}
+
+// SelectCredential returns the first credential of which attribute values
+// match any of the filters. No filters match all of the credentials.
+func SelectCredential(
+	creds []anoncreds.Credentials,
+	filters []ValueFilter,
+) (
+	info anoncreds.CredentialInfo,
+	found bool,
+) {
+	for _, cred := range creds {
+		if matchAny(filters, cred.CredInfo.Attrs) {
+			return cred.CredInfo, true
+		}
+	}
+	return info, false
+}
+
+func matchAny(filters []ValueFilter, attrs map[string]string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	values := make(map[string]string, len(attrs))
+	for name, value := range attrs {
+		values[didcomm.CanonicalAttrName(name)] = value
+	}
+	for _, f := range filters {
+		if f.match(values) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f ValueFilter) match(values map[string]string) bool {
+	for name, value := range f {
+		if v, exists := values[didcomm.CanonicalAttrName(name)]; !exists || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+func hasValues(filters []ValueFilter) bool {
+	for _, f := range filters {
+		if len(f) > 0 {
+			return true
+		}
+	}
+	return false
+}
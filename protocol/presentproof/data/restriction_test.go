@@ -0,0 +1,74 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+)
+
+func TestValueRestrictions(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	req := &anoncreds.ProofRequest{
+		Name:    "ProofReq",
+		Version: "0.1",
+		Nonce:   "123",
+		RequestedAttributes: map[string]anoncreds.AttrInfo{
+			"name": {Name: "name", Restrictions: []anoncreds.Filter{
+				{CredDefID: "cred-def-1"}, {CredDefID: "cred-def-2"},
+			}},
+			"email": {Name: "email"},
+		},
+		RequestedPredicates: map[string]anoncreds.PredicateInfo{},
+	}
+	reqJSON, err := AddValueRestrictions(dto.ToJSON(req), map[string]ValueFilter{
+		"name":  {"Country": "FI"},
+		"email": {},
+	})
+	assert.NoError(err)
+	reqJSON, err = OrderAttrs(reqJSON, []string{"name", "email"})
+	assert.NoError(err)
+
+	// the value is set to every filter, and the rest of the request is kept
+	var got anoncreds.ProofRequest
+	dto.FromJSONStr(reqJSON, &got)
+	assert.SLen(got.RequestedAttributes["name"].Restrictions, 2)
+	assert.Equal(got.RequestedAttributes["name"].Restrictions[1].CredDefID, "cred-def-2")
+	assert.SLen(got.RequestedAttributes["email"].Restrictions, 0)
+
+	filters, err := ValueRestrictions([]byte(reqJSON))
+	assert.NoError(err)
+	assert.MLen(filters, 1)
+	assert.SLen(filters["name"], 2)
+	assert.DeepEqual(filters["name"][0], ValueFilter{"country": "FI"})
+
+	// the credential must have the value
+	creds := []anoncreds.Credentials{
+		{CredInfo: anoncreds.CredentialInfo{Referent: "se",
+			Attrs: map[string]string{"name": "Sven", "Country": "SE"}}},
+		{CredInfo: anoncreds.CredentialInfo{Referent: "fi",
+			Attrs: map[string]string{"name": "Aino", "Country": "FI"}}},
+	}
+	info, found := SelectCredential(creds, filters["name"])
+	assert.That(found)
+	assert.Equal(info.Referent, "fi")
+	_, found = SelectCredential(creds[:1], filters["name"])
+	assert.That(!found, "credential of other country selected")
+
+	// without the filters the first credential is used
+	info, found = SelectCredential(creds, filters["email"])
+	assert.That(found)
+	assert.Equal(info.Referent, "se")
+
+	// the wallet search has the same filters
+	var query map[string]map[string][]map[string]string
+	dto.FromJSONStr(ValueQuery(filters), &query)
+	assert.MLen(query, 1)
+	assert.SLen(query["name"]["$or"], 2)
+	assert.Equal(query["name"]["$or"][1]["attr::country::value"], "FI")
+	assert.Equal(ValueQuery(nil), findy.NullString)
+}
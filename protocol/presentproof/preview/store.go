@@ -10,11 +10,33 @@ import (
 	"github.com/findy-network/findy-wrapper-go/anoncreds"
 )
 
+// CheckProofReqSize checks the received proof request against the limits, see
+// data.CheckRequestSize. Every name of an attribute group is counted.
+func CheckProofReqSize(requestData []byte) error {
+	var proofReq anoncreds.ProofRequest
+	dto.FromJSON(requestData, &proofReq)
+	attrs := 0
+	for _, attr := range proofReq.RequestedAttributes {
+		if attr.Name != "" {
+			attrs++
+		} else {
+			attrs += len(attr.Names)
+		}
+	}
+	return data.CheckRequestSize(attrs, len(proofReq.RequestedPredicates))
+}
+
+// StoreProofData stores the requested attributes of the proof request to the
+// rep in the order they are declared in the request.
 func StoreProofData(requestData []byte, rep *data.PresentProofRep) {
 	var proofReq anoncreds.ProofRequest
 	dto.FromJSON(requestData, &proofReq)
+	// the attributes are kept in the order of the request, which is shown
+	// to the users. The referents are sorted if the order cannot be read.
+	order, _ := data.AttrOrder(requestData)
 	rep.Attributes = make([]didcomm.ProofAttribute, 0)
-	for id, attr := range proofReq.RequestedAttributes {
+	for _, id := range data.OrderedReferents(proofReq.RequestedAttributes, order) {
+		attr := proofReq.RequestedAttributes[id]
 		credDefID := ""
 		if len(attr.Restrictions) > 0 {
 			credDefID = attr.Restrictions[0].CredDefID
@@ -3,6 +3,7 @@ package presentproof
 
 import (
 	"encoding/gob"
+	"encoding/json"
 	"strconv"
 
 	"github.com/findy-network/findy-agent/agent/comm"
@@ -11,6 +12,7 @@ import (
 	"github.com/findy-network/findy-agent/agent/prot"
 	"github.com/findy-network/findy-agent/agent/psm"
 	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/protocol/discoverfeatures"
 	"github.com/findy-network/findy-agent/protocol/presentproof/data"
 	"github.com/findy-network/findy-agent/protocol/presentproof/prover"
 	"github.com/findy-network/findy-agent/protocol/presentproof/verifier"
@@ -45,8 +47,13 @@ var presentProofProcessor = comm.ProtProc{
 		pltype.HandlerPresentProofNACK:         handleProofNACK,
 	},
 	FillStatus: fillPresentProofStatus,
+	Versions:   []string{"1.0", "2.0"},
 }
 
+// presentProofV2 is the protocol ID the connection must disclose before we
+// send it 2.0 messages as a verifier.
+const presentProofV2 = pltype.DIDOrgPresentProof + "/2.0"
+
 func init() {
 	gob.Register(&taskPresentProof{})
 	prot.AddCreator(pltype.ProtocolPresentProof, presentProofProcessor)
@@ -69,39 +76,22 @@ func createPresentProofTask(header *comm.TaskHeader, protocol *pb.Protocol) (t c
 			protocol.GetRole() == pb.Protocol_INITIATOR || protocol.GetRole() == pb.Protocol_ADDRESSEE,
 			"role is needed for proof protocol")
 
-		// attributes - mandatory
-		if proof.GetAttributesJSON() != "" {
-			dto.FromJSONStr(proof.GetAttributesJSON(), &proofAttrs)
-			glog.V(3).Infoln("set proof attrs from json:", proof.GetAttributesJSON())
-		} else {
-			assert.That(proof.GetAttributes() != nil, "present proof attributes data missing")
-			proofAttrs = make([]didcomm.ProofAttribute, len(proof.GetAttributes().GetAttributes()))
-			for i, attribute := range proof.GetAttributes().GetAttributes() {
-				proofAttrs[i] = didcomm.ProofAttribute{
-					ID:        attribute.ID,
-					Name:      attribute.Name,
-					CredDefID: attribute.CredDefID,
-				}
-			}
-			glog.V(3).Infoln("set proof from attrs")
-		}
+		// attributes - optional when predicates are given
+		proofAttrs = try.To1(proofAttributes(proof))
 
 		// predicates - optional
-		if proof.GetPredicatesJSON() != "" {
-			dto.FromJSONStr(proof.GetPredicatesJSON(), &proofPredicates)
-			glog.V(3).Infoln("set proof predicates from json:", proof.GetPredicatesJSON())
-		} else if proof.GetPredicates() != nil {
-			proofPredicates = make([]didcomm.ProofPredicate, len(proof.GetPredicates().GetPredicates()))
-			for i, predicate := range proof.GetPredicates().GetPredicates() {
-				proofPredicates[i] = didcomm.ProofPredicate{
-					ID:     predicate.ID,
-					Name:   predicate.Name,
-					PType:  predicate.PType,
-					PValue: predicate.PValue,
-				}
-			}
-			glog.V(3).Infoln("set proof from predicates")
+		proofPredicates = try.To1(proofPredicateList(proof))
+		for i := range proofAttrs {
+			proofAttrs[i].Name = try.To1(data.NormalizeAttrName(proofAttrs[i].Name))
+			proofAttrs[i].Equals = try.To1(normalizeEquals(proofAttrs[i].Equals))
+		}
+		for i := range proofPredicates {
+			proofPredicates[i].Name = try.To1(data.NormalizeAttrName(proofPredicates[i].Name))
 		}
+		// predicate-only proof doesn't reveal any attribute values
+		assert.That(len(proofAttrs) > 0 || len(proofPredicates) > 0,
+			"present proof attributes or predicates missing")
+		try.To(data.CheckRequestSize(len(proofAttrs), len(proofPredicates)))
 
 		glog.V(1).Infof(
 			"Create task for PresentProof with connection id %s, role %s",
@@ -117,30 +107,100 @@ func createPresentProofTask(header *comm.TaskHeader, protocol *pb.Protocol) (t c
 	}, nil
 }
 
-func generateProofRequest(proofTask *taskPresentProof) *anoncreds.ProofRequest {
+// proofAttributes returns the requested attributes of the proof. The AttrFmt
+// oneof selects the format, which means that the JSON and the structured
+// attributes cannot conflict: only the set alternative is read. An empty JSON
+// is the same as no attributes.
+func proofAttributes(
+	proof *pb.Protocol_PresentProofMsg,
+) (
+	proofAttrs []didcomm.ProofAttribute,
+	err error,
+) {
+	defer err2.Handle(&err, "proof attributes")
+
+	switch attrFmt := proof.GetAttrFmt().(type) {
+	case *pb.Protocol_PresentProofMsg_AttributesJSON:
+		if attrFmt.AttributesJSON == "" {
+			return nil, nil
+		}
+		try.To(json.Unmarshal([]byte(attrFmt.AttributesJSON), &proofAttrs))
+		glog.V(3).Infoln("set proof attrs from json:", attrFmt.AttributesJSON)
+	case *pb.Protocol_PresentProofMsg_Attributes:
+		attributes := attrFmt.Attributes.GetAttributes()
+		proofAttrs = make([]didcomm.ProofAttribute, len(attributes))
+		for i, attribute := range attributes {
+			proofAttrs[i] = didcomm.ProofAttribute{
+				ID:        attribute.ID,
+				Name:      attribute.Name,
+				CredDefID: attribute.CredDefID,
+			}
+		}
+		glog.V(3).Infoln("set proof from attrs")
+	}
+	return proofAttrs, nil
+}
+
+// proofPredicateList returns the requested predicates of the proof by the
+// PredFmt oneof the same way as proofAttributes does the attributes.
+func proofPredicateList(
+	proof *pb.Protocol_PresentProofMsg,
+) (
+	proofPredicates []didcomm.ProofPredicate,
+	err error,
+) {
+	defer err2.Handle(&err, "proof predicates")
+
+	switch predFmt := proof.GetPredFmt().(type) {
+	case *pb.Protocol_PresentProofMsg_PredicatesJSON:
+		if predFmt.PredicatesJSON == "" {
+			return nil, nil
+		}
+		try.To(json.Unmarshal([]byte(predFmt.PredicatesJSON), &proofPredicates))
+		glog.V(3).Infoln("set proof predicates from json:", predFmt.PredicatesJSON)
+	case *pb.Protocol_PresentProofMsg_Predicates:
+		predicates := predFmt.Predicates.GetPredicates()
+		proofPredicates = make([]didcomm.ProofPredicate, len(predicates))
+		for i, predicate := range predicates {
+			proofPredicates[i] = didcomm.ProofPredicate{
+				ID:     predicate.ID,
+				Name:   predicate.Name,
+				PType:  predicate.PType,
+				PValue: predicate.PValue,
+			}
+		}
+		glog.V(3).Infoln("set proof from predicates")
+	}
+	return proofPredicates, nil
+}
+
+// generateProofRequest returns the proof request of the task and the
+// referents of the requested attributes in the task's order.
+func generateProofRequest(
+	proofTask *taskPresentProof,
+) (
+	req *anoncreds.ProofRequest,
+	attrOrder []string,
+) {
 	reqAttrs := make(map[string]anoncreds.AttrInfo)
+	attrOrder = make([]string, 0, len(proofTask.ProofAttrs))
 	for index, attr := range proofTask.ProofAttrs {
 		restrictions := make([]anoncreds.Filter, 0)
 		if attr.CredDefID != "" {
 			restrictions = append(restrictions, anoncreds.Filter{CredDefID: attr.CredDefID})
 		}
-		id := "attr_referent_" + strconv.Itoa(index+1)
-		if attr.ID != "" {
-			id = attr.ID
-		}
+		id := data.AttrReferent(reqAttrs, attr.ID, index)
 		reqAttrs[id] = anoncreds.AttrInfo{
 			Name:         attr.Name,
 			Restrictions: restrictions,
 		}
+		attrOrder = append(attrOrder, id)
 	}
 	reqPredicates := make(map[string]anoncreds.PredicateInfo)
 	if proofTask.ProofPredicates != nil {
 		for index, predicate := range proofTask.ProofPredicates {
 			// TODO: restrictions
-			id := "predicate_" + strconv.Itoa(index+1)
-			if predicate.ID != "" {
-				id = predicate.ID
-			}
+			id := data.PredicateReferent(reqPredicates, predicate.ID, index)
 			reqPredicates[id] = anoncreds.PredicateInfo{
 				Name:   predicate.Name,
 				PType:  predicate.PType,
@@ -154,7 +214,37 @@ func generateProofRequest(proofTask *taskPresentProof) *anoncreds.ProofRequest {
 		Nonce:               utils.NewNonceStr(),
 		RequestedAttributes: reqAttrs,
 		RequestedPredicates: reqPredicates,
+	}, attrOrder
+}
+
+// valueRestrictions returns the value filters of the proof attributes by their
+// referents, which are in the same order as the attributes.
+func valueRestrictions(
+	attrs []didcomm.ProofAttribute,
+	attrOrder []string,
+) map[string]data.ValueFilter {
+	values := make(map[string]data.ValueFilter)
+	for i, attr := range attrs {
+		if len(attr.Equals) > 0 {
+			values[attrOrder[i]] = attr.Equals
+		}
 	}
+	return values
+}
+
+// normalizeEquals returns the value restrictions with the attribute names
+// normalized like the names of the requested attributes.
+func normalizeEquals(equals map[string]string) (_ map[string]string, err error) {
+	defer err2.Handle(&err, "attribute value restrictions")
+
+	if len(equals) == 0 {
+		return nil, nil
+	}
+	normalized := make(map[string]string, len(equals))
+	for name, value := range equals {
+		normalized[try.To1(data.NormalizeAttrName(name))] = value
+	}
+	return normalized, nil
 }
 
 func startProofProtocol(ca comm.Receiver, t comm.Task) {
@@ -163,6 +253,9 @@ func startProofProtocol(ca comm.Receiver, t comm.Task) {
 	proofTask, ok := t.(*taskPresentProof)
 	assert.That(ok)
 
+	proofTask.Comment = prot.TaskComment(ca, t, pltype.ProtocolPresentProof,
+		proofTask.Comment)
+
 	switch t.Type() {
 	case pltype.CAProofPropose: // ----- prover will start -----
 		try.To(prot.StartPSM(prot.Initial{
@@ -182,6 +275,13 @@ func startProofProtocol(ca comm.Receiver, t comm.Task) {
 					}
 				}
 				pp := presentproof.NewPreviewWithAttributes(attrs)
+				for _, pred := range proofTask.ProofPredicates {
+					pp.Predicates = append(pp.Predicates, presentproof.Predicate{
+						Name:      pred.Name,
+						Predicate: pred.PType,
+						Threshold: strconv.FormatInt(pred.PValue, 10),
+					})
+				}
 
 				propose := msg.FieldObj().(*presentproof.Propose)
 				propose.PresentationProposal = pp
@@ -195,9 +295,10 @@ func startProofProtocol(ca comm.Receiver, t comm.Task) {
 			},
 		}))
 	case pltype.CAProofRequest: // ----- verifier will start -----
+		v2 := discoverfeatures.Supports(ca, t.ConnectionID(), presentProofV2)
 		try.To(prot.StartPSM(prot.Initial{
-			SendNext:    pltype.PresentProofRequest,
-			WaitingNext: pltype.PresentProofPresentation,
+			SendNext:    presentproof.Versioned(pltype.PresentProofRequest, v2),
+			WaitingNext: presentproof.Versioned(pltype.PresentProofPresentation, v2),
 			Ca:          ca,
 			T:           t,
 			Setup: func(key psm.StateKey, msg didcomm.MessageHdr) error {
@@ -208,20 +309,24 @@ func startProofProtocol(ca comm.Receiver, t comm.Task) {
 				// we cannot share same Nonce with the proof and messages
 				// here. StartPSM() sends certain Task fields to other end
 				// as PL.Message
-				proofRequest := generateProofRequest(proofTask)
+				proofRequest, attrOrder := generateProofRequest(proofTask)
 				// get proof req from task came in
-				proofReqStr := dto.ToJSON(proofRequest)
+				proofReqStr := try.To1(data.AddValueRestrictions(
+					dto.ToJSON(proofRequest),
+					valueRestrictions(proofTask.ProofAttrs, attrOrder)))
+				proofReqStr = try.To1(data.OrderAttrs(proofReqStr, attrOrder))
 
 				// set proof req to outgoing request message
 				req := msg.FieldObj().(*presentproof.Request)
-				req.RequestPresentations = presentproof.NewRequestPresentation(
-					pltype.LibindyRequestPresentationID, []byte(proofReqStr))
+				presentproof.SetProofReq(req, []byte(proofReqStr))
+				req.Comment = proofTask.Comment
 
 				// create Rep and save it for PSM to run protocol
 				rep := &data.PresentProofRep{
 					StateKey: key,
 					// Verifier cannot provide this..
 					ProofReq: proofReqStr, //  .. but it gives this one.
+					V2:       v2,
 				}
 				return psm.AddRep(rep)
 			},
@@ -312,6 +417,7 @@ func fillPresentProofStatus(workerDID string, taskID string, ps *pb.ProtocolStat
 
 	for _, attr := range proofRep.Attributes {
 		a := &pb.Protocol_Proof_Attribute{
+			ID:        attr.ID,
 			Name:      attr.Name,
 			CredDefID: attr.CredDefID,
 			Value:     attr.Value,
@@ -327,5 +433,13 @@ func fillPresentProofStatus(workerDID string, taskID string, ps *pb.ProtocolStat
 		},
 	}
 
+	// the verifier's status includes the verification details of the
+	// received proof as JSON in the state info, see grpc/server for the Info
+	// fields
+	if status.GetState().GetProtocolID().GetRole() == pb.Protocol_INITIATOR &&
+		proofRep.Proof != "" {
+		status.State.Info = dto.ToJSON(proofRep.Result())
+	}
+
 	return status
 }
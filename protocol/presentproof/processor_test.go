@@ -0,0 +1,319 @@
+package presentproof
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/utils"
+	"github.com/findy-network/findy-agent/protocol/presentproof/data"
+	"github.com/findy-network/findy-agent/protocol/presentproof/preview"
+	"github.com/findy-network/findy-common-go/dto"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+func TestMain(m *testing.M) {
+	try.To(psm.Open("MEMORY_present_proof.bolt"))
+	code := m.Run()
+	psm.Close()
+	os.Exit(code)
+}
+
+func TestCreatePresentProofTask_PredicatesOnly(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	task, err := createPresentProofTask(&comm.TaskHeader{}, &pb.Protocol{
+		Role: pb.Protocol_INITIATOR,
+		StartMsg: &pb.Protocol_PresentProof{PresentProof: &pb.Protocol_PresentProofMsg{
+			PredFmt: &pb.Protocol_PresentProofMsg_Predicates{
+				Predicates: &pb.Protocol_Predicates{
+					Predicates: []*pb.Protocol_Predicates_Predicate{
+						{Name: "age", PType: ">=", PValue: 21},
+					},
+				},
+			},
+		}},
+	})
+	assert.NoError(err)
+	proofTask := task.(*taskPresentProof)
+	assert.SLen(proofTask.ProofAttrs, 0)
+	assert.SLen(proofTask.ProofPredicates, 1)
+
+	genReq, _ := generateProofRequest(proofTask)
+	reqStr := dto.ToJSON(genReq)
+	var req anoncreds.ProofRequest
+	dto.FromJSONStr(reqStr, &req)
+	assert.INotNil(req.RequestedAttributes) // {} instead of null for provers
+	assert.MLen(req.RequestedAttributes, 0)
+	assert.MLen(req.RequestedPredicates, 1)
+	assert.Equal(req.RequestedPredicates["predicate_1"].PValue, 21)
+
+	// a proof request must ask something, the assert must return an error
+	// instead of failing the test
+	old := assert.SetDefault(assert.Production)
+	_, err = createPresentProofTask(&comm.TaskHeader{}, &pb.Protocol{
+		Role: pb.Protocol_INITIATOR,
+		StartMsg: &pb.Protocol_PresentProof{
+			PresentProof: &pb.Protocol_PresentProofMsg{},
+		},
+	})
+	assert.SetDefault(old)
+	assert.Error(err)
+}
+
+func TestCreatePresentProofTask_AttributeNames(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	task, err := createPresentProofTask(&comm.TaskHeader{}, &pb.Protocol{
+		Role: pb.Protocol_INITIATOR,
+		StartMsg: &pb.Protocol_PresentProof{PresentProof: &pb.Protocol_PresentProofMsg{
+			AttrFmt: &pb.Protocol_PresentProofMsg_Attributes{
+				Attributes: &pb.Protocol_Proof{
+					Attributes: []*pb.Protocol_Proof_Attribute{
+						{ID: "home address", Name: " home address "},
+						{ID: "attr_referent_1", Name: "syntymäpäivä"},
+						{Name: "email"},
+					},
+				},
+			},
+		}},
+	})
+	assert.NoError(err)
+	proofTask := task.(*taskPresentProof)
+
+	req, _ := generateProofRequest(proofTask)
+	assert.MLen(req.RequestedAttributes, 3)
+	assert.Equal(req.RequestedAttributes["attr_referent_1"].Name, "home address")
+	assert.Equal(req.RequestedAttributes["attr_referent_1_2"].Name, "syntymäpäivä")
+	assert.Equal(req.RequestedAttributes["attr_referent_3"].Name, "email")
+
+	// the status shows the names as given
+	rep := &data.PresentProofRep{}
+	preview.StoreProofData([]byte(dto.ToJSON(req)), rep)
+	names := make(map[string]string, len(rep.Attributes))
+	for _, attr := range rep.Attributes {
+		names[attr.ID] = attr.Name
+	}
+	assert.Equal(names["attr_referent_1"], "home address")
+	assert.Equal(names["attr_referent_1_2"], "syntymäpäivä")
+	assert.Equal(names["attr_referent_3"], "email")
+}
+
+func TestCreatePresentProofTask_AttrFormats(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	create := func(msg *pb.Protocol_PresentProofMsg) (*taskPresentProof, error) {
+		msg.PredFmt = &pb.Protocol_PresentProofMsg_PredicatesJSON{
+			PredicatesJSON: `[{"name":"age","p_type":">=","p_value":21}]`,
+		}
+		task, err := createPresentProofTask(&comm.TaskHeader{}, &pb.Protocol{
+			Role:     pb.Protocol_INITIATOR,
+			StartMsg: &pb.Protocol_PresentProof{PresentProof: msg},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return task.(*taskPresentProof), nil
+	}
+
+	// the same attributes in the both formats give the same task
+	fromJSON, err := create(&pb.Protocol_PresentProofMsg{
+		AttrFmt: &pb.Protocol_PresentProofMsg_AttributesJSON{
+			AttributesJSON: `[{"name":"email","credDefId":"cred-def-id"}]`,
+		},
+	})
+	assert.NoError(err)
+	fromAttrs, err := create(&pb.Protocol_PresentProofMsg{
+		AttrFmt: &pb.Protocol_PresentProofMsg_Attributes{
+			Attributes: &pb.Protocol_Proof{
+				Attributes: []*pb.Protocol_Proof_Attribute{
+					{Name: "email", CredDefID: "cred-def-id"},
+				},
+			},
+		},
+	})
+	assert.NoError(err)
+	assert.DeepEqual(fromJSON.ProofAttrs, fromAttrs.ProofAttrs)
+	assert.DeepEqual(fromJSON.ProofPredicates, fromAttrs.ProofPredicates)
+	assert.SLen(fromJSON.ProofPredicates, 1)
+
+	// empty JSON means no attributes, the predicate is enough
+	task, err := create(&pb.Protocol_PresentProofMsg{
+		AttrFmt: &pb.Protocol_PresentProofMsg_AttributesJSON{},
+	})
+	assert.NoError(err)
+	assert.SLen(task.ProofAttrs, 0)
+
+	old := assert.SetDefault(assert.Production)
+	_, err = create(&pb.Protocol_PresentProofMsg{
+		AttrFmt: &pb.Protocol_PresentProofMsg_AttributesJSON{
+			AttributesJSON: `[{"name":"email"`,
+		},
+	})
+	assert.SetDefault(old)
+	assert.Error(err)
+}
+
+func TestCreatePresentProofTask_Limits(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	utils.Settings.SetProofMaxAttrs(2)
+	utils.Settings.SetProofMaxPredicates(1)
+	defer utils.Settings.SetProofMaxAttrs(0)
+	defer utils.Settings.SetProofMaxPredicates(0)
+
+	create := func(attrs, preds int) error {
+		proof := &pb.Protocol_Proof{}
+		for i := 0; i < attrs; i++ {
+			proof.Attributes = append(proof.Attributes,
+				&pb.Protocol_Proof_Attribute{Name: fmt.Sprintf("attr%d", i)})
+		}
+		predicates := &pb.Protocol_Predicates{}
+		for i := 0; i < preds; i++ {
+			predicates.Predicates = append(predicates.Predicates,
+				&pb.Protocol_Predicates_Predicate{
+					Name: fmt.Sprintf("pred%d", i), PType: ">=", PValue: 1,
+				})
+		}
+		_, err := createPresentProofTask(&comm.TaskHeader{}, &pb.Protocol{
+			Role: pb.Protocol_INITIATOR,
+			StartMsg: &pb.Protocol_PresentProof{PresentProof: &pb.Protocol_PresentProofMsg{
+				AttrFmt: &pb.Protocol_PresentProofMsg_Attributes{Attributes: proof},
+				PredFmt: &pb.Protocol_PresentProofMsg_Predicates{Predicates: predicates},
+			}},
+		})
+		return err
+	}
+
+	assert.NoError(create(2, 1))
+
+	old := assert.SetDefault(assert.Production)
+	errAttrs := create(3, 0)
+	errPreds := create(1, 2)
+	assert.SetDefault(old)
+	assert.Error(errAttrs)
+	assert.Error(errPreds)
+
+	// the prover checks the received requests with the same limits
+	req := `{"requested_attributes":{"a":{"names":["a1","a2","a3"]}},"requested_predicates":{}}`
+	assert.Error(preview.CheckProofReqSize([]byte(req)))
+	req = `{"requested_attributes":{"a":{"names":["a1","a2"]}},"requested_predicates":{}}`
+	assert.NoError(preview.CheckProofReqSize([]byte(req)))
+}
+
+func TestProofStatus_AttrOrder(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	// the referents of the request don't sort in the declared order
+	names := []string{"zip", "city", "street"}
+	for i := 0; i < 10; i++ {
+		names = append(names, fmt.Sprintf("extra%d", i))
+	}
+	attrs := make([]*pb.Protocol_Proof_Attribute, len(names))
+	for i, name := range names {
+		attrs[i] = &pb.Protocol_Proof_Attribute{Name: name}
+	}
+	attrs[0].ID = "zip_ref"
+	task, err := createPresentProofTask(&comm.TaskHeader{}, &pb.Protocol{
+		Role: pb.Protocol_INITIATOR,
+		StartMsg: &pb.Protocol_PresentProof{PresentProof: &pb.Protocol_PresentProofMsg{
+			AttrFmt: &pb.Protocol_PresentProofMsg_Attributes{
+				Attributes: &pb.Protocol_Proof{Attributes: attrs},
+			},
+		}},
+	})
+	assert.NoError(err)
+	proofTask := task.(*taskPresentProof)
+
+	req, attrOrder := generateProofRequest(proofTask)
+	reqJSON, err := data.OrderAttrs(dto.ToJSON(req), attrOrder)
+	assert.NoError(err)
+
+	// the prover reads the order from the received request
+	key := psm.StateKey{DID: "proverDID", Nonce: "order-test"}
+	rep := &data.PresentProofRep{StateKey: key, ProofReq: reqJSON}
+	preview.StoreProofData([]byte(reqJSON), rep)
+	for i := range rep.Attributes {
+		rep.Attributes[i].Value = "value of " + rep.Attributes[i].Name
+	}
+	assert.NoError(psm.AddRep(rep))
+
+	status := fillPresentProofStatus(key.DID, key.Nonce, &pb.ProtocolStatus{})
+	got := status.GetPresentProof().GetProof().GetAttributes()
+	assert.SLen(got, len(names))
+	for i, attr := range got {
+		assert.Equal(attr.Name, names[i])
+		assert.Equal(attr.Value, "value of "+names[i])
+	}
+}
+
+func TestProofStatus_VerificationResult(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	proofReq := anoncreds.ProofRequest{
+		RequestedAttributes: map[string]anoncreds.AttrInfo{
+			"attr_referent_1": {Name: "name"},
+		},
+		RequestedPredicates: map[string]anoncreds.PredicateInfo{
+			"predicate_1": {Name: "age", PType: ">=", PValue: 18},
+		},
+	}
+	proof := anoncreds.Proof{
+		RequestedProof: anoncreds.RequestedProof{
+			RevealedAttrs: map[string]anoncreds.RevealedAttr{
+				"attr_referent_1": {Raw: "Alice"},
+			},
+			Predicates: map[string]interface{}{
+				"predicate_1": map[string]interface{}{"sub_proof_index": 0},
+			},
+		},
+	}
+	key := psm.StateKey{DID: "verifierDID", Nonce: "result-test"}
+	assert.NoError(psm.AddRep(&data.PresentProofRep{
+		StateKey: key,
+		ProofReq: dto.ToJSON(proofReq),
+		Proof:    dto.ToJSON(proof),
+		Verified: true,
+		Attributes: []didcomm.ProofAttribute{
+			{ID: "attr_referent_1", Name: "name", Value: "Alice"},
+		},
+	}))
+
+	newStatus := func(role pb.Protocol_Role) *pb.ProtocolStatus {
+		return &pb.ProtocolStatus{State: &pb.ProtocolState{
+			ProtocolID: &pb.ProtocolID{ID: key.Nonce, Role: role},
+		}}
+	}
+	status := fillPresentProofStatus(key.DID, key.Nonce,
+		newStatus(pb.Protocol_INITIATOR))
+	attrs := status.GetPresentProof().GetProof().GetAttributes()
+	assert.SLen(attrs, 1)
+	assert.Equal(attrs[0].ID, "attr_referent_1")
+	assert.Equal(attrs[0].Value, "Alice")
+
+	var res data.ProofResult
+	dto.FromJSONStr(status.GetState().GetInfo(), &res)
+	assert.That(res.Verified)
+	assert.SLen(res.Revealed, 1)
+	assert.Equal(res.Revealed[0].Value, "Alice")
+	assert.SLen(res.Predicates, 1)
+	assert.Equal(res.Predicates[0].Name, "age")
+	assert.That(res.Predicates[0].Satisfied)
+
+	// the prover's status has no verification result
+	status = fillPresentProofStatus(key.DID, key.Nonce,
+		newStatus(pb.Protocol_ADDRESSEE))
+	assert.Empty(status.GetState().GetInfo())
+}
@@ -22,15 +22,18 @@ func HandleRequestPresentation(packet comm.Packet) (err error) {
 	key := psm.NewStateKey(packet.Receiver, packet.Payload.ThreadID())
 	rep, _ := data.GetPresentProofRep(key) // ignore not found error
 
+	// we answer with the same version the verifier uses
+	v2 := presentproof.IsV2(packet.Payload.Type())
 	if rep == nil {
-		rep := &data.PresentProofRep{
+		rep = &data.PresentProofRep{
 			StateKey:   key,
 			WeProposed: false,
 		}
-		try.To(psm.AddRep(rep))
 	}
+	rep.V2 = v2
+	try.To(psm.AddRep(rep))
 
-	sendNext, waitingNext := checkAutoPermission(packet)
+	sendNext, waitingNext := checkAutoPermission(packet, v2)
 
 	return prot.ExecPSM(prot.Transition{
 		Packet:      packet,
@@ -48,13 +51,13 @@ func HandleRequestPresentation(packet comm.Packet) (err error) {
 			data := try.To1(presentproof.ProofReqData(req))
 			rep.ProofReq = string(data)
 
+			try.To(preview.CheckProofReqSize(data))
 			preview.StoreProofData(data, rep)
 
 			pres, autoAccept := om.FieldObj().(*presentproof.Presentation)
 			if autoAccept {
 				try.To(rep.CreateProof(packet, repK.DID))
-				pres.PresentationAttaches = presentproof.NewPresentationAttach(
-					pltype.LibindyPresentationID, []byte(rep.Proof))
+				presentproof.SetProof(pres, []byte(rep.Proof))
 			}
 
 			// Save the proof request to the Proof Rep
@@ -68,12 +71,15 @@ func HandleRequestPresentation(packet comm.Packet) (err error) {
 func UserActionProofPresentation(ca comm.Receiver, im didcomm.Msg) {
 	defer err2.Catch()
 
+	repK := psm.NewStateKey(ca, im.Thread().ID)
+	v2 := try.To1(data.GetPresentProofRep(repK)).V2
+
 	try.To(prot.ContinuePSM(prot.Again{
 		CA:          ca,
 		InMsg:       im,
-		SendNext:    pltype.PresentProofPresentation,
-		WaitingNext: pltype.PresentProofACK,
-		SendOnNACK:  pltype.PresentProofNACK,
+		SendNext:    presentproof.Versioned(pltype.PresentProofPresentation, v2),
+		WaitingNext: presentproof.Versioned(pltype.PresentProofACK, v2),
+		SendOnNACK:  presentproof.Versioned(pltype.PresentProofNACK, v2),
 		Transfer: func(wa comm.Receiver, im, om didcomm.MessageHdr) (ack bool, err error) {
 			defer err2.Handle(&err, "proof user action handler")
 
@@ -89,23 +95,34 @@ func UserActionProofPresentation(ca comm.Receiver, im didcomm.Msg) {
 			repK := psm.NewStateKey(agent, im.Thread().ID)
 			rep := try.To1(data.GetPresentProofRep(repK))
 
+			if comm.FeatureOr(agent.MyDID().Did(),
+				comm.FeatureRejectUnfulfillable, false) {
+				f := try.To1(rep.CanFulfill(agent))
+				for _, item := range f.Missing() {
+					glog.Warningf("proof (%s): cannot fulfill %s (%s)",
+						im.Thread().ID, item.Name, item.ID)
+				}
+				if !f.Complete() {
+					return false, nil
+				}
+			}
 			try.To(rep.CreateProof(comm.Packet{Receiver: agent}, repK.DID))
 			// save created proof to Representative
 			try.To(psm.AddRep(rep))
 
 			pres := om.FieldObj().(*presentproof.Presentation)
-			pres.PresentationAttaches = presentproof.NewPresentationAttach(
-				pltype.LibindyPresentationID, []byte(rep.Proof))
+			presentproof.SetProof(pres, []byte(rep.Proof))
 
 			return true, nil
 		},
 	}))
 }
 
-func checkAutoPermission(packet comm.Packet) (next string, wait string) {
-	if packet.Receiver.AutoPermission() {
-		next = pltype.PresentProofPresentation
-		wait = pltype.PresentProofACK
+func checkAutoPermission(packet comm.Packet, v2 bool) (next string, wait string) {
+	if comm.ConnectionAutoAccept(packet.Receiver, packet.Address.ConnID,
+		comm.FeatureAutoAcceptProofs) {
+		next = presentproof.Versioned(pltype.PresentProofPresentation, v2)
+		wait = presentproof.Versioned(pltype.PresentProofACK, v2)
 	} else {
 		next = pltype.Nothing
 		wait = pltype.PresentProofUserAction
@@ -0,0 +1,146 @@
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/protocol/presentproof/data"
+	"github.com/findy-network/findy-agent/protocol/presentproof/preview"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// CounterRequestType is the type of the CounterRequest envelope. The version
+// is changed if the envelope changes incompatibly.
+const CounterRequestType = "findy/counter-proof-request/1.0"
+
+// CounterRequest is the envelope of the countered proof request which the
+// verifier gives in the info of the user action, see
+// ContinueProposePresentation. The info is a plain accept if it isn't this
+// envelope.
+type CounterRequest struct {
+	Type         string                 `json:"type"`
+	ProofRequest anoncreds.ProofRequest `json:"proof_request"`
+}
+
+// NewCounterRequest returns the user action info which counters the proposal
+// with the proof request.
+func NewCounterRequest(req anoncreds.ProofRequest) string {
+	return dto.ToJSON(CounterRequest{
+		Type:         CounterRequestType,
+		ProofRequest: req,
+	})
+}
+
+// counterRequest returns the proof request of the user action info, and false
+// if the info isn't the CounterRequest envelope.
+func counterRequest(info string) (req anoncreds.ProofRequest, ok bool) {
+	var counter CounterRequest
+	if err := json.Unmarshal([]byte(info), &counter); err != nil ||
+		counter.Type != CounterRequestType {
+		return req, false
+	}
+	return counter.ProofRequest, true
+}
+
+// CounterFunc modifies the proof request which is built from the prover's
+// proposal.
+type CounterFunc func(req *anoncreds.ProofRequest)
+
+// CounterProposal lets the verifier change the proof request built from the
+// prover's proposal before it's sent, e.g. to add predicates or restrictions.
+// The protocol must be waiting for the user action, and it must be resumed
+// after this to send the modified request.
+func CounterProposal(ca comm.Receiver, protocolID string, counter CounterFunc) (err error) {
+	defer err2.Handle(&err, "counter proof proposal")
+
+	key := psm.NewStateKey(ca, protocolID)
+	m := try.To1(psm.GetPSM(key))
+	if !m.PendingUserAction() {
+		return fmt.Errorf("proof proposal (%s) isn't waiting user action", protocolID)
+	}
+
+	rep := try.To1(data.GetPresentProofRep(key))
+	if rep == nil {
+		return fmt.Errorf("proof proposal (%s) not found", protocolID)
+	}
+
+	rep.ProofReq = try.To1(counterProofReq(rep.ProofReq, counter))
+	preview.StoreProofData([]byte(rep.ProofReq), rep)
+	return psm.AddRep(rep)
+}
+
+// counterProofReq returns the countered proof request. The attributes are
+// kept in their original order.
+func counterProofReq(reqStr string, counter CounterFunc) (string, error) {
+	order, _ := data.AttrOrder([]byte(reqStr)) // sorted if unreadable
+	var req anoncreds.ProofRequest
+	dto.FromJSONStr(reqStr, &req)
+	counter(&req)
+	return data.OrderAttrs(dto.ToJSON(req), order)
+}
+
+// replaceProofReq returns the CounterFunc which replaces the proof request with
+// the modified one. The nonce of our proof request is kept.
+func replaceProofReq(modified anoncreds.ProofRequest) CounterFunc {
+	return func(req *anoncreds.ProofRequest) {
+		modified.Nonce = req.Nonce
+		*req = modified
+	}
+}
+
+// AddPredicate adds the predicate to the proof request with the next free
+// predicate referent.
+func AddPredicate(req *anoncreds.ProofRequest, predicate anoncreds.PredicateInfo) {
+	if req.RequestedPredicates == nil {
+		req.RequestedPredicates = make(map[string]anoncreds.PredicateInfo)
+	}
+	for i := len(req.RequestedPredicates) + 1; ; i++ {
+		id := "predicate_" + strconv.Itoa(i)
+		if _, exists := req.RequestedPredicates[id]; !exists {
+			req.RequestedPredicates[id] = predicate
+			return
+		}
+	}
+}
+
+// AddRestriction narrows all of the requested attributes and predicates of the
+// proof request with the restriction. Anoncreds restriction filters are OR'ed,
+// which is why the restriction's fields are set to every existing filter
+// instead of appending it as a new one.
+func AddRestriction(req *anoncreds.ProofRequest, restriction anoncreds.Filter) {
+	for id, attr := range req.RequestedAttributes {
+		attr.Restrictions = narrow(attr.Restrictions, restriction)
+		req.RequestedAttributes[id] = attr
+	}
+	for id, pred := range req.RequestedPredicates {
+		pred.Restrictions = narrow(pred.Restrictions, restriction)
+		req.RequestedPredicates[id] = pred
+	}
+}
+
+func narrow(filters []anoncreds.Filter, r anoncreds.Filter) []anoncreds.Filter {
+	if len(filters) == 0 {
+		return []anoncreds.Filter{r}
+	}
+	set := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+	narrowed := make([]anoncreds.Filter, len(filters))
+	for i, f := range filters {
+		set(&f.SchemaID, r.SchemaID)
+		set(&f.SchemaIssuerDID, r.SchemaIssuerDID)
+		set(&f.SchemaName, r.SchemaName)
+		set(&f.IssuerDID, r.IssuerDID)
+		set(&f.CredDefID, r.CredDefID)
+		narrowed[i] = f
+	}
+	return narrowed
+}
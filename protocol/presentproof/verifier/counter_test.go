@@ -0,0 +1,76 @@
+package verifier
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/protocol/presentproof/data"
+	"github.com/findy-network/findy-agent/std/presentproof"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+)
+
+func TestCounterProofReq(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	propose := &presentproof.Propose{
+		PresentationProposal: &presentproof.Preview{
+			Attributes: []presentproof.Attribute{
+				{Name: "email", CredDefID: "cred_def_id"},
+				{Name: "name"},
+			},
+		},
+	}
+	proofReq, _ := generateProofRequest(propose)
+	reqStr, err := counterProofReq(dto.ToJSON(proofReq), func(req *anoncreds.ProofRequest) {
+		AddPredicate(req, anoncreds.PredicateInfo{
+			Name:   "age",
+			PType:  ">=",
+			PValue: 18,
+		})
+		AddRestriction(req, anoncreds.Filter{IssuerDID: "issuer_did"})
+	})
+	assert.NoError(err)
+
+	// the attributes keep the order of the proposal
+	order, err := data.AttrOrder([]byte(reqStr))
+	assert.NoError(err)
+	assert.DeepEqual(order, []string{"attr_referent_1", "attr_referent_2"})
+
+	var req anoncreds.ProofRequest
+	dto.FromJSONStr(reqStr, &req)
+
+	assert.MLen(req.RequestedAttributes, 2)
+	assert.MLen(req.RequestedPredicates, 1)
+
+	pred := req.RequestedPredicates["predicate_1"]
+	assert.Equal(pred.Name, "age")
+	assert.Equal(pred.PType, ">=")
+	assert.Equal(pred.PValue, 18)
+	assert.SLen(pred.Restrictions, 1)
+	assert.Equal(pred.Restrictions[0].IssuerDID, "issuer_did")
+
+	// existing restriction is narrowed, not OR'ed with a new one
+	email := req.RequestedAttributes["attr_referent_1"]
+	assert.SLen(email.Restrictions, 1)
+	assert.Equal(email.Restrictions[0].CredDefID, "cred_def_id")
+	assert.Equal(email.Restrictions[0].IssuerDID, "issuer_did")
+
+	name := req.RequestedAttributes["attr_referent_2"]
+	assert.SLen(name.Restrictions, 1)
+	assert.Equal(name.Restrictions[0].IssuerDID, "issuer_did")
+}
+
+func TestAddPredicate(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	req := &anoncreds.ProofRequest{}
+	AddPredicate(req, anoncreds.PredicateInfo{Name: "age"})
+	AddPredicate(req, anoncreds.PredicateInfo{Name: "score"})
+
+	assert.MLen(req.RequestedPredicates, 2)
+	assert.Equal(req.RequestedPredicates["predicate_1"].Name, "age")
+	assert.Equal(req.RequestedPredicates["predicate_2"].Name, "score")
+}
@@ -23,24 +23,33 @@ import (
 
 const ackOK = "OK"
 
-func generateProofRequest(proofTask *presentproof.Propose) *anoncreds.ProofRequest {
+// generateProofRequest returns the proof request of the proposal and the
+// referents of the requested attributes in the proposal's order.
+func generateProofRequest(
+	proofTask *presentproof.Propose,
+) (
+	req *anoncreds.ProofRequest,
+	attrOrder []string,
+) {
 	reqAttrs := make(map[string]anoncreds.AttrInfo)
+	attrOrder = make([]string, 0, len(proofTask.PresentationProposal.Attributes))
 	for index, attr := range proofTask.PresentationProposal.Attributes {
 		restrictions := make([]anoncreds.Filter, 0)
 		if attr.CredDefID != "" {
 			restrictions = append(restrictions, anoncreds.Filter{CredDefID: attr.CredDefID})
 		}
-		id := "attr_referent_" + strconv.Itoa(index+1)
+		id := data.AttrReferent(reqAttrs, "", index)
 		reqAttrs[id] = anoncreds.AttrInfo{
 			Name:         attr.Name,
 			Restrictions: restrictions,
 		}
+		attrOrder = append(attrOrder, id)
 	}
 	reqPredicates := make(map[string]anoncreds.PredicateInfo)
 	if proofTask.PresentationProposal.Predicates != nil {
 		for index, predicate := range proofTask.PresentationProposal.Predicates {
 			// TODO: restrictions
-			id := "predicate_" + strconv.Itoa(index+1)
+			id := data.PredicateReferent(reqPredicates, "", index)
 			value, _ := strconv.ParseInt(predicate.Threshold, 10, 64) // TODO
 			reqPredicates[id] = anoncreds.PredicateInfo{
 				Name:   predicate.Name,
@@ -55,13 +64,14 @@ func generateProofRequest(proofTask *presentproof.Propose) *anoncreds.ProofReque
 		Nonce:               utils.NewNonceStr(),
 		RequestedAttributes: reqAttrs,
 		RequestedPredicates: reqPredicates,
-	}
+	}, attrOrder
 }
 
 // HandleProposePresentation is a protocol handler function at VERIFIER side.
 func HandleProposePresentation(packet comm.Packet) (err error) {
 	var sendNext, waitingNext string
-	if packet.Receiver.AutoPermission() {
+	if comm.ConnectionAutoAccept(packet.Receiver, packet.Address.ConnID,
+		comm.FeatureAutoAcceptProofs) {
 		sendNext = pltype.PresentProofRequest
 		waitingNext = pltype.PresentProofPresentation
 	} else {
@@ -83,8 +93,8 @@ func HandleProposePresentation(packet comm.Packet) (err error) {
 			key := psm.StateKey{DID: meDID, Nonce: im.Thread().ID}
 
 			propose := im.FieldObj().(*presentproof.Propose)
-			proofReq := generateProofRequest(propose)
-			reqStr := dto.ToJSON(proofReq)
+			proofReq, attrOrder := generateProofRequest(propose)
+			reqStr := try.To1(data.OrderAttrs(dto.ToJSON(proofReq), attrOrder))
 
 			attributes := make([]didcomm.ProofAttribute, 0)
 			for _, attr := range propose.PresentationProposal.Attributes {
@@ -103,8 +113,7 @@ func HandleProposePresentation(packet comm.Packet) (err error) {
 
 			req, autoAccept := om.FieldObj().(*presentproof.Request)
 			if autoAccept {
-				req.RequestPresentations = presentproof.NewRequestPresentation(
-					pltype.LibindyRequestPresentationID, []byte(reqStr))
+				presentproof.SetProofReq(req, []byte(reqStr))
 			}
 
 			return true, nil
@@ -112,10 +121,22 @@ func HandleProposePresentation(packet comm.Packet) (err error) {
 	})
 }
 
+// continuePSM continues the protocol after the user action, which tests can
+// replace.
+var continuePSM = prot.ContinuePSM
+
+// ContinueProposePresentation continues the verifier's protocol after the user
+// has handled the prover's proposal. The user can counter the proposal by
+// giving the modified proof request in the CounterRequest envelope in the info
+// of the user action. Any other info is a plain accept.
 func ContinueProposePresentation(ca comm.Receiver, im didcomm.Msg) {
 	defer err2.Catch()
 
-	try.To(prot.ContinuePSM(prot.Again{
+	if modified, ok := counterRequest(im.Info()); im.Ready() && ok {
+		try.To(CounterProposal(ca, im.Thread().ID, replaceProofReq(modified)))
+	}
+
+	try.To(continuePSM(prot.Again{
 		CA:          ca,
 		InMsg:       im,
 		SendNext:    pltype.PresentProofRequest,
@@ -131,14 +152,12 @@ func ContinueProposePresentation(ca comm.Receiver, im didcomm.Msg) {
 				return false, nil
 			}
 
-			// TODO: support changing proof req
-
+			// the proof req might be countered, see CounterProposal
 			repK := psm.NewStateKey(ca, im.Thread().ID)
 			rep := try.To1(data.GetPresentProofRep(repK))
 
 			req := om.FieldObj().(*presentproof.Request) // query interface
-			req.RequestPresentations = presentproof.NewRequestPresentation(
-				pltype.LibindyRequestPresentationID, []byte(rep.ProofReq))
+			presentproof.SetProofReq(req, []byte(rep.ProofReq))
 
 			return true, nil
 		},
@@ -148,9 +167,11 @@ func ContinueProposePresentation(ca comm.Receiver, im didcomm.Msg) {
 // HandlePresentation is a protocol handler function at VERIFIER side for handling
 // proof presentation.
 func HandlePresentation(packet comm.Packet) (err error) {
+	v2 := presentproof.IsV2(packet.Payload.Type())
 	var sendNext, waitingNext string
-	if packet.Receiver.AutoPermission() {
-		sendNext = pltype.PresentProofACK
+	if comm.ConnectionAutoAccept(packet.Receiver, packet.Address.ConnID,
+		comm.FeatureAutoAcceptProofs) {
+		sendNext = presentproof.Versioned(pltype.PresentProofACK, v2)
 		waitingNext = pltype.Terminate
 	} else {
 		sendNext = pltype.Nothing
@@ -161,7 +182,7 @@ func HandlePresentation(packet comm.Packet) (err error) {
 		Packet:      packet,
 		SendNext:    sendNext,
 		WaitingNext: waitingNext,
-		SendOnNACK:  pltype.PresentProofNACK,
+		SendOnNACK:  presentproof.Versioned(pltype.PresentProofNACK, v2),
 		TaskHeader:  &comm.TaskHeader{UserActionPLType: pltype.SAPresentProofAcceptValues},
 		InOut: func(_ string, im, om didcomm.MessageHdr) (ack bool, err error) {
 			defer err2.Handle(&err, "proof presentation handler")
@@ -170,13 +191,28 @@ func HandlePresentation(packet comm.Packet) (err error) {
 			repK := psm.NewStateKey(agent, im.Thread().ID)
 			rep := try.To1(data.GetPresentProofRep(repK))
 
+			// presentations are accepted only once to our proof requests
+			if err := rep.CheckNotPresented(); err != nil {
+				glog.Errorf("Rejecting presentation (nonce:%v): %v", im.Thread().ID, err)
+				return false, nil
+			}
+
 			// 1st, verify the proof by our selves
 			pres := im.FieldObj().(*presentproof.Presentation)
 			data := try.To1(presentproof.Proof(pres))
 			rep.Proof = string(data)
 
-			if !try.To1(rep.VerifyProof(packet)) {
+			rep.Verified = try.To1(rep.VerifyProof(packet))
+			if rep.Verified {
+				if err := rep.CheckFeatures(agent.MyDID().Did()); err != nil {
+					glog.Errorf("Proof (nonce:%v) refused: %v", im.Thread().ID, err)
+					rep.Verified = false
+				}
+			}
+			if !rep.Verified {
 				glog.Errorf("Cannot verify proof (nonce:%v) terminating presentation protocol", im.Thread().ID)
+				// store the failed proof for the verification result
+				try.To(psm.AddRep(rep))
 				return false, nil
 			}
 
@@ -204,12 +240,15 @@ func HandlePresentation(packet comm.Packet) (err error) {
 func ContinueHandlePresentation(ca comm.Receiver, im didcomm.Msg) {
 	defer err2.Catch()
 
+	repK := psm.NewStateKey(ca, im.Thread().ID)
+	v2 := try.To1(data.GetPresentProofRep(repK)).V2
+
 	try.To(prot.ContinuePSM(prot.Again{
 		CA:          ca,
 		InMsg:       im,
-		SendNext:    pltype.PresentProofACK,
+		SendNext:    presentproof.Versioned(pltype.PresentProofACK, v2),
 		WaitingNext: pltype.Terminate,
-		SendOnNACK:  pltype.PresentProofNACK,
+		SendOnNACK:  presentproof.Versioned(pltype.PresentProofNACK, v2),
 		Transfer: func(_ comm.Receiver, im, om didcomm.MessageHdr) (ack bool, err error) {
 			defer err2.Handle(&err, "proof values user action handler")
 
@@ -0,0 +1,161 @@
+package verifier
+
+import (
+	"os"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/comm"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/prot"
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/agent/ssi"
+	"github.com/findy-network/findy-agent/core"
+	"github.com/findy-network/findy-agent/protocol/presentproof/data"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/findy-network/findy-agent/std/presentproof"
+	"github.com/findy-network/findy-common-go/dto"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+const verifierDID = "verifierDID"
+
+func TestMain(m *testing.M) {
+	try.To(psm.Open("MEMORY_verifier_data.bolt"))
+	code := m.Run()
+	psm.Close()
+	os.Exit(code)
+}
+
+type verifierRcvr struct {
+	comm.Receiver
+}
+
+func (r *verifierRcvr) MyDID() core.DID {
+	return ssi.NewDid(verifierDID, "verkey")
+}
+
+// proposalReceived creates the verifier's PSM which waits the user action for
+// the prover's proposal.
+func proposalReceived(t *testing.T, id string, propose *presentproof.Propose) {
+	t.Helper()
+
+	task := &comm.TaskBase{TaskHeader: comm.TaskHeader{
+		TaskID:       id,
+		TypeID:       pltype.PresentProofPropose,
+		ProtocolRole: pb.Protocol_ADDRESSEE,
+	}}
+	wpl := aries.PayloadCreator.New(didcomm.PayloadInit{
+		ID:   id,
+		Type: pltype.PresentProofUserAction,
+	})
+	assert.NoError(prot.UpdatePSM(verifierDID, "conn", task, wpl, psm.Waiting))
+
+	proofReq, attrOrder := generateProofRequest(propose)
+	reqStr, err := data.OrderAttrs(dto.ToJSON(proofReq), attrOrder)
+	assert.NoError(err)
+	assert.NoError(psm.AddRep(&data.PresentProofRep{
+		StateKey: psm.StateKey{DID: verifierDID, Nonce: id},
+		ProofReq: reqStr,
+	}))
+}
+
+func TestContinueProposePresentation_Counter(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const id = "countered-proposal"
+	proposalReceived(t, id, &presentproof.Propose{
+		PresentationProposal: &presentproof.Preview{
+			Attributes: []presentproof.Attribute{{Name: "name"}},
+		},
+	})
+	rep, err := data.GetPresentProofRep(psm.StateKey{DID: verifierDID, Nonce: id})
+	assert.NoError(err)
+	var proposed anoncreds.ProofRequest
+	dto.FromJSONStr(rep.ProofReq, &proposed)
+
+	// the user adds the age predicate to the proposed request
+	countered := proposed
+	countered.Nonce = "users-nonce"
+	AddPredicate(&countered, anoncreds.PredicateInfo{
+		Name:   "age",
+		PType:  ">=",
+		PValue: 18,
+	})
+
+	req := continueProposal(t, id, NewCounterRequest(countered))
+	assert.MLen(req.RequestedAttributes, 1)
+	assert.MLen(req.RequestedPredicates, 1)
+	assert.Equal(req.RequestedPredicates["predicate_1"].Name, "age")
+	assert.Equal(req.RequestedPredicates["predicate_1"].PValue, 18)
+
+	// the nonce of the request is ours
+	assert.Equal(req.Nonce, proposed.Nonce)
+}
+
+func TestContinueProposePresentation_PlainAccept(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	for _, info := range []string{
+		"",
+		"accepted",
+		`{"nonce":"1","requested_predicates":{"p":{"name":"age"}}}`,
+		`{"type":"findy/counter-proof-request/0.1","proof_request":{}}`,
+	} {
+		id := "plain-" + info
+		proposalReceived(t, id, &presentproof.Propose{
+			PresentationProposal: &presentproof.Preview{
+				Attributes: []presentproof.Attribute{{Name: "name"}},
+			},
+		})
+
+		// the info isn't the counter request, i.e. the proposal is accepted
+		req := continueProposal(t, id, info)
+		assert.MLen(req.RequestedAttributes, 1)
+		assert.MLen(req.RequestedPredicates, 0)
+	}
+}
+
+// continueProposal continues the verifier's protocol with the accepting user
+// action and returns the sent proof request.
+func continueProposal(t *testing.T, id, info string) (req anoncreds.ProofRequest) {
+	t.Helper()
+
+	var sent *presentproof.Request
+	defer func(f func(prot.Again) error) { continuePSM = f }(continuePSM)
+	continuePSM = func(shift prot.Again) error {
+		im := aries.MsgCreator.Create(didcomm.MsgInit{
+			Nonce: shift.InMsg.SubLevelID(),
+			Ready: shift.InMsg.Ready(),
+		})
+		om := aries.MsgCreator.Create(didcomm.MsgInit{
+			Type:   shift.SendNext,
+			Thread: decorator.NewThread(shift.InMsg.SubLevelID(), ""),
+		})
+		ack, err := shift.Transfer(shift.CA, im, om)
+		assert.NoError(err)
+		assert.That(ack)
+		sent = om.FieldObj().(*presentproof.Request)
+		return nil
+	}
+
+	ContinueProposePresentation(&verifierRcvr{}, aries.MsgCreator.Create(
+		didcomm.MsgInit{
+			Ready: true,
+			Info:  info,
+			ID:    id,
+			Nonce: id,
+		}).(didcomm.Msg))
+
+	assert.That(sent != nil, "proof request not sent")
+	reqData, err := presentproof.ProofReqData(sent)
+	assert.NoError(err)
+	dto.FromJSON(reqData, &req)
+	return req
+}
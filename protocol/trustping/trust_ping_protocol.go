@@ -8,6 +8,8 @@ import (
 	"github.com/findy-network/findy-agent/agent/pltype"
 	"github.com/findy-network/findy-agent/agent/prot"
 	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-agent/std/trustping"
+	"github.com/findy-network/findy-common-go/dto"
 	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
 	"github.com/golang/glog"
 	"github.com/lainio/err2"
@@ -58,8 +60,15 @@ func startTrustPing(ca comm.Receiver, t comm.Task) {
 		WaitingNext: pltype.TrustPingResponse,
 		Ca:          ca,
 		T:           t,
-		Setup: func(psm.StateKey, didcomm.MessageHdr) error {
-			return nil
+		Setup: func(key psm.StateKey, om didcomm.MessageHdr) error {
+			ping := om.FieldObj().(*trustping.Ping)
+			ping.Comment = prot.TaskComment(ca, t, pltype.ProtocolTrustPing, "")
+			ping.ResponseRequested = true
+			return psm.AddRep(&trustPingRep{
+				StateKey:          key,
+				Comment:           ping.Comment,
+				ResponseRequested: ping.ResponseRequested,
+			})
 		},
 	}))
 }
@@ -69,8 +78,20 @@ func handleTrustPing(packet comm.Packet) (err error) {
 		Packet:      packet,
 		SendNext:    pltype.TrustPingResponse,
 		WaitingNext: pltype.Terminate,
-		InOut: func(_ string, _, om didcomm.MessageHdr) (ack bool, err error) {
+		InOut: func(_ string, im, om didcomm.MessageHdr) (ack bool, err error) {
+			defer err2.Handle(&err, "trust ping")
+
 			glog.V(3).Info("-- Thread ID: ", om.Thread().ID)
+			ping := im.FieldObj().(*trustping.Ping)
+			try.To(psm.AddRep(&trustPingRep{
+				StateKey: psm.StateKey{
+					DID:   packet.Receiver.MyDID().Did(),
+					Nonce: im.Thread().ID,
+				},
+				Comment:           ping.Comment,
+				ResponseRequested: ping.ResponseRequested,
+				Replied:           true,
+			}))
 			return true, nil
 		},
 	})
@@ -81,14 +102,39 @@ func handleTrustPingResponse(packet comm.Packet) (err error) {
 		Packet:      packet,
 		SendNext:    pltype.Terminate,
 		WaitingNext: pltype.Terminate,
-		InOut: func(_ string, _, om didcomm.MessageHdr) (ack bool, err error) {
+		InOut: func(_ string, im, om didcomm.MessageHdr) (ack bool, err error) {
+			defer err2.Handle(&err, "trust ping response")
+
 			glog.V(3).Info("-- Thread ID: ", om.Thread().ID)
+			rep := try.To1(getTrustPingRep(packet.Receiver.MyDID().Did(), im.Thread().ID))
+			rep.Replied = true
+			rep.ResponseComment = im.FieldObj().(*trustping.PingResponse).Comment
+			try.To(psm.AddRep(rep))
 			return true, nil
 		},
 	})
 }
 
-func fillTrustPingStatus(_ string, _ string, ps *pb.ProtocolStatus) *pb.ProtocolStatus {
+// Status is the state of the trust ping. It's in the protocol status' state
+// info as JSON, because the trust ping status of the gRPC API has only the
+// Replied flag.
+type Status struct {
+	Comment           string
+	ResponseRequested bool
+	Replied           bool
+	ResponseComment   string
+}
+
+func (p *trustPingRep) status() *Status {
+	return &Status{
+		Comment:           p.Comment,
+		ResponseRequested: p.ResponseRequested,
+		Replied:           p.Replied,
+		ResponseComment:   p.ResponseComment,
+	}
+}
+
+func fillTrustPingStatus(workerDID string, taskID string, ps *pb.ProtocolStatus) *pb.ProtocolStatus {
 	defer err2.Catch(err2.Err(func(err error) {
 		glog.Error("Failed to fill trust ping status: ", err)
 	}))
@@ -96,11 +142,19 @@ func fillTrustPingStatus(_ string, _ string, ps *pb.ProtocolStatus) *pb.Protocol
 	assert.That(ps != nil)
 
 	status := ps
-
-	// TODO
+	// not replied is the answer also for the pings older than their reps
 	status.Status = &pb.ProtocolStatus_TrustPing{
 		TrustPing: &pb.ProtocolStatus_TrustPingStatus{Replied: false},
 	}
 
+	rep := try.To1(getTrustPingRep(workerDID, taskID))
+	status.Status = &pb.ProtocolStatus_TrustPing{
+		TrustPing: &pb.ProtocolStatus_TrustPingStatus{Replied: rep.Replied},
+	}
+	if status.State != nil {
+		// see grpc/server for the Info fields
+		status.State.Info = dto.ToJSON(rep.status())
+	}
+
 	return status
 }
@@ -0,0 +1,62 @@
+package trustping
+
+import (
+	"os"
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-common-go/dto"
+	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+const workerDID = "trustPingTestDID"
+
+func TestMain(m *testing.M) {
+	try.To(psm.Open("MEMORY_trust_ping_data.bolt"))
+	code := m.Run()
+	psm.Close()
+	os.Exit(code)
+}
+
+func TestTrustPingStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		rep  trustPingRep
+	}{
+		{"no response", trustPingRep{
+			Comment:           "hello",
+			ResponseRequested: true,
+		}},
+		{"completed", trustPingRep{
+			Comment:           "hello",
+			ResponseRequested: true,
+			Replied:           true,
+			ResponseComment:   "hi there",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			tt.rep.StateKey = psm.StateKey{DID: workerDID, Nonce: tt.name}
+			assert.NoError(psm.AddRep(&tt.rep))
+
+			ps := fillTrustPingStatus(workerDID, tt.name, &pb.ProtocolStatus{
+				State: &pb.ProtocolState{},
+			})
+			assert.Equal(ps.GetTrustPing().GetReplied(), tt.rep.Replied)
+
+			var s Status
+			dto.FromJSONStr(ps.GetState().GetInfo(), &s)
+			assert.Equal(s, Status{
+				Comment:           tt.rep.Comment,
+				ResponseRequested: tt.rep.ResponseRequested,
+				Replied:           tt.rep.Replied,
+				ResponseComment:   tt.rep.ResponseComment,
+			})
+		})
+	}
+}
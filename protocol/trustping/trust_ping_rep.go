@@ -0,0 +1,58 @@
+package trustping
+
+import (
+	"github.com/findy-network/findy-agent/agent/psm"
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
+	"github.com/lainio/err2/try"
+)
+
+const bucketType = psm.BucketTrustPing
+
+// trustPingRep is the state of the ping at both ends. At the sender's side
+// Replied tells that the response is received, and at the receiver's side
+// that it's sent.
+type trustPingRep struct {
+	psm.StateKey
+	Comment           string
+	ResponseRequested bool
+	Replied           bool
+	ResponseComment   string
+}
+
+func init() {
+	psm.Creator.Add(bucketType, NewTrustPingRep)
+}
+
+func NewTrustPingRep(d []byte) psm.Rep {
+	p := &trustPingRep{}
+	dto.FromGOB(d, p)
+	return p
+}
+
+func (p *trustPingRep) Key() psm.StateKey {
+	return p.StateKey
+}
+
+func (p *trustPingRep) Data() []byte {
+	return dto.ToGOB(p)
+}
+
+func (p *trustPingRep) Type() byte {
+	return bucketType
+}
+
+func getTrustPingRep(workerDID, taskID string) (rep *trustPingRep, err error) {
+	defer err2.Handle(&err)
+
+	res := try.To1(psm.GetRep(bucketType, psm.StateKey{
+		DID:   workerDID,
+		Nonce: taskID,
+	}))
+
+	tpRep, ok := res.(*trustPingRep)
+	assert.That(ok, "trust ping type mismatch")
+
+	return tpRep, nil
+}
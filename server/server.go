@@ -20,6 +20,7 @@ import (
 	"github.com/findy-network/findy-agent/agent/psm"
 	"github.com/findy-network/findy-agent/agent/utils"
 	grpcserver "github.com/findy-network/findy-agent/grpc/server"
+	"github.com/findy-network/findy-agent/std/decorator"
 	pb "github.com/findy-network/findy-common-go/grpc/agency/v1"
 	myhttp "github.com/findy-network/findy-common-go/http"
 	"github.com/golang/glog"
@@ -35,29 +36,39 @@ import (
 // Internet, the port the world sees, and is assigned to endpoints.
 func StartHTTPServer(serverPort uint) <-chan os.Signal {
 	sp := fmt.Sprintf(":%v", serverPort)
-	mux := http.NewServeMux()
-
-	pattern := setHandler(utils.Settings.ServiceName(), mux, protocolTransport)
-	pattern2 := buildNewTransportPath(pattern)
-	mux.HandleFunc(pattern2, protocolTransport)
-	mux.HandleFunc("/dyn", dynInvitation)
-	mux.HandleFunc("/version", tellVersion)
-	mux.HandleFunc("/ready", checkReady)
-	mux.HandleFunc("/", tellVersion)
 
 	if glog.V(1) {
 		glog.Info(utils.Settings.VersionInfo())
-		glog.Infof("HTTP Server on port: %v with handle pattern: \"%s\"",
-			serverPort, pattern)
-		glog.Infof("***** New DID-Server v2.0 Path: '%s' *******", pattern2)
+		glog.Infof("HTTP Server on port: %v", serverPort)
 	}
 	server := &http.Server{
 		Addr:    sp,
-		Handler: mux,
+		Handler: newMux(),
 	}
 	return myhttp.Run(server)
 }
 
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	setTransportHandlers(utils.Settings.ServiceName(), mux)
+	for _, family := range utils.Settings.ServiceFamilies() {
+		setTransportHandlers(utils.Settings.ServiceNameFor(family), mux)
+	}
+	mux.HandleFunc("/dyn", dynInvitation)
+	mux.HandleFunc("/version", tellVersion)
+	mux.HandleFunc("/ready", checkReady)
+	mux.HandleFunc("/", tellVersion)
+	return mux
+}
+
+func setTransportHandlers(serviceName string, mux *http.ServeMux) {
+	pattern := setHandler(serviceName, mux, protocolTransport)
+	pattern2 := buildNewTransportPath(pattern)
+	mux.HandleFunc(pattern2, protocolTransport)
+	glog.V(1).Infof("handle patterns: '%s', '%s'", pattern, pattern2)
+}
+
 func buildNewTransportPath(pattern string) string {
 	return strings.TrimSuffix(pattern, "/") + endp.Version2EndpSuffix + "/"
 }
@@ -223,7 +234,7 @@ func transportPL(ourAddress *endp.Addr, data []byte) {
 
 	// Most cases security pipe comes from wEA's pairwise endpoints
 	rcvrCA := agency.ReceiverCA(ourAddress).(*cloud.Agent)
-	rcvrWA := rcvrCA.WEA()
+	rcvrWA := try.To1(rcvrCA.WEA())
 	pipe := rcvrWA.SecPipe(ourAddress.ConnID)
 
 	assert.ThatNot(pipe.IsNull(), "invitations aren't transported thru these anymore")
@@ -235,15 +246,17 @@ func transportPL(ourAddress *endp.Addr, data []byte) {
 
 	inPL := aries.PayloadCreator.NewFromData(d)
 	ourAddress.VerKey = vk // set associated verkey to our endp
+	try.To(checkServiceFamily(ourAddress, inPL.Protocol()))
 
 	// Get handler CA and forward unpacked and typed Payload to it
 	ca := agency.RcvrCA(ourAddress).(*cloud.Agent)
 
 	// Put payload to a Packet and let communication processor handle it
 	packet := comm.Packet{
-		Payload:  inPL,
-		Address:  ourAddress,
-		Receiver: ca.WEA(), // worker EA handles the packet
+		Payload:   inPL,
+		Address:   ourAddress,
+		Receiver:  try.To1(ca.WEA()), // worker EA handles the packet
+		PleaseAck: decorator.PleaseAckOf(d),
 	}
 
 	try.To(comm.Proc.Process(packet))
@@ -251,3 +264,16 @@ func transportPL(ourAddress *endp.Addr, data []byte) {
 	// no error, we can cleanup the received payload
 	rmIncoming(packet.Address)
 }
+
+// checkServiceFamily checks that the payload's protocol family is allowed in
+// the service path it's received from. Family specific paths accept only their
+// own family, the default path accepts all.
+func checkServiceFamily(addr *endp.Addr, family string) error {
+	serviceName := strings.TrimSuffix(addr.Service, endp.Version2EndpSuffix)
+	pathFamily := utils.Settings.ServiceFamily(serviceName)
+	if pathFamily != "" && pathFamily != family {
+		return fmt.Errorf("protocol family %s not allowed in path /%s",
+			family, addr.Service)
+	}
+	return nil
+}
@@ -7,6 +7,9 @@ import (
 	"testing"
 
 	"github.com/findy-network/findy-agent/agent/agency"
+	"github.com/findy-network/findy-agent/agent/endp"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/agent/utils"
 	"github.com/lainio/err2/assert"
 )
 
@@ -55,3 +58,47 @@ func TestDynHandler(t *testing.T) {
 	assert.Equal("500 - Error", string(data))
 	assert.Equal(res.StatusCode, http.StatusInternalServerError)
 }
+
+func TestServicePathRouting(t *testing.T) {
+	defer assert.PushTester(t)()
+
+	utils.Settings.SetServiceName("a2a")
+	utils.Settings.SetServicePaths(map[string]string{
+		pltype.ProtocolPresentProof: "a2a-proof",
+	})
+	defer utils.Settings.SetServicePaths(nil)
+
+	const rcvrPath = "/GLkYrM8ArdLN6DmyTkLB4p/GLkYrM8ArdLN6DmyTkLB4p/" +
+		"9b6ff6e3-b7c1-4b43-a0c6-1ce4b3c3d8d5"
+	mux := newMux()
+	tests := []struct {
+		path    string
+		pattern string
+		family  string
+		ok      bool
+	}{
+		{"/a2a-proof" + rcvrPath, "/a2a-proof/", pltype.ProtocolPresentProof, true},
+		{"/a2a-proof-2" + rcvrPath, "/a2a-proof-2/", pltype.ProtocolPresentProof, true},
+		{"/a2a-proof" + rcvrPath, "/a2a-proof/", pltype.ProtocolIssueCredential, false},
+		{"/a2a" + rcvrPath, "/a2a/", pltype.ProtocolPresentProof, true},
+		{"/a2a" + rcvrPath, "/a2a/", pltype.ProtocolIssueCredential, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.family, func(t *testing.T) {
+			defer assert.PushTester(t)()
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			_, pattern := mux.Handler(req)
+			assert.Equal(pattern, tt.pattern)
+
+			addr := endp.NewServerAddr(tt.path)
+			assert.That(addr.Valid())
+			err := checkServiceFamily(addr, tt.family)
+			if tt.ok {
+				assert.NoError(err)
+			} else {
+				assert.Error(err)
+			}
+		})
+	}
+}
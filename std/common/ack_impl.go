@@ -33,7 +33,11 @@ func init() {
 	aries.Creator.Add(pltype.IssueCredentialACK, AckCreator)
 	aries.Creator.Add(pltype.PresentProofACK, AckCreator)
 	aries.Creator.Add(pltype.DIDOrgIssueCredentialACK, AckCreator)
+	aries.Creator.Add(pltype.DIDOrgIssueCredentialV2ACK, AckCreator)
 	aries.Creator.Add(pltype.DIDOrgPresentProofACK, AckCreator)
+	aries.Creator.Add(pltype.DIDOrgPresentProofV2ACK, AckCreator)
+	aries.Creator.Add(pltype.NotificationAck, AckCreator)
+	aries.Creator.Add(pltype.DIDOrgNotificationAck, AckCreator)
 }
 
 func NewAck(r *Ack) *AckImpl {
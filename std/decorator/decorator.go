@@ -21,6 +21,18 @@ type Thread struct {
 	ReceivedOrders map[string]int `json:"received_orders,omitempty"`
 }
 
+// Please ack points of the PleaseAck decorator.
+const (
+	PleaseAckReceipt = "RECEIPT"
+	PleaseAckOutcome = "OUTCOME"
+)
+
+// PleaseAck asks the receiver to send an ack at the listed points.
+// https://github.com/hyperledger/aries-rfcs/tree/main/features/0317-please-ack
+type PleaseAck struct {
+	On []string `json:"on,omitempty"`
+}
+
 // Timing keeps expiration time
 type Timing struct {
 	ExpiresTime time.Time `json:"expires_time,omitempty"`
@@ -64,6 +76,13 @@ type Attachment struct {
 	Data AttachmentData `json:"data,omitempty"`
 }
 
+// Format tells the format of the attachment in the messages of the 2.0
+// protocols, e.g. hlindy/proof@v2.0. The attachment is referred by its ID.
+type Format struct {
+	AttachID string `json:"attach_id"`
+	Format   string `json:"format"`
+}
+
 // AttachmentData contains attachment payload
 type AttachmentData struct {
 	// Sha256 is a hash of the content. Optional. Used as an integrity check if content is inlined.
@@ -1,5 +1,10 @@
 package decorator
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 func NewThread(ID, PID string) *Thread {
 	realPID := ""
 	if ID != PID {
@@ -17,3 +22,75 @@ func CheckThread(thread *Thread, ID string) *Thread {
 	}
 	return thread
 }
+
+// FormatAttachment returns the attachment of the format. The messages of the
+// 1.0 protocols don't have formats, and the first attachment is used for them.
+func FormatAttachment(
+	attachs []Attachment,
+	formats []Format,
+	format string,
+) (*Attachment, error) {
+	if len(formats) == 0 {
+		if len(attachs) == 0 {
+			return nil, fmt.Errorf("attachment missing")
+		}
+		return &attachs[0], nil
+	}
+	for _, f := range formats {
+		if f.Format != format {
+			continue
+		}
+		for i := range attachs {
+			if attachs[i].ID == f.AttachID {
+				return &attachs[i], nil
+			}
+		}
+		return nil, fmt.Errorf("attachment (%s) missing", f.AttachID)
+	}
+	return nil, fmt.Errorf("attachment format (%s) not supported", format)
+}
+
+// Wants tells if the ack is asked at the point. The decorator without points
+// asks the receipt ack like the first version of the RFC did.
+func (p *PleaseAck) Wants(on string) bool {
+	if p == nil {
+		return false
+	}
+	if len(p.On) == 0 {
+		return on == PleaseAckReceipt
+	}
+	for _, o := range p.On {
+		if o == on {
+			return true
+		}
+	}
+	return false
+}
+
+// PleaseAckOf returns the please ack decorator of the JSON message, or nil if
+// the message doesn't have it. It's read from the raw message because the
+// typed messages don't keep the decorators they don't declare.
+func PleaseAckOf(data []byte) *PleaseAck {
+	var msg struct {
+		PleaseAck *PleaseAck `json:"~please_ack,omitempty"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil
+	}
+	return msg.PleaseAck
+}
+
+// AddPleaseAck returns the JSON message with the please ack decorator asking
+// acks at the points.
+func AddPleaseAck(data []byte, on ...string) ([]byte, error) {
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("add please ack: %w", err)
+	}
+	pa, err := json.Marshal(PleaseAck{On: on})
+	if err != nil {
+		return nil, fmt.Errorf("add please ack: %w", err)
+	}
+	msg["~please_ack"] = pa
+	return json.Marshal(msg)
+}
@@ -3,6 +3,8 @@ package decorator
 import (
 	"reflect"
 	"testing"
+
+	"github.com/lainio/err2/assert"
 )
 
 func TestNewThread(t *testing.T) {
@@ -60,3 +62,61 @@ func TestCheckThread(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatAttachment(t *testing.T) {
+	const indyFormat = "hlindy/proof-req@v2.0"
+	attachs := []Attachment{{ID: "dif"}, {ID: "indy"}}
+	tests := []struct {
+		name    string
+		attachs []Attachment
+		formats []Format
+		wantID  string
+	}{
+		{"v1 first", attachs, nil, "dif"},
+		{"by format", attachs, []Format{
+			{AttachID: "dif", Format: "dif/presentation-exchange/definitions@v1.0"},
+			{AttachID: "indy", Format: indyFormat},
+		}, "indy"},
+		{"format not supported", attachs, []Format{
+			{AttachID: "dif", Format: "dif/presentation-exchange/definitions@v1.0"},
+		}, ""},
+		{"attachment missing", attachs[:1], []Format{
+			{AttachID: "indy", Format: indyFormat},
+		}, ""},
+		{"no attachments", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			a, err := FormatAttachment(tt.attachs, tt.formats, indyFormat)
+			if tt.wantID == "" {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(a.ID, tt.wantID)
+		})
+	}
+}
+
+func TestPleaseAck(t *testing.T) {
+	defer assert.PushTester(t)()
+
+	msg := []byte(`{"@id":"1","@type":"https://didcomm.org/basicmessage/1.0/message"}`)
+	assert.That(PleaseAckOf(msg) == nil)
+	assert.ThatNot(PleaseAckOf(msg).Wants(PleaseAckReceipt))
+
+	data, err := AddPleaseAck(msg, PleaseAckOutcome)
+	assert.NoError(err)
+	pa := PleaseAckOf(data)
+	assert.NotNil(pa)
+	assert.That(pa.Wants(PleaseAckOutcome))
+	assert.ThatNot(pa.Wants(PleaseAckReceipt))
+
+	// RFC's first version didn't have the points
+	pa = PleaseAckOf([]byte(`{"@id":"1","~please_ack":{}}`))
+	assert.That(pa.Wants(PleaseAckReceipt))
+	assert.ThatNot(pa.Wants(PleaseAckOutcome))
+}
@@ -0,0 +1,107 @@
+package didexchange
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/findy-network/findy-common-go/std/didexchange/invitation"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// ErrInvitationExpired is returned for the invitation which expires_time of
+// the timing decorator is passed.
+var ErrInvitationExpired = errors.New("invitation expired")
+
+// invitationQueryKeys are the URL query keys of the invitation, see
+// invitation.Translate.
+var invitationQueryKeys = []string{"c_i", "oob"}
+
+// WithExpiry returns the JSON and the URL of the invitation with the ~timing
+// decorator, which tells when the invitation expires. It limits the time a
+// leaked invitation, e.g. a QR code, can be used.
+func WithExpiry(
+	inv invitation.Invitation,
+	expires time.Time,
+) (
+	jsonStr, urlStr string,
+	err error,
+) {
+	defer err2.Handle(&err, "invitation expiry")
+
+	var m map[string]any
+	try.To(json.Unmarshal(try.To1(json.Marshal(inv)), &m))
+	m["~timing"] = decorator.Timing{ExpiresTime: expires.UTC()}
+	data := try.To1(json.Marshal(m))
+
+	u := try.To1(url.Parse(try.To1(inv.Build())))
+	q := u.Query()
+	for _, key := range invitationQueryKeys {
+		if q.Has(key) {
+			q.Set(key, base64.RawURLEncoding.EncodeToString(data))
+		}
+	}
+	u.RawQuery = q.Encode()
+	return string(data), u.String(), nil
+}
+
+// InvitationExpiry returns the expiry time of the invitation, which is the
+// JSON or the URL like in invitation.Translate. The zero time means that the
+// invitation doesn't expire.
+func InvitationExpiry(s string) (expires time.Time, err error) {
+	defer err2.Handle(&err, "invitation expiry")
+
+	data := []byte(strings.TrimSpace(s))
+	if !strings.HasPrefix(string(data), "{") {
+		u := try.To1(url.Parse(string(data)))
+		q := u.Query()
+		for _, key := range invitationQueryKeys {
+			if v := q.Get(key); v != "" {
+				data = try.To1(decodeB64(v))
+				break
+			}
+		}
+	}
+	var timing struct {
+		Timing *decorator.Timing `json:"~timing,omitempty"`
+	}
+	try.To(json.Unmarshal(data, &timing))
+	if timing.Timing == nil {
+		return time.Time{}, nil
+	}
+	return timing.Timing.ExpiresTime, nil
+}
+
+// CheckExpiry returns ErrInvitationExpired if the invitation is expired at
+// the given time.
+func CheckExpiry(s string, now time.Time) error {
+	expires, err := InvitationExpiry(s)
+	if err != nil {
+		return err
+	}
+	return CheckExpiryTime(expires, now)
+}
+
+// CheckExpiryTime returns ErrInvitationExpired if the expiry time is passed at
+// the given time. The zero expiry time never expires.
+func CheckExpiryTime(expires, now time.Time) error {
+	if !expires.IsZero() && !now.Before(expires) {
+		return fmt.Errorf("%w at %s", ErrInvitationExpired,
+			expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func decodeB64(s string) ([]byte, error) {
+	data, err := base64.URLEncoding.DecodeString(s)
+	if err != nil {
+		data, err = base64.RawURLEncoding.DecodeString(s)
+	}
+	return data, err
+}
@@ -0,0 +1,55 @@
+package didexchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/findy-network/findy-common-go/std/didexchange/invitation"
+	"github.com/lainio/err2/assert"
+)
+
+func TestWithExpiry(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	expires := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, version := range []invitation.DIDExchangeVersion{
+		invitation.DIDExchangeVersionV0,
+		invitation.DIDExchangeVersionV1,
+	} {
+		inv, err := invitation.Create(version, invitation.AgentInfo{
+			InvitationType: "https://didcomm.org/connections/1.0/invitation",
+			InvitationID:   "inv-id",
+			EndpointURL:    "http://example.com",
+			RecipientKey:   "8QhFxKxyaFsJy4CyxeYX34dFH8oWqyBv1P4HLQCsoeLy",
+			AgentLabel:     "label",
+		})
+		assert.NoError(err)
+		jsonStr, urlStr, err := WithExpiry(inv, expires)
+		assert.NoError(err)
+
+		for _, s := range []string{jsonStr, urlStr} {
+			got, err := InvitationExpiry(s)
+			assert.NoError(err)
+			assert.That(got.Equal(expires), s)
+
+			// the expiry doesn't break the invitation
+			translated, err := invitation.Translate(s)
+			assert.NoError(err)
+			assert.Equal(translated.ID(), "inv-id")
+
+			assert.NoError(CheckExpiry(s, expires.Add(-time.Second)))
+			err = CheckExpiry(s, expires)
+			assert.That(errors.Is(err, ErrInvitationExpired))
+		}
+	}
+
+	// invitations without the timing don't expire
+	inv, err := invitation.Create(invitation.DIDExchangeVersionV0,
+		invitation.AgentInfo{InvitationID: "inv-id"})
+	assert.NoError(err)
+	urlStr, err := inv.Build()
+	assert.NoError(err)
+	assert.NoError(CheckExpiry(urlStr, time.Now()))
+}
@@ -1,7 +1,10 @@
 package didexchange
 
 import (
+	"strings"
+
 	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
 	"github.com/findy-network/findy-agent/agent/psm"
 	"github.com/findy-network/findy-agent/agent/service"
 	"github.com/findy-network/findy-agent/core"
@@ -26,6 +29,27 @@ type PwMsg interface {
 	PayloadToWait() (didcomm.Payload, psm.SubState)
 }
 
+// RotateMsg is implemented by the DID Exchange responses. The 1.1 response
+// has the responder's DID signed with the invitation key, which binds the
+// exchanged DID to the invitation.
+type RotateMsg interface {
+	VerifyRotate(DID core.DID, invitationKey string) error
+}
+
+// PrefersV11 tells if DID Exchange 1.1 is the first of the invitation's
+// handshake protocols which we support.
+func PrefersV11(handshakeProtocols []string) bool {
+	for _, p := range handshakeProtocols {
+		switch strings.TrimSuffix(p, "/") {
+		case pltype.DIDOrgAriesDIDExchange11:
+			return true
+		case pltype.DIDOrgAriesDIDExchange10:
+			return false
+		}
+	}
+	return false
+}
+
 type UnsupportedPwMsgBase struct{}
 
 func (m *UnsupportedPwMsgBase) Endpoint() service.Addr {
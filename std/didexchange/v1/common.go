@@ -14,8 +14,10 @@ import (
 	"github.com/findy-network/findy-agent/std/sov/did"
 	"github.com/golang/glog"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/decorator"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/fingerprint"
 
 	"github.com/lainio/err2"
+	"github.com/lainio/err2/assert"
 	"github.com/lainio/err2/try"
 	"github.com/mr-tron/base58"
 )
@@ -42,12 +44,33 @@ func newDIDDocAttach(ourDID core.DID) (attachment *decorator.Attachment, err err
 	defer err2.Handle(&err, "new v1 did doc attachment")
 
 	didDocBytes := try.To1(json.Marshal(ourDID.DOC()))
-	data := decorator.AttachmentData{
-		Base64: base64.StdEncoding.EncodeToString(didDocBytes)}
+	return signedAttach(ourDID, "application/json", didDocBytes)
+}
+
+// newDIDRotateAttach returns the DID Exchange 1.1 did_rotate attachment, i.e.
+// our DID signed with our key, which is the invitation key of the responder.
+func newDIDRotateAttach(ourDID core.DID) (attachment *decorator.Attachment, err error) {
+	defer err2.Handle(&err, "new v1 did rotate attachment")
+
+	return signedAttach(ourDID, "text/string", []byte(ourDID.Did()))
+}
+
+// signedAttach returns the attachment of the data signed with our DID's key.
+func signedAttach(
+	ourDID core.DID,
+	mimeType string,
+	data []byte,
+) (
+	attachment *decorator.Attachment,
+	err error,
+) {
+	defer err2.Handle(&err)
+
 	attachment = &decorator.Attachment{
 		ID:       utils.UUID(),
-		MimeType: "application/json",
-		Data:     data,
+		MimeType: mimeType,
+		Data: decorator.AttachmentData{
+			Base64: base64.StdEncoding.EncodeToString(data)},
 	}
 
 	// sign attachment
@@ -63,6 +86,48 @@ func newDIDDocAttach(ourDID core.DID) (attachment *decorator.Attachment, err err
 	return attachment, nil
 }
 
+// verifyDIDRotate verifies that the did_rotate attachment has the DID, and
+// that it's signed with the invitation key.
+func verifyDIDRotate(
+	attachment *decorator.Attachment,
+	DID core.DID,
+	theirDID, invitationKey string,
+) (
+	err error,
+) {
+	defer err2.Handle(&err, "verify did rotate")
+
+	assert.NotNil(attachment, "did_rotate attachment missing")
+	signed := try.To1(base64.StdEncoding.DecodeString(attachment.Data.Base64))
+	assert.Equal(string(signed), theirDID, "did_rotate has other DID")
+
+	var jws struct {
+		Header struct {
+			KID string `json:"kid"`
+		} `json:"header"`
+	}
+	try.To(json.Unmarshal(attachment.Data.JWS, &jws))
+	sigKey := try.To1(fingerprint.PubKeyFromDIDKey(jws.Header.KID))
+	assert.Equal(base58.Encode(sigKey), invitationKey,
+		"did_rotate isn't signed with the invitation key")
+
+	return attachment.Data.Verify(DID.Packager().Crypto(), DID.Packager().KMS())
+}
+
+// isV11 tells if the message type is of DID Exchange 1.1.
+func isV11(msgType string) bool {
+	return strings.Contains(msgType, "/1.1/")
+}
+
+// versioned returns the DID Exchange 1.0 message type in 1.1 if needed. The
+// protocol continues with the version the requester selected.
+func versioned(msgType string, v11 bool) string {
+	if v11 {
+		return strings.Replace(msgType, "/1.0/", "/1.1/", 1)
+	}
+	return msgType
+}
+
 func (m *commonImpl) Did() string {
 	glog.V(11).Infoln("Did() is returning:", m.commonData.DID)
 	rawDID := strings.TrimPrefix(m.commonData.DID, "did:sov:")
@@ -35,6 +35,7 @@ func init() {
 	gob.Register(&completeImpl{})
 	aries.Creator.Add(pltype.AriesDIDExchangeComplete, completeCreator)
 	aries.Creator.Add(pltype.DIDOrgAriesDIDExchangeComplete, completeCreator)
+	aries.Creator.Add(pltype.DIDOrgAriesDIDExchangeComplete11, completeCreator)
 }
 
 func newComplete(c *Complete) (impl *completeImpl) {
@@ -86,7 +87,7 @@ func (m *completeImpl) PayloadToSend(_ string, _ core.DID) (didcomm.Payload, psm
 	emptyMsg := aries.MsgCreator.Create(didcomm.MsgInit{})
 	return aries.PayloadCreator.NewMsg(
 		m.Complete.Thread.PID,
-		pltype.DIDOrgAriesDIDExchangeComplete,
+		versioned(pltype.DIDOrgAriesDIDExchangeComplete, isV11(m.Complete.Type)),
 		emptyMsg,
 	), psm.ReadyACK, nil
 }
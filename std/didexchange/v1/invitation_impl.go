@@ -118,7 +118,9 @@ func (m *invitationImpl) PayloadToSend(ourLabel string, ourDID core.DID) (
 ) {
 	defer err2.Handle(&err, "next for v1 invitation")
 
-	// build a connection request message to send to another agent
+	// build a connection request message to send to another agent in the
+	// DID Exchange version the invitation prefers
+	v11 := didexchange.PrefersV11(m.Invitation.HandshakeProtocols())
 	msg := try.To1(newRequest(ourDID, &Request{
 		Label:  ourLabel,
 		DID:    ourDID.Did(),
@@ -128,7 +130,7 @@ func (m *invitationImpl) PayloadToSend(ourLabel string, ourDID core.DID) (
 	// Create payload to send
 	return aries.PayloadCreator.NewMsg(
 		m.thread.PID,
-		pltype.DIDOrgAriesDIDExchangeRequest,
+		versioned(pltype.DIDOrgAriesDIDExchangeRequest, v11),
 		msg), psm.Sending, nil
 
 }
@@ -15,11 +15,12 @@ type Request struct {
 }
 
 type Response struct {
-	Type   string                `json:"@type,omitempty"`
-	ID     string                `json:"@id,omitempty"`
-	DID    string                `json:"did,omitempty"`
-	DIDDoc *decorator.Attachment `json:"did_doc~attach,omitempty"`
-	Thread *our.Thread           `json:"~thread,omitempty"`
+	Type      string                `json:"@type,omitempty"`
+	ID        string                `json:"@id,omitempty"`
+	DID       string                `json:"did,omitempty"`
+	DIDDoc    *decorator.Attachment `json:"did_doc~attach,omitempty"`
+	DIDRotate *decorator.Attachment `json:"did_rotate~attach,omitempty"` // 1.1
+	Thread    *our.Thread           `json:"~thread,omitempty"`
 }
 
 type Complete struct {
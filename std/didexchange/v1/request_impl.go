@@ -36,6 +36,7 @@ func init() {
 	gob.Register(&requestImpl{})
 	aries.Creator.Add(pltype.AriesDIDExchangeRequest, requestCreator)
 	aries.Creator.Add(pltype.DIDOrgAriesDIDExchangeRequest, requestCreator)
+	aries.Creator.Add(pltype.DIDOrgAriesDIDExchangeRequest11, requestCreator)
 }
 
 func newRequest(ourDID core.DID, r *Request) (req *requestImpl, err error) {
@@ -99,18 +100,23 @@ func (m *requestImpl) SetType(t string) {
 
 func (m *requestImpl) PayloadToSend(_ string, ourDID core.DID) (pl didcomm.Payload, st psm.SubState, err error) {
 	defer err2.Handle(&err, "next for v1 request")
+
+	// we answer with the same version the requester uses
+	v11 := isV11(m.Request.Type)
 	msg := try.To1(newResponse(ourDID, &Response{
 		DID:    ourDID.Did(),
 		Thread: checkThread(&our.Thread{}, m.Request.Thread.PID),
-	}))
+	}, v11))
 
-	return aries.PayloadCreator.NewMsg(m.Request.Thread.PID, pltype.DIDOrgAriesDIDExchangeResponse, msg), psm.Sending, nil
+	return aries.PayloadCreator.NewMsg(m.Request.Thread.PID,
+		versioned(pltype.DIDOrgAriesDIDExchangeResponse, v11), msg), psm.Sending, nil
 }
 
 func (m *requestImpl) PayloadToWait() (didcomm.Payload, psm.SubState) {
 	return aries.PayloadCreator.New(
 		didcomm.PayloadInit{
-			ID:   m.Request.Thread.PID,
-			Type: pltype.DIDOrgAriesDIDExchangeResponse,
+			ID: m.Request.Thread.PID,
+			Type: versioned(pltype.DIDOrgAriesDIDExchangeResponse,
+				isV11(m.Request.Type)),
 		}), psm.Waiting
 }
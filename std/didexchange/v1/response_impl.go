@@ -41,12 +41,18 @@ func init() {
 	gob.Register(&responseImpl{})
 	aries.Creator.Add(pltype.AriesDIDExchangeResponse, responseCreator)
 	aries.Creator.Add(pltype.DIDOrgAriesDIDExchangeResponse, responseCreator)
+	aries.Creator.Add(pltype.DIDOrgAriesDIDExchangeResponse11, responseCreator)
 }
 
-func newResponse(ourDID core.DID, r *Response) (resp *responseImpl, err error) {
+// newResponse returns the response with our DID Doc. The 1.1 response has
+// our DID signed as well.
+func newResponse(ourDID core.DID, r *Response, v11 bool) (resp *responseImpl, err error) {
 	defer err2.Handle(&err, "new response %s", ourDID.Did())
 
 	r.DIDDoc = try.To1(newDIDDocAttach(ourDID))
+	if v11 {
+		r.DIDRotate = try.To1(newDIDRotateAttach(ourDID))
+	}
 	return &responseImpl{commonImpl{
 		commonData{
 			DID:    r.DID,
@@ -98,13 +104,23 @@ func (m *responseImpl) SetType(t string) {
 	m.Response.Type = t
 }
 
+// VerifyRotate verifies the did_rotate attachment of the 1.1 response. The
+// 1.0 response doesn't have it.
+func (m *responseImpl) VerifyRotate(DID core.DID, invitationKey string) error {
+	if !isV11(m.Response.Type) {
+		return nil
+	}
+	return verifyDIDRotate(m.Response.DIDRotate, DID, m.Response.DID,
+		invitationKey)
+}
+
 func (m *responseImpl) PayloadToSend(_ string, _ core.DID) (didcomm.Payload, psm.SubState, error) {
 	msg := newComplete(&Complete{
 		Thread: checkThread(&our.Thread{}, m.Response.Thread.PID),
 	})
 	return aries.PayloadCreator.NewMsg(
 		m.Response.Thread.PID,
-		pltype.DIDOrgAriesDIDExchangeComplete,
+		versioned(pltype.DIDOrgAriesDIDExchangeComplete, isV11(m.Response.Type)),
 		msg,
 	), psm.Sending, nil
 }
@@ -113,7 +129,7 @@ func (m *responseImpl) PayloadToWait() (didcomm.Payload, psm.SubState) {
 	emptyMsg := aries.MsgCreator.Create(didcomm.MsgInit{})
 	return aries.PayloadCreator.NewMsg(
 		m.Response.Thread.PID,
-		pltype.DIDOrgAriesDIDExchangeComplete,
+		versioned(pltype.DIDOrgAriesDIDExchangeComplete, isV11(m.Response.Type)),
 		emptyMsg,
 	), psm.ReadyACK
 }
@@ -0,0 +1,81 @@
+package discoverfeatures
+
+import (
+	"encoding/gob"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/findy-network/findy-common-go/dto"
+)
+
+var DiscloseCreator = &DiscloseFactor{}
+
+type DiscloseFactor struct{}
+
+func (f *DiscloseFactor) NewMsg(init didcomm.MsgInit) didcomm.MessageHdr {
+	m := &Disclose{
+		Type:   init.Type,
+		ID:     init.AID,
+		Thread: decorator.CheckThread(init.Thread, init.AID),
+	}
+	return NewDisclose(m)
+}
+
+func (f *DiscloseFactor) NewMessage(data []byte) didcomm.MessageHdr {
+	return NewDiscloseMsg(data)
+}
+
+func init() {
+	gob.Register(&DiscloseImpl{})
+	aries.Creator.Add(pltype.DiscoverFeaturesDisclose, DiscloseCreator)
+	aries.Creator.Add(pltype.DIDOrgDiscoverFeaturesDisclose, DiscloseCreator)
+}
+
+func NewDisclose(r *Disclose) *DiscloseImpl {
+	return &DiscloseImpl{Disclose: r}
+}
+
+func NewDiscloseMsg(data []byte) *DiscloseImpl {
+	var mImpl DiscloseImpl
+	dto.FromJSON(data, &mImpl)
+	mImpl.checkThread()
+	return &mImpl
+}
+
+func (p *DiscloseImpl) checkThread() {
+	p.Disclose.Thread = decorator.CheckThread(p.Disclose.Thread, p.Disclose.ID)
+}
+
+type DiscloseImpl struct {
+	*Disclose
+}
+
+func (p *DiscloseImpl) ID() string {
+	return p.Disclose.ID
+}
+
+func (p *DiscloseImpl) Type() string {
+	return p.Disclose.Type
+}
+
+func (p *DiscloseImpl) SetID(id string) {
+	p.Disclose.ID = id
+}
+
+func (p *DiscloseImpl) SetType(t string) {
+	p.Disclose.Type = t
+}
+
+func (p *DiscloseImpl) JSON() []byte {
+	return dto.ToJSONBytes(p)
+}
+
+func (p *DiscloseImpl) Thread() *decorator.Thread {
+	return p.Disclose.Thread
+}
+
+func (p *DiscloseImpl) FieldObj() interface{} {
+	return p.Disclose
+}
@@ -0,0 +1,29 @@
+// Package discoverfeatures implements the messages of the Aries Discover
+// Features protocol (RFC 0031).
+package discoverfeatures
+
+import "github.com/findy-network/findy-agent/std/decorator"
+
+// Query asks which protocols the other end supports. The query is a protocol
+// ID which may end with a '*' wildcard, e.g. "https://didcomm.org/*".
+type Query struct {
+	Type    string            `json:"@type,omitempty"`
+	ID      string            `json:"@id,omitempty"`
+	Thread  *decorator.Thread `json:"~thread,omitempty"`
+	Query   string            `json:"query"`
+	Comment string            `json:"comment,omitempty"`
+}
+
+// Disclose is the answer to Query. It lists the supported protocols which
+// match the query.
+type Disclose struct {
+	Type      string            `json:"@type,omitempty"`
+	ID        string            `json:"@id,omitempty"`
+	Thread    *decorator.Thread `json:"~thread,omitempty"`
+	Protocols []Protocol        `json:"protocols"`
+}
+
+type Protocol struct {
+	PID   string   `json:"pid"`
+	Roles []string `json:"roles,omitempty"`
+}
@@ -0,0 +1,82 @@
+package discoverfeatures
+
+import (
+	"encoding/gob"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/findy-network/findy-common-go/dto"
+)
+
+var QueryCreator = &QueryFactor{}
+
+type QueryFactor struct{}
+
+func (f *QueryFactor) NewMsg(init didcomm.MsgInit) didcomm.MessageHdr {
+	m := &Query{
+		Type:   init.Type,
+		ID:     init.AID,
+		Query:  init.Info,
+		Thread: decorator.CheckThread(init.Thread, init.AID),
+	}
+	return NewQuery(m)
+}
+
+func (f *QueryFactor) NewMessage(data []byte) didcomm.MessageHdr {
+	return NewQueryMsg(data)
+}
+
+func init() {
+	gob.Register(&QueryImpl{})
+	aries.Creator.Add(pltype.DiscoverFeaturesQuery, QueryCreator)
+	aries.Creator.Add(pltype.DIDOrgDiscoverFeaturesQuery, QueryCreator)
+}
+
+func NewQuery(r *Query) *QueryImpl {
+	return &QueryImpl{Query: r}
+}
+
+func NewQueryMsg(data []byte) *QueryImpl {
+	var mImpl QueryImpl
+	dto.FromJSON(data, &mImpl)
+	mImpl.checkThread()
+	return &mImpl
+}
+
+func (p *QueryImpl) checkThread() {
+	p.Query.Thread = decorator.CheckThread(p.Query.Thread, p.Query.ID)
+}
+
+type QueryImpl struct {
+	*Query
+}
+
+func (p *QueryImpl) ID() string {
+	return p.Query.ID
+}
+
+func (p *QueryImpl) Type() string {
+	return p.Query.Type
+}
+
+func (p *QueryImpl) SetID(id string) {
+	p.Query.ID = id
+}
+
+func (p *QueryImpl) SetType(t string) {
+	p.Query.Type = t
+}
+
+func (p *QueryImpl) JSON() []byte {
+	return dto.ToJSONBytes(p)
+}
+
+func (p *QueryImpl) Thread() *decorator.Thread {
+	return p.Query.Thread
+}
+
+func (p *QueryImpl) FieldObj() interface{} {
+	return p.Query
+}
@@ -33,6 +33,7 @@ func init() {
 	gob.Register(&IssueImpl{})
 	aries.Creator.Add(pltype.IssueCredentialIssue, IssueCreator)
 	aries.Creator.Add(pltype.DIDOrgIssueCredentialIssue, IssueCreator)
+	aries.Creator.Add(pltype.DIDOrgIssueCredentialV2Issue, IssueCreator)
 }
 
 func NewIssue(r *Issue) *IssueImpl {
@@ -49,7 +50,24 @@ func NewIssueMsg(data []byte) *IssueImpl {
 // MARK: Helpers
 
 func CredentialAttach(p *Issue) (data []byte, err error) {
-	return base64.StdEncoding.DecodeString(p.CredentialsAttach[0].Data.Base64)
+	a, err := decorator.FormatAttachment(p.CredentialsAttach, p.Formats,
+		pltype.IssueCredentialFormatIndy)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(a.Data.Base64)
+}
+
+// SetCredentialsAttach sets the indy credential as the attachment of the
+// issue message. The format of the attachment is set for the 2.0 messages.
+func SetCredentialsAttach(p *Issue, cred []byte) {
+	p.CredentialsAttach = NewCredentialsAttach(cred)
+	if IsV2(p.Type) {
+		p.Formats = []decorator.Format{{
+			AttachID: p.CredentialsAttach[0].ID,
+			Format:   pltype.IssueCredentialFormatIndy,
+		}}
+	}
 }
 
 func (p *IssueImpl) checkThread() {
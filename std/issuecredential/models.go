@@ -59,6 +59,8 @@ type Offer struct {
 	Comment string `json:"comment,omitempty"`
 	// CredentialPreview is a JSON-LD object that represents the credential data that Issuer is willing to issue.
 	CredentialPreview PreviewCredential `json:"credential_preview,omitempty"`
+	// Formats tell the formats of the attachments in the 2.0 messages.
+	Formats []decorator.Format `json:"formats,omitempty"`
 	// OffersAttach is a slice of attachments that further define the credential being offered.
 	// This might be used to clarify which formats or format versions will be issued.
 	OffersAttach []decorator.Attachment `json:"offers~attach,omitempty"`
@@ -79,6 +81,8 @@ type Request struct {
 	// so the offer can be evaluated by human judgment.
 	// TODO: Should follow DIDComm conventions for l10n. [Issue #1300]
 	Comment string `json:"comment,omitempty"`
+	// Formats tell the formats of the attachments in the 2.0 messages.
+	Formats []decorator.Format `json:"formats,omitempty"`
 	// RequestsAttach is a slice of attachments defining the requested formats for the credential
 	RequestsAttach []decorator.Attachment `json:"requests~attach,omitempty"`
 
@@ -95,6 +99,8 @@ type Issue struct {
 	// so the offer can be evaluated by human judgment.
 	// TODO: Should follow DIDComm conventions for l10n. [Issue #1300]
 	Comment string `json:"comment,omitempty"`
+	// Formats tell the formats of the attachments in the 2.0 messages.
+	Formats []decorator.Format `json:"formats,omitempty"`
 	// CredentialsAttach is a slice of attachments containing the issued credentials.
 	CredentialsAttach []decorator.Attachment `json:"credentials~attach,omitempty"`
 
@@ -107,9 +113,12 @@ type PreviewCredential struct {
 	Attributes []Attribute `json:"attributes,omitempty"`
 }
 
-// Attribute describes an attribute for a Preview Credential
+// Attribute describes an attribute for a Preview Credential. DataType is our
+// extension, which tells e.g. that the value is an integer and can be used in
+// the proof predicates.
 type Attribute struct {
 	Name     string `json:"name,omitempty"`
 	MimeType string `json:"mime-type,omitempty"`
 	Value    string `json:"value,omitempty"`
+	DataType string `json:"data-type,omitempty"`
 }
@@ -33,6 +33,7 @@ func init() {
 	gob.Register(&OfferImpl{})
 	aries.Creator.Add(pltype.IssueCredentialOffer, OfferCreator)
 	aries.Creator.Add(pltype.DIDOrgIssueCredentialOffer, OfferCreator)
+	aries.Creator.Add(pltype.DIDOrgIssueCredentialV2Offer, OfferCreator)
 }
 
 func NewOffer(r *Offer) *OfferImpl {
@@ -49,7 +50,26 @@ func NewOfferMsg(data []byte) *OfferImpl {
 // MARK: Helpers
 
 func OfferAttach(p *Offer) (data []byte, err error) {
-	return base64.StdEncoding.DecodeString(p.OffersAttach[0].Data.Base64)
+	a, err := decorator.FormatAttachment(p.OffersAttach, p.Formats,
+		pltype.IssueCredentialFormatIndyOffer)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(a.Data.Base64)
+}
+
+// SetOfferAttach sets the indy credential offer as the attachment of the
+// offer. The 2.0 offers get the format of the attachment and the 2.0 preview
+// type as well.
+func SetOfferAttach(p *Offer, offer []byte) {
+	p.OffersAttach = NewOfferAttach(offer)
+	if IsV2(p.Type) {
+		p.Formats = []decorator.Format{{
+			AttachID: p.OffersAttach[0].ID,
+			Format:   pltype.IssueCredentialFormatIndyOffer,
+		}}
+		p.CredentialPreview.Type = pltype.DIDOrgIssueCredentialV2CredentialPreview
+	}
 }
 
 func (p *OfferImpl) checkThread() {
@@ -33,6 +33,7 @@ func init() {
 	gob.Register(&RequestImpl{})
 	aries.Creator.Add(pltype.IssueCredentialRequest, RequestCreator)
 	aries.Creator.Add(pltype.DIDOrgIssueCredentialRequest, RequestCreator)
+	aries.Creator.Add(pltype.DIDOrgIssueCredentialV2Request, RequestCreator)
 }
 
 func NewRequest(r *Request) *RequestImpl {
@@ -49,7 +50,24 @@ func NewRequestMsg(data []byte) *RequestImpl {
 // MARK: Helpers
 
 func RequestAttach(p *Request) (data []byte, err error) {
-	return base64.StdEncoding.DecodeString(p.RequestsAttach[0].Data.Base64)
+	a, err := decorator.FormatAttachment(p.RequestsAttach, p.Formats,
+		pltype.IssueCredentialFormatIndyRequest)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(a.Data.Base64)
+}
+
+// SetRequestAttach sets the indy credential request as the attachment of the
+// request. The format of the attachment is set for the 2.0 messages.
+func SetRequestAttach(p *Request, credReq []byte) {
+	p.RequestsAttach = NewRequestAttach(credReq)
+	if IsV2(p.Type) {
+		p.Formats = []decorator.Format{{
+			AttachID: p.RequestsAttach[0].ID,
+			Format:   pltype.IssueCredentialFormatIndyRequest,
+		}}
+	}
 }
 
 func (p *RequestImpl) checkThread() {
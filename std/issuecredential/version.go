@@ -0,0 +1,31 @@
+package issuecredential
+
+import (
+	"strings"
+
+	"github.com/findy-network/findy-agent/agent/pltype"
+)
+
+// v2Types are the 2.0 message types of the 1.0 message types we send. The
+// proposals are 1.0 only for now.
+var v2Types = map[string]string{
+	pltype.IssueCredentialOffer:   pltype.DIDOrgIssueCredentialV2Offer,
+	pltype.IssueCredentialRequest: pltype.DIDOrgIssueCredentialV2Request,
+	pltype.IssueCredentialIssue:   pltype.DIDOrgIssueCredentialV2Issue,
+	pltype.IssueCredentialACK:     pltype.DIDOrgIssueCredentialV2ACK,
+	pltype.IssueCredentialNACK:    pltype.DIDOrgIssueCredentialV2NACK,
+}
+
+// IsV2 tells if the message type is an issue credential 2.0 message.
+func IsV2(msgType string) bool {
+	return strings.HasPrefix(msgType, pltype.DIDOrgIssueCredential+"/2.0/")
+}
+
+// Versioned returns the 2.0 message type of the 1.0 type when v2 is set.
+// Other types, e.g. pltype.Nothing, are returned as is.
+func Versioned(msgType string, v2 bool) string {
+	if t, ok := v2Types[msgType]; ok && v2 {
+		return t
+	}
+	return msgType
+}
@@ -0,0 +1,108 @@
+package issuecredential
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/std/common"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/lainio/err2/assert"
+)
+
+func TestVersioned(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	assert.Equal(Versioned(pltype.IssueCredentialOffer, false), pltype.IssueCredentialOffer)
+	assert.Equal(Versioned(pltype.IssueCredentialOffer, true), pltype.DIDOrgIssueCredentialV2Offer)
+	assert.Equal(Versioned(pltype.IssueCredentialPropose, true), pltype.IssueCredentialPropose)
+	assert.Equal(Versioned(pltype.Terminate, true), pltype.Terminate)
+
+	assert.That(IsV2(pltype.DIDOrgIssueCredentialV2Issue))
+	assert.ThatNot(IsV2(pltype.DIDOrgIssueCredentialIssue))
+	assert.ThatNot(IsV2(pltype.IssueCredentialIssue))
+}
+
+func TestV2_RoundTrip(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const (
+		credOffer = `{"cred_def_id":"cred def id","nonce":"123"}`
+		credReq   = `{"prover_did":"did","nonce":"456"}`
+		cred      = `{"cred_def_id":"cred def id","values":{}}`
+	)
+
+	// issuer sends the offer
+	offer := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:    "offer id",
+		Type:   Versioned(pltype.IssueCredentialOffer, true),
+		Thread: decorator.NewThread("offer id", ""),
+	}).(*OfferImpl)
+	offer.CredentialPreview = NewPreviewCredential(`[{"name":"email","value":"a@b.c"}]`)
+	SetOfferAttach(offer.Offer, []byte(credOffer))
+	assert.Equal(offer.CredentialPreview.Type, pltype.DIDOrgIssueCredentialV2CredentialPreview)
+
+	// holder receives it and answers with the same version
+	inOffer := OfferCreator.NewMessage(offer.JSON()).(*OfferImpl)
+	assert.Equal(inOffer.Type(), pltype.DIDOrgIssueCredentialV2Offer)
+	data, err := OfferAttach(inOffer.Offer)
+	assert.NoError(err)
+	assert.Equal(string(data), credOffer)
+	assert.Equal(PreviewCredentialToValues(inOffer.CredentialPreview),
+		PreviewCredentialToValues(offer.CredentialPreview))
+
+	req := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:    "req id",
+		Type:   Versioned(pltype.IssueCredentialRequest, IsV2(inOffer.Type())),
+		Thread: inOffer.Thread(),
+	}).(*RequestImpl)
+	SetRequestAttach(req.Request, []byte(credReq))
+
+	// issuer receives the request and issues the credential
+	inReq := RequestCreator.NewMessage(req.JSON()).(*RequestImpl)
+	assert.Equal(inReq.Type(), pltype.DIDOrgIssueCredentialV2Request)
+	data, err = RequestAttach(inReq.Request)
+	assert.NoError(err)
+	assert.Equal(string(data), credReq)
+
+	issue := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:    "issue id",
+		Type:   Versioned(pltype.IssueCredentialIssue, IsV2(inReq.Type())),
+		Thread: inReq.Thread(),
+	}).(*IssueImpl)
+	SetCredentialsAttach(issue.Issue, []byte(cred))
+
+	// holder receives the credential and acks it
+	inIssue := IssueCreator.NewMessage(issue.JSON()).(*IssueImpl)
+	assert.Equal(inIssue.Type(), pltype.DIDOrgIssueCredentialV2Issue)
+	assert.Equal(inIssue.Thread().ID, "offer id")
+	data, err = CredentialAttach(inIssue.Issue)
+	assert.NoError(err)
+	assert.Equal(string(data), cred)
+
+	ack := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:    "ack id",
+		Type:   Versioned(pltype.IssueCredentialACK, IsV2(inIssue.Type())),
+		Thread: inIssue.Thread(),
+	})
+	_, isAck := ack.FieldObj().(*common.Ack)
+	assert.That(isAck)
+}
+
+func TestV1_NoFormats(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	offer := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:  "offer id",
+		Type: pltype.IssueCredentialOffer,
+	}).(*OfferImpl)
+	offer.CredentialPreview = NewPreviewCredential(`[]`)
+	SetOfferAttach(offer.Offer, []byte(`{}`))
+	assert.SLen(offer.Formats, 0)
+	assert.SLen(offer.OffersAttach, 1)
+	assert.Equal(offer.CredentialPreview.Type, pltype.IssueCredentialCredentialPreview)
+}
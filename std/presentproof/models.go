@@ -8,6 +8,7 @@ type Request struct {
 	Type                 string                 `json:"@type,omitempty"`
 	ID                   string                 `json:"@id,omitempty"`
 	Comment              string                 `json:"comment,omitempty"`
+	Formats              []decorator.Format     `json:"formats,omitempty"`
 	RequestPresentations []decorator.Attachment `json:"request_presentations~attach,omitempty"`
 	Thread               *decorator.Thread      `json:"~thread,omitempty"`
 }
@@ -18,6 +19,7 @@ type Presentation struct {
 	Type                 string                 `json:"@type,omitempty"`
 	ID                   string                 `json:"@id,omitempty"`
 	Comment              string                 `json:"comment,omitempty"`
+	Formats              []decorator.Format     `json:"formats,omitempty"`
 	PresentationAttaches []decorator.Attachment `json:"presentations~attach,omitempty"`
 	Thread               *decorator.Thread      `json:"~thread,omitempty"`
 }
@@ -33,6 +33,7 @@ func init() {
 	gob.Register(&PresentationImpl{})
 	aries.Creator.Add(pltype.PresentProofPresentation, PresentationCreator)
 	aries.Creator.Add(pltype.DIDOrgPresentProofPresentation, PresentationCreator)
+	aries.Creator.Add(pltype.DIDOrgPresentProofV2Presentation, PresentationCreator)
 }
 
 func NewPresentation(r *Presentation) *PresentationImpl {
@@ -49,7 +50,25 @@ func NewPresentationMsg(data []byte) *PresentationImpl {
 // MARK: Helpers
 
 func Proof(p *Presentation) (data []byte, err error) {
-	return base64.StdEncoding.DecodeString(p.PresentationAttaches[0].Data.Base64)
+	a, err := decorator.FormatAttachment(p.PresentationAttaches, p.Formats,
+		pltype.PresentProofFormatIndyProof)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(a.Data.Base64)
+}
+
+// SetProof sets the indy proof as the attachment of the presentation. The
+// format of the attachment is set for the 2.0 messages.
+func SetProof(p *Presentation, proof []byte) {
+	p.PresentationAttaches = NewPresentationAttach(
+		pltype.LibindyPresentationID, proof)
+	if IsV2(p.Type) {
+		p.Formats = []decorator.Format{{
+			AttachID: pltype.LibindyPresentationID,
+			Format:   pltype.PresentProofFormatIndyProof,
+		}}
+	}
 }
 
 func (p *PresentationImpl) checkThread() {
@@ -33,6 +33,7 @@ func init() {
 	gob.Register(&RequestImpl{})
 	aries.Creator.Add(pltype.PresentProofRequest, RequestCreator)
 	aries.Creator.Add(pltype.DIDOrgPresentProofRequest, RequestCreator)
+	aries.Creator.Add(pltype.DIDOrgPresentProofV2Request, RequestCreator)
 }
 
 func NewRequest(r *Request) *RequestImpl {
@@ -60,7 +61,25 @@ func ProofReq(req *Request) (pr map[string]interface{}, err error) {
 }
 
 func ProofReqData(req *Request) (data []byte, err error) {
-	return base64.StdEncoding.DecodeString(req.RequestPresentations[0].Data.Base64)
+	a, err := decorator.FormatAttachment(req.RequestPresentations, req.Formats,
+		pltype.PresentProofFormatIndyRequest)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(a.Data.Base64)
+}
+
+// SetProofReq sets the indy proof request as the attachment of the request.
+// The format of the attachment is set for the 2.0 messages.
+func SetProofReq(req *Request, proofReq []byte) {
+	req.RequestPresentations = NewRequestPresentation(
+		pltype.LibindyRequestPresentationID, proofReq)
+	if IsV2(req.Type) {
+		req.Formats = []decorator.Format{{
+			AttachID: pltype.LibindyRequestPresentationID,
+			Format:   pltype.PresentProofFormatIndyRequest,
+		}}
+	}
 }
 
 func (p *RequestImpl) checkThread() {
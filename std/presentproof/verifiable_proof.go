@@ -0,0 +1,162 @@
+package presentproof
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/findy-network/findy-wrapper-go/anoncreds"
+	"github.com/lainio/err2"
+	"github.com/lainio/err2/try"
+)
+
+// VerifiableProof is the indy proof of the presentation parsed for custom
+// verification. The attributes and predicates are bound to the schemas and
+// cred defs of the credentials they are proved from. Note! Parsing doesn't
+// verify the proof.
+type VerifiableProof struct {
+	Identifiers  []anoncreds.IdentifiersObj
+	Revealed     []ProvedAttribute
+	SelfAttested []ProvedAttribute
+	Predicates   []ProvedPredicate
+}
+
+// ProvedAttribute is a revealed or self-attested attribute of the proof. ID is
+// the referent in the proof request. Self-attested attributes don't have a
+// schema or cred def.
+type ProvedAttribute struct {
+	ID        string
+	Name      string
+	Value     string
+	SchemaID  string
+	CredDefID string
+}
+
+// ProvedPredicate is a predicate the proof claims to satisfy.
+type ProvedPredicate struct {
+	ID        string
+	Name      string
+	PType     string
+	PValue    int
+	SchemaID  string
+	CredDefID string
+}
+
+// indyProof is the part of the libindy proof JSON we parse. Unlike in
+// anoncreds.Proof, the predicates are typed.
+type indyProof struct {
+	RequestedProof struct {
+		RevealedAttrs     map[string]anoncreds.RevealedAttr `json:"revealed_attrs"`
+		SelfAttestedAttrs map[string]string                 `json:"self_attested_attrs"`
+		Predicates        map[string]struct {
+			SubProofIndex int `json:"sub_proof_index"`
+		} `json:"predicates"`
+	} `json:"requested_proof"`
+	Identifiers []anoncreds.IdentifiersObj `json:"identifiers"`
+}
+
+// NewVerifiableProof parses the proof of the presentation. The proof request
+// message gives the names of the attributes and the predicates, and it can be
+// nil.
+func NewVerifiableProof(pres *Presentation, req *Request) (vp *VerifiableProof, err error) {
+	defer err2.Handle(&err, "verifiable proof")
+
+	var proofReq []byte
+	if req != nil {
+		proofReq = try.To1(ProofReqData(req))
+	}
+	return ParseVerifiableProof(try.To1(Proof(pres)), proofReq)
+}
+
+// ParseVerifiableProof parses the libindy proof JSON. The proof request JSON
+// gives the names of the attributes and the predicates, and it can be empty.
+// Attributes and predicates are sorted by their IDs.
+func ParseVerifiableProof(proof, proofReq []byte) (vp *VerifiableProof, err error) {
+	defer err2.Handle(&err, "parse proof")
+
+	var p indyProof
+	try.To(json.Unmarshal(proof, &p))
+	var req anoncreds.ProofRequest
+	if len(proofReq) > 0 {
+		try.To(json.Unmarshal(proofReq, &req))
+	}
+
+	vp = &VerifiableProof{
+		Identifiers:  p.Identifiers,
+		Revealed:     make([]ProvedAttribute, 0, len(p.RequestedProof.RevealedAttrs)),
+		SelfAttested: make([]ProvedAttribute, 0, len(p.RequestedProof.SelfAttestedAttrs)),
+		Predicates:   make([]ProvedPredicate, 0, len(p.RequestedProof.Predicates)),
+	}
+	for id, attr := range p.RequestedProof.RevealedAttrs {
+		ident := identifier(p.Identifiers, attr.SubProofIndex)
+		vp.Revealed = append(vp.Revealed, ProvedAttribute{
+			ID:        id,
+			Name:      req.RequestedAttributes[id].Name,
+			Value:     attr.Raw,
+			SchemaID:  ident.SchemaID,
+			CredDefID: ident.CredDefID,
+		})
+	}
+	for id, value := range p.RequestedProof.SelfAttestedAttrs {
+		vp.SelfAttested = append(vp.SelfAttested, ProvedAttribute{
+			ID:    id,
+			Name:  req.RequestedAttributes[id].Name,
+			Value: value,
+		})
+	}
+	for id, pred := range p.RequestedProof.Predicates {
+		ident := identifier(p.Identifiers, pred.SubProofIndex)
+		info := req.RequestedPredicates[id]
+		vp.Predicates = append(vp.Predicates, ProvedPredicate{
+			ID:        id,
+			Name:      info.Name,
+			PType:     info.PType,
+			PValue:    info.PValue,
+			SchemaID:  ident.SchemaID,
+			CredDefID: ident.CredDefID,
+		})
+	}
+	sort.Slice(vp.Revealed, func(i, j int) bool {
+		return vp.Revealed[i].ID < vp.Revealed[j].ID
+	})
+	sort.Slice(vp.SelfAttested, func(i, j int) bool {
+		return vp.SelfAttested[i].ID < vp.SelfAttested[j].ID
+	})
+	sort.Slice(vp.Predicates, func(i, j int) bool {
+		return vp.Predicates[i].ID < vp.Predicates[j].ID
+	})
+	return vp, nil
+}
+
+// SchemaIDs returns the distinct schema IDs of the proof.
+func (vp *VerifiableProof) SchemaIDs() []string {
+	return distinct(vp.Identifiers, func(i anoncreds.IdentifiersObj) string {
+		return i.SchemaID
+	})
+}
+
+// CredDefIDs returns the distinct cred def IDs of the proof.
+func (vp *VerifiableProof) CredDefIDs() []string {
+	return distinct(vp.Identifiers, func(i anoncreds.IdentifiersObj) string {
+		return i.CredDefID
+	})
+}
+
+func identifier(identifiers []anoncreds.IdentifiersObj, subProofIndex int) anoncreds.IdentifiersObj {
+	if subProofIndex < 0 || subProofIndex >= len(identifiers) {
+		return anoncreds.IdentifiersObj{}
+	}
+	return identifiers[subProofIndex]
+}
+
+func distinct(identifiers []anoncreds.IdentifiersObj, id func(anoncreds.IdentifiersObj) string) []string {
+	ids := make([]string, 0, len(identifiers))
+	found := make(map[string]struct{}, len(identifiers))
+	for _, i := range identifiers {
+		if _, ok := found[id(i)]; ok {
+			continue
+		}
+		found[id(i)] = struct{}{}
+		ids = append(ids, id(i))
+	}
+	return ids
+}
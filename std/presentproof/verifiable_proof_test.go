@@ -0,0 +1,67 @@
+package presentproof
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-common-go/dto"
+	"github.com/lainio/err2/assert"
+)
+
+func TestNewVerifiableProof(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const (
+		schemaID  = "SazgVreUXtwF4ZBwAxUPwU:2:degree schema:15.43.93"
+		credDefID = "SazgVreUXtwF4ZBwAxUPwU:3:CL:25:default"
+	)
+	var pres Presentation
+	dto.FromJSONStr(presentation, &pres)
+	var req Request
+	dto.FromJSONStr(request, &req)
+
+	vp, err := NewVerifiableProof(&pres, &req)
+	assert.NoError(err)
+
+	assert.SLen(vp.SchemaIDs(), 1)
+	assert.Equal(vp.SchemaIDs()[0], schemaID)
+	assert.SLen(vp.CredDefIDs(), 1)
+	assert.Equal(vp.CredDefIDs()[0], credDefID)
+
+	assert.SLen(vp.Revealed, 3)
+	assert.Equal(vp.Revealed[0], ProvedAttribute{
+		ID:        "0_date_uuid",
+		Name:      "date",
+		Value:     "2018-05-28",
+		SchemaID:  schemaID,
+		CredDefID: credDefID,
+	})
+	assert.Equal(vp.Revealed[1].Name, "degree")
+	assert.Equal(vp.Revealed[1].Value, "Maths")
+	assert.Equal(vp.Revealed[2].Name, "name")
+	assert.Equal(vp.Revealed[2].Value, "Alice Smith")
+
+	assert.SLen(vp.SelfAttested, 1)
+	assert.Equal(vp.SelfAttested[0], ProvedAttribute{
+		ID:    "0_self_attested_thing_uuid",
+		Name:  "self_attested_thing",
+		Value: "my self-attested value",
+	})
+
+	assert.SLen(vp.Predicates, 1)
+	assert.Equal(vp.Predicates[0], ProvedPredicate{
+		ID:        "0_age_GE_uuid",
+		Name:      "age",
+		PType:     ">=",
+		PValue:    18,
+		SchemaID:  schemaID,
+		CredDefID: credDefID,
+	})
+
+	// without the proof request the names are unknown
+	vp, err = NewVerifiableProof(&pres, nil)
+	assert.NoError(err)
+	assert.SLen(vp.Revealed, 3)
+	assert.Empty(vp.Revealed[0].Name)
+	assert.Equal(vp.Revealed[0].Value, "2018-05-28")
+}
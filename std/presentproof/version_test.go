@@ -0,0 +1,90 @@
+package presentproof
+
+import (
+	"testing"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/std/common"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/lainio/err2/assert"
+)
+
+func TestVersioned(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	assert.Equal(Versioned(pltype.PresentProofRequest, false), pltype.PresentProofRequest)
+	assert.Equal(Versioned(pltype.PresentProofRequest, true), pltype.DIDOrgPresentProofV2Request)
+	assert.Equal(Versioned(pltype.Nothing, true), pltype.Nothing)
+	assert.Equal(Versioned(pltype.PresentProofUserAction, true), pltype.PresentProofUserAction)
+
+	assert.That(IsV2(pltype.DIDOrgPresentProofV2Presentation))
+	assert.ThatNot(IsV2(pltype.DIDOrgPresentProofPresentation))
+	assert.ThatNot(IsV2(pltype.PresentProofPresentation))
+}
+
+func TestV2_RoundTrip(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	const (
+		proofReq = `{"name":"ProofReq","nonce":"123"}`
+		proof    = `{"proof":{}}`
+	)
+
+	// verifier sends the request
+	req := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:    "req id",
+		Type:   Versioned(pltype.PresentProofRequest, true),
+		Thread: decorator.NewThread("req id", ""),
+	}).(*RequestImpl)
+	SetProofReq(req.Request, []byte(proofReq))
+	assert.SLen(req.Formats, 1)
+	assert.Equal(req.Formats[0].Format, pltype.PresentProofFormatIndyRequest)
+
+	// prover receives it and answers with the same version
+	inReq := RequestCreator.NewMessage(req.JSON()).(*RequestImpl)
+	assert.Equal(inReq.Type(), pltype.DIDOrgPresentProofV2Request)
+	data, err := ProofReqData(inReq.Request)
+	assert.NoError(err)
+	assert.Equal(string(data), proofReq)
+
+	v2 := IsV2(inReq.Type())
+	pres := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:    "pres id",
+		Type:   Versioned(pltype.PresentProofPresentation, v2),
+		Thread: inReq.Thread(),
+	}).(*PresentationImpl)
+	SetProof(pres.Presentation, []byte(proof))
+
+	// verifier receives the presentation and acks it
+	inPres := PresentationCreator.NewMessage(pres.JSON()).(*PresentationImpl)
+	assert.Equal(inPres.Type(), pltype.DIDOrgPresentProofV2Presentation)
+	assert.Equal(inPres.Thread().ID, "req id")
+	data, err = Proof(inPres.Presentation)
+	assert.NoError(err)
+	assert.Equal(string(data), proof)
+
+	ack := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:    "ack id",
+		Type:   Versioned(pltype.PresentProofACK, IsV2(inPres.Type())),
+		Thread: inPres.Thread(),
+	})
+	_, isAck := ack.FieldObj().(*common.Ack)
+	assert.That(isAck)
+}
+
+func TestV1_NoFormats(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	req := aries.MsgCreator.Create(didcomm.MsgInit{
+		AID:  "req id",
+		Type: pltype.PresentProofRequest,
+	}).(*RequestImpl)
+	SetProofReq(req.Request, []byte(`{}`))
+	assert.SLen(req.Formats, 0)
+	assert.SLen(req.RequestPresentations, 1)
+}
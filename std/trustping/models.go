@@ -0,0 +1,23 @@
+// Package trustping implements the messages of the Aries Trust Ping protocol
+// (RFC 0048).
+package trustping
+
+import "github.com/findy-network/findy-agent/std/decorator"
+
+// Ping tests the connection. ResponseRequested is true by default, also when
+// it's missing from the received message.
+type Ping struct {
+	Type              string            `json:"@type,omitempty"`
+	ID                string            `json:"@id,omitempty"`
+	Thread            *decorator.Thread `json:"~thread,omitempty"`
+	Comment           string            `json:"comment,omitempty"`
+	ResponseRequested bool              `json:"response_requested"`
+}
+
+// PingResponse is the answer to Ping.
+type PingResponse struct {
+	Type    string            `json:"@type,omitempty"`
+	ID      string            `json:"@id,omitempty"`
+	Thread  *decorator.Thread `json:"~thread,omitempty"`
+	Comment string            `json:"comment,omitempty"`
+}
@@ -0,0 +1,82 @@
+package trustping
+
+import (
+	"encoding/gob"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/findy-network/findy-common-go/dto"
+)
+
+var PingCreator = &PingFactor{}
+
+type PingFactor struct{}
+
+func (f *PingFactor) NewMsg(init didcomm.MsgInit) didcomm.MessageHdr {
+	m := &Ping{
+		Type:              init.Type,
+		ID:                init.AID,
+		Thread:            decorator.CheckThread(init.Thread, init.AID),
+		ResponseRequested: true,
+	}
+	return NewPing(m)
+}
+
+func (f *PingFactor) NewMessage(data []byte) didcomm.MessageHdr {
+	return NewPingMsg(data)
+}
+
+func init() {
+	gob.Register(&PingImpl{})
+	aries.Creator.Add(pltype.TrustPingPing, PingCreator)
+	aries.Creator.Add(pltype.DIDOrgTrustPingPing, PingCreator)
+}
+
+func NewPing(r *Ping) *PingImpl {
+	return &PingImpl{Ping: r}
+}
+
+func NewPingMsg(data []byte) *PingImpl {
+	mImpl := PingImpl{Ping: &Ping{ResponseRequested: true}}
+	dto.FromJSON(data, &mImpl)
+	mImpl.checkThread()
+	return &mImpl
+}
+
+func (p *PingImpl) checkThread() {
+	p.Ping.Thread = decorator.CheckThread(p.Ping.Thread, p.Ping.ID)
+}
+
+type PingImpl struct {
+	*Ping
+}
+
+func (p *PingImpl) ID() string {
+	return p.Ping.ID
+}
+
+func (p *PingImpl) Type() string {
+	return p.Ping.Type
+}
+
+func (p *PingImpl) SetID(id string) {
+	p.Ping.ID = id
+}
+
+func (p *PingImpl) SetType(t string) {
+	p.Ping.Type = t
+}
+
+func (p *PingImpl) JSON() []byte {
+	return dto.ToJSONBytes(p)
+}
+
+func (p *PingImpl) Thread() *decorator.Thread {
+	return p.Ping.Thread
+}
+
+func (p *PingImpl) FieldObj() interface{} {
+	return p.Ping
+}
@@ -0,0 +1,52 @@
+package trustping
+
+import (
+	"testing"
+
+	"github.com/lainio/err2/assert"
+)
+
+func TestNewPingMsg(t *testing.T) {
+	tests := []struct {
+		name              string
+		json              string
+		responseRequested bool
+	}{
+		{"default", `{
+  "@type": "https://didcomm.org/trust_ping/1.0/ping",
+  "@id": "518be002-de8e-456e-b3d5-8fe472477a86",
+  "comment": "Hi. Are you listening?"
+}`, true},
+		{"no response", `{
+  "@type": "https://didcomm.org/trust_ping/1.0/ping",
+  "@id": "518be002-de8e-456e-b3d5-8fe472477a86",
+  "comment": "Hi. Are you listening?",
+  "response_requested": false
+}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PushTester(t)
+			defer assert.PopTester()
+
+			ping := NewPingMsg([]byte(tt.json))
+			assert.Equal(ping.Ping.Comment, "Hi. Are you listening?")
+			assert.Equal(ping.Ping.ResponseRequested, tt.responseRequested)
+			assert.Equal(ping.Thread().ID, "518be002-de8e-456e-b3d5-8fe472477a86")
+		})
+	}
+}
+
+func TestNewResponseMsg(t *testing.T) {
+	assert.PushTester(t)
+	defer assert.PopTester()
+
+	resp := NewResponseMsg([]byte(`{
+  "@type": "https://didcomm.org/trust_ping/1.0/ping_response",
+  "@id": "e002518b-456e-b3d5-de8e-7a86fe472847",
+  "~thread": { "thid": "518be002-de8e-456e-b3d5-8fe472477a86" },
+  "comment": "Hi yourself. I'm here."
+}`))
+	assert.Equal(resp.PingResponse.Comment, "Hi yourself. I'm here.")
+	assert.Equal(resp.Thread().ID, "518be002-de8e-456e-b3d5-8fe472477a86")
+}
@@ -0,0 +1,81 @@
+package trustping
+
+import (
+	"encoding/gob"
+
+	"github.com/findy-network/findy-agent/agent/aries"
+	"github.com/findy-network/findy-agent/agent/didcomm"
+	"github.com/findy-network/findy-agent/agent/pltype"
+	"github.com/findy-network/findy-agent/std/decorator"
+	"github.com/findy-network/findy-common-go/dto"
+)
+
+var ResponseCreator = &ResponseFactor{}
+
+type ResponseFactor struct{}
+
+func (f *ResponseFactor) NewMsg(init didcomm.MsgInit) didcomm.MessageHdr {
+	m := &PingResponse{
+		Type:   init.Type,
+		ID:     init.AID,
+		Thread: decorator.CheckThread(init.Thread, init.AID),
+	}
+	return NewResponse(m)
+}
+
+func (f *ResponseFactor) NewMessage(data []byte) didcomm.MessageHdr {
+	return NewResponseMsg(data)
+}
+
+func init() {
+	gob.Register(&ResponseImpl{})
+	aries.Creator.Add(pltype.TrustPingResponse, ResponseCreator)
+	aries.Creator.Add(pltype.DIDOrgTrustPingResponse, ResponseCreator)
+}
+
+func NewResponse(r *PingResponse) *ResponseImpl {
+	return &ResponseImpl{PingResponse: r}
+}
+
+func NewResponseMsg(data []byte) *ResponseImpl {
+	var mImpl ResponseImpl
+	dto.FromJSON(data, &mImpl)
+	mImpl.checkThread()
+	return &mImpl
+}
+
+func (p *ResponseImpl) checkThread() {
+	p.PingResponse.Thread = decorator.CheckThread(p.PingResponse.Thread, p.PingResponse.ID)
+}
+
+type ResponseImpl struct {
+	*PingResponse
+}
+
+func (p *ResponseImpl) ID() string {
+	return p.PingResponse.ID
+}
+
+func (p *ResponseImpl) Type() string {
+	return p.PingResponse.Type
+}
+
+func (p *ResponseImpl) SetID(id string) {
+	p.PingResponse.ID = id
+}
+
+func (p *ResponseImpl) SetType(t string) {
+	p.PingResponse.Type = t
+}
+
+func (p *ResponseImpl) JSON() []byte {
+	return dto.ToJSONBytes(p)
+}
+
+func (p *ResponseImpl) Thread() *decorator.Thread {
+	return p.PingResponse.Thread
+}
+
+func (p *ResponseImpl) FieldObj() interface{} {
+	return p.PingResponse
+}